@@ -23,6 +23,7 @@ import (
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
 
@@ -82,6 +83,12 @@ type Topology struct {
 	// +optional
 	RolloutAfter *metav1.Time `json:"rolloutAfter,omitempty"`
 
+	// Upgrade defines how a change of the Kubernetes version is rolled out to the cluster.
+	// NOTE: The control plane is always upgraded first; MachineDeployments are upgraded only
+	// after the control plane reports the new version and it is stable.
+	// +optional
+	Upgrade *TopologyUpgrade `json:"upgrade,omitempty"`
+
 	// ControlPlane describes the cluster control plane.
 	ControlPlane ControlPlaneTopology `json:"controlPlane"`
 
@@ -89,6 +96,28 @@ type Topology struct {
 	// for the cluster.
 	// +optional
 	Workers *WorkersTopology `json:"workers,omitempty"`
+
+	// Variables can be used to customize the Cluster through
+	// patches. They must comply to the corresponding
+	// variables defined in the ClusterClass.
+	// +optional
+	Variables []ClusterVariable `json:"variables,omitempty"`
+}
+
+// TopologyUpgrade defines how a change of the Kubernetes version is rolled out to the MachineDeployments of a Cluster topology.
+type TopologyUpgrade struct {
+	// MachineDeploymentOrder is a list of names of MachineDeployments in the topology, defining the order
+	// in which MachineDeployments are upgraded; MachineDeployments not included in the list are upgraded after
+	// the ones in the list, in the order they are defined in the topology.
+	// NOTE: The order is relevant only if MaxConcurrentMachineDeployments is set.
+	// +optional
+	MachineDeploymentOrder []string `json:"machineDeploymentOrder,omitempty"`
+
+	// MaxConcurrentMachineDeployments is the maximum number of MachineDeployments upgraded at the same time.
+	// If not set, all the MachineDeployments are upgraded at the same time.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxConcurrentMachineDeployments *int32 `json:"maxConcurrentMachineDeployments,omitempty"`
 }
 
 // ControlPlaneTopology specifies the parameters for the control plane nodes in the cluster.
@@ -131,6 +160,46 @@ type MachineDeploymentTopology struct {
 	// of this value.
 	// +optional
 	Replicas *int `json:"replicas,omitempty"`
+
+	// FailureDomain is the failure domain the machines will be created in.
+	// Must match a key in the FailureDomains map stored on the cluster object.
+	// NOTE: If not set, the value defined in the corresponding MachineDeploymentClass is used.
+	// +optional
+	FailureDomain *string `json:"failureDomain,omitempty"`
+
+	// NodeDrainTimeout is the total amount of time that the controller will spend on draining a node.
+	// The default value is 0, meaning that the node can be drained without any time limitations.
+	// NOTE: NodeDrainTimeout is different from `kubectl drain --timeout`
+	// NOTE: If not set, the value defined in the corresponding MachineDeploymentClass is used.
+	// +optional
+	NodeDrainTimeout *metav1.Duration `json:"nodeDrainTimeout,omitempty"`
+
+	// Minimum number of seconds for which a newly created machine should
+	// be ready.
+	// Defaults to 0 (machine will be considered available as soon as it
+	// is ready)
+	// NOTE: If not set, the value defined in the corresponding MachineDeploymentClass is used.
+	// +optional
+	MinReadySeconds *int32 `json:"minReadySeconds,omitempty"`
+
+	// The deployment strategy to use to replace existing machines with
+	// new ones.
+	// NOTE: If not set, the value defined in the corresponding MachineDeploymentClass is used.
+	// +optional
+	Strategy *MachineDeploymentStrategy `json:"strategy,omitempty"`
+}
+
+// ClusterVariable can be used to customize the Cluster through
+// patches. It must comply to the corresponding
+// ClusterClassVariable defined in the ClusterClass.
+type ClusterVariable struct {
+	// Name of the variable.
+	Name string `json:"name"`
+
+	// Value of the variable.
+	// NOTE: the value will be validated against the schema of the corresponding ClusterClassVariable
+	// from the ClusterClass.
+	Value apiextensionsv1.JSON `json:"value"`
 }
 
 // ANCHOR_END: ClusterSpec
@@ -214,6 +283,11 @@ type ClusterStatus struct {
 	// ObservedGeneration is the latest generation observed by the controller.
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ObservedClusterClassGeneration is the generation of the ClusterClass last reconciled
+	// into the managed topology of the Cluster.
+	// +optional
+	ObservedClusterClassGeneration int64 `json:"observedClusterClassGeneration,omitempty"`
 }
 
 // ANCHOR_END: ClusterStatus
@@ -118,6 +118,42 @@ func (c *Cluster) validate(old *Cluster) error {
 	return apierrors.NewInvalid(GroupVersion.WithKind("Cluster").GroupKind(), c.Name, allErrs)
 }
 
+func (c *Cluster) validateTopologyUpgrade() field.ErrorList {
+	var allErrs field.ErrorList
+
+	mdNames := sets.String{}
+	if c.Spec.Topology.Workers != nil {
+		for _, md := range c.Spec.Topology.Workers.MachineDeployments {
+			mdNames.Insert(md.Name)
+		}
+	}
+
+	// MachineDeploymentOrder must contain only MachineDeployments defined in the topology, at most once.
+	orderNames := sets.String{}
+	for i, name := range c.Spec.Topology.Upgrade.MachineDeploymentOrder {
+		fldPath := field.NewPath("spec", "topology", "upgrade", "machineDeploymentOrder").Index(i)
+		if !mdNames.Has(name) {
+			allErrs = append(allErrs, field.Invalid(fldPath, name, fmt.Sprintf("MachineDeployment with name %q is not defined in the topology.", name)))
+		}
+		if orderNames.Has(name) {
+			allErrs = append(allErrs, field.Invalid(fldPath, name, fmt.Sprintf("MachineDeployment with name %q is defined more than once.", name)))
+		}
+		orderNames.Insert(name)
+	}
+
+	if c.Spec.Topology.Upgrade.MaxConcurrentMachineDeployments != nil && *c.Spec.Topology.Upgrade.MaxConcurrentMachineDeployments < 1 {
+		allErrs = append(allErrs,
+			field.Invalid(
+				field.NewPath("spec", "topology", "upgrade", "maxConcurrentMachineDeployments"),
+				*c.Spec.Topology.Upgrade.MaxConcurrentMachineDeployments,
+				"must be greater than or equal to 1",
+			),
+		)
+	}
+
+	return allErrs
+}
+
 func (c *Cluster) validateTopology(old *Cluster) field.ErrorList {
 	// NOTE: ClusterClass and managed topologies are behind ClusterTopology feature gate flag; the web hook
 	// must prevent the usage of Cluster.Topology in case the feature flag is disabled.
@@ -173,6 +209,26 @@ func (c *Cluster) validateTopology(old *Cluster) field.ErrorList {
 		}
 	}
 
+	// Upgrade settings must be valid.
+	if c.Spec.Topology.Upgrade != nil {
+		allErrs = append(allErrs, c.validateTopologyUpgrade()...)
+	}
+
+	// Variable names must be unique.
+	variableNames := sets.String{}
+	for i, variable := range c.Spec.Topology.Variables {
+		if variableNames.Has(variable.Name) {
+			allErrs = append(allErrs,
+				field.Invalid(
+					field.NewPath("spec", "topology", "variables").Index(i).Child("name"),
+					variable.Name,
+					fmt.Sprintf("Variable names should be unique. Variable with name %q is defined more than once.", variable.Name),
+				),
+			)
+		}
+		variableNames.Insert(variable.Name)
+	}
+
 	switch old {
 	case nil: // On create
 		// c.Spec.InfrastructureRef and c.Spec.ControlPlaneRef could not be set
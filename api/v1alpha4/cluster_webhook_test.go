@@ -21,8 +21,10 @@ import (
 
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilfeature "k8s.io/component-base/featuregate/testing"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/cluster-api/feature"
 	utildefaulting "sigs.k8s.io/cluster-api/util/defaulting"
 )
@@ -186,6 +188,80 @@ func TestClusterTopologyValidation(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "should return error when variable names are duplicated",
+			expectErr: true,
+			in: &Cluster{
+				Spec: ClusterSpec{
+					Topology: &Topology{
+						Class:   "foo",
+						Version: "v1.19.1",
+						Variables: []ClusterVariable{
+							{Name: "region", Value: apiextensionsv1.JSON{Raw: []byte(`"us-east-1"`)}},
+							{Name: "region", Value: apiextensionsv1.JSON{Raw: []byte(`"us-east-2"`)}},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "should pass when the MachineDeployment upgrade order and concurrency are valid",
+			expectErr: false,
+			in: &Cluster{
+				Spec: ClusterSpec{
+					Topology: &Topology{
+						Class:   "foo",
+						Version: "v1.19.1",
+						Upgrade: &TopologyUpgrade{
+							MachineDeploymentOrder:          []string{"bb", "aa"},
+							MaxConcurrentMachineDeployments: pointer.Int32Ptr(1),
+						},
+						Workers: &WorkersTopology{
+							MachineDeployments: []MachineDeploymentTopology{
+								{Name: "aa"},
+								{Name: "bb"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "should return error when the MachineDeployment upgrade order contains unknown or duplicated names",
+			expectErr: true,
+			in: &Cluster{
+				Spec: ClusterSpec{
+					Topology: &Topology{
+						Class:   "foo",
+						Version: "v1.19.1",
+						Upgrade: &TopologyUpgrade{
+							MachineDeploymentOrder: []string{"aa", "aa", "cc"},
+						},
+						Workers: &WorkersTopology{
+							MachineDeployments: []MachineDeploymentTopology{
+								{Name: "aa"},
+								{Name: "bb"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "should return error when the MachineDeployment upgrade concurrency is less than 1",
+			expectErr: true,
+			in: &Cluster{
+				Spec: ClusterSpec{
+					Topology: &Topology{
+						Class:   "foo",
+						Version: "v1.19.1",
+						Upgrade: &TopologyUpgrade{
+							MaxConcurrentMachineDeployments: pointer.Int32Ptr(0),
+						},
+					},
+				},
+			},
+		},
 		{
 			name:      "should return error when topology does not have valid version",
 			expectErr: true,
@@ -18,7 +18,9 @@ package v1alpha4
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // +kubebuilder:object:root=true
@@ -51,6 +53,17 @@ type ClusterClassSpec struct {
 	// the worker nodes of the cluster.
 	// +optional
 	Workers WorkersClass `json:"workers,omitempty"`
+
+	// Variables defines the variables which can be configured
+	// in the Cluster topology and are then used in patches.
+	// +optional
+	Variables []ClusterClassVariable `json:"variables,omitempty"`
+
+	// Patches defines the patches which are applied to customize
+	// referenced templates of a ClusterClass.
+	// NOTE: Patches will be applied in the order of the array.
+	// +optional
+	Patches []ClusterClassPatch `json:"patches,omitempty"`
 }
 
 // ControlPlaneClass defines the class for the control plane.
@@ -68,6 +81,12 @@ type ControlPlaneClass struct {
 	//
 	// +optional
 	MachineInfrastructure *LocalObjectTemplate `json:"machineInfrastructure,omitempty"`
+
+	// MachineHealthCheck defines a MachineHealthCheck for the control plane machines.
+	// If set, the topology controller creates a MachineHealthCheck for the control plane of every
+	// Cluster using this ClusterClass.
+	// +optional
+	MachineHealthCheck *MachineHealthCheckClass `json:"machineHealthCheck,omitempty"`
 }
 
 // WorkersClass is a collection of deployment classes.
@@ -88,6 +107,71 @@ type MachineDeploymentClass struct {
 	// Template is a local struct containing a collection of templates for creation of
 	// MachineDeployment objects representing a set of worker nodes.
 	Template MachineDeploymentClassTemplate `json:"template"`
+
+	// FailureDomain is the failure domain the machines will be created in.
+	// Must match a key in the FailureDomains map stored on the cluster object.
+	// NOTE: This value can be overridden while defining a Cluster.Topology using this MachineDeploymentClass.
+	// +optional
+	FailureDomain *string `json:"failureDomain,omitempty"`
+
+	// NodeDrainTimeout is the total amount of time that the controller will spend on draining a node.
+	// The default value is 0, meaning that the node can be drained without any time limitations.
+	// NOTE: NodeDrainTimeout is different from `kubectl drain --timeout`
+	// NOTE: This value can be overridden while defining a Cluster.Topology using this MachineDeploymentClass.
+	// +optional
+	NodeDrainTimeout *metav1.Duration `json:"nodeDrainTimeout,omitempty"`
+
+	// Minimum number of seconds for which a newly created machine should
+	// be ready.
+	// Defaults to 0 (machine will be considered available as soon as it
+	// is ready)
+	// NOTE: This value can be overridden while defining a Cluster.Topology using this MachineDeploymentClass.
+	// +optional
+	MinReadySeconds *int32 `json:"minReadySeconds,omitempty"`
+
+	// The deployment strategy to use to replace existing machines with
+	// new ones.
+	// NOTE: This value can be overridden while defining a Cluster.Topology using this MachineDeploymentClass.
+	// +optional
+	Strategy *MachineDeploymentStrategy `json:"strategy,omitempty"`
+
+	// MachineHealthCheck defines a MachineHealthCheck for the machines of this MachineDeploymentClass.
+	// If set, the topology controller creates a MachineHealthCheck for every MachineDeployment
+	// using this MachineDeploymentClass.
+	// +optional
+	MachineHealthCheck *MachineHealthCheckClass `json:"machineHealthCheck,omitempty"`
+}
+
+// MachineHealthCheckClass defines a MachineHealthCheck for a group of machines of a managed topology.
+// NOTE: The cluster name and the selector of the MachineHealthCheck are computed by the topology controller.
+type MachineHealthCheckClass struct {
+	// UnhealthyConditions contains a list of the conditions that determine
+	// whether a node is considered unhealthy. The conditions are combined in a
+	// logical OR, i.e. if any of the conditions is met, the node is unhealthy.
+	//
+	// +kubebuilder:validation:MinItems=1
+	UnhealthyConditions []UnhealthyCondition `json:"unhealthyConditions"`
+
+	// Any further remediation is only allowed if at most "MaxUnhealthy" machines selected by
+	// the MachineHealthCheck are not healthy.
+	// +optional
+	MaxUnhealthy *intstr.IntOrString `json:"maxUnhealthy,omitempty"`
+
+	// Machines older than this duration without a node will be considered to have
+	// failed and will be remediated.
+	// If not set, this value is defaulted to 10 minutes.
+	// If you wish to disable this feature, set the value explicitly to 0.
+	// +optional
+	NodeStartupTimeout *metav1.Duration `json:"nodeStartupTimeout,omitempty"`
+
+	// RemediationTemplate is a reference to a remediation template
+	// provided by an infrastructure provider.
+	//
+	// This field is completely optional, when filled, the MachineHealthCheck controller
+	// creates a new object from the template referenced and hands off remediation of the machine to
+	// a controller that lives outside of Cluster API.
+	// +optional
+	RemediationTemplate *corev1.ObjectReference `json:"remediationTemplate,omitempty"`
 }
 
 // MachineDeploymentClassTemplate defines how a MachineDeployment generated from a MachineDeploymentClass
@@ -111,6 +195,255 @@ type LocalObjectTemplate struct {
 	Ref *corev1.ObjectReference `json:"ref"`
 }
 
+// ClusterClassVariable defines a variable which can
+// be configured in the Cluster topology and used in patches.
+type ClusterClassVariable struct {
+	// Name of the variable.
+	Name string `json:"name"`
+
+	// Required specifies if the variable is required.
+	// NOTE: this applies to the variable as a whole and thus the
+	// top-level object defined in the schema. If nested fields are
+	// required, this will be specified inside the schema.
+	Required bool `json:"required"`
+
+	// Schema defines the schema of the variable.
+	Schema VariableSchema `json:"schema"`
+}
+
+// VariableSchema defines the schema of a variable.
+type VariableSchema struct {
+	// OpenAPIV3Schema defines the schema of a variable via OpenAPI v3
+	// schema. The schema is a subset of the schema used in
+	// Kubernetes CRDs.
+	OpenAPIV3Schema JSONSchemaProps `json:"openAPIV3Schema"`
+}
+
+// JSONSchemaProps is a JSON-Schema following Specification Draft 4 (http://json-schema.org/).
+// This struct has been initially copied from apiextensionsv1.JSONSchemaProps, but all fields
+// which are not supported in ClusterClass variables have been removed.
+type JSONSchemaProps struct {
+	// Type is the type of the variable.
+	// Valid values are: object, array, string, integer, number or boolean.
+	// +kubebuilder:validation:Enum=object;array;string;integer;number;boolean
+	Type string `json:"type"`
+
+	// Properties specifies fields of an object.
+	// NOTE: Can only be set if type is object.
+	// NOTE: This field uses PreserveUnknownFields and Schemaless,
+	// because recursive validation is not possible.
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	Properties map[string]JSONSchemaProps `json:"properties,omitempty"`
+
+	// Required specifies which fields of an object are required.
+	// NOTE: Can only be set if type is object.
+	// +optional
+	Required []string `json:"required,omitempty"`
+
+	// Items specifies fields of an array.
+	// NOTE: Can only be set if type is array.
+	// NOTE: This field uses PreserveUnknownFields and Schemaless,
+	// because recursive validation is not possible.
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	Items *JSONSchemaProps `json:"items,omitempty"`
+
+	// MaxItems is the max length of an array variable.
+	// NOTE: Can only be set if type is array.
+	// +optional
+	MaxItems *int64 `json:"maxItems,omitempty"`
+
+	// MinItems is the min length of an array variable.
+	// NOTE: Can only be set if type is array.
+	// +optional
+	MinItems *int64 `json:"minItems,omitempty"`
+
+	// UniqueItems specifies if items in an array must be unique.
+	// NOTE: Can only be set if type is array.
+	// +optional
+	UniqueItems bool `json:"uniqueItems,omitempty"`
+
+	// Format is an OpenAPI v3 format string. Unknown formats are ignored.
+	// For a list of supported formats please see: (of the k8s.io/apiextensions-apiserver version we're currently using)
+	// https://github.com/kubernetes/apiextensions-apiserver/blob/master/pkg/apiserver/validation/formats.go
+	// NOTE: Can only be set if type is string.
+	// +optional
+	Format string `json:"format,omitempty"`
+
+	// MaxLength is the max length of a string variable.
+	// NOTE: Can only be set if type is string.
+	// +optional
+	MaxLength *int64 `json:"maxLength,omitempty"`
+
+	// MinLength is the min length of a string variable.
+	// NOTE: Can only be set if type is string.
+	// +optional
+	MinLength *int64 `json:"minLength,omitempty"`
+
+	// Pattern is the regex which a string variable must match.
+	// NOTE: Can only be set if type is string.
+	// +optional
+	Pattern string `json:"pattern,omitempty"`
+
+	// Maximum is the maximum of an integer or number variable.
+	// If ExclusiveMaximum is false, the variable is valid if it is lower than, or equal to, the value of Maximum.
+	// If ExclusiveMaximum is true, the variable is valid if it is strictly lower than the value of Maximum.
+	// NOTE: Can only be set if type is integer or number.
+	// +optional
+	Maximum *int64 `json:"maximum,omitempty"`
+
+	// ExclusiveMaximum specifies if the Maximum is exclusive.
+	// NOTE: Can only be set if type is integer or number.
+	// +optional
+	ExclusiveMaximum bool `json:"exclusiveMaximum,omitempty"`
+
+	// Minimum is the minimum of an integer or number variable.
+	// If ExclusiveMinimum is false, the variable is valid if it is greater than, or equal to, the value of Minimum.
+	// If ExclusiveMinimum is true, the variable is valid if it is strictly greater than the value of Minimum.
+	// NOTE: Can only be set if type is integer or number.
+	// +optional
+	Minimum *int64 `json:"minimum,omitempty"`
+
+	// ExclusiveMinimum specifies if the Minimum is exclusive.
+	// NOTE: Can only be set if type is integer or number.
+	// +optional
+	ExclusiveMinimum bool `json:"exclusiveMinimum,omitempty"`
+
+	// Enum is the list of valid values of the variable.
+	// NOTE: Can be set for all types.
+	// +optional
+	Enum []apiextensionsv1.JSON `json:"enum,omitempty"`
+
+	// Default is the default value of the variable.
+	// NOTE: Can be set for all types.
+	// +optional
+	Default *apiextensionsv1.JSON `json:"default,omitempty"`
+}
+
+// ClusterClassPatch defines a patch which is applied to customize the referenced templates.
+type ClusterClassPatch struct {
+	// Name of the patch.
+	Name string `json:"name"`
+
+	// Definitions define the patches inline.
+	// NOTE: Patches are applied in the order in which they are defined.
+	Definitions []PatchDefinition `json:"definitions"`
+}
+
+// PatchDefinition defines a patch which is applied to customize the referenced templates.
+type PatchDefinition struct {
+	// Selector defines on which templates the patch should be applied.
+	Selector PatchSelector `json:"selector"`
+
+	// JSONPatches defines the JSON patches (RFC 6902) which should be applied on the templates
+	// matching the selector.
+	// NOTE: Patches are applied in the order in which they are defined.
+	// +optional
+	JSONPatches []JSONPatch `json:"jsonPatches,omitempty"`
+
+	// MergePatch defines a JSON merge patch (RFC 7386) which should be applied on the templates
+	// matching the selector, after the JSON patches.
+	// +optional
+	MergePatch *MergePatch `json:"mergePatch,omitempty"`
+}
+
+// PatchSelector defines on which templates the patch should be applied.
+// NOTE: Matching on APIVersion and Kind is mandatory, to enforce that the patches are
+// written for the correct version. The version of the references in the ClusterClass may
+// be automatically updated during reconciliation if there is a newer version for the same contract.
+// NOTE: The results of selection based on the individual fields are ANDed.
+type PatchSelector struct {
+	// APIVersion filters templates by apiVersion.
+	APIVersion string `json:"apiVersion"`
+
+	// Kind filters templates by kind.
+	Kind string `json:"kind"`
+
+	// MatchResources selects templates based on where they are referenced.
+	MatchResources PatchSelectorMatch `json:"matchResources"`
+}
+
+// PatchSelectorMatch selects templates based on where they are referenced.
+// NOTE: The results of selection based on the individual fields are ORed.
+type PatchSelectorMatch struct {
+	// ControlPlane selects templates referenced in .spec.ControlPlane.
+	// NOTE: this will match the controlPlane and also the controlPlane
+	// machineInfrastructure (depending on the kind and apiVersion).
+	// +optional
+	ControlPlane bool `json:"controlPlane,omitempty"`
+
+	// InfrastructureCluster selects templates referenced in .spec.infrastructure.
+	// +optional
+	InfrastructureCluster bool `json:"infrastructureCluster,omitempty"`
+
+	// MachineDeploymentClass selects templates referenced in specific MachineDeploymentClasses in
+	// .spec.workers.machineDeployments.
+	// +optional
+	MachineDeploymentClass *PatchSelectorMatchMachineDeploymentClass `json:"machineDeploymentClass,omitempty"`
+}
+
+// PatchSelectorMatchMachineDeploymentClass selects templates referenced
+// in specific MachineDeploymentClasses in .spec.workers.machineDeployments.
+type PatchSelectorMatchMachineDeploymentClass struct {
+	// Names selects templates by class names.
+	Names []string `json:"names,omitempty"`
+}
+
+// JSONPatch defines a JSON patch.
+type JSONPatch struct {
+	// Op defines the operation of the patch.
+	// NOTE: Only `add`, `replace` and `remove` are supported.
+	// +kubebuilder:validation:Enum=add;replace;remove
+	Op string `json:"op"`
+
+	// Path defines the path of the patch.
+	// NOTE: Only the spec of a template can be patched, thus the path has to start with /spec/.
+	Path string `json:"path"`
+
+	// Value defines the value of the patch.
+	// NOTE: Either Value or ValueFrom is required for add and replace
+	// operations. Only one of them is allowed to be set at the same time.
+	// +optional
+	Value *apiextensionsv1.JSON `json:"value,omitempty"`
+
+	// ValueFrom defines the value of the patch.
+	// NOTE: Either Value or ValueFrom is required for add and replace
+	// operations. Only one of them is allowed to be set at the same time.
+	// +optional
+	ValueFrom *PatchValueFrom `json:"valueFrom,omitempty"`
+}
+
+// MergePatch defines a JSON merge patch.
+type MergePatch struct {
+	// Value defines the merge patch document.
+	// NOTE: Only the spec of a template can be patched, thus the document can only contain the spec field.
+	// NOTE: Either Value or ValueFrom is required. Only one of them is allowed to be set at the same time.
+	// +optional
+	Value *apiextensionsv1.JSON `json:"value,omitempty"`
+
+	// ValueFrom defines the variable to read the merge patch document from.
+	// NOTE: Only the spec of a template can be patched, thus the document can only contain the spec field.
+	// NOTE: Either Value or ValueFrom is required. Only one of them is allowed to be set at the same time.
+	// +optional
+	ValueFrom *PatchValueFrom `json:"valueFrom,omitempty"`
+}
+
+// BuiltinVariableName is the name of the variable automatically provided by the topology controller
+// to patches; it contains information about the Cluster, e.g. `builtin.cluster.name`.
+const BuiltinVariableName = "builtin"
+
+// PatchValueFrom defines the value of a patch.
+type PatchValueFrom struct {
+	// Variable is the variable to be used as value.
+	// Variable can be one of the variables defined in .spec.variables or a builtin variable.
+	// Fields of object variables can be referenced using the dot notation, e.g. `instance.type`.
+	// NOTE: If the variable or the referenced field is not set, the patch is skipped.
+	Variable string `json:"variable"`
+}
+
 // +kubebuilder:object:root=true
 
 // ClusterClassList contains a list of Cluster.
@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha4
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"strings"
@@ -25,6 +26,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"sigs.k8s.io/cluster-api/feature"
@@ -54,9 +56,16 @@ func (in *ClusterClass) Default() {
 		defaultNamespace(in.Spec.ControlPlane.MachineInfrastructure.Ref, in.Namespace)
 	}
 
+	if in.Spec.ControlPlane.MachineHealthCheck != nil {
+		defaultNamespace(in.Spec.ControlPlane.MachineHealthCheck.RemediationTemplate, in.Namespace)
+	}
+
 	for i := range in.Spec.Workers.MachineDeployments {
 		defaultNamespace(in.Spec.Workers.MachineDeployments[i].Template.Bootstrap.Ref, in.Namespace)
 		defaultNamespace(in.Spec.Workers.MachineDeployments[i].Template.Infrastructure.Ref, in.Namespace)
+		if in.Spec.Workers.MachineDeployments[i].MachineHealthCheck != nil {
+			defaultNamespace(in.Spec.Workers.MachineDeployments[i].MachineHealthCheck.RemediationTemplate, in.Namespace)
+		}
 	}
 }
 
@@ -103,6 +112,13 @@ func (in *ClusterClass) validate(old *ClusterClass) error {
 	// Ensure all MachineDeployment classes are unique.
 	allErrs = append(allErrs, in.Spec.Workers.validateUniqueClasses(field.NewPath("spec", "workers"))...)
 
+	// Ensure MachineHealthCheck definitions are valid.
+	allErrs = append(allErrs, in.validateMachineHealthChecks()...)
+
+	// Ensure variables and patches are valid.
+	allErrs = append(allErrs, in.validateVariables()...)
+	allErrs = append(allErrs, in.validatePatches()...)
+
 	// Ensure spec changes are compatible.
 	allErrs = append(allErrs, in.validateCompatibleSpecChanges(old)...)
 
@@ -129,6 +145,226 @@ func (in ClusterClass) validateAllRefs() field.ErrorList {
 	return allErrs
 }
 
+func (in ClusterClass) validateMachineHealthChecks() field.ErrorList {
+	var allErrs field.ErrorList
+
+	if in.Spec.ControlPlane.MachineHealthCheck != nil {
+		allErrs = append(allErrs, in.Spec.ControlPlane.MachineHealthCheck.validate(in.Namespace, field.NewPath("spec", "controlPlane", "machineHealthCheck"))...)
+	}
+
+	for i, class := range in.Spec.Workers.MachineDeployments {
+		if class.MachineHealthCheck != nil {
+			allErrs = append(allErrs, class.MachineHealthCheck.validate(in.Namespace, field.NewPath("spec", "workers", fmt.Sprintf("machineDeployments[%v]", i), "machineHealthCheck"))...)
+		}
+	}
+
+	return allErrs
+}
+
+func (m MachineHealthCheckClass) validate(namespace string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if len(m.UnhealthyConditions) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("unhealthyConditions"), "at least one unhealthy condition must be defined"))
+	}
+
+	if m.NodeStartupTimeout != nil &&
+		m.NodeStartupTimeout.Seconds() != disabledNodeStartupTimeout.Seconds() &&
+		m.NodeStartupTimeout.Seconds() < minNodeStartupTimeout.Seconds() {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("nodeStartupTimeout"), m.NodeStartupTimeout.Seconds(), "must be at least 30s"))
+	}
+
+	if m.MaxUnhealthy != nil {
+		if _, err := intstr.GetScaledValueFromIntOrPercent(m.MaxUnhealthy, 0, false); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("maxUnhealthy"), m.MaxUnhealthy, fmt.Sprintf("must be either an int or a percentage: %v", err.Error())))
+		}
+	}
+
+	if m.RemediationTemplate != nil && m.RemediationTemplate.Namespace != namespace {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("remediationTemplate", "namespace"), m.RemediationTemplate.Namespace, "must match metadata.namespace"))
+	}
+
+	return allErrs
+}
+
+func (in ClusterClass) validateVariables() field.ErrorList {
+	var allErrs field.ErrorList
+
+	names := sets.NewString()
+	for i, variable := range in.Spec.Variables {
+		fldPath := field.NewPath("spec", "variables").Index(i)
+
+		switch {
+		case variable.Name == "":
+			allErrs = append(allErrs, field.Required(fldPath.Child("name"), "variable name must be defined"))
+		case variable.Name == BuiltinVariableName:
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), variable.Name, fmt.Sprintf("%q is a reserved variable name", BuiltinVariableName)))
+		case strings.Contains(variable.Name, "."):
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), variable.Name, "variable name cannot contain \".\""))
+		case names.Has(variable.Name):
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), variable.Name, "variable names must be unique"))
+		}
+		names.Insert(variable.Name)
+
+		allErrs = append(allErrs, variable.Schema.OpenAPIV3Schema.validate(fldPath.Child("schema", "openAPIV3Schema"))...)
+	}
+
+	return allErrs
+}
+
+func (s JSONSchemaProps) validate(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if len(s.Properties) > 0 && s.Type != "object" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("properties"), s.Properties, "can be set only if type is object"))
+	}
+	if len(s.Required) > 0 && s.Type != "object" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("required"), s.Required, "can be set only if type is object"))
+	}
+	for _, name := range s.Required {
+		if _, ok := s.Properties[name]; !ok {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("required"), s.Required, fmt.Sprintf("property %q is not defined", name)))
+		}
+	}
+	if s.Type == "array" && s.Items == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("items"), "must be set if type is array"))
+	}
+	if s.Items != nil && s.Type != "array" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("items"), s.Items, "can be set only if type is array"))
+	}
+
+	for name, property := range s.Properties {
+		allErrs = append(allErrs, property.validate(fldPath.Child("properties").Key(name))...)
+	}
+	if s.Items != nil {
+		allErrs = append(allErrs, s.Items.validate(fldPath.Child("items"))...)
+	}
+
+	return allErrs
+}
+
+func (in ClusterClass) validatePatches() field.ErrorList {
+	var allErrs field.ErrorList
+
+	variables := sets.NewString(BuiltinVariableName)
+	for _, variable := range in.Spec.Variables {
+		variables.Insert(variable.Name)
+	}
+	classes := in.Spec.Workers.classNames()
+
+	names := sets.NewString()
+	for i, patch := range in.Spec.Patches {
+		fldPath := field.NewPath("spec", "patches").Index(i)
+
+		switch {
+		case patch.Name == "":
+			allErrs = append(allErrs, field.Required(fldPath.Child("name"), "patch name must be defined"))
+		case names.Has(patch.Name):
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), patch.Name, "patch names must be unique"))
+		}
+		names.Insert(patch.Name)
+
+		if len(patch.Definitions) == 0 {
+			allErrs = append(allErrs, field.Required(fldPath.Child("definitions"), "at least one patch definition must be defined"))
+		}
+		for j, definition := range patch.Definitions {
+			allErrs = append(allErrs, definition.validate(variables, classes, fldPath.Child("definitions").Index(j))...)
+		}
+	}
+
+	return allErrs
+}
+
+func (d PatchDefinition) validate(variables, classes sets.String, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	// Ensure the selector is valid.
+	selectorPath := fldPath.Child("selector")
+	if d.Selector.APIVersion == "" {
+		allErrs = append(allErrs, field.Required(selectorPath.Child("apiVersion"), "apiVersion must be defined"))
+	}
+	if d.Selector.Kind == "" {
+		allErrs = append(allErrs, field.Required(selectorPath.Child("kind"), "kind must be defined"))
+	}
+	match := d.Selector.MatchResources
+	if !match.ControlPlane && !match.InfrastructureCluster && (match.MachineDeploymentClass == nil || len(match.MachineDeploymentClass.Names) == 0) {
+		allErrs = append(allErrs, field.Invalid(selectorPath.Child("matchResources"), match, "at least one selector must be defined"))
+	}
+	if match.MachineDeploymentClass != nil {
+		for k, name := range match.MachineDeploymentClass.Names {
+			if !classes.Has(name) {
+				allErrs = append(allErrs, field.Invalid(selectorPath.Child("matchResources", "machineDeploymentClass", "names").Index(k), name, "MachineDeployment class is not defined in the ClusterClass"))
+			}
+		}
+	}
+
+	if len(d.JSONPatches) == 0 && d.MergePatch == nil {
+		allErrs = append(allErrs, field.Required(fldPath, "either jsonPatches or mergePatch must be defined"))
+	}
+
+	// Ensure JSON patches are valid.
+	for k, p := range d.JSONPatches {
+		patchPath := fldPath.Child("jsonPatches").Index(k)
+
+		if !strings.HasPrefix(p.Path, "/spec/") {
+			allErrs = append(allErrs, field.Invalid(patchPath.Child("path"), p.Path, "only the spec of a template can be patched, the path must start with /spec/"))
+		}
+
+		switch p.Op {
+		case "add", "replace":
+			if (p.Value == nil) == (p.ValueFrom == nil) {
+				allErrs = append(allErrs, field.Invalid(patchPath, p, "exactly one of value and valueFrom must be defined"))
+			}
+		case "remove":
+			if p.Value != nil || p.ValueFrom != nil {
+				allErrs = append(allErrs, field.Invalid(patchPath, p, "value and valueFrom cannot be defined for remove operations"))
+			}
+		default:
+			allErrs = append(allErrs, field.NotSupported(patchPath.Child("op"), p.Op, []string{"add", "replace", "remove"}))
+		}
+
+		if p.ValueFrom != nil {
+			allErrs = append(allErrs, p.ValueFrom.validate(variables, patchPath.Child("valueFrom"))...)
+		}
+	}
+
+	// Ensure the merge patch is valid.
+	if d.MergePatch != nil {
+		patchPath := fldPath.Child("mergePatch")
+
+		if (d.MergePatch.Value == nil) == (d.MergePatch.ValueFrom == nil) {
+			allErrs = append(allErrs, field.Invalid(patchPath, d.MergePatch, "exactly one of value and valueFrom must be defined"))
+		}
+
+		if d.MergePatch.Value != nil {
+			fields := map[string]interface{}{}
+			if err := json.Unmarshal(d.MergePatch.Value.Raw, &fields); err != nil {
+				allErrs = append(allErrs, field.Invalid(patchPath.Child("value"), string(d.MergePatch.Value.Raw), "must be an object"))
+			}
+			for name := range fields {
+				if name != "spec" {
+					allErrs = append(allErrs, field.Invalid(patchPath.Child("value"), string(d.MergePatch.Value.Raw), "only the spec of a template can be patched"))
+					break
+				}
+			}
+		}
+
+		if d.MergePatch.ValueFrom != nil {
+			allErrs = append(allErrs, d.MergePatch.ValueFrom.validate(variables, patchPath.Child("valueFrom"))...)
+		}
+	}
+
+	return allErrs
+}
+
+func (v PatchValueFrom) validate(variables sets.String, fldPath *field.Path) field.ErrorList {
+	name := strings.Split(v.Variable, ".")[0]
+	if !variables.Has(name) {
+		return field.ErrorList{field.Invalid(fldPath.Child("variable"), v.Variable, fmt.Sprintf("variable %q is not defined in the ClusterClass", name))}
+	}
+	return nil
+}
+
 func (in ClusterClass) validateCompatibleSpecChanges(old *ClusterClass) field.ErrorList {
 	var allErrs field.ErrorList
 
@@ -151,7 +387,10 @@ func (in ClusterClass) validateCompatibleSpecChanges(old *ClusterClass) field.Er
 		)
 	}
 
-	if !reflect.DeepEqual(in.Spec.ControlPlane, old.Spec.ControlPlane) {
+	// NOTE: The MachineHealthCheck definition for the control plane can be changed.
+	controlPlane := in.Spec.ControlPlane.DeepCopy()
+	controlPlane.MachineHealthCheck = old.Spec.ControlPlane.MachineHealthCheck
+	if !reflect.DeepEqual(*controlPlane, old.Spec.ControlPlane) {
 		allErrs = append(allErrs,
 			field.Invalid(
 				field.NewPath("spec", "controlPlane"),
@@ -181,10 +420,12 @@ func (in ClusterClass) validateMachineDeploymentsChanges(old *ClusterClass) fiel
 		}
 	}
 
-	// Ensure no previous MachineDeployment class was modified.
+	// Ensure no previous MachineDeployment class template was modified.
+	// NOTE: The values used as defaults for the MachineDeployments (e.g. strategy, failureDomain) and
+	// the MachineHealthCheck definition can be changed.
 	for _, class := range in.Spec.Workers.MachineDeployments {
 		for _, oldClass := range old.Spec.Workers.MachineDeployments {
-			if class.Class == oldClass.Class && !reflect.DeepEqual(class, oldClass) {
+			if class.Class == oldClass.Class && !reflect.DeepEqual(class.Template, oldClass.Template) {
 				allErrs = append(allErrs,
 					field.Invalid(
 						field.NewPath("spec", "workers", "machineDeployments"),
@@ -18,11 +18,15 @@ package v1alpha4
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	utilfeature "k8s.io/component-base/featuregate/testing"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/cluster-api/feature"
 
 	utildefaulting "sigs.k8s.io/cluster-api/util/defaulting"
@@ -48,6 +52,12 @@ func TestClusterClassDefaultNamespaces(t *testing.T) {
 			ControlPlane: ControlPlaneClass{
 				LocalObjectTemplate:   LocalObjectTemplate{Ref: ref},
 				MachineInfrastructure: &LocalObjectTemplate{Ref: ref},
+				MachineHealthCheck: &MachineHealthCheckClass{
+					UnhealthyConditions: []UnhealthyCondition{
+						{Type: corev1.NodeReady, Status: corev1.ConditionFalse, Timeout: metav1.Duration{Duration: 5 * time.Minute}},
+					},
+					RemediationTemplate: &corev1.ObjectReference{APIVersion: "foo", Kind: "barTemplate", Name: "remediation"},
+				},
 			},
 			Workers: WorkersClass{
 				MachineDeployments: []MachineDeploymentClass{
@@ -71,6 +81,7 @@ func TestClusterClassDefaultNamespaces(t *testing.T) {
 	g.Expect(in.Spec.Infrastructure.Ref.Namespace).To(Equal(namespace))
 	g.Expect(in.Spec.ControlPlane.Ref.Namespace).To(Equal(namespace))
 	g.Expect(in.Spec.ControlPlane.MachineInfrastructure.Ref.Namespace).To(Equal(namespace))
+	g.Expect(in.Spec.ControlPlane.MachineHealthCheck.RemediationTemplate.Namespace).To(Equal(namespace))
 	for i := range in.Spec.Workers.MachineDeployments {
 		g.Expect(in.Spec.Workers.MachineDeployments[i].Template.Bootstrap.Ref.Namespace).To(Equal(namespace))
 		g.Expect(in.Spec.Workers.MachineDeployments[i].Template.Infrastructure.Ref.Namespace).To(Equal(namespace))
@@ -1056,6 +1067,62 @@ func TestClusterClassValidation(t *testing.T) {
 			},
 			expectErr: true,
 		},
+		{
+			name: "update pass if the defaults of a machine deployment class change",
+			old: &ClusterClass{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+				},
+				Spec: ClusterClassSpec{
+					Infrastructure: LocalObjectTemplate{Ref: ref},
+					ControlPlane: ControlPlaneClass{
+						LocalObjectTemplate: LocalObjectTemplate{Ref: ref},
+					},
+					Workers: WorkersClass{
+						MachineDeployments: []MachineDeploymentClass{
+							{
+								Class: "aa",
+								Template: MachineDeploymentClassTemplate{
+									Metadata:       ObjectMeta{},
+									Bootstrap:      LocalObjectTemplate{Ref: ref},
+									Infrastructure: LocalObjectTemplate{Ref: ref},
+								},
+							},
+						},
+					},
+				},
+			},
+			in: &ClusterClass{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+				},
+				Spec: ClusterClassSpec{
+					Infrastructure: LocalObjectTemplate{Ref: ref},
+					ControlPlane: ControlPlaneClass{
+						LocalObjectTemplate: LocalObjectTemplate{Ref: ref},
+					},
+					Workers: WorkersClass{
+						MachineDeployments: []MachineDeploymentClass{
+							{
+								Class: "aa",
+								Template: MachineDeploymentClassTemplate{
+									Metadata:       ObjectMeta{},
+									Bootstrap:      LocalObjectTemplate{Ref: ref},
+									Infrastructure: LocalObjectTemplate{Ref: ref},
+								},
+								FailureDomain:    pointer.StringPtr("fd1"),
+								NodeDrainTimeout: &metav1.Duration{Duration: 10 * time.Second},
+								MinReadySeconds:  pointer.Int32Ptr(5),
+								Strategy: &MachineDeploymentStrategy{
+									Type: RollingUpdateMachineDeploymentStrategyType,
+								},
+							},
+						},
+					},
+				},
+			},
+			expectErr: false,
+		},
 		{
 			name: "update pass if a machine deployment class gets added",
 			old: &ClusterClass{
@@ -1234,3 +1301,320 @@ func TestClusterClassValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestClusterClassVariablesAndPatchesValidation(t *testing.T) {
+	// NOTE: ClusterTopology feature flag is disabled by default, thus preventing to create or update ClusterClasses.
+	// Enabling the feature flag temporarily for this test.
+	defer utilfeature.SetFeatureGateDuringTest(t, feature.Gates, feature.ClusterTopology, true)()
+
+	ref := &corev1.ObjectReference{
+		APIVersion: "group.test.io/foo",
+		Kind:       "barTemplate",
+		Name:       "baz",
+		Namespace:  "default",
+	}
+	newClusterClass := func() *ClusterClass {
+		return &ClusterClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+			},
+			Spec: ClusterClassSpec{
+				Infrastructure: LocalObjectTemplate{Ref: ref},
+				ControlPlane: ControlPlaneClass{
+					LocalObjectTemplate: LocalObjectTemplate{Ref: ref},
+				},
+				Workers: WorkersClass{
+					MachineDeployments: []MachineDeploymentClass{
+						{
+							Class: "aa",
+							Template: MachineDeploymentClassTemplate{
+								Bootstrap:      LocalObjectTemplate{Ref: ref},
+								Infrastructure: LocalObjectTemplate{Ref: ref},
+							},
+						},
+					},
+				},
+				Variables: []ClusterClassVariable{
+					{
+						Name:     "region",
+						Required: true,
+						Schema: VariableSchema{
+							OpenAPIV3Schema: JSONSchemaProps{Type: "string"},
+						},
+					},
+				},
+				Patches: []ClusterClassPatch{
+					{
+						Name: "region",
+						Definitions: []PatchDefinition{
+							{
+								Selector: PatchSelector{
+									APIVersion: "group.test.io/foo",
+									Kind:       "barTemplate",
+									MatchResources: PatchSelectorMatch{
+										InfrastructureCluster:  true,
+										MachineDeploymentClass: &PatchSelectorMatchMachineDeploymentClass{Names: []string{"aa"}},
+									},
+								},
+								JSONPatches: []JSONPatch{
+									{Op: "add", Path: "/spec/template/spec/region", ValueFrom: &PatchValueFrom{Variable: "region"}},
+									{Op: "add", Path: "/spec/template/spec/clusterName", ValueFrom: &PatchValueFrom{Variable: "builtin.cluster.name"}},
+									{Op: "remove", Path: "/spec/template/spec/zone"},
+								},
+								MergePatch: &MergePatch{
+									Value: &apiextensionsv1.JSON{Raw: []byte(`{"spec":{"template":{"spec":{"size":"large"}}}}`)},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		modify    func(*ClusterClass)
+		expectErr bool
+	}{
+		{
+			name:      "pass with valid variables and patches",
+			modify:    func(*ClusterClass) {},
+			expectErr: false,
+		},
+		{
+			name: "fail with duplicated variable names",
+			modify: func(in *ClusterClass) {
+				in.Spec.Variables = append(in.Spec.Variables, in.Spec.Variables[0])
+			},
+			expectErr: true,
+		},
+		{
+			name: "fail with the builtin variable name",
+			modify: func(in *ClusterClass) {
+				in.Spec.Variables[0].Name = BuiltinVariableName
+			},
+			expectErr: true,
+		},
+		{
+			name: "fail with properties in a non object variable schema",
+			modify: func(in *ClusterClass) {
+				in.Spec.Variables[0].Schema.OpenAPIV3Schema.Properties = map[string]JSONSchemaProps{"foo": {Type: "string"}}
+			},
+			expectErr: true,
+		},
+		{
+			name: "fail with an array variable schema without items",
+			modify: func(in *ClusterClass) {
+				in.Spec.Variables[0].Schema.OpenAPIV3Schema.Type = "array"
+			},
+			expectErr: true,
+		},
+		{
+			name: "fail with duplicated patch names",
+			modify: func(in *ClusterClass) {
+				in.Spec.Patches = append(in.Spec.Patches, in.Spec.Patches[0])
+			},
+			expectErr: true,
+		},
+		{
+			name: "fail with a selector without match resources",
+			modify: func(in *ClusterClass) {
+				in.Spec.Patches[0].Definitions[0].Selector.MatchResources = PatchSelectorMatch{}
+			},
+			expectErr: true,
+		},
+		{
+			name: "fail with a selector matching an unknown MachineDeployment class",
+			modify: func(in *ClusterClass) {
+				in.Spec.Patches[0].Definitions[0].Selector.MatchResources.MachineDeploymentClass.Names = []string{"bb"}
+			},
+			expectErr: true,
+		},
+		{
+			name: "fail with a json patch outside of spec",
+			modify: func(in *ClusterClass) {
+				in.Spec.Patches[0].Definitions[0].JSONPatches[0].Path = "/metadata/labels"
+			},
+			expectErr: true,
+		},
+		{
+			name: "fail with a json patch with both value and valueFrom",
+			modify: func(in *ClusterClass) {
+				in.Spec.Patches[0].Definitions[0].JSONPatches[0].Value = &apiextensionsv1.JSON{Raw: []byte(`"us-east-1"`)}
+			},
+			expectErr: true,
+		},
+		{
+			name: "fail with a remove json patch with a value",
+			modify: func(in *ClusterClass) {
+				in.Spec.Patches[0].Definitions[0].JSONPatches[2].Value = &apiextensionsv1.JSON{Raw: []byte(`"a"`)}
+			},
+			expectErr: true,
+		},
+		{
+			name: "fail with a json patch referencing an undefined variable",
+			modify: func(in *ClusterClass) {
+				in.Spec.Patches[0].Definitions[0].JSONPatches[0].ValueFrom.Variable = "zone"
+			},
+			expectErr: true,
+		},
+		{
+			name: "fail with a merge patch outside of spec",
+			modify: func(in *ClusterClass) {
+				in.Spec.Patches[0].Definitions[0].MergePatch.Value = &apiextensionsv1.JSON{Raw: []byte(`{"metadata":{"name":"foo"}}`)}
+			},
+			expectErr: true,
+		},
+		{
+			name: "fail with a definition without patches",
+			modify: func(in *ClusterClass) {
+				in.Spec.Patches[0].Definitions[0].JSONPatches = nil
+				in.Spec.Patches[0].Definitions[0].MergePatch = nil
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			in := newClusterClass()
+			tt.modify(in)
+			if tt.expectErr {
+				g.Expect(in.validate(nil)).NotTo(Succeed())
+			} else {
+				g.Expect(in.validate(nil)).To(Succeed())
+			}
+		})
+	}
+}
+
+func TestClusterClassMachineHealthCheckValidation(t *testing.T) {
+	// NOTE: ClusterTopology feature flag is disabled by default, thus preventing to create or update ClusterClasses.
+	// Enabling the feature flag temporarily for this test.
+	defer utilfeature.SetFeatureGateDuringTest(t, feature.Gates, feature.ClusterTopology, true)()
+
+	ref := &corev1.ObjectReference{
+		APIVersion: "group.test.io/foo",
+		Kind:       "barTemplate",
+		Name:       "baz",
+		Namespace:  "default",
+	}
+	maxUnhealthy := intstr.FromString("40%")
+	newClusterClass := func() *ClusterClass {
+		return &ClusterClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+			},
+			Spec: ClusterClassSpec{
+				Infrastructure: LocalObjectTemplate{Ref: ref},
+				ControlPlane: ControlPlaneClass{
+					LocalObjectTemplate: LocalObjectTemplate{Ref: ref},
+					MachineHealthCheck: &MachineHealthCheckClass{
+						UnhealthyConditions: []UnhealthyCondition{
+							{Type: corev1.NodeReady, Status: corev1.ConditionFalse, Timeout: metav1.Duration{Duration: 5 * time.Minute}},
+						},
+					},
+				},
+				Workers: WorkersClass{
+					MachineDeployments: []MachineDeploymentClass{
+						{
+							Class: "aa",
+							Template: MachineDeploymentClassTemplate{
+								Bootstrap:      LocalObjectTemplate{Ref: ref},
+								Infrastructure: LocalObjectTemplate{Ref: ref},
+							},
+							MachineHealthCheck: &MachineHealthCheckClass{
+								UnhealthyConditions: []UnhealthyCondition{
+									{Type: corev1.NodeReady, Status: corev1.ConditionUnknown, Timeout: metav1.Duration{Duration: 5 * time.Minute}},
+								},
+								MaxUnhealthy:        &maxUnhealthy,
+								NodeStartupTimeout:  &metav1.Duration{Duration: 10 * time.Minute},
+								RemediationTemplate: ref,
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		modify    func(in *ClusterClass)
+		expectErr bool
+	}{
+		{
+			name:      "pass with valid MachineHealthChecks",
+			modify:    func(in *ClusterClass) {},
+			expectErr: false,
+		},
+		{
+			name: "pass with nodeStartupTimeout disabled",
+			modify: func(in *ClusterClass) {
+				in.Spec.ControlPlane.MachineHealthCheck.NodeStartupTimeout = &metav1.Duration{Duration: 0}
+			},
+			expectErr: false,
+		},
+		{
+			name: "fail without unhealthy conditions",
+			modify: func(in *ClusterClass) {
+				in.Spec.ControlPlane.MachineHealthCheck.UnhealthyConditions = nil
+			},
+			expectErr: true,
+		},
+		{
+			name: "fail with a nodeStartupTimeout shorter than 30s",
+			modify: func(in *ClusterClass) {
+				in.Spec.Workers.MachineDeployments[0].MachineHealthCheck.NodeStartupTimeout = &metav1.Duration{Duration: 10 * time.Second}
+			},
+			expectErr: true,
+		},
+		{
+			name: "fail with an invalid maxUnhealthy",
+			modify: func(in *ClusterClass) {
+				invalid := intstr.FromString("foo")
+				in.Spec.Workers.MachineDeployments[0].MachineHealthCheck.MaxUnhealthy = &invalid
+			},
+			expectErr: true,
+		},
+		{
+			name: "fail with a remediationTemplate in another namespace",
+			modify: func(in *ClusterClass) {
+				in.Spec.Workers.MachineDeployments[0].MachineHealthCheck.RemediationTemplate = &corev1.ObjectReference{
+					APIVersion: "group.test.io/foo",
+					Kind:       "barTemplate",
+					Name:       "baz",
+					Namespace:  "another-namespace",
+				}
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			in := newClusterClass()
+			tt.modify(in)
+			if tt.expectErr {
+				g.Expect(in.validate(nil)).NotTo(Succeed())
+			} else {
+				g.Expect(in.validate(nil)).To(Succeed())
+			}
+		})
+	}
+
+	t.Run("update pass if the MachineHealthChecks change", func(t *testing.T) {
+		g := NewWithT(t)
+
+		old := newClusterClass()
+		in := newClusterClass()
+		in.Spec.ControlPlane.MachineHealthCheck = nil
+		in.Spec.Workers.MachineDeployments[0].MachineHealthCheck.MaxUnhealthy = nil
+		g.Expect(in.validate(old)).To(Succeed())
+	})
+}
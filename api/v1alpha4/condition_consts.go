@@ -91,6 +91,47 @@ const (
 	WaitingForControlPlaneAvailableReason = "WaitingForControlPlaneAvailable"
 )
 
+// Conditions and condition Reasons for the managed topology of a Cluster object
+
+const (
+	// TopologyUpgradedCondition reports if the Kubernetes version defined in the Cluster topology has been
+	// rolled out to the control plane and to all the MachineDeployments of the Cluster.
+	TopologyUpgradedCondition ConditionType = "TopologyUpgraded"
+
+	// TopologyControlPlaneUpgradingReason (Severity=Info) documents a Cluster topology waiting for the control plane
+	// to be upgraded to the Kubernetes version defined in the topology and to be stable.
+	TopologyControlPlaneUpgradingReason = "ControlPlaneUpgrading"
+
+	// TopologyMachineDeploymentsUpgradingReason (Severity=Info) documents a Cluster topology waiting for the
+	// MachineDeployments to be upgraded to the Kubernetes version defined in the topology.
+	TopologyMachineDeploymentsUpgradingReason = "MachineDeploymentsUpgrading"
+
+	// TopologyReconciledCondition provides evidence about the reconciliation of a Cluster topology into
+	// the managed objects of the Cluster.
+	// NOTE: This condition is False also while the desired state is only partially applied because an upgrade is pending.
+	TopologyReconciledCondition ConditionType = "TopologyReconciled"
+
+	// TopologyReconciledClassNotFoundReason (Severity=Error) documents a Cluster topology that cannot be reconciled
+	// because the ClusterClass, or one of the classes referenced in the topology, does not exist.
+	TopologyReconciledClassNotFoundReason = "ClassNotFound"
+
+	// TopologyReconciledIncompatibleChangeReason (Severity=Error) documents a Cluster topology that cannot be reconciled
+	// because the desired state requires a change not supported for one of the managed objects, e.g. changing its Kind.
+	TopologyReconciledIncompatibleChangeReason = "IncompatibleChange"
+
+	// TopologyReconciledPatchFailedReason (Severity=Error) documents a Cluster topology that cannot be reconciled
+	// because the patches defined in the ClusterClass failed to apply.
+	TopologyReconciledPatchFailedReason = "PatchFailed"
+
+	// TopologyReconciledUpgradePendingReason (Severity=Info) documents a Cluster topology where the Kubernetes version
+	// defined in the topology is not yet applied to all the managed objects because the upgrade is still in progress.
+	TopologyReconciledUpgradePendingReason = "UpgradePending"
+
+	// TopologyReconcileFailedReason (Severity=Error) documents a Cluster topology that failed to reconcile
+	// for any other reason.
+	TopologyReconcileFailedReason = "ReconcileFailed"
+)
+
 // Conditions and condition Reasons for the Machine object
 
 const (
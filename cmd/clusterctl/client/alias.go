@@ -17,6 +17,7 @@ limitations under the License.
 package client
 
 import (
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/alpha"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
@@ -29,6 +30,9 @@ import (
 // Provider defines a provider configuration.
 type Provider config.Provider
 
+// ConfigValue is a clusterctl configuration value, with the layer it is read from.
+type ConfigValue config.Value
+
 // Components wraps a YAML file that defines the provider's components (CRDs, controller, RBAC rules etc.).
 type Components repository.Components
 
@@ -45,6 +49,12 @@ type UpgradePlan cluster.UpgradePlan
 // upgraded to a different version.
 type CertManagerUpgradePlan cluster.CertManagerUpgradePlan
 
+// MovePlan describes the objects that would be moved by a move operation, and the order in which they would be moved.
+type MovePlan cluster.MovePlan
+
+// RolloutRevision describes a revision of a cluster-api resource, e.g. a MachineDeployment.
+type RolloutRevision alpha.RolloutRevision
+
 // Kubeconfig is a type that specifies inputs related to the actual kubeconfig.
 type Kubeconfig cluster.Kubeconfig
 
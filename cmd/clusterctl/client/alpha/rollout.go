@@ -21,10 +21,22 @@ import (
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
 )
 
-// MachineDeployment is a resource type.
-const MachineDeployment = "machinedeployment"
+const (
+	// MachineDeployment is a resource type.
+	MachineDeployment = "machinedeployment"
 
-var validResourceTypes = []string{MachineDeployment}
+	// KubeadmControlPlane is a resource type.
+	KubeadmControlPlane = "kubeadmcontrolplane"
+
+	// MachinePool is a resource type.
+	MachinePool = "machinepool"
+)
+
+var (
+	validResourceTypes         = []string{MachineDeployment, KubeadmControlPlane, MachinePool}
+	validPauseResourceTypes    = []string{MachineDeployment, KubeadmControlPlane}
+	validRollbackResourceTypes = []string{MachineDeployment}
+)
 
 // Rollout defines the behavior of a rollout implementation.
 type Rollout interface {
@@ -32,6 +44,11 @@ type Rollout interface {
 	ObjectPauser(cluster.Proxy, corev1.ObjectReference) error
 	ObjectResumer(cluster.Proxy, corev1.ObjectReference) error
 	ObjectRollbacker(cluster.Proxy, corev1.ObjectReference, int64) error
+	// ObjectStatusViewer returns a message describing the rollout status of the specified cluster-api resource,
+	// and true if the rollout is completed.
+	ObjectStatusViewer(cluster.Proxy, corev1.ObjectReference) (string, bool, error)
+	// ObjectHistoryViewer returns the revisions of the specified cluster-api resource, sorted by revision number.
+	ObjectHistoryViewer(cluster.Proxy, corev1.ObjectReference) ([]RolloutRevision, error)
 }
 
 var _ Rollout = &rollout{}
@@ -0,0 +1,187 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alpha
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	"sigs.k8s.io/cluster-api/controllers/mdutil"
+	"sigs.k8s.io/yaml"
+)
+
+// ChangeCauseAnnotation is the annotation used to record the cause of a change, e.g. the command
+// that triggered a rollout; the annotation is copied from the MachineDeployment to its MachineSets.
+const ChangeCauseAnnotation = "kubernetes.io/change-cause"
+
+// RolloutRevision describes a revision of a cluster-api resource.
+type RolloutRevision struct {
+	// Revision number.
+	Revision int64
+
+	// MachineSet is the name of the MachineSet implementing the revision.
+	MachineSet string
+
+	// InfrastructureRef is the reference to the infrastructure template used by the revision.
+	InfrastructureRef corev1.ObjectReference
+
+	// BootstrapRef is the reference to the bootstrap config template used by the revision, if any.
+	BootstrapRef *corev1.ObjectReference
+
+	// BootstrapDataSecretName is the name of the secret with the bootstrap data used by the revision, if any.
+	BootstrapDataSecretName *string
+
+	// Version is the Kubernetes version used by the revision, if any.
+	Version *string
+
+	// ChangeCause is the cause of the revision, read from the kubernetes.io/change-cause annotation.
+	ChangeCause string
+
+	// Template is the machine template used by the revision.
+	Template clusterv1.MachineTemplateSpec
+}
+
+// ObjectHistoryViewer returns the revisions of the specified cluster-api resource, sorted by revision number.
+func (r *rollout) ObjectHistoryViewer(proxy cluster.Proxy, ref corev1.ObjectReference) ([]RolloutRevision, error) {
+	switch ref.Kind {
+	case MachineDeployment:
+		deployment, err := getMachineDeployment(proxy, ref.Name, ref.Namespace)
+		if err != nil || deployment == nil {
+			return nil, errors.Wrapf(err, "failed to get %v/%v", ref.Kind, ref.Name)
+		}
+		return machineDeploymentHistory(proxy, deployment)
+	default:
+		return nil, errors.Errorf("invalid resource type %q, valid values are %v", ref.Kind, validRollbackResourceTypes)
+	}
+}
+
+// machineDeploymentHistory returns the revisions of a MachineDeployment, one for each of its MachineSets.
+func machineDeploymentHistory(proxy cluster.Proxy, d *clusterv1.MachineDeployment) ([]RolloutRevision, error) {
+	msList, err := getMachineSetsForDeployment(proxy, d)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]RolloutRevision, 0, len(msList))
+	for _, ms := range msList {
+		// MachineSets without a valid revision are not part of the history.
+		if _, ok := ms.Annotations[clusterv1.RevisionAnnotation]; !ok {
+			continue
+		}
+		v, err := mdutil.Revision(ms)
+		if err != nil {
+			continue
+		}
+
+		// The unique label is different for each MachineSet, so it is not part of the revision.
+		template := *ms.Spec.Template.DeepCopy()
+		delete(template.Labels, mdutil.DefaultMachineDeploymentUniqueLabelKey)
+
+		revisions = append(revisions, RolloutRevision{
+			Revision:                v,
+			MachineSet:              ms.Name,
+			InfrastructureRef:       template.Spec.InfrastructureRef,
+			BootstrapRef:            template.Spec.Bootstrap.ConfigRef,
+			BootstrapDataSecretName: template.Spec.Bootstrap.DataSecretName,
+			Version:                 template.Spec.Version,
+			ChangeCause:             ms.Annotations[ChangeCauseAnnotation],
+			Template:                template,
+		})
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+	return revisions, nil
+}
+
+// DiffRevisions returns a line by line diff of the machine templates of two revisions, in a format similar to
+// unified diff; lines removed from the first revision are prefixed by "-", lines added by the second revision
+// are prefixed by "+".
+func DiffRevisions(from, to RolloutRevision) (string, error) {
+	fromYAML, err := yaml.Marshal(from.Template)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to marshal the template of revision %d", from.Revision)
+	}
+	toYAML, err := yaml.Marshal(to.Template)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to marshal the template of revision %d", to.Revision)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- revision %d\n", from.Revision)
+	fmt.Fprintf(&b, "+++ revision %d\n", to.Revision)
+	for _, l := range diffLines(splitLines(string(fromYAML)), splitLines(string(toYAML))) {
+		b.WriteString(l)
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+func splitLines(s string) []string {
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines computes the diff between two list of lines using the longest common subsequence;
+// given that machine templates are small, the quadratic complexity is not a concern.
+func diffLines(a, b []string) []string {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	ret := make([]string, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ret = append(ret, " "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ret = append(ret, "-"+a[i])
+			i++
+		default:
+			ret = append(ret, "+"+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ret = append(ret, "-"+a[i])
+	}
+	for ; j < len(b); j++ {
+		ret = append(ret, "+"+b[j])
+	}
+	return ret
+}
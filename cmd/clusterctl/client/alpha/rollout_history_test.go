@@ -0,0 +1,195 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alpha
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/cluster-api/controllers/mdutil"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_ObjectHistoryViewer(t *testing.T) {
+	deployment := &clusterv1.MachineDeployment{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "MachineDeployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-md-0",
+			Namespace: "default",
+		},
+		Spec: clusterv1.MachineDeploymentSpec{
+			ClusterName: "test",
+			Selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					clusterv1.ClusterLabelName: "test",
+				},
+			},
+		},
+	}
+	machineSet := func(name string, annotations map[string]string, version, infraTemplate string) *clusterv1.MachineSet {
+		return &clusterv1.MachineSet{
+			TypeMeta: metav1.TypeMeta{
+				Kind: "MachineSet",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(deployment, clusterv1.GroupVersion.WithKind("MachineDeployment")),
+				},
+				Labels: map[string]string{
+					clusterv1.ClusterLabelName: "test",
+				},
+				Annotations: annotations,
+			},
+			Spec: clusterv1.MachineSetSpec{
+				ClusterName: "test",
+				Template: clusterv1.MachineTemplateSpec{
+					ObjectMeta: clusterv1.ObjectMeta{
+						Labels: map[string]string{
+							clusterv1.ClusterLabelName:                    "test",
+							mdutil.DefaultMachineDeploymentUniqueLabelKey: name,
+						},
+					},
+					Spec: clusterv1.MachineSpec{
+						ClusterName: "test",
+						Version:     pointer.StringPtr(version),
+						InfrastructureRef: corev1.ObjectReference{
+							Kind: "InfrastructureMachineTemplate",
+							Name: infraTemplate,
+						},
+						Bootstrap: clusterv1.Bootstrap{
+							ConfigRef: &corev1.ObjectReference{
+								Kind: "BootstrapConfigTemplate",
+								Name: "bootstrap-template",
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	type fields struct {
+		objs []client.Object
+		ref  corev1.ObjectReference
+	}
+	tests := []struct {
+		name          string
+		fields        fields
+		wantRevisions []int64
+		wantCauses    []string
+		wantErr       bool
+	}{
+		{
+			name: "machinedeployment revisions are sorted by revision number",
+			fields: fields{
+				objs: []client.Object{
+					deployment,
+					machineSet("ms-rev-10", map[string]string{clusterv1.RevisionAnnotation: "10", ChangeCauseAnnotation: "upgrade to v1.19.3"}, "v1.19.3", "md-template-2"),
+					machineSet("ms-rev-2", map[string]string{clusterv1.RevisionAnnotation: "2"}, "v1.19.1", "md-template-1"),
+					machineSet("ms-no-rev", nil, "v1.19.1", "md-template-1"),
+				},
+				ref: corev1.ObjectReference{
+					Kind:      MachineDeployment,
+					Name:      "test-md-0",
+					Namespace: "default",
+				},
+			},
+			wantRevisions: []int64{2, 10},
+			wantCauses:    []string{"", "upgrade to v1.19.3"},
+		},
+		{
+			name: "return error if the machinedeployment does not exist",
+			fields: fields{
+				ref: corev1.ObjectReference{
+					Kind:      MachineDeployment,
+					Name:      "test-md-0",
+					Namespace: "default",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "return error for unsupported resource types",
+			fields: fields{
+				ref: corev1.ObjectReference{
+					Kind:      KubeadmControlPlane,
+					Name:      "kcp",
+					Namespace: "default",
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			r := newRolloutClient()
+			proxy := test.NewFakeProxy().WithObjs(tt.fields.objs...)
+			revisions, err := r.ObjectHistoryViewer(proxy, tt.fields.ref)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(revisions).To(HaveLen(len(tt.wantRevisions)))
+			for i, rev := range revisions {
+				g.Expect(rev.Revision).To(Equal(tt.wantRevisions[i]))
+				g.Expect(rev.ChangeCause).To(Equal(tt.wantCauses[i]))
+				g.Expect(rev.BootstrapRef).ToNot(BeNil())
+				g.Expect(rev.Template.Labels).ToNot(HaveKey(mdutil.DefaultMachineDeploymentUniqueLabelKey))
+			}
+		})
+	}
+}
+
+func Test_DiffRevisions(t *testing.T) {
+	g := NewWithT(t)
+
+	from := RolloutRevision{
+		Revision: 1,
+		Template: clusterv1.MachineTemplateSpec{
+			Spec: clusterv1.MachineSpec{
+				ClusterName: "test",
+				Version:     pointer.StringPtr("v1.19.1"),
+			},
+		},
+	}
+	to := RolloutRevision{
+		Revision: 2,
+		Template: clusterv1.MachineTemplateSpec{
+			Spec: clusterv1.MachineSpec{
+				ClusterName: "test",
+				Version:     pointer.StringPtr("v1.19.3"),
+			},
+		},
+	}
+
+	diff, err := DiffRevisions(from, to)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(diff).To(ContainSubstring("--- revision 1\n+++ revision 2\n"))
+	g.Expect(diff).To(ContainSubstring("-  version: v1.19.1\n+  version: v1.19.3\n"))
+	g.Expect(diff).To(ContainSubstring("   clusterName: test\n"))
+}
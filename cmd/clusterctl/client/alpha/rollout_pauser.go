@@ -22,7 +22,9 @@ import (
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -40,8 +42,19 @@ func (r *rollout) ObjectPauser(proxy cluster.Proxy, ref corev1.ObjectReference)
 		if err := pauseMachineDeployment(proxy, ref.Name, ref.Namespace); err != nil {
 			return err
 		}
+	case KubeadmControlPlane:
+		kcp, err := getKubeadmControlPlane(proxy, ref.Name, ref.Namespace)
+		if err != nil || kcp == nil {
+			return errors.Wrapf(err, "failed to fetch %v/%v", ref.Kind, ref.Name)
+		}
+		if annotations.HasPausedAnnotation(kcp) {
+			return errors.Errorf("KubeadmControlPlane is already paused: %v/%v\n", ref.Kind, ref.Name)
+		}
+		if err := pauseKubeadmControlPlane(proxy, ref.Name, ref.Namespace); err != nil {
+			return err
+		}
 	default:
-		return errors.Errorf("Invalid resource type %q, valid values are %v", ref.Kind, validResourceTypes)
+		return errors.Errorf("Invalid resource type %q, valid values are %v", ref.Kind, validPauseResourceTypes)
 	}
 	return nil
 }
@@ -51,3 +64,10 @@ func pauseMachineDeployment(proxy cluster.Proxy, name, namespace string) error {
 	patch := client.RawPatch(types.MergePatchType, []byte(fmt.Sprintf("{\"spec\":{\"paused\":%t}}", true)))
 	return patchMachineDeployemt(proxy, name, namespace, patch)
 }
+
+// pauseKubeadmControlPlane sets the paused annotation on the KubeadmControlPlane.
+// NOTE: KubeadmControlPlane does not have a spec.paused field, so the paused annotation is used instead.
+func pauseKubeadmControlPlane(proxy cluster.Proxy, name, namespace string) error {
+	patch := client.RawPatch(types.MergePatchType, []byte(fmt.Sprintf("{\"metadata\":{\"annotations\":{%q:\"true\"}}}", clusterv1.PausedAnnotation)))
+	return patchKubeadmControlPlane(proxy, name, namespace, patch)
+}
@@ -24,6 +24,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -41,6 +42,31 @@ func (r *rollout) ObjectRestarter(proxy cluster.Proxy, ref corev1.ObjectReferenc
 		if err := setRestartedAtAnnotation(proxy, ref.Name, ref.Namespace); err != nil {
 			return err
 		}
+	case KubeadmControlPlane:
+		kcp, err := getKubeadmControlPlane(proxy, ref.Name, ref.Namespace)
+		if err != nil || kcp == nil {
+			return errors.Wrapf(err, "failed to fetch %v/%v", ref.Kind, ref.Name)
+		}
+		if annotations.HasPausedAnnotation(kcp) {
+			return errors.Errorf("can't restart paused kubeadmcontrolplane (run rollout resume first): %v/%v\n", ref.Kind, ref.Name)
+		}
+		if kcp.Spec.RolloutAfter != nil && kcp.Spec.RolloutAfter.After(time.Now()) {
+			return errors.Errorf("can't update kubeadmcontrolplane (remove 'spec.rolloutAfter' first): %v/%v\n", ref.Kind, ref.Name)
+		}
+		if err := setRolloutAfter(proxy, ref.Name, ref.Namespace); err != nil {
+			return err
+		}
+	case MachinePool:
+		machinePool, err := getMachinePool(proxy, ref.Name, ref.Namespace)
+		if err != nil || machinePool == nil {
+			return errors.Wrapf(err, "failed to fetch %v/%v", ref.Kind, ref.Name)
+		}
+		if annotations.HasPausedAnnotation(machinePool) {
+			return errors.Errorf("can't restart paused machinepool: %v/%v\n", ref.Kind, ref.Name)
+		}
+		if err := patchMachinePool(proxy, ref.Name, ref.Namespace, restartedAtPatch()); err != nil {
+			return err
+		}
 	default:
 		return errors.Errorf("Invalid resource type %v. Valid values: %v", ref.Kind, validResourceTypes)
 	}
@@ -49,6 +75,18 @@ func (r *rollout) ObjectRestarter(proxy cluster.Proxy, ref corev1.ObjectReferenc
 
 // setRestartedAtAnnotation sets the restartedAt annotation in the MachineDeployment's spec.template.objectmeta.
 func setRestartedAtAnnotation(proxy cluster.Proxy, name, namespace string) error {
-	patch := client.RawPatch(types.MergePatchType, []byte(fmt.Sprintf("{\"spec\":{\"template\":{\"metadata\":{\"annotations\":{\"cluster.x-k8s.io/restartedAt\":\"%v\"}}}}}", time.Now().Format(time.RFC3339))))
-	return patchMachineDeployemt(proxy, name, namespace, patch)
+	return patchMachineDeployemt(proxy, name, namespace, restartedAtPatch())
+}
+
+// restartedAtPatch returns a patch setting the restartedAt annotation in spec.template.objectmeta, which is
+// used both by MachineDeployments and MachinePools.
+func restartedAtPatch() client.Patch {
+	return client.RawPatch(types.MergePatchType, []byte(fmt.Sprintf("{\"spec\":{\"template\":{\"metadata\":{\"annotations\":{\"cluster.x-k8s.io/restartedAt\":\"%v\"}}}}}", time.Now().Format(time.RFC3339))))
+}
+
+// setRolloutAfter sets RolloutAfter to the current time in the KubeadmControlPlane's spec, so all the machines
+// created before are rolled out.
+func setRolloutAfter(proxy cluster.Proxy, name, namespace string) error {
+	patch := client.RawPatch(types.MergePatchType, []byte(fmt.Sprintf("{\"spec\":{\"rolloutAfter\":\"%v\"}}", time.Now().Format(time.RFC3339))))
+	return patchKubeadmControlPlane(proxy, name, namespace, patch)
 }
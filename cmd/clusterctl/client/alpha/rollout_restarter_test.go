@@ -25,6 +25,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha4"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha4"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -38,6 +40,8 @@ func Test_ObjectRestarter(t *testing.T) {
 		fields         fields
 		wantErr        bool
 		wantAnnotation bool
+		// wantRolloutAfter is used instead of wantAnnotation for KubeadmControlPlanes.
+		wantRolloutAfter bool
 	}{
 		{
 			name: "machinedeployment should have restart annotation",
@@ -90,6 +94,104 @@ func Test_ObjectRestarter(t *testing.T) {
 			wantErr:        true,
 			wantAnnotation: false,
 		},
+		{
+			name: "kubeadmcontrolplane should have rolloutAfter",
+			fields: fields{
+				objs: []client.Object{
+					&controlplanev1.KubeadmControlPlane{
+						TypeMeta: metav1.TypeMeta{
+							Kind: "KubeadmControlPlane",
+						},
+						ObjectMeta: metav1.ObjectMeta{
+							Namespace: "default",
+							Name:      "kcp",
+						},
+					},
+				},
+				ref: corev1.ObjectReference{
+					Kind:      KubeadmControlPlane,
+					Name:      "kcp",
+					Namespace: "default",
+				},
+			},
+			wantErr:          false,
+			wantRolloutAfter: true,
+		},
+		{
+			name: "paused kubeadmcontrolplane should not have rolloutAfter",
+			fields: fields{
+				objs: []client.Object{
+					&controlplanev1.KubeadmControlPlane{
+						TypeMeta: metav1.TypeMeta{
+							Kind: "KubeadmControlPlane",
+						},
+						ObjectMeta: metav1.ObjectMeta{
+							Namespace: "default",
+							Name:      "kcp",
+							Annotations: map[string]string{
+								clusterv1.PausedAnnotation: "true",
+							},
+						},
+					},
+				},
+				ref: corev1.ObjectReference{
+					Kind:      KubeadmControlPlane,
+					Name:      "kcp",
+					Namespace: "default",
+				},
+			},
+			wantErr:          true,
+			wantRolloutAfter: false,
+		},
+		{
+			name: "machinepool should have restart annotation",
+			fields: fields{
+				objs: []client.Object{
+					&expv1.MachinePool{
+						TypeMeta: metav1.TypeMeta{
+							Kind: "MachinePool",
+						},
+						ObjectMeta: metav1.ObjectMeta{
+							Namespace: "default",
+							Name:      "mp-1",
+						},
+					},
+				},
+				ref: corev1.ObjectReference{
+					Kind:      MachinePool,
+					Name:      "mp-1",
+					Namespace: "default",
+				},
+			},
+			wantErr:        false,
+			wantAnnotation: true,
+		},
+		{
+			name: "paused machinepool should not have restart annotation",
+			fields: fields{
+				objs: []client.Object{
+					&expv1.MachinePool{
+						TypeMeta: metav1.TypeMeta{
+							Kind: "MachinePool",
+						},
+						ObjectMeta: metav1.ObjectMeta{
+							Namespace: "default",
+							Name:      "mp-1",
+							Annotations: map[string]string{
+								clusterv1.PausedAnnotation: "true",
+							},
+						},
+					},
+				},
+				ref: corev1.ObjectReference{
+					Kind:      MachinePool,
+					Name:      "mp-1",
+					Namespace: "default",
+				},
+			},
+			wantErr:        true,
+			wantAnnotation: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -106,13 +208,30 @@ func Test_ObjectRestarter(t *testing.T) {
 				cl, err := proxy.NewClient()
 				g.Expect(err).ToNot(HaveOccurred())
 				key := client.ObjectKeyFromObject(obj)
-				md := &clusterv1.MachineDeployment{}
-				err = cl.Get(context.TODO(), key, md)
-				g.Expect(err).ToNot(HaveOccurred())
-				if tt.wantAnnotation {
-					g.Expect(md.Spec.Template.Annotations).To(HaveKey("cluster.x-k8s.io/restartedAt"))
-				} else {
-					g.Expect(md.Spec.Template.Annotations).ToNot(HaveKey("cluster.x-k8s.io/restartedAt"))
+				switch obj.(type) {
+				case *controlplanev1.KubeadmControlPlane:
+					kcp := &controlplanev1.KubeadmControlPlane{}
+					err = cl.Get(context.TODO(), key, kcp)
+					g.Expect(err).ToNot(HaveOccurred())
+					g.Expect(kcp.Spec.RolloutAfter != nil).To(Equal(tt.wantRolloutAfter))
+				case *expv1.MachinePool:
+					mp := &expv1.MachinePool{}
+					err = cl.Get(context.TODO(), key, mp)
+					g.Expect(err).ToNot(HaveOccurred())
+					if tt.wantAnnotation {
+						g.Expect(mp.Spec.Template.Annotations).To(HaveKey("cluster.x-k8s.io/restartedAt"))
+					} else {
+						g.Expect(mp.Spec.Template.Annotations).ToNot(HaveKey("cluster.x-k8s.io/restartedAt"))
+					}
+				default:
+					md := &clusterv1.MachineDeployment{}
+					err = cl.Get(context.TODO(), key, md)
+					g.Expect(err).ToNot(HaveOccurred())
+					if tt.wantAnnotation {
+						g.Expect(md.Spec.Template.Annotations).To(HaveKey("cluster.x-k8s.io/restartedAt"))
+					} else {
+						g.Expect(md.Spec.Template.Annotations).ToNot(HaveKey("cluster.x-k8s.io/restartedAt"))
+					}
 				}
 			}
 		})
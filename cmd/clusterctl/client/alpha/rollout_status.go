@@ -0,0 +1,133 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alpha
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha4"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha4"
+)
+
+// ObjectStatusViewer returns a message describing the rollout status of the specified cluster-api resource,
+// and true if the rollout is completed.
+func (r *rollout) ObjectStatusViewer(proxy cluster.Proxy, ref corev1.ObjectReference) (string, bool, error) {
+	switch ref.Kind {
+	case MachineDeployment:
+		deployment, err := getMachineDeployment(proxy, ref.Name, ref.Namespace)
+		if err != nil || deployment == nil {
+			return "", false, errors.Wrapf(err, "failed to fetch %v/%v", ref.Kind, ref.Name)
+		}
+		msg, done := machineDeploymentStatus(deployment)
+		return msg, done, nil
+	case KubeadmControlPlane:
+		kcp, err := getKubeadmControlPlane(proxy, ref.Name, ref.Namespace)
+		if err != nil || kcp == nil {
+			return "", false, errors.Wrapf(err, "failed to fetch %v/%v", ref.Kind, ref.Name)
+		}
+		msg, done := kubeadmControlPlaneStatus(kcp)
+		return msg, done, nil
+	case MachinePool:
+		machinePool, err := getMachinePool(proxy, ref.Name, ref.Namespace)
+		if err != nil || machinePool == nil {
+			return "", false, errors.Wrapf(err, "failed to fetch %v/%v", ref.Kind, ref.Name)
+		}
+		msg, done := machinePoolStatus(machinePool)
+		return msg, done, nil
+	default:
+		return "", false, errors.Errorf("Invalid resource type %q, valid values are %v", ref.Kind, validResourceTypes)
+	}
+}
+
+// machineDeploymentStatus returns the rollout status of a MachineDeployment, using the same criteria
+// as kubectl rollout status for Deployments.
+func machineDeploymentStatus(deployment *clusterv1.MachineDeployment) (string, bool) {
+	name := deployment.Name
+	if deployment.Generation > deployment.Status.ObservedGeneration {
+		return fmt.Sprintf("Waiting for machinedeployment spec update to be observed: %s", name), false
+	}
+
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	status := deployment.Status
+	if status.UpdatedReplicas < desired {
+		return fmt.Sprintf("Waiting for machinedeployment %q rollout to finish: %d out of %d new machines have been updated", name, status.UpdatedReplicas, desired), false
+	}
+	if status.Replicas > status.UpdatedReplicas {
+		return fmt.Sprintf("Waiting for machinedeployment %q rollout to finish: %d old machines are pending termination", name, status.Replicas-status.UpdatedReplicas), false
+	}
+	if status.AvailableReplicas < status.UpdatedReplicas {
+		return fmt.Sprintf("Waiting for machinedeployment %q rollout to finish: %d of %d updated machines are available", name, status.AvailableReplicas, status.UpdatedReplicas), false
+	}
+	return fmt.Sprintf("machinedeployment %q successfully rolled out", name), true
+}
+
+// kubeadmControlPlaneStatus returns the rollout status of a KubeadmControlPlane; the rollout is completed
+// when all the desired machines are up-to-date and ready, and there are no old machines left.
+func kubeadmControlPlaneStatus(kcp *controlplanev1.KubeadmControlPlane) (string, bool) {
+	name := kcp.Name
+	if kcp.Generation > kcp.Status.ObservedGeneration {
+		return fmt.Sprintf("Waiting for kubeadmcontrolplane spec update to be observed: %s", name), false
+	}
+
+	desired := int32(1)
+	if kcp.Spec.Replicas != nil {
+		desired = *kcp.Spec.Replicas
+	}
+	status := kcp.Status
+	if status.UpdatedReplicas < desired {
+		return fmt.Sprintf("Waiting for kubeadmcontrolplane %q rollout to finish: %d out of %d new machines have been updated", name, status.UpdatedReplicas, desired), false
+	}
+	if status.Replicas > status.UpdatedReplicas {
+		return fmt.Sprintf("Waiting for kubeadmcontrolplane %q rollout to finish: %d old machines are pending termination", name, status.Replicas-status.UpdatedReplicas), false
+	}
+	if status.ReadyReplicas < desired {
+		return fmt.Sprintf("Waiting for kubeadmcontrolplane %q rollout to finish: %d of %d updated machines are ready", name, status.ReadyReplicas, desired), false
+	}
+	return fmt.Sprintf("kubeadmcontrolplane %q successfully rolled out", name), true
+}
+
+// machinePoolStatus returns the rollout status of a MachinePool; given that MachinePools do not report
+// the number of updated replicas, the rollout is completed when all the desired replicas are ready and available.
+func machinePoolStatus(machinePool *expv1.MachinePool) (string, bool) {
+	name := machinePool.Name
+	if machinePool.Generation > machinePool.Status.ObservedGeneration {
+		return fmt.Sprintf("Waiting for machinepool spec update to be observed: %s", name), false
+	}
+
+	desired := int32(1)
+	if machinePool.Spec.Replicas != nil {
+		desired = *machinePool.Spec.Replicas
+	}
+	status := machinePool.Status
+	if status.Replicas != desired {
+		return fmt.Sprintf("Waiting for machinepool %q rollout to finish: %d out of %d replicas have been created", name, status.Replicas, desired), false
+	}
+	if status.ReadyReplicas < desired {
+		return fmt.Sprintf("Waiting for machinepool %q rollout to finish: %d of %d replicas are ready", name, status.ReadyReplicas, desired), false
+	}
+	if status.AvailableReplicas < desired || status.UnavailableReplicas > 0 {
+		return fmt.Sprintf("Waiting for machinepool %q rollout to finish: %d of %d replicas are available", name, status.AvailableReplicas, desired), false
+	}
+	return fmt.Sprintf("machinepool %q successfully rolled out", name), true
+}
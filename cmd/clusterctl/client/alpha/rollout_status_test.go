@@ -0,0 +1,265 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alpha
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha4"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_ObjectStatusViewer(t *testing.T) {
+	type fields struct {
+		objs []client.Object
+		ref  corev1.ObjectReference
+	}
+	tests := []struct {
+		name        string
+		fields      fields
+		wantMessage string
+		wantDone    bool
+		wantErr     bool
+	}{
+		{
+			name: "machinedeployment with a spec update not yet observed",
+			fields: fields{
+				objs: []client.Object{
+					&clusterv1.MachineDeployment{
+						ObjectMeta: metav1.ObjectMeta{
+							Namespace:  "default",
+							Name:       "md-1",
+							Generation: 2,
+						},
+						Status: clusterv1.MachineDeploymentStatus{
+							ObservedGeneration: 1,
+						},
+					},
+				},
+				ref: corev1.ObjectReference{
+					Kind:      MachineDeployment,
+					Name:      "md-1",
+					Namespace: "default",
+				},
+			},
+			wantMessage: "Waiting for machinedeployment spec update to be observed: md-1",
+			wantDone:    false,
+		},
+		{
+			name: "machinedeployment with old machines pending termination",
+			fields: fields{
+				objs: []client.Object{
+					&clusterv1.MachineDeployment{
+						ObjectMeta: metav1.ObjectMeta{
+							Namespace: "default",
+							Name:      "md-1",
+						},
+						Spec: clusterv1.MachineDeploymentSpec{
+							Replicas: pointer.Int32Ptr(3),
+						},
+						Status: clusterv1.MachineDeploymentStatus{
+							Replicas:          4,
+							UpdatedReplicas:   3,
+							AvailableReplicas: 3,
+						},
+					},
+				},
+				ref: corev1.ObjectReference{
+					Kind:      MachineDeployment,
+					Name:      "md-1",
+					Namespace: "default",
+				},
+			},
+			wantMessage: "Waiting for machinedeployment \"md-1\" rollout to finish: 1 old machines are pending termination",
+			wantDone:    false,
+		},
+		{
+			name: "machinedeployment successfully rolled out",
+			fields: fields{
+				objs: []client.Object{
+					&clusterv1.MachineDeployment{
+						ObjectMeta: metav1.ObjectMeta{
+							Namespace: "default",
+							Name:      "md-1",
+						},
+						Spec: clusterv1.MachineDeploymentSpec{
+							Replicas: pointer.Int32Ptr(3),
+						},
+						Status: clusterv1.MachineDeploymentStatus{
+							Replicas:          3,
+							UpdatedReplicas:   3,
+							AvailableReplicas: 3,
+						},
+					},
+				},
+				ref: corev1.ObjectReference{
+					Kind:      MachineDeployment,
+					Name:      "md-1",
+					Namespace: "default",
+				},
+			},
+			wantMessage: "machinedeployment \"md-1\" successfully rolled out",
+			wantDone:    true,
+		},
+		{
+			name: "kubeadmcontrolplane with machines not yet updated",
+			fields: fields{
+				objs: []client.Object{
+					&controlplanev1.KubeadmControlPlane{
+						ObjectMeta: metav1.ObjectMeta{
+							Namespace: "default",
+							Name:      "kcp",
+						},
+						Spec: controlplanev1.KubeadmControlPlaneSpec{
+							Replicas: pointer.Int32Ptr(3),
+						},
+						Status: controlplanev1.KubeadmControlPlaneStatus{
+							Replicas:        3,
+							UpdatedReplicas: 1,
+							ReadyReplicas:   3,
+						},
+					},
+				},
+				ref: corev1.ObjectReference{
+					Kind:      KubeadmControlPlane,
+					Name:      "kcp",
+					Namespace: "default",
+				},
+			},
+			wantMessage: "Waiting for kubeadmcontrolplane \"kcp\" rollout to finish: 1 out of 3 new machines have been updated",
+			wantDone:    false,
+		},
+		{
+			name: "kubeadmcontrolplane successfully rolled out",
+			fields: fields{
+				objs: []client.Object{
+					&controlplanev1.KubeadmControlPlane{
+						ObjectMeta: metav1.ObjectMeta{
+							Namespace: "default",
+							Name:      "kcp",
+						},
+						Spec: controlplanev1.KubeadmControlPlaneSpec{
+							Replicas: pointer.Int32Ptr(3),
+						},
+						Status: controlplanev1.KubeadmControlPlaneStatus{
+							Replicas:        3,
+							UpdatedReplicas: 3,
+							ReadyReplicas:   3,
+						},
+					},
+				},
+				ref: corev1.ObjectReference{
+					Kind:      KubeadmControlPlane,
+					Name:      "kcp",
+					Namespace: "default",
+				},
+			},
+			wantMessage: "kubeadmcontrolplane \"kcp\" successfully rolled out",
+			wantDone:    true,
+		},
+		{
+			name: "machinepool with unavailable replicas",
+			fields: fields{
+				objs: []client.Object{
+					&expv1.MachinePool{
+						ObjectMeta: metav1.ObjectMeta{
+							Namespace: "default",
+							Name:      "mp-1",
+						},
+						Spec: expv1.MachinePoolSpec{
+							Replicas: pointer.Int32Ptr(2),
+						},
+						Status: expv1.MachinePoolStatus{
+							Replicas:            2,
+							ReadyReplicas:       2,
+							AvailableReplicas:   1,
+							UnavailableReplicas: 1,
+						},
+					},
+				},
+				ref: corev1.ObjectReference{
+					Kind:      MachinePool,
+					Name:      "mp-1",
+					Namespace: "default",
+				},
+			},
+			wantMessage: "Waiting for machinepool \"mp-1\" rollout to finish: 1 of 2 replicas are available",
+			wantDone:    false,
+		},
+		{
+			name: "machinepool successfully rolled out",
+			fields: fields{
+				objs: []client.Object{
+					&expv1.MachinePool{
+						ObjectMeta: metav1.ObjectMeta{
+							Namespace: "default",
+							Name:      "mp-1",
+						},
+						Spec: expv1.MachinePoolSpec{
+							Replicas: pointer.Int32Ptr(2),
+						},
+						Status: expv1.MachinePoolStatus{
+							Replicas:          2,
+							ReadyReplicas:     2,
+							AvailableReplicas: 2,
+						},
+					},
+				},
+				ref: corev1.ObjectReference{
+					Kind:      MachinePool,
+					Name:      "mp-1",
+					Namespace: "default",
+				},
+			},
+			wantMessage: "machinepool \"mp-1\" successfully rolled out",
+			wantDone:    true,
+		},
+		{
+			name: "return error if the object does not exist",
+			fields: fields{
+				ref: corev1.ObjectReference{
+					Kind:      KubeadmControlPlane,
+					Name:      "kcp",
+					Namespace: "default",
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			r := newRolloutClient()
+			proxy := test.NewFakeProxy().WithObjs(tt.fields.objs...)
+			message, done, err := r.ObjectStatusViewer(proxy, tt.fields.ref)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(message).To(Equal(tt.wantMessage))
+			g.Expect(done).To(Equal(tt.wantDone))
+		})
+	}
+}
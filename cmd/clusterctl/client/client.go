@@ -30,6 +30,13 @@ type Client interface {
 	// GetProvidersConfig returns the list of providers configured for this instance of clusterctl.
 	GetProvidersConfig() ([]Provider, error)
 
+	// GetConfigValues returns the effective clusterctl configuration values, with the layer each value is read from.
+	GetConfigValues() ([]ConfigValue, error)
+
+	// ValidateConfig checks the clusterctl configuration, and more specifically the provider URLs, the image overrides
+	// and the cert-manager configuration.
+	ValidateConfig() error
+
 	// GetProviderComponents returns the provider components for a given provider with options including targetNamespace.
 	GetProviderComponents(provider string, providerType clusterctlv1.ProviderType, options ComponentsOptions) (Components, error)
 
@@ -51,10 +58,13 @@ type Client interface {
 	// Move moves all the Cluster API objects existing in a namespace (or from all the namespaces if empty) to a target management cluster.
 	Move(options MoveOptions) error
 
-	// Backup saves all the Cluster API objects existing in a namespace (or from all the namespaces if empty) to a target management cluster.
+	// PlanMove returns the plan of a move operation without performing any action.
+	PlanMove(options MoveOptions) (*MovePlan, error)
+
+	// Backup saves all the Cluster API objects existing in a namespace (or from all the namespaces if empty) to a backup archive.
 	Backup(options BackupOptions) error
 
-	// Restore restores all the Cluster API objects existing in a configured directory based on a glob to a target management cluster.
+	// Restore restores all the Cluster API objects existing in a backup archive to a target management cluster.
 	Restore(options RestoreOptions) error
 
 	// PlanUpgrade returns a set of suggested Upgrade plans for the cluster, and more specifically:
@@ -89,6 +99,12 @@ type AlphaClient interface {
 	RolloutResume(options RolloutOptions) error
 	// RolloutUndo provides rollout rollback of cluster-api resources
 	RolloutUndo(options RolloutOptions) error
+	// RolloutStatus provides rollout status of cluster-api resources
+	RolloutStatus(options RolloutOptions) error
+	// RolloutHistory provides rollout history of cluster-api resources
+	RolloutHistory(options RolloutOptions) ([]RolloutHistory, error)
+	// Mirror creates a bundle with providers, cert-manager and the list of required images for air-gapped environments
+	Mirror(options MirrorOptions) (*MirrorBundle, error)
 }
 
 // YamlPrinter exposes methods that prints the processed template and
@@ -103,6 +119,7 @@ type YamlPrinter interface {
 
 // clusterctlClient implements Client.
 type clusterctlClient struct {
+	kubeconfig              Kubeconfig
 	configClient            config.Client
 	repositoryClientFactory RepositoryClientFactory
 	clusterClientFactory    ClusterClientFactory
@@ -140,6 +157,15 @@ func InjectConfig(config config.Client) Option {
 	}
 }
 
+// InjectKubeconfig sets the kubeconfig for the management cluster, so the clusterctl configuration for its
+// kube context is used; if not set, the current context in the default kubeconfig is used.
+// NOTE: this option is ignored if a configuration client is injected.
+func InjectKubeconfig(kubeconfig Kubeconfig) Option {
+	return func(c *clusterctlClient) {
+		c.kubeconfig = kubeconfig
+	}
+}
+
 // InjectRepositoryFactory allows to override the default factory used for creating
 // RepositoryClient objects.
 func InjectRepositoryFactory(factory RepositoryClientFactory) Option {
@@ -170,7 +196,7 @@ func newClusterctlClient(path string, options ...Option) (*clusterctlClient, err
 	// if there is an injected config, use it, otherwise use the default one
 	// provided by the config low level library.
 	if client.configClient == nil {
-		c, err := config.New(path)
+		c, err := config.New(path, config.InjectKubeconfig(client.kubeconfig.Path, client.kubeconfig.Context))
 		if err != nil {
 			return nil, err
 		}
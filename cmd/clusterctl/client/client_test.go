@@ -76,6 +76,14 @@ func (f fakeClient) GetProvidersConfig() ([]Provider, error) {
 	return f.internalClient.GetProvidersConfig()
 }
 
+func (f fakeClient) GetConfigValues() ([]ConfigValue, error) {
+	return f.internalClient.GetConfigValues()
+}
+
+func (f fakeClient) ValidateConfig() error {
+	return f.internalClient.ValidateConfig()
+}
+
 func (f fakeClient) GetProviderComponents(provider string, providerType clusterctlv1.ProviderType, options ComponentsOptions) (Components, error) {
 	return f.internalClient.GetProviderComponents(provider, providerType, options)
 }
@@ -104,6 +112,10 @@ func (f fakeClient) Move(options MoveOptions) error {
 	return f.internalClient.Move(options)
 }
 
+func (f fakeClient) PlanMove(options MoveOptions) (*MovePlan, error) {
+	return f.internalClient.PlanMove(options)
+}
+
 func (f fakeClient) Backup(options BackupOptions) error {
 	return f.internalClient.Backup(options)
 }
@@ -148,6 +160,18 @@ func (f fakeClient) RolloutUndo(options RolloutOptions) error {
 	return f.internalClient.RolloutUndo(options)
 }
 
+func (f fakeClient) RolloutStatus(options RolloutOptions) error {
+	return f.internalClient.RolloutStatus(options)
+}
+
+func (f fakeClient) RolloutHistory(options RolloutOptions) ([]RolloutHistory, error) {
+	return f.internalClient.RolloutHistory(options)
+}
+
+func (f fakeClient) Mirror(options MirrorOptions) (*MirrorBundle, error) {
+	return f.internalClient.Mirror(options)
+}
+
 // newFakeClient returns a clusterctl client that allows to execute tests on a set of fake config, fake repositories and fake clusters.
 // you can use WithCluster and WithRepository to prepare for the test case.
 func newFakeClient(configClient config.Client) *fakeClient {
@@ -177,7 +201,7 @@ func newFakeClient(configClient config.Client) *fakeClient {
 			if _, ok := fake.repositories[input.Provider.ManifestLabel()]; !ok {
 				return nil, errors.Errorf("Repository for kubeconfig %q does not exist.", input.Provider.ManifestLabel())
 			}
-			return fake.repositories[input.Provider.ManifestLabel()], nil
+			return withRepositoryProcessor(fake.repositories[input.Provider.ManifestLabel()], input.Processor), nil
 		}),
 	)
 
@@ -380,13 +404,21 @@ func (f fakeConfigClient) ImageMeta() config.ImageMetaClient {
 	return f.internalclient.ImageMeta()
 }
 
+func (f fakeConfigClient) View() ([]config.Value, error) {
+	return f.internalclient.View()
+}
+
+func (f fakeConfigClient) Validate() error {
+	return f.internalclient.Validate()
+}
+
 func (f *fakeConfigClient) WithVar(key, value string) *fakeConfigClient {
 	f.fakeReader.WithVar(key, value)
 	return f
 }
 
 func (f *fakeConfigClient) WithProvider(provider config.Provider) *fakeConfigClient {
-	f.fakeReader.WithProvider(provider.Name(), provider.Type(), provider.URL())
+	f.fakeReader.WithProviderTemplateProcessor(provider.Name(), provider.Type(), provider.URL(), provider.TemplateProcessor())
 	return f
 }
 
@@ -453,6 +485,17 @@ func (f fakeRepositoryClient) Metadata(version string) repository.MetadataClient
 	}
 }
 
+// withRepositoryProcessor returns a copy of a fake repository using the given yaml processor, if any.
+func withRepositoryProcessor(repositoryClient repository.Client, processor yaml.Processor) repository.Client {
+	f, ok := repositoryClient.(*fakeRepositoryClient)
+	if !ok || processor == nil {
+		return repositoryClient
+	}
+	r := *f
+	r.processor = processor
+	return &r
+}
+
 func (f *fakeRepositoryClient) WithPaths(rootPath, componentsPath string) *fakeRepositoryClient {
 	f.fakeRepository.WithPaths(rootPath, componentsPath)
 	return f
@@ -486,14 +529,18 @@ type fakeTemplateClient struct {
 	processor             yaml.Processor
 }
 
-func (f *fakeTemplateClient) Get(flavor, targetNamespace string, skipTemplateProcess bool) (repository.Template, error) {
+func (f *fakeTemplateClient) Raw(flavor string) ([]byte, error) {
 	name := "cluster-template"
 	if flavor != "" {
 		name = fmt.Sprintf("%s-%s", name, flavor)
 	}
 	name = fmt.Sprintf("%s.yaml", name)
 
-	content, err := f.fakeRepository.GetFile(f.version, name)
+	return f.fakeRepository.GetFile(f.version, name)
+}
+
+func (f *fakeTemplateClient) Get(flavor, targetNamespace string, skipTemplateProcess bool) (repository.Template, error) {
+	content, err := f.Raw(flavor)
 	if err != nil {
 		return nil, err
 	}
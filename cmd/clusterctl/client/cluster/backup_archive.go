@@ -0,0 +1,390 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// backupManifestFileName is the name of the file describing the content of a backup archive.
+	backupManifestFileName = "manifest.yaml"
+
+	// backupManifestVersion is the version of the backup manifest format written by this version of clusterctl.
+	backupManifestVersion = "v1"
+
+	// maxBackupArchiveEntrySize is the max size of a single file read from a backup archive.
+	maxBackupArchiveEntrySize = 100 * 1024 * 1024
+)
+
+// backupManifest describes the content of a backup archive.
+type backupManifest struct {
+	// Version of the backup manifest format.
+	Version string `json:"version"`
+
+	// Groups contains the objects included in the backup, grouped according to the move sequence.
+	Groups [][]backupManifestObject `json:"groups"`
+
+	// Providers contains the provider inventory of the source management cluster at backup time.
+	Providers []backupManifestProvider `json:"providers,omitempty"`
+}
+
+// backupManifestObject describes an object included in a backup archive.
+type backupManifestObject struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+
+	// File is the name of the file holding the object in the backup archive.
+	File string `json:"file"`
+
+	// SHA256 is the hex encoded checksum of the file holding the object.
+	// NOTE: checksums are stored in the archive together with the files, so they detect accidental corruption of
+	// the archive only, not a deliberate modification of both a file and the manifest.
+	SHA256 string `json:"sha256"`
+}
+
+// backupManifestProvider describes a provider installed in the source management cluster at backup time.
+type backupManifestProvider struct {
+	Name         string `json:"name"`
+	Namespace    string `json:"namespace"`
+	ProviderName string `json:"providerName"`
+	Type         string `json:"type"`
+	Version      string `json:"version"`
+
+	// Contracts lists the API Version of Cluster API (contract) implemented by the provider.
+	Contracts []string `json:"contracts,omitempty"`
+}
+
+// provider returns the clusterctl Provider corresponding to a backupManifestProvider.
+func (p backupManifestProvider) provider() clusterctlv1.Provider {
+	return clusterctlv1.Provider{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      p.Name,
+			Namespace: p.Namespace,
+		},
+		ProviderName: p.ProviderName,
+		Type:         p.Type,
+		Version:      p.Version,
+	}
+}
+
+// getBackupManifestProviders returns the providers in the inventory together with the contract they implement,
+// so they can be recorded in the backup manifest.
+func getBackupManifestProviders(inventory InventoryClient) ([]backupManifestProvider, error) {
+	providers, err := inventory.List()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get provider list from the source cluster")
+	}
+
+	ret := []backupManifestProvider{}
+	for _, p := range providers.Items {
+		contracts, err := inventory.GetProviderContracts(p)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, backupManifestProvider{
+			Name:         p.Name,
+			Namespace:    p.Namespace,
+			ProviderName: p.ProviderName,
+			Type:         p.Type,
+			Version:      p.Version,
+			Contracts:    contracts,
+		})
+	}
+	return ret, nil
+}
+
+// newBackupManifest returns a backupManifest listing the nodes in the move sequence and the given providers.
+// NOTE: checksums are computed when writing the archive.
+func newBackupManifest(moveSequence *moveSequence, providers []backupManifestProvider) *backupManifest {
+	manifest := &backupManifest{
+		Version:   backupManifestVersion,
+		Providers: providers,
+	}
+
+	for _, group := range moveSequence.groups {
+		manifestGroup := []backupManifestObject{}
+		for _, n := range group {
+			manifestGroup = append(manifestGroup, backupManifestObject{
+				APIVersion: n.identity.APIVersion,
+				Kind:       n.identity.Kind,
+				Namespace:  n.identity.Namespace,
+				Name:       n.identity.Name,
+				File:       n.getFilename(),
+			})
+		}
+		manifest.Groups = append(manifest.Groups, manifestGroup)
+	}
+
+	return manifest
+}
+
+// files returns the names of all the object files listed in the manifest.
+func (m *backupManifest) files() []string {
+	files := []string{}
+	for _, group := range m.Groups {
+		for _, o := range group {
+			files = append(files, o.File)
+		}
+	}
+	return files
+}
+
+// writeBackupArchive writes a gzipped tar archive containing the manifest and all the object files listed
+// in the manifest, reading them from the given directory.
+func writeBackupArchive(manifest *backupManifest, directory string, file string) error {
+	// Compute the checksum of all the object files before writing the archive, so the manifest can be the first entry.
+	contents := map[string][]byte{}
+	for i := range manifest.Groups {
+		for j := range manifest.Groups[i] {
+			o := &manifest.Groups[i][j]
+			content, err := ioutil.ReadFile(filepath.Clean(filepath.Join(directory, o.File)))
+			if err != nil {
+				return errors.Wrapf(err, "failed to read %s", o.File)
+			}
+			o.SHA256 = sha256Sum(content)
+			contents[o.File] = content
+		}
+	}
+
+	manifestContent, err := yaml.Marshal(manifest)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the backup manifest")
+	}
+
+	// Write the archive to a temporary file, then rename it, so an interrupted backup never leaves a partial archive behind.
+	tmpFile, err := ioutil.TempFile(filepath.Dir(file), ".clusterctl-backup-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create the backup archive")
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := writeTarGz(tmpFile, manifestContent, manifest.files(), contents); err != nil {
+		_ = tmpFile.Close()
+		return errors.Wrapf(err, "failed to write the backup archive")
+	}
+	if err := tmpFile.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write the backup archive")
+	}
+
+	return os.Rename(tmpFile.Name(), file)
+}
+
+func writeTarGz(w io.Writer, manifestContent []byte, files []string, contents map[string][]byte) error {
+	gzw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gzw)
+
+	modTime := time.Now()
+	writeEntry := func(name string, content []byte) error {
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0600,
+			Size:     int64(len(content)),
+			ModTime:  modTime,
+		}); err != nil {
+			return err
+		}
+		_, err := tw.Write(content)
+		return err
+	}
+
+	if err := writeEntry(backupManifestFileName, manifestContent); err != nil {
+		return err
+	}
+	for _, name := range files {
+		if err := writeEntry(name, contents[name]); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gzw.Close()
+}
+
+// extractBackupArchive reads a backup archive, verifies its content against the manifest and then
+// writes all the object files into the given directory.
+// The archive is fully verified before writing anything, so a partial or corrupted archive is rejected as a whole.
+func extractBackupArchive(file string, directory string) (*backupManifest, error) {
+	f, err := os.Open(filepath.Clean(file))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open the backup archive")
+	}
+	defer f.Close()
+
+	contents, err := readTarGz(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the backup archive %s", file)
+	}
+
+	manifestContent, ok := contents[backupManifestFileName]
+	if !ok {
+		return nil, errors.Errorf("invalid backup archive %s: %s is missing", file, backupManifestFileName)
+	}
+	delete(contents, backupManifestFileName)
+
+	manifest := &backupManifest{}
+	if err := yaml.UnmarshalStrict(manifestContent, manifest); err != nil {
+		return nil, errors.Wrapf(err, "invalid backup archive %s: failed to parse %s", file, backupManifestFileName)
+	}
+
+	if err := manifest.verify(contents); err != nil {
+		return nil, errors.Wrapf(err, "invalid backup archive %s", file)
+	}
+
+	for name, content := range contents {
+		if err := ioutil.WriteFile(filepath.Join(directory, name), content, 0600); err != nil {
+			return nil, err
+		}
+	}
+
+	return manifest, nil
+}
+
+func readTarGz(r io.Reader) (map[string][]byte, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gzr.Close()
+
+	contents := map[string][]byte{}
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Only flat archives with regular files are supported; this also prevents path traversal when extracting.
+		if header.Typeflag != tar.TypeReg || header.Name != filepath.Base(header.Name) || header.Name == "." || header.Name == ".." {
+			return nil, errors.Errorf("unexpected entry %q", header.Name)
+		}
+		if _, ok := contents[header.Name]; ok {
+			return nil, errors.Errorf("duplicated entry %q", header.Name)
+		}
+		if header.Size > maxBackupArchiveEntrySize {
+			return nil, errors.Errorf("entry %q exceeds the max allowed size", header.Name)
+		}
+
+		content, err := ioutil.ReadAll(io.LimitReader(tr, maxBackupArchiveEntrySize))
+		if err != nil {
+			return nil, err
+		}
+		contents[header.Name] = content
+	}
+	return contents, nil
+}
+
+// verify checks that the given files match exactly the object files listed in the manifest.
+func (m *backupManifest) verify(contents map[string][]byte) error {
+	if m.Version != backupManifestVersion {
+		return errors.Errorf("unsupported backup manifest version %q", m.Version)
+	}
+
+	errList := []error{}
+	listed := sets.NewString()
+	for _, group := range m.Groups {
+		for _, o := range group {
+			listed.Insert(o.File)
+
+			content, ok := contents[o.File]
+			if !ok {
+				errList = append(errList, errors.Errorf("file %s for %s %s/%s is missing", o.File, o.Kind, o.Namespace, o.Name))
+				continue
+			}
+			if sum := sha256Sum(content); sum != o.SHA256 {
+				errList = append(errList, errors.Errorf("checksum mismatch for file %s (expected %s, got %s)", o.File, o.SHA256, sum))
+			}
+		}
+	}
+
+	for name := range contents {
+		if !listed.Has(name) {
+			errList = append(errList, errors.Errorf("file %s is not listed in the manifest", name))
+		}
+	}
+
+	return kerrors.NewAggregate(errList)
+}
+
+// moveSequence returns the move sequence for restoring the objects in the graph in the same order recorded in the manifest.
+// It fails if an object listed in the manifest is not in the graph, or if it is listed before one of its owners.
+func (m *backupManifest) moveSequence(graph *objectGraph) (*moveSequence, error) {
+	nodes := map[string]*node{}
+	for _, n := range graph.uidToNode {
+		nodes[backupManifestKey(n.identity.GroupVersionKind().GroupKind().String(), n.identity.Namespace, n.identity.Name)] = n
+	}
+
+	moveSequence := &moveSequence{
+		groups:   []moveGroup{},
+		nodesMap: make(map[*node]empty),
+	}
+	for i, manifestGroup := range m.Groups {
+		group := moveGroup{}
+		for _, o := range manifestGroup {
+			gk := schema.FromAPIVersionAndKind(o.APIVersion, o.Kind).GroupKind()
+			n, ok := nodes[backupManifestKey(gk.String(), o.Namespace, o.Name)]
+			if !ok {
+				return nil, errors.Errorf("%s %s/%s listed in the manifest is missing", o.Kind, o.Namespace, o.Name)
+			}
+			for owner := range n.owners {
+				if !owner.virtual && !moveSequence.hasNode(owner) {
+					return nil, errors.Errorf("%s %s/%s is listed in group %d of the manifest before its owner %s %s/%s",
+						o.Kind, o.Namespace, o.Name, i, owner.identity.Kind, owner.identity.Namespace, owner.identity.Name)
+				}
+			}
+			group = append(group, n)
+		}
+		moveSequence.addGroup(group)
+	}
+	return moveSequence, nil
+}
+
+func backupManifestKey(groupKind, namespace, name string) string {
+	return fmt.Sprintf("%s, %s/%s", groupKind, namespace, name)
+}
+
+func sha256Sum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
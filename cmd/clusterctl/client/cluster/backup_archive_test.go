@@ -0,0 +1,328 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/yaml"
+)
+
+func Test_backupArchive(t *testing.T) {
+	files := map[string]string{
+		"Cluster_ns1_foo.yaml":           `{"apiVersion":"cluster.x-k8s.io/v1alpha4","kind":"Cluster","metadata":{"name":"foo","namespace":"ns1"}}`,
+		"Secret_ns1_foo-ca.yaml":         `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"foo-ca","namespace":"ns1"}}`,
+		"Secret_ns1_foo-kubeconfig.yaml": `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"foo-kubeconfig","namespace":"ns1"}}`,
+	}
+	manifest := func() *backupManifest {
+		return &backupManifest{
+			Version: backupManifestVersion,
+			Groups: [][]backupManifestObject{
+				{
+					{APIVersion: "cluster.x-k8s.io/v1alpha4", Kind: "Cluster", Namespace: "ns1", Name: "foo", File: "Cluster_ns1_foo.yaml"},
+				},
+				{
+					{APIVersion: "v1", Kind: "Secret", Namespace: "ns1", Name: "foo-ca", File: "Secret_ns1_foo-ca.yaml"},
+					{APIVersion: "v1", Kind: "Secret", Namespace: "ns1", Name: "foo-kubeconfig", File: "Secret_ns1_foo-kubeconfig.yaml"},
+				},
+			},
+			Providers: []backupManifestProvider{
+				{Name: "infra1", Namespace: "infra1-system", ProviderName: "infra1", Type: "InfrastructureProvider", Version: "v1.2.3"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		tamper  func(g *WithT, manifestContent []byte, contents map[string][]byte) ([]byte, map[string][]byte)
+		wantErr bool
+	}{
+		{
+			name:    "valid archive",
+			wantErr: false,
+		},
+		{
+			name: "fails if the manifest is missing",
+			tamper: func(g *WithT, manifestContent []byte, contents map[string][]byte) ([]byte, map[string][]byte) {
+				return nil, contents
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if an object file is missing",
+			tamper: func(g *WithT, manifestContent []byte, contents map[string][]byte) ([]byte, map[string][]byte) {
+				delete(contents, "Secret_ns1_foo-ca.yaml")
+				return manifestContent, contents
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if an object file was modified",
+			tamper: func(g *WithT, manifestContent []byte, contents map[string][]byte) ([]byte, map[string][]byte) {
+				contents["Cluster_ns1_foo.yaml"] = append(contents["Cluster_ns1_foo.yaml"], '\n')
+				return manifestContent, contents
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if there is a file not listed in the manifest",
+			tamper: func(g *WithT, manifestContent []byte, contents map[string][]byte) ([]byte, map[string][]byte) {
+				contents["Secret_ns1_bar.yaml"] = []byte(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"bar","namespace":"ns1"}}`)
+				return manifestContent, contents
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if the manifest version is not supported",
+			tamper: func(g *WithT, manifestContent []byte, contents map[string][]byte) ([]byte, map[string][]byte) {
+				m := &backupManifest{}
+				g.Expect(yaml.Unmarshal(manifestContent, m)).To(Succeed())
+				m.Version = "v0"
+				manifestContent, err := yaml.Marshal(m)
+				g.Expect(err).NotTo(HaveOccurred())
+				return manifestContent, contents
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			dir, err := ioutil.TempDir("/tmp", "cluster-api")
+			g.Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			srcDir := filepath.Join(dir, "src")
+			dstDir := filepath.Join(dir, "dst")
+			g.Expect(os.Mkdir(srcDir, 0700)).To(Succeed())
+			g.Expect(os.Mkdir(dstDir, 0700)).To(Succeed())
+			for name, content := range files {
+				g.Expect(ioutil.WriteFile(filepath.Join(srcDir, name), []byte(content), 0600)).To(Succeed())
+			}
+
+			file := filepath.Join(dir, "backup.tar.gz")
+			g.Expect(writeBackupArchive(manifest(), srcDir, file)).To(Succeed())
+
+			if tt.tamper != nil {
+				f, err := os.Open(file)
+				g.Expect(err).NotTo(HaveOccurred())
+				contents, err := readTarGz(f)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(f.Close()).To(Succeed())
+
+				manifestContent := contents[backupManifestFileName]
+				delete(contents, backupManifestFileName)
+
+				manifestContent, contents = tt.tamper(g, manifestContent, contents)
+
+				if manifestContent != nil {
+					contents[backupManifestFileName] = manifestContent
+				}
+				var buf bytes.Buffer
+				g.Expect(writeTestArchive(&buf, contents)).To(Succeed())
+				g.Expect(ioutil.WriteFile(file, buf.Bytes(), 0600)).To(Succeed())
+			}
+
+			got, err := extractBackupArchive(file, dstDir)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+
+				// Nothing is extracted from an invalid archive.
+				extracted, err := ioutil.ReadDir(dstDir)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(extracted).To(BeEmpty())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			g.Expect(got.Providers).To(Equal(manifest().Providers))
+			g.Expect(got.files()).To(Equal(manifest().files()))
+			for name, content := range files {
+				extracted, err := ioutil.ReadFile(filepath.Join(dstDir, name))
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(string(extracted)).To(Equal(content))
+			}
+		})
+	}
+}
+
+func Test_extractBackupArchive_truncated(t *testing.T) {
+	g := NewWithT(t)
+
+	dir, err := ioutil.TempDir("/tmp", "cluster-api")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, "Cluster_ns1_foo.yaml"), []byte(`{"apiVersion":"cluster.x-k8s.io/v1alpha4","kind":"Cluster","metadata":{"name":"foo","namespace":"ns1"}}`), 0600)).To(Succeed())
+
+	m := &backupManifest{
+		Version: backupManifestVersion,
+		Groups: [][]backupManifestObject{
+			{{APIVersion: "cluster.x-k8s.io/v1alpha4", Kind: "Cluster", Namespace: "ns1", Name: "foo", File: "Cluster_ns1_foo.yaml"}},
+		},
+	}
+	file := filepath.Join(dir, "backup.tar.gz")
+	g.Expect(writeBackupArchive(m, dir, file)).To(Succeed())
+
+	content, err := ioutil.ReadFile(file)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ioutil.WriteFile(file, content[:len(content)/2], 0600)).To(Succeed())
+
+	dstDir := filepath.Join(dir, "dst")
+	g.Expect(os.Mkdir(dstDir, 0700)).To(Succeed())
+	_, err = extractBackupArchive(file, dstDir)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_readTarGz(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   string
+		wantErr bool
+	}{
+		{
+			name:    "accepts a file",
+			entry:   "Cluster_ns1_foo.yaml",
+			wantErr: false,
+		},
+		{
+			name:    "rejects the current directory",
+			entry:   ".",
+			wantErr: true,
+		},
+		{
+			name:    "rejects the parent directory",
+			entry:   "..",
+			wantErr: true,
+		},
+		{
+			name:    "rejects a path",
+			entry:   "../Cluster_ns1_foo.yaml",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var buf bytes.Buffer
+			g.Expect(writeTestArchive(&buf, map[string][]byte{tt.entry: []byte("foo")})).To(Succeed())
+
+			got, err := readTarGz(&buf)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(HaveKeyWithValue(tt.entry, []byte("foo")))
+		})
+	}
+}
+
+func Test_backupManifest_moveSequence(t *testing.T) {
+	tests := []struct {
+		name    string
+		groups  func(groups [][]backupManifestObject) [][]backupManifestObject
+		wantErr string
+	}{
+		{
+			name: "follows the order of the manifest",
+			groups: func(groups [][]backupManifestObject) [][]backupManifestObject {
+				return groups
+			},
+			wantErr: "",
+		},
+		{
+			name: "fails if an object is missing",
+			groups: func(groups [][]backupManifestObject) [][]backupManifestObject {
+				groups[0] = append(groups[0], backupManifestObject{APIVersion: "cluster.x-k8s.io/v1alpha4", Kind: "Cluster", Namespace: "ns1", Name: "bar"})
+				return groups
+			},
+			wantErr: "Cluster ns1/bar listed in the manifest is missing",
+		},
+		{
+			name: "fails if an object is listed before its owner",
+			groups: func(groups [][]backupManifestObject) [][]backupManifestObject {
+				for i, j := 0, len(groups)-1; i < j; i, j = i+1, j-1 {
+					groups[i], groups[j] = groups[j], groups[i]
+				}
+				return groups
+			},
+			wantErr: "before its owner",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			graph := getObjectGraphWithObjs(test.NewFakeCluster("ns1", "foo").Objs())
+			g.Expect(getFakeDiscoveryTypes(graph)).To(Succeed())
+			g.Expect(graph.Discovery("")).To(Succeed())
+
+			manifest := newBackupManifest(getMoveSequence(graph), nil)
+			manifest.Groups = tt.groups(manifest.Groups)
+
+			got, err := manifest.moveSequence(graph)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			g.Expect(got.groups).To(HaveLen(len(manifest.Groups)))
+			for i, group := range manifest.Groups {
+				gotGroup := []string{}
+				for _, n := range got.getGroup(i) {
+					gotGroup = append(gotGroup, n.getFilename())
+				}
+				wantGroup := []string{}
+				for _, o := range group {
+					wantGroup = append(wantGroup, o.File)
+				}
+				g.Expect(gotGroup).To(Equal(wantGroup))
+			}
+		})
+	}
+}
+
+func writeTestArchive(w io.Writer, contents map[string][]byte) error {
+	gzw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gzw)
+	for name, content := range contents {
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0600, Size: int64(len(content))}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gzw.Close()
+}
@@ -690,6 +690,14 @@ func (f fakeConfigClient) ImageMeta() config.ImageMetaClient {
 	return f.internalclient.ImageMeta()
 }
 
+func (f fakeConfigClient) View() ([]config.Value, error) {
+	return f.internalclient.View()
+}
+
+func (f fakeConfigClient) Validate() error {
+	return f.internalclient.Validate()
+}
+
 func (f *fakeConfigClient) WithVar(key, value string) *fakeConfigClient {
 	f.fakeReader.WithVar(key, value)
 	return f
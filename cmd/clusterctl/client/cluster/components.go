@@ -18,10 +18,12 @@ package cluster
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -59,6 +61,14 @@ type ComponentsClient interface {
 	// and for the deletion of the provider's CRDs.
 	Delete(options DeleteOptions) error
 
+	// DependentObjects returns the objects of the kinds defined by the provider's CRDs existing in the management cluster,
+	// e.g. the AWSClusters, AWSMachines and AWSMachineTemplates for the AWS infrastructure provider; those objects are
+	// used by Clusters that depend on the provider, and they can't be reconciled anymore once the provider is deleted.
+	// If the provider instance is watching a single namespace, only the objects in that namespace are returned.
+	// NOTE: Objects can't be attributed to a specific instance when several instances of the same provider
+	// are watching all the namespaces, so in this case all the objects of the provider's kinds are returned.
+	DependentObjects(provider clusterctlv1.Provider) ([]unstructured.Unstructured, error)
+
 	// DeleteWebhookNamespace deletes the core provider webhook namespace (eg. capi-webhook-system).
 	// This is required when upgrading to v1alpha4 where webhooks are included in the controller itself.
 	DeleteWebhookNamespace() error
@@ -215,6 +225,63 @@ func (p *providerComponents) Delete(options DeleteOptions) error {
 	return kerrors.NewAggregate(errList)
 }
 
+func (p *providerComponents) DependentObjects(provider clusterctlv1.Provider) ([]unstructured.Unstructured, error) {
+	c, err := p.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	// Gets the CRDs belonging to the provider.
+	crdList := &apiextensionsv1.CustomResourceDefinitionList{}
+	if err := retryWithExponentialBackoff(newReadBackoff(), func() error {
+		return c.List(ctx, crdList, client.HasLabels{clusterctlv1.ClusterctlLabelName}, client.MatchingLabels{clusterv1.ProviderLabelName: provider.ManifestLabel()})
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to get the list of CRDs for the %q provider", provider.Name)
+	}
+
+	// Limits the search to the namespace the provider instance is watching, if any.
+	var selectors []client.ListOption
+	if provider.WatchedNamespace != "" {
+		selectors = append(selectors, client.InNamespace(provider.WatchedNamespace))
+	}
+
+	// Gets all the objects of the kinds defined by the CRDs, reading them with the storage version.
+	objs := []unstructured.Unstructured{}
+	for _, crd := range crdList.Items {
+		for _, version := range crd.Spec.Versions {
+			if !version.Storage {
+				continue
+			}
+
+			typeMeta := metav1.TypeMeta{
+				Kind: crd.Spec.Names.Kind,
+				APIVersion: metav1.GroupVersion{
+					Group:   crd.Spec.Group,
+					Version: version.Name,
+				}.String(),
+			}
+			objList := new(unstructured.UnstructuredList)
+			if err := retryWithExponentialBackoff(newReadBackoff(), func() error {
+				return getObjList(p.proxy, typeMeta, selectors, objList)
+			}); err != nil {
+				return nil, err
+			}
+			objs = append(objs, objList.Items...)
+		}
+	}
+
+	sort.Slice(objs, func(i, j int) bool {
+		if objs[i].GetKind() != objs[j].GetKind() {
+			return objs[i].GetKind() < objs[j].GetKind()
+		}
+		if objs[i].GetNamespace() != objs[j].GetNamespace() {
+			return objs[i].GetNamespace() < objs[j].GetNamespace()
+		}
+		return objs[i].GetName() < objs[j].GetName()
+	})
+	return objs, nil
+}
+
 func (p *providerComponents) DeleteWebhookNamespace() error {
 	const webhookNamespaceName = "capi-webhook-system"
 
@@ -17,6 +17,7 @@ limitations under the License.
 package cluster
 
 import (
+	"fmt"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -29,6 +30,7 @@ import (
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	fakeinfrastructure "sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test/providers/infrastructure"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -295,3 +297,101 @@ func Test_providerComponents_DeleteCoreProviderWebhookNamespace(t *testing.T) {
 		g.Expect(len(nsList.Items)).Should(Equal(0))
 	})
 }
+
+func Test_providerComponents_DependentObjects(t *testing.T) {
+	provider := clusterctlv1.Provider{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "infrastructure-infra",
+			Namespace: "ns1",
+		},
+		ProviderName: "infra",
+		Type:         string(clusterctlv1.InfrastructureProviderType),
+	}
+
+	infraClusterCRD := test.FakeNamespacedCustomResourceDefinition(fakeinfrastructure.GroupVersion.Group, "GenericInfrastructureCluster", fakeinfrastructure.GroupVersion.Version)
+	infraClusterCRD.Labels[clusterv1.ProviderLabelName] = provider.ManifestLabel()
+	infraMachineCRD := test.FakeNamespacedCustomResourceDefinition(fakeinfrastructure.GroupVersion.Group, "GenericInfrastructureMachine", fakeinfrastructure.GroupVersion.Version)
+	infraMachineCRD.Labels[clusterv1.ProviderLabelName] = provider.ManifestLabel()
+	// A CRD belonging to another provider.
+	clusterCRD := test.FakeNamespacedCustomResourceDefinition(clusterv1.GroupVersion.Group, "Cluster", clusterv1.GroupVersion.Version)
+	clusterCRD.Labels[clusterv1.ProviderLabelName] = "cluster-api"
+
+	infraCluster := &fakeinfrastructure.GenericInfrastructureCluster{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: fakeinfrastructure.GroupVersion.String(),
+			Kind:       "GenericInfrastructureCluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns2",
+			Name:      "cluster1",
+		},
+	}
+	infraMachine := &fakeinfrastructure.GenericInfrastructureMachine{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: fakeinfrastructure.GroupVersion.String(),
+			Kind:       "GenericInfrastructureMachine",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns2",
+			Name:      "machine1",
+		},
+	}
+	cluster := &clusterv1.Cluster{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "Cluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns2",
+			Name:      "cluster1",
+		},
+	}
+
+	tests := []struct {
+		name             string
+		watchedNamespace string
+		objs             []client.Object
+		wantObj          []string
+	}{
+		{
+			name:    "no dependent objects",
+			objs:    []client.Object{infraClusterCRD, infraMachineCRD, clusterCRD, cluster},
+			wantObj: []string{},
+		},
+		{
+			name:    "returns the objects of the kinds defined by the provider's CRDs",
+			objs:    []client.Object{infraClusterCRD, infraMachineCRD, clusterCRD, cluster, infraCluster, infraMachine},
+			wantObj: []string{"GenericInfrastructureCluster ns2/cluster1", "GenericInfrastructureMachine ns2/machine1"},
+		},
+		{
+			name:             "returns the objects in the namespace watched by the provider",
+			watchedNamespace: "ns2",
+			objs:             []client.Object{infraClusterCRD, infraMachineCRD, clusterCRD, cluster, infraCluster, infraMachine},
+			wantObj:          []string{"GenericInfrastructureCluster ns2/cluster1", "GenericInfrastructureMachine ns2/machine1"},
+		},
+		{
+			name:             "ignores the objects outside of the namespace watched by the provider",
+			watchedNamespace: "ns3",
+			objs:             []client.Object{infraClusterCRD, infraMachineCRD, clusterCRD, cluster, infraCluster, infraMachine},
+			wantObj:          []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			provider := provider.DeepCopy()
+			provider.WatchedNamespace = tt.watchedNamespace
+
+			c := newComponentsClient(test.NewFakeProxy().WithObjs(tt.objs...))
+			objs, err := c.DependentObjects(*provider)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			gotObjs := []string{}
+			for _, o := range objs {
+				gotObjs = append(gotObjs, fmt.Sprintf("%s %s/%s", o.GetKind(), o.GetNamespace(), o.GetName()))
+			}
+			g.Expect(gotObjs).To(Equal(tt.wantObj))
+		})
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/pkg/errors"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/scheme"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// crdMigrator migrates the objects stored with versions that are removed from a CustomResourceDefinition
+// by an upgrade to the current storage version, so the new CustomResourceDefinition can be applied.
+type crdMigrator struct {
+	proxy Proxy
+}
+
+// newCRDMigrator returns a crdMigrator.
+func newCRDMigrator(proxy Proxy) *crdMigrator {
+	return &crdMigrator{
+		proxy: proxy,
+	}
+}
+
+// Run migrates the objects of the CustomResourceDefinitions in the given list, if required.
+func (m *crdMigrator) Run(objs []unstructured.Unstructured) error {
+	c, err := m.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	for i := range objs {
+		if objs[i].GetKind() != customResourceDefinitionKind {
+			continue
+		}
+		newCRD := &apiextensionsv1.CustomResourceDefinition{}
+		if err := scheme.Scheme.Convert(&objs[i], newCRD, nil); err != nil {
+			return errors.Wrapf(err, "failed to convert CustomResourceDefinition %s", objs[i].GetName())
+		}
+		if err := m.run(c, newCRD); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *crdMigrator) run(c client.Client, newCRD *apiextensionsv1.CustomResourceDefinition) error {
+	log := logf.Log
+
+	currentCRD := &apiextensionsv1.CustomResourceDefinition{}
+	if err := c.Get(ctx, client.ObjectKey{Name: newCRD.Name}, currentCRD); err != nil {
+		// If the CustomResourceDefinition does not exist yet, there is nothing to migrate.
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get CustomResourceDefinition %s", newCRD.Name)
+	}
+
+	impact := crdUpgradeImpact(currentCRD, newCRD)
+	if impact == nil || !impact.RequiresStorageVersionMigration {
+		return nil
+	}
+
+	// Objects are migrated by rewriting them, so they are stored with the current storage version; the current storage
+	// version must exist in the new CustomResourceDefinition, otherwise objects can't be read after the upgrade.
+	currentStorageVersion := storageVersion(currentCRD)
+	newVersions := sets.NewString()
+	for _, v := range newCRD.Spec.Versions {
+		newVersions.Insert(v.Name)
+	}
+	if !newVersions.Has(currentStorageVersion) {
+		return errors.Errorf("unable to migrate CustomResourceDefinition %s: the current storage version %s is removed by the upgrade", newCRD.Name, currentStorageVersion)
+	}
+
+	log.Info("Migrating stored objects", "CustomResourceDefinition", newCRD.Name, "StoredVersions", currentCRD.Status.StoredVersions, "StorageVersion", currentStorageVersion)
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   currentCRD.Spec.Group,
+		Version: currentStorageVersion,
+		Kind:    currentCRD.Spec.Names.ListKind,
+	})
+	for {
+		if err := retryWithExponentialBackoff(newReadBackoff(), func() error {
+			return c.List(ctx, list, client.Continue(list.GetContinue()))
+		}); err != nil {
+			return errors.Wrapf(err, "failed to list %s", currentCRD.Spec.Names.Plural)
+		}
+
+		for i := range list.Items {
+			obj := &list.Items[i]
+			// A no-op update rewrites the object using the current storage version.
+			if err := c.Update(ctx, obj); err != nil {
+				// If the object has been changed or deleted in the meantime, it has already been stored with the current storage version.
+				if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+					continue
+				}
+				return errors.Wrapf(err, "failed to migrate %s %s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+			}
+		}
+
+		if list.GetContinue() == "" {
+			break
+		}
+	}
+
+	// All the objects are now stored with the current storage version.
+	if err := retryWithExponentialBackoff(newWriteBackoff(), func() error {
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := c.Get(ctx, client.ObjectKey{Name: currentCRD.Name}, crd); err != nil {
+			return err
+		}
+		crd.Status.StoredVersions = []string{currentStorageVersion}
+		return c.Status().Update(ctx, crd)
+	}); err != nil {
+		return errors.Wrapf(err, "failed to update the stored versions of CustomResourceDefinition %s", currentCRD.Name)
+	}
+	return nil
+}
@@ -18,6 +18,8 @@ package cluster
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -40,6 +42,9 @@ const (
 	waitInventoryCRDTimeout  = 1 * time.Minute
 )
 
+// contractRegex matches API Version of Cluster API (contract) values, e.g. v1alpha4.
+var contractRegex = regexp.MustCompile(`^v\d+((alpha|beta)\d+)?$`)
+
 // CheckCAPIContractOption is some configuration that modifies options for CheckCAPIContract.
 type CheckCAPIContractOption interface {
 	// Apply applies this configuration to the given CheckCAPIContractOptions.
@@ -105,6 +110,11 @@ type InventoryClient interface {
 
 	// CheckSingleProviderInstance ensures that only one instance of a provider is running, returns error otherwise.
 	CheckSingleProviderInstance() error
+
+	// GetProviderContracts returns the API Version of Cluster API (contract) implemented by a provider installed in the cluster.
+	// For the core provider this is the storage version of the Cluster API CRDs, while for the other providers the
+	// contracts are derived from the cluster.x-k8s.io/<contract> labels applied to the provider's CRDs.
+	GetProviderContracts(provider clusterctlv1.Provider) ([]string, error)
 }
 
 // inventoryClient implements InventoryClient.
@@ -436,3 +446,40 @@ func (p *inventoryClient) CheckSingleProviderInstance() error {
 
 	return nil
 }
+
+func (p *inventoryClient) GetProviderContracts(provider clusterctlv1.Provider) ([]string, error) {
+	c, err := p.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	crdList := &apiextensionsv1.CustomResourceDefinitionList{}
+	if err := c.List(ctx, crdList, client.MatchingLabels{clusterv1.ProviderLabelName: provider.ManifestLabel()}); err != nil {
+		return nil, errors.Wrapf(err, "failed to get the list of CRDs for the %s provider", provider.InstanceName())
+	}
+
+	contracts := sets.NewString()
+	for _, crd := range crdList.Items {
+		// The core provider defines the contract, so the contract is the storage version of its own CRDs.
+		if provider.GetProviderType() == clusterctlv1.CoreProviderType {
+			if crd.Spec.Group != clusterv1.GroupVersion.Group {
+				continue
+			}
+			for _, version := range crd.Spec.Versions {
+				if version.Storage {
+					contracts.Insert(version.Name)
+				}
+			}
+			continue
+		}
+
+		// Other providers declare the contracts they implement with labels like cluster.x-k8s.io/v1alpha4.
+		for label := range crd.Labels {
+			contract := strings.TrimPrefix(label, clusterv1.GroupVersion.Group+"/")
+			if contract != label && contractRegex.MatchString(contract) {
+				contracts.Insert(contract)
+			}
+		}
+	}
+	return contracts.List(), nil
+}
@@ -24,6 +24,7 @@ import (
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -385,3 +386,54 @@ func Test_inventoryClient_CheckSingleProviderInstance(t *testing.T) {
 		})
 	}
 }
+
+func Test_inventoryClient_GetProviderContracts(t *testing.T) {
+	coreCRD := test.FakeNamespacedCustomResourceDefinition(clusterv1.GroupVersion.Group, "Cluster", "v1alpha4", "v1alpha3")
+	coreCRD.Labels[clusterv1.ProviderLabelName] = "cluster-api"
+
+	infraCRD := test.FakeNamespacedCustomResourceDefinition("infrastructure.cluster.x-k8s.io", "FooCluster", "v1alpha4")
+	infraCRD.Labels[clusterv1.ProviderLabelName] = "infrastructure-foo"
+	infraCRD.Labels["cluster.x-k8s.io/v1alpha3"] = "v1alpha3"
+	infraCRD.Labels["cluster.x-k8s.io/v1alpha4"] = "v1alpha4"
+
+	type args struct {
+		provider clusterctlv1.Provider
+	}
+	tests := []struct {
+		name string
+		args args
+		want []string
+	}{
+		{
+			name: "core provider contract is the storage version of its CRDs",
+			args: args{
+				provider: clusterctlv1.Provider{ProviderName: "cluster-api", Type: string(clusterctlv1.CoreProviderType)},
+			},
+			want: []string{"v1alpha4"},
+		},
+		{
+			name: "other providers contracts are read from the CRD labels",
+			args: args{
+				provider: clusterctlv1.Provider{ProviderName: "foo", Type: string(clusterctlv1.InfrastructureProviderType)},
+			},
+			want: []string{"v1alpha3", "v1alpha4"},
+		},
+		{
+			name: "returns no contracts for providers without CRDs",
+			args: args{
+				provider: clusterctlv1.Provider{ProviderName: "bar", Type: string(clusterctlv1.InfrastructureProviderType)},
+			},
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			p := newInventoryClient(test.NewFakeProxy().WithObjs(coreCRD, infraCRD), fakePollImmediateWaiter)
+			got, err := p.GetProviderContracts(tt.args.provider)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
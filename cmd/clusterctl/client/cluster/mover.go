@@ -21,17 +21,20 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/version"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/yaml"
@@ -40,12 +43,35 @@ import (
 
 // ObjectMover defines methods for moving Cluster API objects to another management cluster.
 type ObjectMover interface {
-	// Move moves all the Cluster API objects existing in a namespace (or from all the namespaces if empty) to a target management cluster.
-	Move(namespace string, toCluster Client, dryRun bool) error
-	// Backup saves all the Cluster API objects existing in a namespace (or from all the namespaces if empty) to a target management cluster.
-	Backup(namespace string, directory string) error
-	// Restore restores all the Cluster API objects existing in a configured directory to a target management cluster.
-	Restore(toCluster Client, directory string) error
+	// Move moves all the Cluster API objects existing in a namespace (or from all the namespaces if empty) to a target management cluster;
+	// if a selection is provided, only the selected Clusters and the objects linked to them are moved.
+	Move(namespace string, toCluster Client, dryRun bool, selection ClusterSelection) error
+	// PlanMove returns the plan of a move operation without performing any action, describing the objects that would be moved
+	// and the order in which they would be moved.
+	PlanMove(namespace string, selection ClusterSelection) (*MovePlan, error)
+	// ResumeMove resumes an interrupted move operation from the last checkpoint recorded in the source management cluster.
+	ResumeMove(namespace string, toCluster Client) error
+	// RollbackMove reverts an interrupted move operation, deleting the objects already created in the target management cluster and unpausing the source clusters.
+	RollbackMove(namespace string, toCluster Client) error
+	// Backup saves all the Cluster API objects existing in a namespace (or from all the namespaces if empty) to a backup archive.
+	Backup(namespace string, file string) error
+	// Restore restores all the Cluster API objects existing in a backup archive to a target management cluster.
+	Restore(toCluster Client, file string) error
+}
+
+// ClusterSelection defines the Clusters to be included in a move operation.
+// If both Names and LabelSelector are empty, all the Clusters are selected.
+type ClusterSelection struct {
+	// Names of the Clusters to be selected, in the form name or namespace/name.
+	Names []string
+
+	// LabelSelector selects the Clusters with matching labels.
+	LabelSelector string
+}
+
+// isEmpty returns true if no selection criteria are defined.
+func (s ClusterSelection) isEmpty() bool {
+	return len(s.Names) == 0 && s.LabelSelector == ""
 }
 
 // objectMover implements the ObjectMover interface.
@@ -53,21 +79,36 @@ type objectMover struct {
 	fromProxy             Proxy
 	fromProviderInventory InventoryClient
 	dryRun                bool
+
+	// namespace is the namespace being moved; it is used also for storing the move checkpoint.
+	namespace string
 }
 
 // ensure objectMover implements the ObjectMover interface.
 var _ ObjectMover = &objectMover{}
 
-func (o *objectMover) Move(namespace string, toCluster Client, dryRun bool) error {
+func (o *objectMover) Move(namespace string, toCluster Client, dryRun bool, selection ClusterSelection) error {
 	log := logf.Log
 	log.Info("Performing move...")
 	o.dryRun = dryRun
+	o.namespace = namespace
 	if o.dryRun {
 		log.Info("********************************************************")
 		log.Info("This is a dry-run move, will not perform any real action")
 		log.Info("********************************************************")
 	}
 
+	// checks there are no interrupted move operations, because starting a new one would overwrite the checkpoint.
+	if !o.dryRun {
+		checkpoint, err := o.getCheckpoint()
+		if err != nil {
+			return err
+		}
+		if checkpoint != nil {
+			return errors.Errorf("an interrupted move operation exists (%s); please run clusterctl move with --resume or --rollback", checkpoint.describe())
+		}
+	}
+
 	// checks that all the required providers in place in the target cluster.
 	if !o.dryRun {
 		if err := o.checkTargetProviders(toCluster.ProviderInventory()); err != nil {
@@ -75,7 +116,7 @@ func (o *objectMover) Move(namespace string, toCluster Client, dryRun bool) erro
 		}
 	}
 
-	objectGraph, err := o.getObjectGraph(namespace)
+	objectGraph, err := o.getObjectGraph(namespace, selection)
 	if err != nil {
 		return errors.Wrap(err, "failed to get object graph")
 	}
@@ -89,19 +130,93 @@ func (o *objectMover) Move(namespace string, toCluster Client, dryRun bool) erro
 	return o.move(objectGraph, proxy)
 }
 
-func (o *objectMover) Backup(namespace string, directory string) error {
+func (o *objectMover) PlanMove(namespace string, selection ClusterSelection) (*MovePlan, error) {
+	log := logf.Log
+	log.Info("Planning move...")
+	o.dryRun = true
+	o.namespace = namespace
+
+	objectGraph, err := o.getObjectGraph(namespace, selection)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get object graph")
+	}
+
+	// Gets the objects that would prevent the move operation from starting; this is skipped by getObjectGraph when
+	// running in dry-run mode.
+	blockers, err := o.getProvisioningBlockers(objectGraph)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check for provisioned infrastructure")
+	}
+
+	return newMovePlan(objectGraph, getMoveSequence(objectGraph), blockers), nil
+}
+
+func (o *objectMover) ResumeMove(namespace string, toCluster Client) error {
+	log := logf.Log
+	log.Info("Resuming move...")
+	o.namespace = namespace
+
+	checkpoint, err := o.getCheckpoint()
+	if err != nil {
+		return err
+	}
+	if checkpoint == nil {
+		return errors.New("there is no interrupted move operation to resume")
+	}
+	if err := checkpoint.checkNamespace(namespace); err != nil {
+		return err
+	}
+
+	// checks that all the required providers in place in the target cluster.
+	if err := o.checkTargetProviders(toCluster.ProviderInventory()); err != nil {
+		return errors.Wrap(err, "failed to check providers in target cluster")
+	}
+
+	// If no object has been deleted from the source cluster yet, the object graph can be discovered again; this is
+	// required for re-creating owner references in the target cluster.
+	var objectGraph *objectGraph
+	if checkpoint.Phase == moveCreatePhase {
+		objectGraph, err = o.getObjectGraph(namespace, ClusterSelection{Names: checkpoint.Clusters})
+		if err != nil {
+			return errors.Wrap(err, "failed to get object graph")
+		}
+	}
+
+	return o.resumeMove(objectGraph, checkpoint, toCluster.Proxy())
+}
+
+func (o *objectMover) RollbackMove(namespace string, toCluster Client) error {
+	log := logf.Log
+	log.Info("Rolling back move...")
+	o.namespace = namespace
+
+	checkpoint, err := o.getCheckpoint()
+	if err != nil {
+		return err
+	}
+	if checkpoint == nil {
+		return errors.New("there is no interrupted move operation to rollback")
+	}
+	if err := checkpoint.checkNamespace(namespace); err != nil {
+		return err
+	}
+
+	return o.rollbackMove(checkpoint, toCluster.Proxy())
+}
+
+func (o *objectMover) Backup(namespace string, file string) error {
 	log := logf.Log
 	log.Info("Performing backup...")
 
-	objectGraph, err := o.getObjectGraph(namespace)
+	objectGraph, err := o.getObjectGraph(namespace, ClusterSelection{})
 	if err != nil {
 		return errors.Wrap(err, "failed to get object graph")
 	}
 
-	return o.backup(objectGraph, directory)
+	return o.backup(objectGraph, file)
 }
 
-func (o *objectMover) Restore(toCluster Client, directory string) error {
+func (o *objectMover) Restore(toCluster Client, file string) error {
 	log := logf.Log
 	log.Info("Performing restore...")
 
@@ -114,6 +229,26 @@ func (o *objectMover) Restore(toCluster Client, directory string) error {
 		return errors.Wrap(err, "failed to retrieve discovery types")
 	}
 
+	// Extract the backup archive into a temporary directory; this fails if the archive is partial or tampered, and
+	// in this case nothing gets created in the target cluster.
+	directory, err := ioutil.TempDir("", "clusterctl-restore")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(directory)
+
+	log.Info(fmt.Sprintf("Verifying backup archive %s", file))
+	manifest, err := extractBackupArchive(file, directory)
+	if err != nil {
+		return err
+	}
+
+	// Checks that all the providers used for creating the backup are in place in the target cluster, so the restore
+	// does not fail halfway because of missing CRDs, webhooks or conversions.
+	if err := o.checkRestoreProviders(manifest.Providers, toCluster.ProviderInventory()); err != nil {
+		return errors.Wrap(err, "failed to check providers in target cluster")
+	}
+
 	objs, err := o.filesToObjs(directory)
 	if err != nil {
 		return errors.Wrap(err, "failed to process object files")
@@ -132,10 +267,16 @@ func (o *objectMover) Restore(toCluster Client, directory string) error {
 	// Check whether nodes are not included in GVK considered for restore.
 	objectGraph.checkVirtualNode()
 
+	// Restore the objects in the same order they were saved, as recorded in the manifest.
+	moveSequence, err := manifest.moveSequence(objectGraph)
+	if err != nil {
+		return errors.Wrapf(err, "invalid backup archive %s", file)
+	}
+
 	// Restore the objects to the target cluster.
 	proxy := toCluster.Proxy()
 
-	return o.restore(objectGraph, proxy)
+	return o.restore(objectGraph, moveSequence, proxy)
 }
 
 func (o *objectMover) filesToObjs(dir string) ([]unstructured.Unstructured, error) {
@@ -169,7 +310,7 @@ func (o *objectMover) filesToObjs(dir string) ([]unstructured.Unstructured, erro
 	return objs, nil
 }
 
-func (o *objectMover) getObjectGraph(namespace string) (*objectGraph, error) {
+func (o *objectMover) getObjectGraph(namespace string, selection ClusterSelection) (*objectGraph, error) {
 	objectGraph := newObjectGraph(o.fromProxy, o.fromProviderInventory)
 
 	// Gets all the types defined by the CRDs installed by clusterctl plus the ConfigMap/Secret core types.
@@ -185,6 +326,15 @@ func (o *objectMover) getObjectGraph(namespace string) (*objectGraph, error) {
 		return nil, errors.Wrap(err, "failed to discover the object graph")
 	}
 
+	// If only some Clusters are selected, restricts the object graph to the selected Clusters and to the objects linked to them.
+	if !selection.isEmpty() {
+		selectedClusters, err := o.getSelectedClusters(objectGraph, selection)
+		if err != nil {
+			return nil, err
+		}
+		objectGraph.filterClusters(selectedClusters)
+	}
+
 	// Checks if Cluster API has already completed the provisioning of the infrastructure for the objects involved in the move/backup operation.
 	// This is required because if the infrastructure is provisioned, then we can reasonably assume that the objects we are moving/backing up are
 	// not currently waiting for long-running reconciliation loops, and so we can safely rely on the pause field on the Cluster object
@@ -199,6 +349,55 @@ func (o *objectMover) getObjectGraph(namespace string) (*objectGraph, error) {
 	return objectGraph, nil
 }
 
+// getSelectedClusters returns the Clusters in the object graph matching the selection.
+func (o *objectMover) getSelectedClusters(graph *objectGraph, selection ClusterSelection) ([]*node, error) {
+	selector := labels.Everything()
+	if selection.LabelSelector != "" {
+		var err error
+		selector, err = labels.Parse(selection.LabelSelector)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid label selector %q", selection.LabelSelector)
+		}
+	}
+
+	names := sets.NewString(selection.Names...)
+	found := sets.NewString()
+
+	selected := []*node{}
+	readClusterBackoff := newReadBackoff()
+	for _, cluster := range graph.getClusters() {
+		key := fmt.Sprintf("%s/%s", cluster.identity.Namespace, cluster.identity.Name)
+		if names.Len() > 0 {
+			if !names.Has(cluster.identity.Name) && !names.Has(key) {
+				continue
+			}
+			found.Insert(cluster.identity.Name, key)
+		}
+
+		if selection.LabelSelector != "" {
+			clusterObj := &clusterv1.Cluster{}
+			if err := retryWithExponentialBackoff(readClusterBackoff, func() error {
+				return getClusterObj(o.fromProxy, cluster, clusterObj)
+			}); err != nil {
+				return nil, err
+			}
+			if !selector.Matches(labels.Set(clusterObj.GetLabels())) {
+				continue
+			}
+		}
+
+		selected = append(selected, cluster)
+	}
+
+	if missing := names.Difference(found); missing.Len() > 0 {
+		return nil, errors.Errorf("failed to find Clusters %s", strings.Join(missing.List(), ", "))
+	}
+	if len(selected) == 0 {
+		return nil, errors.New("no Clusters matching the selection")
+	}
+	return selected, nil
+}
+
 func newObjectMover(fromProxy Proxy, fromProviderInventory InventoryClient) *objectMover {
 	return &objectMover{
 		fromProxy:             fromProxy,
@@ -211,7 +410,28 @@ func (o *objectMover) checkProvisioningCompleted(graph *objectGraph) error {
 	if o.dryRun {
 		return nil
 	}
+
+	blockers, err := o.getProvisioningBlockers(graph)
+	if err != nil {
+		return err
+	}
+
 	errList := []error{}
+	for _, b := range blockers {
+		errList = append(errList, b.err)
+	}
+	return kerrors.NewAggregate(errList)
+}
+
+// provisioningBlocker is an object preventing the move operation to start because its provisioning is not yet completed.
+type provisioningBlocker struct {
+	node *node
+	err  error
+}
+
+// getProvisioningBlockers returns the objects involved in the move operation for which Cluster API has not yet completed the provisioning of the infrastructure.
+func (o *objectMover) getProvisioningBlockers(graph *objectGraph) ([]provisioningBlocker, error) {
+	blockers := []provisioningBlocker{}
 
 	// Checking all the clusters have infrastructure is ready
 	readClusterBackoff := newReadBackoff()
@@ -222,22 +442,22 @@ func (o *objectMover) checkProvisioningCompleted(graph *objectGraph) error {
 		if err := retryWithExponentialBackoff(readClusterBackoff, func() error {
 			return getClusterObj(o.fromProxy, cluster, clusterObj)
 		}); err != nil {
-			return err
+			return nil, err
 		}
 
 		if !clusterObj.Status.InfrastructureReady {
-			errList = append(errList, errors.Errorf("cannot start the move operation while %q %s/%s is still provisioning the infrastructure", clusterObj.GroupVersionKind(), clusterObj.GetNamespace(), clusterObj.GetName()))
+			blockers = append(blockers, provisioningBlocker{node: cluster, err: errors.Errorf("cannot start the move operation while %q %s/%s is still provisioning the infrastructure", clusterObj.GroupVersionKind(), clusterObj.GetNamespace(), clusterObj.GetName())})
 			continue
 		}
 
 		// Note: can't use IsFalse here because we need to handle the absence of the condition as well as false.
 		if !conditions.IsTrue(clusterObj, clusterv1.ControlPlaneInitializedCondition) {
-			errList = append(errList, errors.Errorf("cannot start the move operation while the control plane for %q %s/%s is not yet initialized", clusterObj.GroupVersionKind(), clusterObj.GetNamespace(), clusterObj.GetName()))
+			blockers = append(blockers, provisioningBlocker{node: cluster, err: errors.Errorf("cannot start the move operation while the control plane for %q %s/%s is not yet initialized", clusterObj.GroupVersionKind(), clusterObj.GetNamespace(), clusterObj.GetName())})
 			continue
 		}
 
 		if clusterObj.Spec.ControlPlaneRef != nil && !clusterObj.Status.ControlPlaneReady {
-			errList = append(errList, errors.Errorf("cannot start the move operation while the control plane for %q %s/%s is not yet ready", clusterObj.GroupVersionKind(), clusterObj.GetNamespace(), clusterObj.GetName()))
+			blockers = append(blockers, provisioningBlocker{node: cluster, err: errors.Errorf("cannot start the move operation while the control plane for %q %s/%s is not yet ready", clusterObj.GroupVersionKind(), clusterObj.GetNamespace(), clusterObj.GetName())})
 			continue
 		}
 	}
@@ -252,15 +472,15 @@ func (o *objectMover) checkProvisioningCompleted(graph *objectGraph) error {
 		if err := retryWithExponentialBackoff(readMachinesBackoff, func() error {
 			return getMachineObj(o.fromProxy, machine, machineObj)
 		}); err != nil {
-			return err
+			return nil, err
 		}
 
 		if machineObj.Status.NodeRef == nil {
-			errList = append(errList, errors.Errorf("cannot start the move operation while %q %s/%s is still provisioning the node", machineObj.GroupVersionKind(), machineObj.GetNamespace(), machineObj.GetName()))
+			blockers = append(blockers, provisioningBlocker{node: machine, err: errors.Errorf("cannot start the move operation while %q %s/%s is still provisioning the node", machineObj.GroupVersionKind(), machineObj.GetNamespace(), machineObj.GetName())})
 		}
 	}
 
-	return kerrors.NewAggregate(errList)
+	return blockers, nil
 }
 
 // getClusterObj retrieves the the clusterObj corresponding to a node with type Cluster.
@@ -325,28 +545,139 @@ func (o *objectMover) move(graph *objectGraph, toProxy Proxy) error {
 	// - then all the MachineSets, then all the Machines, etc.
 	moveSequence := getMoveSequence(graph)
 
-	// Create all objects group by group, ensuring all the ownerReferences are re-created.
-	log.Info("Creating objects in the target cluster")
-	for groupIndex := 0; groupIndex < len(moveSequence.groups); groupIndex++ {
-		if err := o.createGroup(moveSequence.getGroup(groupIndex), toProxy); err != nil {
+	// Checks which shared objects already exist in the target cluster, so a rollback preserves them.
+	if err := o.checkSharedObjectsInTarget(moveSequence, toProxy); err != nil {
+		return err
+	}
+
+	// Record a checkpoint before creating any object, so an interrupted move can be resumed or rolled back.
+	checkpoint := newMoveCheckpoint(o.namespace, moveSequence, graph.selectedClusters)
+	if err := o.saveCheckpoint(checkpoint); err != nil {
+		return err
+	}
+
+	return o.moveGroups(moveSequence, checkpoint, toProxy)
+}
+
+// moveGroups creates all the objects in the target cluster and then deletes them from the source cluster, starting
+// from the checkpoint and updating it every time a group is completed.
+func (o *objectMover) moveGroups(moveSequence *moveSequence, checkpoint *moveCheckpoint, toProxy Proxy) error {
+	log := logf.Log
+
+	if checkpoint.Phase == moveCreatePhase {
+		// Create all objects group by group, ensuring all the ownerReferences are re-created.
+		log.Info("Creating objects in the target cluster")
+		for groupIndex := checkpoint.CompletedGroups; groupIndex < len(moveSequence.groups); groupIndex++ {
+			if err := o.createGroup(moveSequence.getGroup(groupIndex), toProxy); err != nil {
+				return err
+			}
+
+			checkpoint.CompletedGroups = groupIndex + 1
+			if err := o.saveCheckpoint(checkpoint); err != nil {
+				return err
+			}
+		}
+
+		checkpoint.Phase = moveDeletePhase
+		checkpoint.CompletedGroups = 0
+		if err := o.saveCheckpoint(checkpoint); err != nil {
 			return err
 		}
 	}
 
 	// Delete all objects group by group in reverse order.
 	log.Info("Deleting objects from the source cluster")
-	for groupIndex := len(moveSequence.groups) - 1; groupIndex >= 0; groupIndex-- {
+	for groupIndex := len(moveSequence.groups) - 1 - checkpoint.CompletedGroups; groupIndex >= 0; groupIndex-- {
 		if err := o.deleteGroup(moveSequence.getGroup(groupIndex)); err != nil {
 			return err
 		}
+
+		checkpoint.CompletedGroups++
+		if err := o.saveCheckpoint(checkpoint); err != nil {
+			return err
+		}
 	}
 
 	// Reset the pause field on the Cluster object in the target management cluster, so the controllers start reconciling it.
 	log.V(1).Info("Resuming the target cluster")
-	return setClusterPause(toProxy, clusters, false, o.dryRun)
+	if err := setClusterPause(toProxy, moveSequence.getClusters(), false, o.dryRun); err != nil {
+		return err
+	}
+
+	return o.deleteCheckpoint()
 }
 
-func (o *objectMover) backup(graph *objectGraph, directory string) error {
+// resumeMove resumes an interrupted move operation from a checkpoint.
+// If the checkpoint is still in the create phase, the graph rediscovered from the source cluster is required
+// for re-creating owner references in the target cluster.
+func (o *objectMover) resumeMove(graph *objectGraph, checkpoint *moveCheckpoint, toProxy Proxy) error {
+	log := logf.Log
+	log.Info("Resuming interrupted move", "Phase", checkpoint.Phase, "CompletedGroups", checkpoint.CompletedGroups)
+
+	if checkpoint.Phase != moveCreatePhase {
+		// Objects are already in the target cluster, so the move can be completed using the objects recorded in the checkpoint.
+		return o.moveGroups(checkpoint.moveSequence(), checkpoint, toProxy)
+	}
+
+	if err := checkpoint.checkClusters(graph.selectedClusters); err != nil {
+		return err
+	}
+
+	moveSequence := getMoveSequence(graph)
+	if err := checkpoint.matches(moveSequence); err != nil {
+		return errors.Wrap(err, "the source cluster has changed since the move operation was interrupted; please run clusterctl move --rollback")
+	}
+
+	// Ensure all the expected target namespaces are in place before creating objects.
+	log.V(1).Info("Creating target namespaces, if missing")
+	if err := o.ensureNamespaces(graph, toProxy); err != nil {
+		return err
+	}
+
+	// Gets the UID of the objects already created in the target cluster, so owner references can be rebuilt.
+	for groupIndex := 0; groupIndex < checkpoint.CompletedGroups; groupIndex++ {
+		if err := o.getTargetUIDs(moveSequence.getGroup(groupIndex), toProxy); err != nil {
+			return err
+		}
+	}
+
+	return o.moveGroups(moveSequence, checkpoint, toProxy)
+}
+
+// rollbackMove reverts an interrupted move operation by deleting the objects created in the target cluster and
+// unpausing the Clusters in the source cluster.
+func (o *objectMover) rollbackMove(checkpoint *moveCheckpoint, toProxy Proxy) error {
+	log := logf.Log
+
+	if checkpoint.Phase != moveCreatePhase {
+		return errors.New("cannot rollback the move operation because objects have already been deleted from the source cluster; please run clusterctl move --resume")
+	}
+
+	// Delete all the objects created in the target cluster group by group in reverse order, including the group
+	// that was in progress when the move operation was interrupted.
+	moveSequence := checkpoint.moveSequence()
+	lastGroupIndex := checkpoint.CompletedGroups
+	if lastGroupIndex > len(moveSequence.groups)-1 {
+		lastGroupIndex = len(moveSequence.groups) - 1
+	}
+
+	log.Info("Deleting objects from the target cluster")
+	for groupIndex := lastGroupIndex; groupIndex >= 0; groupIndex-- {
+		if err := o.deleteTargetGroup(moveSequence.getGroup(groupIndex), toProxy); err != nil {
+			return err
+		}
+	}
+
+	// Reset the pause field on the Cluster object in the source management cluster, so the controllers start reconciling it again.
+	log.V(1).Info("Resuming the source cluster")
+	if err := setClusterPause(o.fromProxy, moveSequence.getClusters(), false, o.dryRun); err != nil {
+		return err
+	}
+
+	return o.deleteCheckpoint()
+}
+
+func (o *objectMover) backup(graph *objectGraph, file string) error {
 	log := logf.Log
 
 	clusters := graph.getClusters()
@@ -365,20 +696,37 @@ func (o *objectMover) backup(graph *objectGraph, directory string) error {
 	// - then all the MachineSets, then all the Machines, etc.
 	moveSequence := getMoveSequence(graph)
 
-	// Save all objects group by group
-	log.Info(fmt.Sprintf("Saving files to %s", directory))
+	// Gets the provider inventory, so it can be recorded in the backup manifest.
+	providers, err := getBackupManifestProviders(o.fromProviderInventory)
+	if err != nil {
+		return err
+	}
+
+	// Save all objects group by group into a temporary directory.
+	directory, err := ioutil.TempDir("", "clusterctl-backup")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(directory)
+
 	for groupIndex := 0; groupIndex < len(moveSequence.groups); groupIndex++ {
 		if err := o.backupGroup(moveSequence.getGroup(groupIndex), directory); err != nil {
 			return err
 		}
 	}
 
+	// Pack all the objects into the backup archive, together with a manifest describing them.
+	log.Info(fmt.Sprintf("Saving backup archive to %s", file))
+	if err := writeBackupArchive(newBackupManifest(moveSequence, providers), directory, file); err != nil {
+		return err
+	}
+
 	// Reset the pause field on the Cluster object in the target management cluster, so the controllers start reconciling it.
 	log.V(1).Info("Resuming the source cluster")
 	return setClusterPause(o.fromProxy, clusters, false, o.dryRun)
 }
 
-func (o *objectMover) restore(graph *objectGraph, toProxy Proxy) error {
+func (o *objectMover) restore(graph *objectGraph, moveSequence *moveSequence, toProxy Proxy) error {
 	log := logf.Log
 
 	// Get clusters from graph
@@ -390,13 +738,6 @@ func (o *objectMover) restore(graph *objectGraph, toProxy Proxy) error {
 		return err
 	}
 
-	// Define the move sequence by processing the ownerReference chain, so we ensure that a Kubernetes object is moved only after its owners.
-	// The sequence is bases on object graph nodes, each one representing a Kubernetes object; nodes are grouped, so bulk of nodes can be moved in parallel. e.g.
-	// - All the Clusters should be moved first (group 1, processed in parallel)
-	// - All the MachineDeployments should be moved second (group 1, processed in parallel)
-	// - then all the MachineSets, then all the Machines, etc.
-	moveSequence := getMoveSequence(graph)
-
 	// Create all objects group by group, ensuring all the ownerReferences are re-created.
 	log.Info("Restoring objects into the target cluster")
 	for groupIndex := 0; groupIndex < len(moveSequence.groups); groupIndex++ {
@@ -750,6 +1091,9 @@ func (o *objectMover) createTargetObject(nodeToCreate *node, toProxy Proxy) erro
 		// If the object already exists, try to update it if it is node a global object / something belonging to a global object hierarchy (e.g. a secrets owned by a global identity object).
 		if nodeToCreate.isGlobal || nodeToCreate.isGlobalHierarchy {
 			log.V(5).Info("Object already exists, skipping upgrade because it is global/it is owned by a global object", nodeToCreate.identity.Kind, nodeToCreate.identity.Name, "Namespace", nodeToCreate.identity.Namespace)
+		} else if nodeToCreate.isShared {
+			// Shared objects could have been already moved together with other Clusters, so the existing object is preserved.
+			log.V(5).Info("Object already exists, skipping upgrade because it is shared with other Clusters", nodeToCreate.identity.Kind, nodeToCreate.identity.Name, "Namespace", nodeToCreate.identity.Namespace)
 		} else {
 			// Nb. This should not happen, but it is supported to make move more resilient to unexpected interrupt/restarts of the move process.
 			log.V(5).Info("Object already exists, updating", nodeToCreate.identity.Kind, nodeToCreate.identity.Name, "Namespace", nodeToCreate.identity.Namespace)
@@ -939,6 +1283,55 @@ func (o *objectMover) deleteSourceObject(nodeToDelete *node) error {
 		return nil
 	}
 
+	// Don't delete nodes shared with Clusters not included in the move operation (e.g. a ClusterResourceSet applied also to other Clusters).
+	if nodeToDelete.isShared {
+		return nil
+	}
+
+	return o.deleteObject(nodeToDelete, o.fromProxy)
+}
+
+// deleteTargetObject deletes the Kubernetes object corresponding to the node from the target management cluster, taking care of removing all the finalizers so
+// the objects gets immediately deleted (force delete).
+func (o *objectMover) deleteTargetObject(nodeToDelete *node, toProxy Proxy) error {
+	// Don't delete cluster-wide nodes or nodes that are below a hierarchy that starts with a global object (e.g. a secrets owned by a global identity object).
+	if nodeToDelete.isGlobal || nodeToDelete.isGlobalHierarchy {
+		return nil
+	}
+
+	// Don't delete shared nodes that existed in the target cluster before the move operation (e.g. a ClusterResourceSet
+	// moved together with other Clusters); shared nodes created by the move operation are deleted instead.
+	if nodeToDelete.isShared && nodeToDelete.existsInTarget {
+		return nil
+	}
+
+	return o.deleteObject(nodeToDelete, toProxy)
+}
+
+// deleteTargetGroup deletes all the Kubernetes objects from the target management cluster corresponding to the object graph nodes in a moveGroup.
+func (o *objectMover) deleteTargetGroup(group moveGroup, toProxy Proxy) error {
+	deleteTargetObjectBackoff := newWriteBackoff()
+	errList := []error{}
+	for i := range group {
+		nodeToDelete := group[i]
+
+		// Delete the Kubernetes object corresponding to the current node.
+		// Nb. The operation is wrapped in a retry loop to make rollback more resilient to unexpected conditions.
+		err := retryWithExponentialBackoff(deleteTargetObjectBackoff, func() error {
+			return o.deleteTargetObject(nodeToDelete, toProxy)
+		})
+
+		if err != nil {
+			errList = append(errList, err)
+		}
+	}
+
+	return kerrors.NewAggregate(errList)
+}
+
+// deleteObject deletes the Kubernetes object corresponding to the node from a management cluster, taking care of removing all the finalizers so
+// the objects gets immediately deleted (force delete).
+func (o *objectMover) deleteObject(nodeToDelete *node, proxy Proxy) error {
 	log := logf.Log
 	log.V(1).Info("Deleting", nodeToDelete.identity.Kind, nodeToDelete.identity.Name, "Namespace", nodeToDelete.identity.Namespace)
 
@@ -946,7 +1339,7 @@ func (o *objectMover) deleteSourceObject(nodeToDelete *node) error {
 		return nil
 	}
 
-	cFrom, err := o.fromProxy.NewClient()
+	cFrom, err := proxy.NewClient()
 	if err != nil {
 		return err
 	}
@@ -985,6 +1378,70 @@ func (o *objectMover) deleteSourceObject(nodeToDelete *node) error {
 	return nil
 }
 
+// checkSharedObjectsInTarget checks if the shared objects in the move sequence already exist in the target management cluster.
+func (o *objectMover) checkSharedObjectsInTarget(moveSequence *moveSequence, toProxy Proxy) error {
+	if o.dryRun {
+		return nil
+	}
+
+	readTargetObjectBackoff := newReadBackoff()
+	for n := range moveSequence.nodesMap {
+		if !n.isShared {
+			continue
+		}
+		if err := retryWithExponentialBackoff(readTargetObjectBackoff, func() error {
+			cTo, err := toProxy.NewClient()
+			if err != nil {
+				return err
+			}
+
+			obj := &unstructured.Unstructured{}
+			obj.SetAPIVersion(n.identity.APIVersion)
+			obj.SetKind(n.identity.Kind)
+			if err := cTo.Get(ctx, client.ObjectKey{Namespace: n.identity.Namespace, Name: n.identity.Name}, obj); err != nil {
+				if apierrors.IsNotFound(err) {
+					n.existsInTarget = false
+					return nil
+				}
+				return errors.Wrapf(err, "error reading %q %s/%s from the target cluster",
+					obj.GroupVersionKind(), n.identity.Namespace, n.identity.Name)
+			}
+			n.existsInTarget = true
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getTargetUIDs reads the UIDs of objects already created in the target management cluster corresponding to the object graph nodes in a moveGroup.
+func (o *objectMover) getTargetUIDs(group moveGroup, toProxy Proxy) error {
+	readTargetObjectBackoff := newReadBackoff()
+	for i := range group {
+		n := group[i]
+		if err := retryWithExponentialBackoff(readTargetObjectBackoff, func() error {
+			cTo, err := toProxy.NewClient()
+			if err != nil {
+				return err
+			}
+
+			obj := &unstructured.Unstructured{}
+			obj.SetAPIVersion(n.identity.APIVersion)
+			obj.SetKind(n.identity.Kind)
+			if err := cTo.Get(ctx, client.ObjectKey{Namespace: n.identity.Namespace, Name: n.identity.Name}, obj); err != nil {
+				return errors.Wrapf(err, "error reading %q %s/%s from the target cluster",
+					obj.GroupVersionKind(), n.identity.Namespace, n.identity.Name)
+			}
+			n.newUID = obj.GetUID()
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // checkTargetProviders checks that all the providers installed in the source cluster exists in the target cluster as well (with a version >= of the current version).
 func (o *objectMover) checkTargetProviders(toInventory InventoryClient) error {
 	if o.dryRun {
@@ -1038,3 +1495,64 @@ func (o *objectMover) checkTargetProviders(toInventory InventoryClient) error {
 
 	return kerrors.NewAggregate(errList)
 }
+
+// checkRestoreProviders checks that all the providers recorded in a backup exist in the target cluster as well, with a
+// version >= of the version at backup time and implementing the same API Version of Cluster API (contract).
+func (o *objectMover) checkRestoreProviders(backupProviders []backupManifestProvider, toInventory InventoryClient) error {
+	toProviders, err := toInventory.List()
+	if err != nil {
+		return errors.Wrapf(err, "failed to get provider list from the target cluster")
+	}
+
+	errList := []error{}
+	for _, backupProvider := range backupProviders {
+		sourceProvider := backupProvider.provider()
+		sourceVersion, err := version.ParseSemantic(sourceProvider.Version)
+		if err != nil {
+			return errors.Wrapf(err, "unable to parse version %q for the %s provider in the backup", sourceProvider.Version, sourceProvider.InstanceName())
+		}
+
+		// Check corresponding providers in the target cluster and gets the latest version installed.
+		var maxTargetVersion *version.Version
+		var maxTargetProvider clusterctlv1.Provider
+		for _, targetProvider := range toProviders.Items {
+			// Skips other providers.
+			if !sourceProvider.SameAs(targetProvider) {
+				continue
+			}
+
+			targetVersion, err := version.ParseSemantic(targetProvider.Version)
+			if err != nil {
+				return errors.Wrapf(err, "unable to parse version %q for the %s provider in the target cluster", targetProvider.Version, targetProvider.InstanceName())
+			}
+			if maxTargetVersion == nil || maxTargetVersion.LessThan(targetVersion) {
+				maxTargetVersion = targetVersion
+				maxTargetProvider = targetProvider
+			}
+		}
+		if maxTargetVersion == nil {
+			errList = append(errList, errors.Errorf("provider %s not found in the target cluster", sourceProvider.ManifestLabel()))
+			continue
+		}
+
+		if !maxTargetVersion.AtLeast(sourceVersion) {
+			errList = append(errList, errors.Errorf("provider %s in the target cluster is older than in the backup (backup: %s, target: %s)", sourceProvider.ManifestLabel(), sourceVersion.String(), maxTargetVersion.String()))
+			continue
+		}
+
+		// Check the target provider implements the contracts recorded in the backup, if any.
+		// NOTE: backups without contract information are checked by version only.
+		if len(backupProvider.Contracts) == 0 {
+			continue
+		}
+		targetContracts, err := toInventory.GetProviderContracts(maxTargetProvider)
+		if err != nil {
+			return err
+		}
+		if !sets.NewString(targetContracts...).HasAny(backupProvider.Contracts...) {
+			errList = append(errList, errors.Errorf("provider %s in the target cluster implements a contract incompatible with the backup (backup: %v, target: %v)", sourceProvider.ManifestLabel(), backupProvider.Contracts, targetContracts))
+		}
+	}
+
+	return kerrors.NewAggregate(errList)
+}
@@ -0,0 +1,327 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// moveCheckpointName is the name of the ConfigMap storing the checkpoint of a move operation in the source management cluster.
+	moveCheckpointName = "clusterctl-move-checkpoint"
+
+	// moveCheckpointDataKey is the key of the ConfigMap data storing the checkpoint.
+	moveCheckpointDataKey = "checkpoint"
+)
+
+// movePhase defines the phases of a move operation.
+type movePhase string
+
+const (
+	// moveCreatePhase is the phase where objects are created in the target management cluster.
+	// During this phase the source management cluster is not modified, except for pausing Clusters.
+	moveCreatePhase = movePhase("Create")
+
+	// moveDeletePhase is the phase where objects are deleted from the source management cluster.
+	moveDeletePhase = movePhase("Delete")
+)
+
+// moveCheckpoint records the progress of a move operation, so an interrupted move can be resumed or rolled back.
+type moveCheckpoint struct {
+	// Phase of the move operation.
+	Phase movePhase `json:"phase"`
+
+	// Namespace is the namespace being moved.
+	// NOTE: this is empty if all the namespaces are moved; in this case the checkpoint is stored in the default namespace,
+	// the same used by a move of the default namespace, so the two moves can't be run at the same time.
+	Namespace string `json:"namespace,omitempty"`
+
+	// CompletedGroups is the number of move groups already processed in the current phase.
+	// NOTE: during the create phase groups are processed in order, while during the delete phase they are processed in reverse order.
+	CompletedGroups int `json:"completedGroups"`
+
+	// Clusters lists the Clusters selected for the move operation, in the form namespace/name.
+	// NOTE: this is empty if all the Clusters are moved.
+	Clusters []string `json:"clusters,omitempty"`
+
+	// Groups contains the objects to be moved, grouped according to the move sequence.
+	Groups [][]moveCheckpointObject `json:"groups"`
+}
+
+// moveCheckpointObject describes an object included in a move operation.
+type moveCheckpointObject struct {
+	APIVersion        string    `json:"apiVersion"`
+	Kind              string    `json:"kind"`
+	Namespace         string    `json:"namespace,omitempty"`
+	Name              string    `json:"name"`
+	UID               types.UID `json:"uid"`
+	IsGlobal          bool      `json:"isGlobal,omitempty"`
+	IsGlobalHierarchy bool      `json:"isGlobalHierarchy,omitempty"`
+	IsShared          bool      `json:"isShared,omitempty"`
+
+	// ExistsInTarget records if a shared object already existed in the target cluster before the move operation
+	// started, so it is preserved when rolling back the move operation.
+	ExistsInTarget bool `json:"existsInTarget,omitempty"`
+}
+
+// newMoveCheckpoint returns a moveCheckpoint for a move operation of the given namespace that is about to start.
+// If the object graph was restricted to the selected Clusters, those Clusters are recorded, so a resumed
+// move operation can apply the same selection.
+func newMoveCheckpoint(namespace string, moveSequence *moveSequence, selectedClusters []*node) *moveCheckpoint {
+	checkpoint := &moveCheckpoint{
+		Phase:     moveCreatePhase,
+		Namespace: namespace,
+		Clusters:  clusterNames(selectedClusters),
+	}
+	for _, group := range moveSequence.groups {
+		checkpointGroup := []moveCheckpointObject{}
+		for _, n := range group {
+			checkpointGroup = append(checkpointGroup, moveCheckpointObject{
+				APIVersion:        n.identity.APIVersion,
+				Kind:              n.identity.Kind,
+				Namespace:         n.identity.Namespace,
+				Name:              n.identity.Name,
+				UID:               n.identity.UID,
+				IsGlobal:          n.isGlobal,
+				IsGlobalHierarchy: n.isGlobalHierarchy,
+				IsShared:          n.isShared,
+				ExistsInTarget:    n.existsInTarget,
+			})
+		}
+		checkpoint.Groups = append(checkpoint.Groups, checkpointGroup)
+	}
+	return checkpoint
+}
+
+// moveSequence returns a moveSequence with the nodes recorded in the checkpoint.
+// NOTE: nodes rebuilt from a checkpoint do not carry owner references, so they can be used only for deleting objects.
+func (c *moveCheckpoint) moveSequence() *moveSequence {
+	moveSequence := &moveSequence{
+		groups:   []moveGroup{},
+		nodesMap: make(map[*node]empty),
+	}
+	for _, checkpointGroup := range c.Groups {
+		group := moveGroup{}
+		for _, o := range checkpointGroup {
+			group = append(group, &node{
+				identity: corev1.ObjectReference{
+					APIVersion: o.APIVersion,
+					Kind:       o.Kind,
+					Namespace:  o.Namespace,
+					Name:       o.Name,
+					UID:        o.UID,
+				},
+				owners:            make(map[*node]ownerReferenceAttributes),
+				softOwners:        make(map[*node]empty),
+				tenant:            make(map[*node]empty),
+				isGlobal:          o.IsGlobal,
+				isGlobalHierarchy: o.IsGlobalHierarchy,
+				isShared:          o.IsShared,
+				existsInTarget:    o.ExistsInTarget,
+			})
+		}
+		moveSequence.addGroup(group)
+	}
+	return moveSequence
+}
+
+// clusterNames returns the sorted names of the given Clusters, in the form namespace/name.
+func clusterNames(clusters []*node) []string {
+	var names []string
+	for _, cluster := range clusters {
+		names = append(names, fmt.Sprintf("%s/%s", cluster.identity.Namespace, cluster.identity.Name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// describe returns a description of the move operation that recorded the checkpoint.
+func (c *moveCheckpoint) describe() string {
+	description := "move of all the namespaces"
+	if c.Namespace != "" {
+		description = fmt.Sprintf("move of namespace %q", c.Namespace)
+	}
+	if len(c.Clusters) > 0 {
+		description = fmt.Sprintf("%s, Clusters %s", description, strings.Join(c.Clusters, ", "))
+	}
+	return description
+}
+
+// checkNamespace checks that the checkpoint was recorded by a move operation of the given namespace.
+func (c *moveCheckpoint) checkNamespace(namespace string) error {
+	if c.Namespace != namespace {
+		return errors.Errorf("the move checkpoint is owned by a different move operation (%s); please run clusterctl move with --resume or --rollback for that move operation", c.describe())
+	}
+	return nil
+}
+
+// checkClusters checks that the checkpoint was recorded by a move operation of the given Clusters.
+func (c *moveCheckpoint) checkClusters(selectedClusters []*node) error {
+	if !sets.NewString(c.Clusters...).Equal(sets.NewString(clusterNames(selectedClusters)...)) {
+		return errors.Errorf("the move checkpoint is owned by a different move operation (%s)", c.describe())
+	}
+	return nil
+}
+
+// matches checks that a move sequence computed from the source cluster is the same recorded in the checkpoint.
+func (c *moveCheckpoint) matches(moveSequence *moveSequence) error {
+	if len(c.Groups) != len(moveSequence.groups) {
+		return errors.Errorf("expected %d move groups, got %d", len(c.Groups), len(moveSequence.groups))
+	}
+	for i, checkpointGroup := range c.Groups {
+		expected := sets.NewString()
+		for _, o := range checkpointGroup {
+			expected.Insert(string(o.UID))
+		}
+		actual := sets.NewString()
+		for _, n := range moveSequence.getGroup(i) {
+			actual.Insert(string(n.identity.UID))
+		}
+		if !expected.Equal(actual) {
+			return errors.Errorf("move group %d has changed", i)
+		}
+	}
+	return nil
+}
+
+// getClusters returns the list of Clusters existing in the move sequence.
+func (s *moveSequence) getClusters() []*node {
+	clusters := []*node{}
+	for n := range s.nodesMap {
+		if n.identity.GroupVersionKind().GroupKind() == clusterv1.GroupVersion.WithKind("Cluster").GroupKind() {
+			clusters = append(clusters, n)
+		}
+	}
+	return clusters
+}
+
+// getCheckpointNamespace returns the namespace where the move checkpoint is stored.
+func (o *objectMover) getCheckpointNamespace() string {
+	if o.namespace == "" {
+		return metav1.NamespaceDefault
+	}
+	return o.namespace
+}
+
+// getCheckpoint reads the move checkpoint from the source management cluster, if any.
+func (o *objectMover) getCheckpoint() (*moveCheckpoint, error) {
+	var checkpoint *moveCheckpoint
+	getCheckpointBackoff := newReadBackoff()
+	if err := retryWithExponentialBackoff(getCheckpointBackoff, func() error {
+		c, err := o.fromProxy.NewClient()
+		if err != nil {
+			return err
+		}
+
+		cm := &corev1.ConfigMap{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: o.getCheckpointNamespace(), Name: moveCheckpointName}, cm); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return errors.Wrap(err, "failed to read the move checkpoint")
+		}
+
+		checkpoint = &moveCheckpoint{}
+		return yaml.Unmarshal([]byte(cm.Data[moveCheckpointDataKey]), checkpoint)
+	}); err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// saveCheckpoint creates or updates the move checkpoint in the source management cluster.
+func (o *objectMover) saveCheckpoint(checkpoint *moveCheckpoint) error {
+	if o.dryRun {
+		return nil
+	}
+
+	data, err := yaml.Marshal(checkpoint)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the move checkpoint")
+	}
+
+	saveCheckpointBackoff := newWriteBackoff()
+	return retryWithExponentialBackoff(saveCheckpointBackoff, func() error {
+		c, err := o.fromProxy.NewClient()
+		if err != nil {
+			return err
+		}
+
+		cm := &corev1.ConfigMap{}
+		key := client.ObjectKey{Namespace: o.getCheckpointNamespace(), Name: moveCheckpointName}
+		if err := c.Get(ctx, key, cm); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return errors.Wrap(err, "failed to read the move checkpoint")
+			}
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: key.Namespace,
+					Name:      key.Name,
+				},
+				Data: map[string]string{moveCheckpointDataKey: string(data)},
+			}
+			if err := c.Create(ctx, cm); err != nil {
+				return errors.Wrap(err, "failed to create the move checkpoint")
+			}
+			return nil
+		}
+
+		cm.Data = map[string]string{moveCheckpointDataKey: string(data)}
+		if err := c.Update(ctx, cm); err != nil {
+			return errors.Wrap(err, "failed to update the move checkpoint")
+		}
+		return nil
+	})
+}
+
+// deleteCheckpoint deletes the move checkpoint from the source management cluster.
+func (o *objectMover) deleteCheckpoint() error {
+	if o.dryRun {
+		return nil
+	}
+
+	deleteCheckpointBackoff := newWriteBackoff()
+	return retryWithExponentialBackoff(deleteCheckpointBackoff, func() error {
+		c, err := o.fromProxy.NewClient()
+		if err != nil {
+			return err
+		}
+
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: o.getCheckpointNamespace(),
+				Name:      moveCheckpointName,
+			},
+		}
+		if err := c.Delete(ctx, cm); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrap(err, "failed to delete the move checkpoint")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MovePlan describes the objects that would be moved by a move operation, and the order in which they would be moved.
+type MovePlan struct {
+	// Objects lists all the objects that would be moved, together with their relations and the reasons why they are included.
+	Objects []MovePlanObject `json:"objects"`
+
+	// Groups lists the objects in each move group, in the order they would be created in the target management cluster;
+	// objects are deleted from the source management cluster in the reverse order.
+	Groups [][]MovePlanObjectReference `json:"groups"`
+
+	// Skipped lists the objects discovered in the source management cluster that would not be moved.
+	Skipped []MovePlanSkippedObject `json:"skipped,omitempty"`
+
+	// Blocked lists the objects preventing the move operation from starting, e.g. because they are still provisioning.
+	Blocked []MovePlanBlockedObject `json:"blocked,omitempty"`
+}
+
+// MovePlanObjectReference identifies an object in a MovePlan.
+type MovePlanObjectReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+// MovePlanObject describes an object that would be moved.
+type MovePlanObject struct {
+	MovePlanObjectReference
+
+	// Group is the index of the move group the object belongs to.
+	Group int `json:"group"`
+
+	// Owners lists the objects referenced by the OwnerReferences of the object.
+	Owners []MovePlanObjectReference `json:"owners,omitempty"`
+
+	// SoftOwners lists the objects the object is linked to without an OwnerReference, e.g. the Cluster
+	// a Secret belongs to according to the naming convention.
+	SoftOwners []MovePlanObjectReference `json:"softOwners,omitempty"`
+
+	// ForceMove is true if the object is moved no matter of its owners.
+	ForceMove bool `json:"forceMove,omitempty"`
+
+	// ForceMoveHierarchy is true if the object is moved together with all its dependants.
+	ForceMoveHierarchy bool `json:"forceMoveHierarchy,omitempty"`
+
+	// Global is true if the object is cluster-wide or it belongs to a cluster-wide object hierarchy;
+	// global objects are not deleted from the source management cluster.
+	Global bool `json:"global,omitempty"`
+
+	// Shared is true if the object is linked also to Clusters not being moved;
+	// shared objects are not deleted from the source management cluster.
+	Shared bool `json:"shared,omitempty"`
+
+	// Reasons explains why the object is included in the move operation.
+	Reasons []string `json:"reasons"`
+}
+
+// MovePlanSkippedObject describes an object that would not be moved.
+type MovePlanSkippedObject struct {
+	MovePlanObjectReference
+
+	// Reason explains why the object is not included in the move operation.
+	Reason string `json:"reason"`
+}
+
+// MovePlanBlockedObject describes an object preventing the move operation from starting.
+type MovePlanBlockedObject struct {
+	MovePlanObjectReference
+
+	// Reason explains why the object is preventing the move operation from starting.
+	Reason string `json:"reason"`
+}
+
+// newMovePlan returns the MovePlan for an object graph and the corresponding move sequence.
+func newMovePlan(graph *objectGraph, moveSequence *moveSequence, blockers []provisioningBlocker) *MovePlan {
+	plan := &MovePlan{
+		Objects: []MovePlanObject{},
+		Groups:  [][]MovePlanObjectReference{},
+	}
+
+	for i, group := range moveSequence.groups {
+		planGroup := []MovePlanObjectReference{}
+		for _, n := range sortedNodes(group) {
+			planGroup = append(planGroup, n.planReference())
+			plan.Objects = append(plan.Objects, MovePlanObject{
+				MovePlanObjectReference: n.planReference(),
+				Group:                   i,
+				Owners:                  planReferences(ownerNodes(n.owners)),
+				SoftOwners:              planReferences(nodeSetList(n.softOwners)),
+				ForceMove:               n.forceMove,
+				ForceMoveHierarchy:      n.forceMoveHierarchy,
+				Global:                  n.isGlobal || n.isGlobalHierarchy,
+				Shared:                  n.isShared,
+				Reasons:                 n.moveReasons(),
+			})
+		}
+		plan.Groups = append(plan.Groups, planGroup)
+	}
+
+	for _, n := range sortedNodes(graph.getNodes()) {
+		if moveSequence.hasNode(n) {
+			continue
+		}
+		reason := "it does not belong to any Cluster, ClusterResourceSet or other object moved together with all its dependants"
+		if n.virtual {
+			reason = "it is not included in the types considered for move"
+		}
+		plan.Skipped = append(plan.Skipped, MovePlanSkippedObject{
+			MovePlanObjectReference: n.planReference(),
+			Reason:                  reason,
+		})
+	}
+
+	for _, b := range blockers {
+		plan.Blocked = append(plan.Blocked, MovePlanBlockedObject{
+			MovePlanObjectReference: b.node.planReference(),
+			Reason:                  b.err.Error(),
+		})
+	}
+
+	return plan
+}
+
+// planReference returns the MovePlanObjectReference for a node.
+func (n *node) planReference() MovePlanObjectReference {
+	return MovePlanObjectReference{
+		APIVersion: n.identity.APIVersion,
+		Kind:       n.identity.Kind,
+		Namespace:  n.identity.Namespace,
+		Name:       n.identity.Name,
+	}
+}
+
+// moveReasons explains why a node is included in the move operation.
+func (n *node) moveReasons() []string {
+	reasons := []string{}
+	if n.forceMoveHierarchy {
+		reasons = append(reasons, fmt.Sprintf("%s objects are moved together with all their dependants", n.identity.Kind))
+	} else if n.forceMove {
+		reasons = append(reasons, fmt.Sprintf("%s objects are always moved", n.identity.Kind))
+	}
+	for _, owner := range ownerNodes(n.owners) {
+		reasons = append(reasons, fmt.Sprintf("owned by %s", owner.displayName()))
+	}
+	for _, owner := range nodeSetList(n.softOwners) {
+		reasons = append(reasons, fmt.Sprintf("secret referenced by %s via naming convention", owner.displayName()))
+	}
+	for _, tenant := range nodeSetList(n.tenant) {
+		if tenant == n {
+			continue
+		}
+		reasons = append(reasons, fmt.Sprintf("belongs to %s", tenant.displayName()))
+	}
+	if n.isShared {
+		reasons = append(reasons, "shared with Clusters not being moved, it will not be deleted from the source cluster")
+	}
+	if n.isGlobal || n.isGlobalHierarchy {
+		reasons = append(reasons, "global object, it will not be deleted from the source cluster")
+	}
+	return reasons
+}
+
+// displayName returns a human readable name for a node, e.g. Cluster ns1/foo.
+func (n *node) displayName() string {
+	if n.identity.Namespace == "" {
+		return fmt.Sprintf("%s %s", n.identity.Kind, n.identity.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", n.identity.Kind, n.identity.Namespace, n.identity.Name)
+}
+
+func planReferences(nodes []*node) []MovePlanObjectReference {
+	refs := []MovePlanObjectReference{}
+	for _, n := range nodes {
+		refs = append(refs, n.planReference())
+	}
+	return refs
+}
+
+// ownerNodes returns the owners of a node, sorted by identity.
+func ownerNodes(owners map[*node]ownerReferenceAttributes) []*node {
+	nodes := []*node{}
+	for n := range owners {
+		nodes = append(nodes, n)
+	}
+	return sortedNodes(nodes)
+}
+
+// nodeSetList returns the nodes in a set, sorted by identity.
+func nodeSetList(set map[*node]empty) []*node {
+	nodes := []*node{}
+	for n := range set {
+		nodes = append(nodes, n)
+	}
+	return sortedNodes(nodes)
+}
+
+// sortedNodes returns a copy of a list of nodes sorted by identity, so the move plan is stable across runs.
+func sortedNodes(nodes []*node) []*node {
+	sorted := make([]*node, len(nodes))
+	copy(sorted, nodes)
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i].identity, sorted[j].identity
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return sorted
+}
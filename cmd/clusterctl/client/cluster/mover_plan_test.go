@@ -0,0 +1,114 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func Test_newMovePlan(t *testing.T) {
+	// NB. we are testing the move plan using the same set of moveTests used for testing the move sequence
+	for _, tt := range moveTests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			// Create an objectGraph bound a source cluster with all the CRDs for the types involved in the test.
+			graph := getObjectGraphWithObjs(tt.fields.objs)
+
+			// Get all the types to be considered for discovery
+			g.Expect(getFakeDiscoveryTypes(graph)).To(Succeed())
+
+			// trigger discovery the content of the source cluster
+			g.Expect(graph.Discovery("")).To(Succeed())
+
+			blockers := []provisioningBlocker{}
+			for _, cluster := range graph.getClusters() {
+				blockers = append(blockers, provisioningBlocker{node: cluster, err: errors.New("still provisioning")})
+			}
+
+			plan := newMovePlan(graph, getMoveSequence(graph), blockers)
+
+			// Groups in the plan should match the move sequence.
+			g.Expect(plan.Groups).To(HaveLen(len(tt.wantMoveGroups)))
+			for i, gotGroup := range plan.Groups {
+				gotObjs := []string{}
+				for _, ref := range gotGroup {
+					gotObjs = append(gotObjs, planReferenceUID(ref))
+				}
+				g.Expect(gotObjs).To(ConsistOf(tt.wantMoveGroups[i]))
+			}
+
+			// All the objects should be listed with the reason why they are included in the move.
+			objs := 0
+			for _, group := range plan.Groups {
+				objs += len(group)
+			}
+			g.Expect(plan.Objects).To(HaveLen(objs))
+			for _, o := range plan.Objects {
+				g.Expect(o.Reasons).NotTo(BeEmpty())
+
+				if o.Kind == "Cluster" {
+					g.Expect(o.ForceMoveHierarchy).To(BeTrue())
+				}
+				for _, owner := range o.Owners {
+					ownerName := owner.Name
+					if owner.Namespace != "" {
+						ownerName = fmt.Sprintf("%s/%s", owner.Namespace, owner.Name)
+					}
+					g.Expect(o.Reasons).To(ContainElement(fmt.Sprintf("owned by %s %s", owner.Kind, ownerName)))
+				}
+			}
+
+			g.Expect(plan.Blocked).To(HaveLen(len(blockers)))
+			for _, b := range plan.Blocked {
+				g.Expect(b.Kind).To(Equal("Cluster"))
+				g.Expect(b.Reason).To(Equal("still provisioning"))
+			}
+		})
+	}
+}
+
+func Test_newMovePlan_skipped(t *testing.T) {
+	g := NewWithT(t)
+
+	graph := getObjectGraph()
+
+	// A secret not linked to any Cluster should be reported as skipped.
+	secret := &unstructured.Unstructured{}
+	secret.SetAPIVersion("v1")
+	secret.SetKind("Secret")
+	secret.SetNamespace("ns1")
+	secret.SetName("not-linked")
+	secret.SetUID("/v1, Kind=Secret, ns1/not-linked")
+	graph.addObj(secret)
+
+	plan := newMovePlan(graph, getMoveSequence(graph), nil)
+	g.Expect(plan.Objects).To(BeEmpty())
+	g.Expect(plan.Skipped).To(HaveLen(1))
+	g.Expect(plan.Skipped[0].Name).To(Equal("not-linked"))
+	g.Expect(plan.Skipped[0].Reason).NotTo(BeEmpty())
+}
+
+func planReferenceUID(ref MovePlanObjectReference) string {
+	return fmt.Sprintf("%s, %s/%s", schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind).String(), ref.Namespace, ref.Name)
+}
@@ -27,6 +27,7 @@ import (
 
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -741,7 +742,8 @@ func Test_objectMover_backup(t *testing.T) {
 
 			// Run backup
 			mover := objectMover{
-				fromProxy: graph.proxy,
+				fromProxy:             graph.proxy,
+				fromProviderInventory: graph.providerInventory,
 			}
 
 			dir, err := ioutil.TempDir("/tmp", "cluster-api")
@@ -750,7 +752,8 @@ func Test_objectMover_backup(t *testing.T) {
 			}
 			defer os.RemoveAll(dir)
 
-			err = mover.backup(graph, dir)
+			file := filepath.Join(dir, "backup.tar.gz")
+			err = mover.backup(graph, file)
 			if tt.wantErr {
 				g.Expect(err).To(HaveOccurred())
 				return
@@ -758,6 +761,12 @@ func Test_objectMover_backup(t *testing.T) {
 
 			g.Expect(err).NotTo(HaveOccurred())
 
+			// the backup archive is verified and extracted into the temporary directory
+			manifest, err := extractBackupArchive(file, dir)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(manifest.Providers).To(HaveLen(1))
+			g.Expect(manifest.Providers[0].Version).To(Equal("v1.2.3"))
+
 			// check that the objects are stored in the temporary directory but not deleted from the source cluster
 			csFrom, err := graph.proxy.NewClient()
 			g.Expect(err).NotTo(HaveOccurred())
@@ -905,7 +914,7 @@ func Test_objectMover_restore(t *testing.T) {
 			// trigger discovery the content of the source cluster
 			g.Expect(graph.Discovery("")).To(Succeed())
 
-			err = mover.restore(graph, toProxy)
+			err = mover.restore(graph, getMoveSequence(graph), toProxy)
 			if tt.wantErr {
 				g.Expect(err).To(HaveOccurred())
 				return
@@ -1115,6 +1124,403 @@ func Test_objectMover_move(t *testing.T) {
 	}
 }
 
+func Test_objectMover_resumeMove(t *testing.T) {
+	// NB. we are testing resume using the same set of moveTests, interrupting the move at different stages of the move process
+	for _, tt := range moveTests {
+		if tt.wantErr {
+			continue
+		}
+		for _, phase := range []movePhase{moveCreatePhase, moveDeletePhase} {
+			t.Run(fmt.Sprintf("%s (%s phase)", tt.name, phase), func(t *testing.T) {
+				g := NewWithT(t)
+
+				// Create an objectGraph bound a source cluster with all the CRDs for the types involved in the test.
+				graph := getObjectGraphWithObjs(tt.fields.objs)
+
+				// Get all the types to be considered for discovery
+				g.Expect(getFakeDiscoveryTypes(graph)).To(Succeed())
+
+				// trigger discovery the content of the source cluster
+				g.Expect(graph.Discovery("")).To(Succeed())
+
+				// gets a fakeProxy to an empty cluster with all the required CRDs
+				toProxy := getFakeProxyWithCRDs()
+
+				mover := objectMover{
+					fromProxy: graph.proxy,
+				}
+
+				// Simulate a move interrupted after processing the first group in the given phase.
+				moveSequence := getMoveSequence(graph)
+				checkpoint := newMoveCheckpoint("", moveSequence, nil)
+				g.Expect(mover.ensureNamespaces(graph, toProxy)).To(Succeed())
+				g.Expect(mover.createGroup(moveSequence.getGroup(0), toProxy)).To(Succeed())
+				checkpoint.CompletedGroups = 1
+
+				var resumeGraph *objectGraph
+				if phase == moveDeletePhase {
+					for groupIndex := 1; groupIndex < len(moveSequence.groups); groupIndex++ {
+						g.Expect(mover.createGroup(moveSequence.getGroup(groupIndex), toProxy)).To(Succeed())
+					}
+					g.Expect(mover.deleteGroup(moveSequence.getGroup(len(moveSequence.groups) - 1))).To(Succeed())
+					checkpoint.Phase = moveDeletePhase
+				} else {
+					// The object graph is discovered again when resuming the create phase.
+					resumeGraph = newObjectGraph(graph.proxy, graph.providerInventory)
+					g.Expect(getFakeDiscoveryTypes(resumeGraph)).To(Succeed())
+					g.Expect(resumeGraph.Discovery("")).To(Succeed())
+				}
+				g.Expect(mover.saveCheckpoint(checkpoint)).To(Succeed())
+
+				g.Expect(mover.resumeMove(resumeGraph, checkpoint, toProxy)).To(Succeed())
+
+				// check that the objects are removed from the source cluster and are created in the target cluster
+				csFrom, err := graph.proxy.NewClient()
+				g.Expect(err).NotTo(HaveOccurred())
+
+				csTo, err := toProxy.NewClient()
+				g.Expect(err).NotTo(HaveOccurred())
+
+				for _, node := range graph.uidToNode {
+					key := client.ObjectKey{
+						Namespace: node.identity.Namespace,
+						Name:      node.identity.Name,
+					}
+
+					// objects are deleted from the source cluster
+					oFrom := &unstructured.Unstructured{}
+					oFrom.SetAPIVersion(node.identity.APIVersion)
+					oFrom.SetKind(node.identity.Kind)
+
+					err := csFrom.Get(ctx, key, oFrom)
+					if err == nil {
+						if !node.isGlobal && !node.isGlobalHierarchy {
+							t.Errorf("%v not deleted in source cluster", key)
+							continue
+						}
+					} else if !apierrors.IsNotFound(err) {
+						t.Errorf("error = %v when checking for %v deleted in source cluster", err, key)
+						continue
+					}
+
+					// objects are created in the target cluster
+					oTo := &unstructured.Unstructured{}
+					oTo.SetAPIVersion(node.identity.APIVersion)
+					oTo.SetKind(node.identity.Kind)
+
+					if err := csTo.Get(ctx, key, oTo); err != nil {
+						t.Errorf("error = %v when checking for %v created in target cluster", err, key)
+						continue
+					}
+				}
+
+				// the checkpoint is removed once the move completes
+				checkpoint, err = mover.getCheckpoint()
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(checkpoint).To(BeNil())
+			})
+		}
+	}
+}
+
+func Test_objectMover_rollbackMove(t *testing.T) {
+	// NB. we are testing rollback using the same set of moveTests, interrupting the move after the first group is created
+	for _, tt := range moveTests {
+		if tt.wantErr {
+			continue
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			// Create an objectGraph bound a source cluster with all the CRDs for the types involved in the test.
+			graph := getObjectGraphWithObjs(tt.fields.objs)
+
+			// Get all the types to be considered for discovery
+			g.Expect(getFakeDiscoveryTypes(graph)).To(Succeed())
+
+			// trigger discovery the content of the source cluster
+			g.Expect(graph.Discovery("")).To(Succeed())
+
+			// gets a fakeProxy to an empty cluster with all the required CRDs
+			toProxy := getFakeProxyWithCRDs()
+
+			mover := objectMover{
+				fromProxy: graph.proxy,
+			}
+
+			// Simulate a move interrupted after creating the first group.
+			moveSequence := getMoveSequence(graph)
+			checkpoint := newMoveCheckpoint("", moveSequence, nil)
+			g.Expect(mover.ensureNamespaces(graph, toProxy)).To(Succeed())
+			g.Expect(mover.createGroup(moveSequence.getGroup(0), toProxy)).To(Succeed())
+			checkpoint.CompletedGroups = 1
+			g.Expect(mover.saveCheckpoint(checkpoint)).To(Succeed())
+
+			g.Expect(mover.rollbackMove(checkpoint, toProxy)).To(Succeed())
+
+			// check that the objects are still in the source cluster and are removed from the target cluster
+			csFrom, err := graph.proxy.NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			csTo, err := toProxy.NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			for node := range moveSequence.nodesMap {
+				key := client.ObjectKey{
+					Namespace: node.identity.Namespace,
+					Name:      node.identity.Name,
+				}
+
+				// objects are not deleted from the source cluster
+				oFrom := &unstructured.Unstructured{}
+				oFrom.SetAPIVersion(node.identity.APIVersion)
+				oFrom.SetKind(node.identity.Kind)
+				g.Expect(csFrom.Get(ctx, key, oFrom)).To(Succeed())
+
+				if node.isGlobal || node.isGlobalHierarchy {
+					continue
+				}
+
+				// objects are deleted from the target cluster
+				oTo := &unstructured.Unstructured{}
+				oTo.SetAPIVersion(node.identity.APIVersion)
+				oTo.SetKind(node.identity.Kind)
+
+				err := csTo.Get(ctx, key, oTo)
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "%v not deleted in target cluster", key)
+			}
+
+			// the checkpoint is removed once the rollback completes
+			checkpoint, err = mover.getCheckpoint()
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(checkpoint).To(BeNil())
+		})
+	}
+}
+
+func Test_objectMover_rollbackMove_deletePhase(t *testing.T) {
+	g := NewWithT(t)
+
+	mover := objectMover{
+		fromProxy: test.NewFakeProxy(),
+	}
+
+	checkpoint := &moveCheckpoint{Phase: moveDeletePhase}
+	g.Expect(mover.rollbackMove(checkpoint, test.NewFakeProxy())).NotTo(Succeed())
+}
+
+func Test_moveCheckpoint_checkOwner(t *testing.T) {
+	cluster := func(namespace, name string) *node {
+		return &node{identity: corev1.ObjectReference{Kind: "Cluster", Namespace: namespace, Name: name}}
+	}
+
+	tests := []struct {
+		name             string
+		checkpoint       *moveCheckpoint
+		namespace        string
+		selectedClusters []*node
+		wantErr          string
+	}{
+		{
+			name:       "same namespace, all the Clusters",
+			checkpoint: &moveCheckpoint{Namespace: "ns1"},
+			namespace:  "ns1",
+			wantErr:    "",
+		},
+		{
+			name:             "same namespace, same Clusters",
+			checkpoint:       &moveCheckpoint{Namespace: "ns1", Clusters: []string{"ns1/cluster1", "ns1/cluster2"}},
+			namespace:        "ns1",
+			selectedClusters: []*node{cluster("ns1", "cluster2"), cluster("ns1", "cluster1")},
+			wantErr:          "",
+		},
+		{
+			name:       "move of all the namespaces checked by a move of the default namespace",
+			checkpoint: &moveCheckpoint{Namespace: ""},
+			namespace:  "default",
+			wantErr:    "the move checkpoint is owned by a different move operation (move of all the namespaces)",
+		},
+		{
+			name:       "move of the default namespace checked by a move of all the namespaces",
+			checkpoint: &moveCheckpoint{Namespace: "default"},
+			namespace:  "",
+			wantErr:    "the move checkpoint is owned by a different move operation (move of namespace \"default\")",
+		},
+		{
+			name:             "same namespace, different Clusters",
+			checkpoint:       &moveCheckpoint{Namespace: "ns1", Clusters: []string{"ns1/cluster1"}},
+			namespace:        "ns1",
+			selectedClusters: []*node{cluster("ns1", "cluster2")},
+			wantErr:          "the move checkpoint is owned by a different move operation (move of namespace \"ns1\", Clusters ns1/cluster1)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := tt.checkpoint.checkNamespace(tt.namespace)
+			if err == nil {
+				err = tt.checkpoint.checkClusters(tt.selectedClusters)
+			}
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+	}
+}
+
+func Test_objectMover_resumeAndRollbackMove_sharedObjects(t *testing.T) {
+	// NB. a ClusterResourceSet applied to two Clusters is shared when moving only one of them, so it should never be
+	// deleted from the source cluster; when rolling back, it should be deleted from the target cluster only if it
+	// was created by the move operation.
+	objs := func() []client.Object {
+		objs := []client.Object{}
+		objs = append(objs, test.NewFakeCluster("ns1", "cluster1").Objs()...)
+		objs = append(objs, test.NewFakeCluster("ns1", "cluster2").Objs()...)
+		objs = append(objs, test.NewFakeClusterResourceSet("ns1", "crs1").
+			WithSecret("resource-s1").
+			WithConfigMap("resource-c1").
+			ApplyToCluster(test.SelectClusterObj(objs, "ns1", "cluster1")).
+			ApplyToCluster(test.SelectClusterObj(objs, "ns1", "cluster2")).
+			Objs()...)
+		return objs
+	}
+
+	tests := []struct {
+		name               string
+		rollback           bool
+		sharedInTarget     bool
+		wantSharedInTarget bool
+	}{
+		{
+			name:               "resume in the delete phase",
+			rollback:           false,
+			sharedInTarget:     false,
+			wantSharedInTarget: true,
+		},
+		{
+			name:               "rollback in the create phase deletes shared objects created by the move",
+			rollback:           true,
+			sharedInTarget:     false,
+			wantSharedInTarget: false,
+		},
+		{
+			name:               "rollback in the create phase preserves shared objects existing in the target cluster before the move",
+			rollback:           true,
+			sharedInTarget:     true,
+			wantSharedInTarget: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			// Create an objectGraph bound a source cluster with all the CRDs for the types involved in the test.
+			graph := getObjectGraphWithObjs(objs())
+
+			// Get all the types to be considered for discovery
+			g.Expect(getFakeDiscoveryTypes(graph)).To(Succeed())
+
+			// trigger discovery the content of the source cluster
+			g.Expect(graph.Discovery("")).To(Succeed())
+
+			// Restrict the move to cluster1.
+			selected := []*node{}
+			for _, cluster := range graph.getClusters() {
+				if cluster.identity.Name == "cluster1" {
+					selected = append(selected, cluster)
+				}
+			}
+			graph.filterClusters(selected)
+
+			// gets a fakeProxy to an empty cluster with all the required CRDs
+			toProxy := getFakeProxyWithCRDs()
+
+			mover := objectMover{
+				fromProxy: graph.proxy,
+			}
+
+			moveSequence := getMoveSequence(graph)
+			g.Expect(mover.ensureNamespaces(graph, toProxy)).To(Succeed())
+
+			// Simulate shared objects already moved to the target cluster together with other Clusters.
+			if tt.sharedInTarget {
+				for groupIndex := 0; groupIndex < len(moveSequence.groups); groupIndex++ {
+					for _, n := range moveSequence.getGroup(groupIndex) {
+						if n.isShared {
+							g.Expect(mover.createTargetObject(n, toProxy)).To(Succeed())
+						}
+					}
+				}
+			}
+
+			// Simulate a move interrupted after creating all the objects in the target cluster.
+			g.Expect(mover.checkSharedObjectsInTarget(moveSequence, toProxy)).To(Succeed())
+			checkpoint := newMoveCheckpoint("", moveSequence, selected)
+			for groupIndex := 0; groupIndex < len(moveSequence.groups); groupIndex++ {
+				g.Expect(mover.createGroup(moveSequence.getGroup(groupIndex), toProxy)).To(Succeed())
+			}
+			if tt.rollback {
+				checkpoint.CompletedGroups = len(moveSequence.groups)
+			} else {
+				checkpoint.Phase = moveDeletePhase
+			}
+			g.Expect(mover.saveCheckpoint(checkpoint)).To(Succeed())
+
+			// Read the checkpoint back, so the move sequence is rebuilt from the saved data.
+			checkpoint, err := mover.getCheckpoint()
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(checkpoint).NotTo(BeNil())
+
+			if tt.rollback {
+				g.Expect(mover.rollbackMove(checkpoint, toProxy)).To(Succeed())
+			} else {
+				g.Expect(mover.resumeMove(nil, checkpoint, toProxy)).To(Succeed())
+			}
+
+			csFrom, err := graph.proxy.NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			csTo, err := toProxy.NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			gotShared := 0
+			for node := range moveSequence.nodesMap {
+				if !node.isShared {
+					continue
+				}
+				gotShared++
+
+				key := client.ObjectKey{
+					Namespace: node.identity.Namespace,
+					Name:      node.identity.Name,
+				}
+
+				// shared objects are not deleted from the source cluster
+				oFrom := &unstructured.Unstructured{}
+				oFrom.SetAPIVersion(node.identity.APIVersion)
+				oFrom.SetKind(node.identity.Kind)
+				g.Expect(csFrom.Get(ctx, key, oFrom)).To(Succeed(), "%v deleted from the source cluster", key)
+
+				// shared objects are deleted from the target cluster only by a rollback, and only if created by the move
+				oTo := &unstructured.Unstructured{}
+				oTo.SetAPIVersion(node.identity.APIVersion)
+				oTo.SetKind(node.identity.Kind)
+				err = csTo.Get(ctx, key, oTo)
+				if tt.wantSharedInTarget {
+					g.Expect(err).NotTo(HaveOccurred(), "%v deleted from the target cluster", key)
+				} else {
+					g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "%v not deleted from the target cluster", key)
+				}
+			}
+			g.Expect(gotShared).To(Equal(3))
+		})
+	}
+}
+
 func Test_objectMover_checkProvisioningCompleted(t *testing.T) {
 	type fields struct {
 		objs []client.Object
@@ -1422,6 +1828,90 @@ func Test_objectsMoverService_checkTargetProviders(t *testing.T) {
 	}
 }
 
+func Test_objectMover_checkRestoreProviders(t *testing.T) {
+	infraCRD := func(contracts ...string) *apiextensionsv1.CustomResourceDefinition {
+		crd := test.FakeNamespacedCustomResourceDefinition("infrastructure.cluster.x-k8s.io", "FooCluster", "v1alpha4")
+		crd.Labels[clusterv1.ProviderLabelName] = "infrastructure-capa"
+		for _, contract := range contracts {
+			crd.Labels[fmt.Sprintf("%s/%s", clusterv1.GroupVersion.Group, contract)] = contract
+		}
+		return crd
+	}
+
+	type args struct {
+		backupProviders []backupManifestProvider
+		toProxy         Proxy
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "all the providers in place",
+			args: args{
+				backupProviders: []backupManifestProvider{
+					{Name: "capi", Namespace: "capi-system", ProviderName: "capi", Type: string(clusterctlv1.CoreProviderType), Version: "v1.0.0"},
+					{Name: "capa", Namespace: "capa-system", ProviderName: "capa", Type: string(clusterctlv1.InfrastructureProviderType), Version: "v1.0.0", Contracts: []string{"v1alpha4"}},
+				},
+				toProxy: test.NewFakeProxy().
+					WithObjs(infraCRD("v1alpha4")).
+					WithProviderInventory("capi", clusterctlv1.CoreProviderType, "v1.0.0", "capi-system").
+					WithProviderInventory("capa", clusterctlv1.InfrastructureProviderType, "v1.1.0", "capa-system"),
+			},
+			wantErr: false,
+		},
+		{
+			name: "fails if a provider is missing",
+			args: args{
+				backupProviders: []backupManifestProvider{
+					{Name: "capi", Namespace: "capi-system", ProviderName: "capi", Type: string(clusterctlv1.CoreProviderType), Version: "v1.0.0"},
+					{Name: "capa", Namespace: "capa-system", ProviderName: "capa", Type: string(clusterctlv1.InfrastructureProviderType), Version: "v1.0.0"},
+				},
+				toProxy: test.NewFakeProxy().
+					WithProviderInventory("capi", clusterctlv1.CoreProviderType, "v1.0.0", "capi-system"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if a provider version is older than in the backup",
+			args: args{
+				backupProviders: []backupManifestProvider{
+					{Name: "capi", Namespace: "capi-system", ProviderName: "capi", Type: string(clusterctlv1.CoreProviderType), Version: "v2.0.0"},
+				},
+				toProxy: test.NewFakeProxy().
+					WithProviderInventory("capi", clusterctlv1.CoreProviderType, "v1.0.0", "capi-system"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if a provider implements an incompatible contract",
+			args: args{
+				backupProviders: []backupManifestProvider{
+					{Name: "capa", Namespace: "capa-system", ProviderName: "capa", Type: string(clusterctlv1.InfrastructureProviderType), Version: "v1.0.0", Contracts: []string{"v1alpha3"}},
+				},
+				toProxy: test.NewFakeProxy().
+					WithObjs(infraCRD("v1alpha4")).
+					WithProviderInventory("capa", clusterctlv1.InfrastructureProviderType, "v1.0.0", "capa-system"),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			o := &objectMover{}
+			err := o.checkRestoreProviders(tt.args.backupProviders, newInventoryClient(tt.args.toProxy, nil))
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+	}
+}
+
 func Test_objectMoverService_ensureNamespace(t *testing.T) {
 	type args struct {
 		toProxy   Proxy
@@ -1947,6 +2437,36 @@ func Test_deleteSourceObject(t *testing.T) {
 				g.Expect(apierrors.IsNotFound(toClient.Get(ctx, key, c))).To(BeTrue())
 			},
 		},
+		{
+			name: "does not delete from source if the object is shared with other Clusters",
+			args: args{
+				fromProxy: test.NewFakeProxy().WithObjs(
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "foo",
+							Namespace: "ns1",
+						},
+					},
+				),
+				node: &node{
+					identity: corev1.ObjectReference{
+						Kind:       "Secret",
+						Namespace:  "ns1",
+						Name:       "foo",
+						APIVersion: "v1",
+					},
+					isShared: true,
+				},
+			},
+			want: func(g *WithT, toClient client.Client) {
+				s := &corev1.Secret{}
+				key := client.ObjectKey{
+					Namespace: "ns1",
+					Name:      "foo",
+				}
+				g.Expect(toClient.Get(ctx, key, s)).To(Succeed())
+			},
+		},
 	}
 
 	for _, tt := range tests {
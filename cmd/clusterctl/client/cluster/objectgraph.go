@@ -69,6 +69,16 @@ type node struct {
 	// When this flag is true the object should not be deleted from the source cluster.
 	isGlobalHierarchy bool
 
+	// isShared gets set to true if this object is linked also to Clusters not included in a move operation, e.g. a
+	// ClusterResourceSet applied to many Clusters or an identity used by many Clusters.
+	// When this flag is true the object should not be deleted from the source cluster.
+	isShared bool
+
+	// existsInTarget gets set to true if a shared object already existed in the target cluster before the move operation
+	// started, e.g. because it was moved together with other Clusters.
+	// When this flag is true the object should not be deleted from the target cluster when rolling back a move operation.
+	existsInTarget bool
+
 	// virtual records if this node was discovered indirectly, e.g. by processing an OwnerRef, but not yet observed as a concrete object.
 	virtual bool
 
@@ -124,6 +134,9 @@ type objectGraph struct {
 	providerInventory InventoryClient
 	uidToNode         map[types.UID]*node
 	types             map[string]*discoveryTypeInfo
+
+	// selectedClusters are the Clusters the graph was restricted to; it is empty if the graph includes all the Clusters.
+	selectedClusters []*node
 }
 
 func newObjectGraph(proxy Proxy, providerInventory InventoryClient) *objectGraph {
@@ -484,6 +497,139 @@ func (o *objectGraph) getMoveNodes() []*node {
 	return nodes
 }
 
+// filterClusters restricts the object graph to the given Clusters and to the objects linked to them.
+// Objects linked also to other Clusters or not linked to any Cluster (e.g. a ClusterResourceSet applied to many Clusters,
+// or an identity) are kept in the graph but marked as shared, so they are copied to the target cluster without being
+// deleted from the source cluster.
+func (o *objectGraph) filterClusters(selectedClusters []*node) {
+	o.selectedClusters = selectedClusters
+
+	selected := map[*node]empty{}
+	for _, cluster := range selectedClusters {
+		selected[cluster] = empty{}
+	}
+
+	moveNodes := map[*node]empty{}
+	for _, n := range o.getMoveNodes() {
+		moveNodes[n] = empty{}
+	}
+
+	// Collects the Clusters linked to tenants which are not Clusters, e.g. the ClusterResourceSet is linked
+	// to the Clusters it is applied to via the ClusterResourceSetBinding, which is a dependant of both.
+	tenantClusters := map[*node]map[*node]empty{}
+	for n := range moveNodes {
+		for tenant := range n.tenant {
+			if isClusterNode(tenant) {
+				continue
+			}
+			if _, ok := tenantClusters[tenant]; !ok {
+				tenantClusters[tenant] = map[*node]empty{}
+			}
+			for other := range n.tenant {
+				if isClusterNode(other) {
+					tenantClusters[tenant][other] = empty{}
+				}
+			}
+		}
+	}
+
+	keep := map[*node]empty{}
+	for n := range moveNodes {
+		// Gets the Clusters the object belongs to; if the object does not belong directly to any Cluster, e.g.
+		// a ClusterResourceSet, gets the Clusters linked to its tenants.
+		clusters := map[*node]empty{}
+		for tenant := range n.tenant {
+			if isClusterNode(tenant) {
+				clusters[tenant] = empty{}
+			}
+		}
+		if len(clusters) == 0 {
+			for tenant := range n.tenant {
+				for cluster := range tenantClusters[tenant] {
+					clusters[cluster] = empty{}
+				}
+			}
+		}
+
+		// Objects not linked to any Cluster are considered shared by all the Clusters.
+		if len(clusters) == 0 {
+			n.isShared = true
+			keep[n] = empty{}
+			continue
+		}
+
+		selectedCount := 0
+		for cluster := range clusters {
+			if _, ok := selected[cluster]; ok {
+				selectedCount++
+			}
+		}
+		if selectedCount == 0 {
+			continue
+		}
+		if selectedCount < len(clusters) {
+			n.isShared = true
+		}
+		keep[n] = empty{}
+	}
+
+	// Ensures the owners of the objects to be moved are moved as well, otherwise owner references cannot be rebuilt
+	// in the target cluster. Clusters not selected are never moved, no matter of the objects they own.
+	queue := []*node{}
+	for n := range keep {
+		queue = append(queue, n)
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		owners := []*node{}
+		for owner := range n.owners {
+			owners = append(owners, owner)
+		}
+		for owner := range n.softOwners {
+			owners = append(owners, owner)
+		}
+		for _, owner := range owners {
+			if _, ok := keep[owner]; ok {
+				continue
+			}
+			if _, ok := moveNodes[owner]; !ok || isClusterNode(owner) {
+				continue
+			}
+			owner.isShared = true
+			keep[owner] = empty{}
+			queue = append(queue, owner)
+		}
+	}
+
+	// Removes all the other nodes from the graph, as well as the references to them from the nodes being moved
+	// (e.g. the owner reference from a shared object to a Cluster not selected); objects linked to removed nodes are
+	// shared, so they are not deleted from the source cluster.
+	for uid, n := range o.uidToNode {
+		if _, ok := keep[n]; !ok {
+			delete(o.uidToNode, uid)
+			continue
+		}
+		for owner := range n.owners {
+			if _, ok := keep[owner]; !ok {
+				delete(n.owners, owner)
+				n.isShared = true
+			}
+		}
+		for owner := range n.softOwners {
+			if _, ok := keep[owner]; !ok {
+				delete(n.softOwners, owner)
+				n.isShared = true
+			}
+		}
+	}
+}
+
+func isClusterNode(n *node) bool {
+	return n.identity.GroupVersionKind().GroupKind() == clusterv1.GroupVersion.WithKind("Cluster").GroupKind()
+}
+
 // getMachines returns the list of Machine existing in the object graph.
 func (o *objectGraph) getMachines() []*node {
 	machines := []*node{}
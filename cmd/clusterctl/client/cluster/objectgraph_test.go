@@ -1979,6 +1979,122 @@ func Test_objectGraph_setCRSTenants(t *testing.T) {
 	}
 }
 
+func Test_objectGraph_filterClusters(t *testing.T) {
+	type fields struct {
+		objs []client.Object
+	}
+	tests := []struct {
+		name             string
+		fields           fields
+		selectedClusters []string
+		wantClusters     []string
+		wantShared       []string
+	}{
+		{
+			name: "Selecting one of two clusters",
+			fields: fields{
+				objs: func() []client.Object {
+					objs := []client.Object{}
+					objs = append(objs, test.NewFakeCluster("ns1", "cluster1").Objs()...)
+					objs = append(objs, test.NewFakeCluster("ns1", "cluster2").Objs()...)
+					return objs
+				}(),
+			},
+			selectedClusters: []string{"cluster1"},
+			wantClusters:     []string{"cluster1"},
+			wantShared:       []string{},
+		},
+		{
+			name: "Selecting one of two clusters with a ClusterResourceSet applied to both",
+			fields: fields{
+				objs: func() []client.Object {
+					objs := []client.Object{}
+					objs = append(objs, test.NewFakeCluster("ns1", "cluster1").Objs()...)
+					objs = append(objs, test.NewFakeCluster("ns1", "cluster2").Objs()...)
+
+					objs = append(objs, test.NewFakeClusterResourceSet("ns1", "crs1").
+						WithSecret("resource-s1").
+						WithConfigMap("resource-c1").
+						ApplyToCluster(test.SelectClusterObj(objs, "ns1", "cluster1")).
+						ApplyToCluster(test.SelectClusterObj(objs, "ns1", "cluster2")).
+						Objs()...)
+
+					return objs
+				}(),
+			},
+			selectedClusters: []string{"cluster1"},
+			wantClusters:     []string{"cluster1"},
+			wantShared: []string{
+				"addons.cluster.x-k8s.io/v1alpha4, Kind=ClusterResourceSet, ns1/crs1", // the ClusterResourceSet is applied also to cluster2
+				"/v1, Kind=Secret, ns1/resource-s1",                                   // resources are owned by the ClusterResourceSet
+				"/v1, Kind=ConfigMap, ns1/resource-c1",                                // resources are owned by the ClusterResourceSet
+			},
+		},
+		{
+			name: "Selecting one of two clusters with a global identity",
+			fields: fields{
+				objs: func() []client.Object {
+					objs := []client.Object{}
+					objs = append(objs, test.NewFakeCluster("ns1", "cluster1").Objs()...)
+					objs = append(objs, test.NewFakeCluster("ns1", "cluster2").Objs()...)
+					objs = append(objs, test.NewFakeClusterInfrastructureIdentity("infra1-identity").
+						WithSecretIn("infra1-system").
+						Objs()...)
+					return objs
+				}(),
+			},
+			selectedClusters: []string{"cluster1"},
+			wantClusters:     []string{"cluster1"},
+			wantShared: []string{
+				"infrastructure.cluster.x-k8s.io/v1alpha4, Kind=GenericClusterInfrastructureIdentity, /infra1-identity", // the identity is not linked to any cluster
+				"/v1, Kind=Secret, infra1-system/infra1-identity-credentials",                                           // the secret is owned by the identity
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gb, err := getDetachedObjectGraphWihObjs(tt.fields.objs)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			gb.setSoftOwnership()
+			gb.setTenants()
+
+			selected := []*node{}
+			for _, cluster := range gb.getClusters() {
+				for _, name := range tt.selectedClusters {
+					if cluster.identity.Name == name {
+						selected = append(selected, cluster)
+					}
+				}
+			}
+
+			gb.filterClusters(selected)
+
+			gotClusters := []string{}
+			for _, cluster := range gb.getClusters() {
+				gotClusters = append(gotClusters, cluster.identity.Name)
+			}
+			g.Expect(gotClusters).To(ConsistOf(tt.wantClusters))
+
+			gotShared := []string{}
+			for _, n := range gb.getMoveNodes() {
+				// All the objects linked only to clusters not selected should be removed from the graph.
+				for tenant := range n.tenant {
+					if tenant.identity.Kind == "Cluster" {
+						g.Expect(tt.selectedClusters).To(ContainElement(tenant.identity.Name))
+					}
+				}
+				if n.isShared {
+					gotShared = append(gotShared, string(n.identity.UID))
+				}
+			}
+			g.Expect(gotShared).To(ConsistOf(tt.wantShared))
+		})
+	}
+}
+
 func Test_objectGraph_setGlobalIdentityTenants(t *testing.T) {
 	type fields struct {
 		objs []client.Object
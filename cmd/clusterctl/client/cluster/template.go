@@ -22,6 +22,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"strings"
 
 	"github.com/google/go-github/v33/github"
@@ -31,6 +32,7 @@ import (
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
 	yaml "sigs.k8s.io/cluster-api/cmd/clusterctl/client/yamlprocessor"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -99,12 +101,22 @@ func (t *templateClient) GetFromConfigMap(configMapNamespace, configMapName, con
 		return nil, errors.Errorf("the ConfigMap %s/%s does not have the %q data key", configMapNamespace, configMapName, configMapDataKey)
 	}
 
+	// The variables schema, if any, is stored in the same ConfigMap.
+	var variablesSchema *repository.VariablesSchema
+	if rawSchema, ok := configMap.Data[repository.VariablesSchemaFile]; ok {
+		variablesSchema, err = repository.ParseVariablesSchema([]byte(rawSchema))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read the %q data key from the ConfigMap %s/%s", repository.VariablesSchemaFile, configMapNamespace, configMapName)
+		}
+	}
+
 	return repository.NewTemplate(repository.TemplateInput{
 		RawArtifact:           []byte(data),
 		ConfigVariablesClient: t.configClient.Variables(),
 		Processor:             t.processor,
 		TargetNamespace:       targetNamespace,
 		SkipTemplateProcess:   skipTemplateProcess,
+		VariablesSchema:       variablesSchema,
 	})
 }
 
@@ -118,15 +130,52 @@ func (t *templateClient) GetFromURL(templateURL, targetNamespace string, skipTem
 		return nil, errors.Wrapf(err, "invalid GetFromURL operation")
 	}
 
+	variablesSchema, err := t.getURLVariablesSchema(templateURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid GetFromURL operation")
+	}
+
 	return repository.NewTemplate(repository.TemplateInput{
 		RawArtifact:           content,
 		ConfigVariablesClient: t.configClient.Variables(),
 		Processor:             t.processor,
 		TargetNamespace:       targetNamespace,
 		SkipTemplateProcess:   skipTemplateProcess,
+		VariablesSchema:       variablesSchema,
 	})
 }
 
+// getURLVariablesSchema returns the variables schema stored next to the template, if any.
+func (t *templateClient) getURLVariablesSchema(templateURL string) (*repository.VariablesSchema, error) {
+	rURL, err := url.Parse(templateURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %q", templateURL)
+	}
+	rURL.Path = path.Join(path.Dir(rURL.Path), repository.VariablesSchemaFile)
+
+	if rURL.Scheme == "file" || rURL.Scheme == "" {
+		if _, err := os.Stat(rURL.Path); os.IsNotExist(err) {
+			return nil, nil
+		}
+	}
+
+	rawSchema, err := t.getURLContent(rURL.String())
+	if err != nil {
+		if rURL.Scheme == "file" || rURL.Scheme == "" {
+			return nil, err
+		}
+		// The variables schema is optional, so templates without a schema are processed as usual.
+		logf.Log.V(5).Info("Variables schema not found", "URL", rURL.String())
+		return nil, nil
+	}
+
+	variablesSchema, err := repository.ParseVariablesSchema(rawSchema)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %q", rURL.String())
+	}
+	return variablesSchema, nil
+}
+
 func (t *templateClient) getURLContent(templateURL string) ([]byte, error) {
 	rURL, err := url.Parse(templateURL)
 	if err != nil {
@@ -17,7 +17,12 @@ limitations under the License.
 package cluster
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/version"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
@@ -35,10 +40,31 @@ type ProviderUpgrader interface {
 	Plan() ([]UpgradePlan, error)
 
 	// ApplyPlan executes an upgrade following an UpgradePlan generated by clusterctl.
-	ApplyPlan(clusterAPIVersion string) error
+	ApplyPlan(opts UpgradeOptions, clusterAPIVersion string) error
 
 	// ApplyCustomPlan plan executes an upgrade using the UpgradeItems provided by the user.
-	ApplyCustomPlan(providersToUpgrade ...UpgradeItem) error
+	ApplyCustomPlan(opts UpgradeOptions, providersToUpgrade ...UpgradeItem) error
+}
+
+// UpgradeOptions defines the options used when applying an upgrade.
+type UpgradeOptions struct {
+	// MigrateStorageVersions enables the migration of the objects stored with versions that are removed from
+	// CustomResourceDefinitions by the upgrade; if not set, the upgrade fails when a migration is required.
+	MigrateStorageVersions bool
+
+	// SkipPreflightChecks disables the checks on the health of the management cluster before the upgrade.
+	SkipPreflightChecks bool
+
+	// SkipVerification disables the checks on the upgraded providers after the upgrade.
+	SkipVerification bool
+
+	// VerificationTimeout is the time to wait for the upgraded providers to become ready; if not set,
+	// DefaultUpgradeVerificationTimeout is used.
+	VerificationTimeout time.Duration
+
+	// RollbackOnFailure reinstalls the previous versions of the upgraded providers if the verification fails;
+	// the rollback is not supported if the upgrade changes the CustomResourceDefinitions of the providers.
+	RollbackOnFailure bool
 }
 
 // UpgradePlan defines a list of possible upgrade targets for a management cluster.
@@ -61,6 +87,10 @@ func (u *UpgradePlan) isPartialUpgrade() bool {
 type UpgradeItem struct {
 	clusterctlv1.Provider
 	NextVersion string
+
+	// Impact describes the changes to CustomResourceDefinitions and webhooks when upgrading to NextVersion;
+	// it is computed only when planning an upgrade, and it is nil if the impact can't be computed.
+	Impact *UpgradeImpact
 }
 
 // UpgradeRef returns a string identifying the upgrade item; this string is derived by the provider.
@@ -69,6 +99,7 @@ func (u *UpgradeItem) UpgradeRef() string {
 }
 
 type providerUpgrader struct {
+	proxy                   Proxy
 	configClient            config.Client
 	repositoryClientFactory RepositoryClientFactory
 	providerInventory       InventoryClient
@@ -133,13 +164,16 @@ func (u *providerUpgrader) Plan() ([]UpgradePlan, error) {
 			continue
 		}
 
+		// Computes the impact of the upgrade on CustomResourceDefinitions and webhooks.
+		u.setUpgradeImpact(upgradePlan)
+
 		ret = append(ret, *upgradePlan)
 	}
 
 	return ret, nil
 }
 
-func (u *providerUpgrader) ApplyPlan(contract string) error {
+func (u *providerUpgrader) ApplyPlan(opts UpgradeOptions, contract string) error {
 	if contract != clusterv1.GroupVersion.Version {
 		return errors.Errorf("current version of clusterctl could only upgrade to %s contract, requested %s", clusterv1.GroupVersion.Version, contract)
 	}
@@ -159,10 +193,10 @@ func (u *providerUpgrader) ApplyPlan(contract string) error {
 	}
 
 	// Do the upgrade
-	return u.doUpgrade(upgradePlan)
+	return u.doUpgrade(upgradePlan, opts)
 }
 
-func (u *providerUpgrader) ApplyCustomPlan(upgradeItems ...UpgradeItem) error {
+func (u *providerUpgrader) ApplyCustomPlan(opts UpgradeOptions, upgradeItems ...UpgradeItem) error {
 	log := logf.Log
 	log.Info("Performing upgrade...")
 
@@ -174,7 +208,7 @@ func (u *providerUpgrader) ApplyCustomPlan(upgradeItems ...UpgradeItem) error {
 	}
 
 	// Do the upgrade
-	return u.doUpgrade(upgradePlan)
+	return u.doUpgrade(upgradePlan, opts)
 }
 
 // getUpgradePlan returns the upgrade plan for a specific set of providers/contract
@@ -336,7 +370,80 @@ func (u *providerUpgrader) getUpgradeComponents(provider UpgradeItem) (repositor
 	return components, nil
 }
 
-func (u *providerUpgrader) doUpgrade(upgradePlan *UpgradePlan) error {
+// setUpgradeImpact sets the impact of the upgrade on CustomResourceDefinitions and webhooks for each upgrade item in the plan.
+// NOTE: the impact is informative, so if it can't be computed the upgrade plan is returned without it.
+func (u *providerUpgrader) setUpgradeImpact(upgradePlan *UpgradePlan) {
+	log := logf.Log
+
+	if u.proxy == nil {
+		return
+	}
+	c, err := u.proxy.NewClient()
+	if err != nil {
+		log.V(1).Info("Unable to compute the upgrade impact", "Cause", err.Error())
+		return
+	}
+
+	for i := range upgradePlan.Providers {
+		upgradeItem := &upgradePlan.Providers[i]
+		if upgradeItem.NextVersion == "" {
+			continue
+		}
+
+		components, err := u.getUpgradeComponents(*upgradeItem)
+		if err != nil {
+			log.V(1).Info("Unable to compute the upgrade impact", "Provider", upgradeItem.InstanceName(), "Cause", err.Error())
+			continue
+		}
+
+		impact, err := getUpgradeImpact(c, upgradeItem.ManifestLabel(), components.Objs())
+		if err != nil {
+			log.V(1).Info("Unable to compute the upgrade impact", "Provider", upgradeItem.InstanceName(), "Cause", err.Error())
+			continue
+		}
+		upgradeItem.Impact = impact
+	}
+}
+
+// getUpgradeImpact returns the changes to CustomResourceDefinitions and webhooks when upgrading a provider to the
+// target components, or nil if the management cluster can't be accessed.
+func (u *providerUpgrader) getUpgradeImpact(upgradeItem UpgradeItem, components repository.Components) (*UpgradeImpact, error) {
+	if u.proxy == nil {
+		return nil, nil
+	}
+	c, err := u.proxy.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	impact, err := getUpgradeImpact(c, upgradeItem.ManifestLabel(), components.Objs())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to compute the upgrade impact for the %s provider", upgradeItem.InstanceName())
+	}
+	return impact, nil
+}
+
+// checkStorageVersions returns an error if objects of the CustomResourceDefinitions in the target components are
+// stored with versions removed by the upgrade.
+func checkStorageVersions(upgradeItem UpgradeItem, impact *UpgradeImpact) error {
+	if impact == nil {
+		return nil
+	}
+
+	var crds []string
+	for _, crd := range impact.CRDs {
+		if crd.RequiresStorageVersionMigration {
+			crds = append(crds, fmt.Sprintf("%s (stored versions: %s)", crd.Name, strings.Join(crd.StoredVersions, ", ")))
+		}
+	}
+	if len(crds) > 0 {
+		return errors.Errorf("unable to upgrade the %s provider: objects are stored with versions removed by the upgrade for the following CustomResourceDefinitions: %s. "+
+			"Please enable the storage version migration to migrate those objects before upgrading", upgradeItem.InstanceName(), strings.Join(crds, "; "))
+	}
+	return nil
+}
+
+func (u *providerUpgrader) doUpgrade(upgradePlan *UpgradePlan, opts UpgradeOptions) error {
 	// Check for multiple instances of the same provider if current contract is v1alpha3.
 	if upgradePlan.Contract == clusterv1.GroupVersion.Version {
 		if err := u.providerInventory.CheckSingleProviderInstance(); err != nil {
@@ -344,17 +451,84 @@ func (u *providerUpgrader) doUpgrade(upgradePlan *UpgradePlan) error {
 		}
 	}
 
+	// Checks the management cluster is healthy before changing any provider.
+	if !opts.SkipPreflightChecks && u.proxy != nil {
+		if err := u.preflightChecks(); err != nil {
+			return err
+		}
+	}
+
+	// Gets the provider components for the target versions, and checks if the upgrade can be completed before
+	// changing any provider.
+	upgradeComponents := map[string]repository.Components{}
+	crdChanges := sets.NewString()
 	for _, upgradeItem := range upgradePlan.Providers {
 		// If there is not a specified next version, skip it (we are already up-to-date).
 		if upgradeItem.NextVersion == "" {
 			continue
 		}
 
-		// Gets the provider components for the target version.
 		components, err := u.getUpgradeComponents(upgradeItem)
 		if err != nil {
 			return err
 		}
+		upgradeComponents[upgradeItem.InstanceName()] = components
+
+		impact, err := u.getUpgradeImpact(upgradeItem, components)
+		if err != nil {
+			return err
+		}
+		if impact != nil && len(impact.CRDs) > 0 {
+			crdChanges.Insert(upgradeItem.InstanceName())
+		}
+
+		if !opts.MigrateStorageVersions {
+			if err := checkStorageVersions(upgradeItem, impact); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Gets the provider components for the current versions, so the providers can be rolled back if the verification fails.
+	// NOTE: Rolling back is supported only if the upgrade does not change any CustomResourceDefinition, because the previous
+	// versions of the providers can't work with CustomResourceDefinitions (and storage versions) changed by the upgrade.
+	previousComponents := map[string]repository.Components{}
+	if opts.RollbackOnFailure && !opts.SkipVerification && u.proxy != nil {
+		if crdChanges.Len() > 0 {
+			logf.Log.Info("Automatic rollback is not supported because the upgrade changes the CustomResourceDefinitions", "Providers", strings.Join(crdChanges.List(), ", "))
+		} else {
+			previousVersions, err := u.currentVersions()
+			if err != nil {
+				return err
+			}
+			for _, upgradeItem := range upgradePlan.Providers {
+				if upgradeItem.NextVersion == "" {
+					continue
+				}
+				previousItem := upgradeItem
+				previousItem.NextVersion = previousVersions[upgradeItem.InstanceName()]
+				previous, err := u.getUpgradeComponents(previousItem)
+				if err != nil {
+					return errors.Wrapf(err, "failed to get the components for rolling back the %s provider to version %s", upgradeItem.InstanceName(), previousItem.NextVersion)
+				}
+				previousComponents[upgradeItem.InstanceName()] = previous
+			}
+		}
+	}
+
+	for _, upgradeItem := range upgradePlan.Providers {
+		// If there is not a specified next version, skip it (we are already up-to-date).
+		if upgradeItem.NextVersion == "" {
+			continue
+		}
+		components := upgradeComponents[upgradeItem.InstanceName()]
+
+		// Migrate the objects stored with versions removed by the upgrade, if any, before applying the new CRDs.
+		if opts.MigrateStorageVersions && u.proxy != nil {
+			if err := newCRDMigrator(u.proxy).Run(components.Objs()); err != nil {
+				return err
+			}
+		}
 
 		// Delete the provider, preserving CRD and namespace.
 		if err := u.providerComponents.Delete(DeleteOptions{
@@ -378,11 +552,42 @@ func (u *providerUpgrader) doUpgrade(upgradePlan *UpgradePlan) error {
 		}
 	}
 
+	// Verifies the upgraded providers, rolling back to the previous versions if required.
+	if !opts.SkipVerification && u.proxy != nil && len(upgradeComponents) > 0 {
+		if err := u.verifyUpgrade(upgradeComponents, opts.VerificationTimeout); err != nil {
+			if !opts.RollbackOnFailure {
+				return errors.Wrap(err, "failed to verify the upgrade")
+			}
+			if crdChanges.Len() > 0 {
+				return errors.Wrapf(err, "failed to verify the upgrade; providers have not been rolled back because the upgrade changed the CustomResourceDefinitions of %s, "+
+					"and the previous versions of the providers can't work with them", strings.Join(crdChanges.List(), ", "))
+			}
+			if rollbackErr := u.rollbackUpgrade(upgradePlan, previousComponents); rollbackErr != nil {
+				return errors.Wrap(kerrors.NewAggregate([]error{err, rollbackErr}), "failed to verify the upgrade, and failed to roll back")
+			}
+			return errors.Wrap(err, "failed to verify the upgrade; providers have been rolled back to the previous versions")
+		}
+	}
+
 	return nil
 }
 
-func newProviderUpgrader(configClient config.Client, repositoryClientFactory RepositoryClientFactory, providerInventory InventoryClient, providerComponents ComponentsClient) *providerUpgrader {
+// currentVersions returns the current version of the providers in the management cluster, by instance name.
+func (u *providerUpgrader) currentVersions() (map[string]string, error) {
+	providerList, err := u.providerInventory.List()
+	if err != nil {
+		return nil, err
+	}
+	versions := map[string]string{}
+	for _, provider := range providerList.Items {
+		versions[provider.InstanceName()] = provider.Version
+	}
+	return versions, nil
+}
+
+func newProviderUpgrader(proxy Proxy, configClient config.Client, repositoryClientFactory RepositoryClientFactory, providerInventory InventoryClient, providerComponents ComponentsClient) *providerUpgrader {
 	return &providerUpgrader{
+		proxy:                   proxy,
 		configClient:            configClient,
 		repositoryClientFactory: repositoryClientFactory,
 		providerInventory:       providerInventory,
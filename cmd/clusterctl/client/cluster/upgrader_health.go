@@ -0,0 +1,353 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/scheme"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/util"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultUpgradeVerificationTimeout is the default time to wait for the new provider controllers to become ready
+	// after an upgrade.
+	DefaultUpgradeVerificationTimeout = 5 * time.Minute
+)
+
+// upgradeVerificationInterval is the interval between checks when waiting for the new provider controllers to become ready.
+var upgradeVerificationInterval = 2 * time.Second
+
+// preflightChecks checks that the management cluster is healthy before upgrading providers, and more specifically
+// that all the provider Deployments are available, that no MachineDeployments or control planes are rolling out,
+// and that webhooks and conversion webhooks are reachable.
+func (u *providerUpgrader) preflightChecks() error {
+	log := logf.Log
+	log.Info("Running preflight checks")
+
+	c, err := u.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	providerList, err := u.providerInventory.List()
+	if err != nil {
+		return err
+	}
+
+	errList := []error{}
+	errList = append(errList, checkProviderDeploymentsAvailable(c, providerList.Items)...)
+	errList = append(errList, checkNoRollouts(c)...)
+	errList = append(errList, checkWebhooksReachable(c)...)
+	if len(errList) > 0 {
+		return errors.Wrap(kerrors.NewAggregate(errList), "preflight checks failed: the management cluster is not healthy, fix the issues before upgrading or skip the preflight checks")
+	}
+	return nil
+}
+
+// checkProviderDeploymentsAvailable checks that all the Deployments of the given providers are available.
+func checkProviderDeploymentsAvailable(c client.Client, providers []clusterctlv1.Provider) []error {
+	errList := []error{}
+	for _, provider := range providers {
+		deploymentList := &appsv1.DeploymentList{}
+		if err := c.List(ctx, deploymentList, client.InNamespace(provider.Namespace), client.MatchingLabels{clusterv1.ProviderLabelName: provider.ManifestLabel()}); err != nil {
+			errList = append(errList, errors.Wrapf(err, "failed to list Deployments for provider %s", provider.InstanceName()))
+			continue
+		}
+		for i := range deploymentList.Items {
+			if !isDeploymentAvailable(&deploymentList.Items[i]) {
+				errList = append(errList, errors.Errorf("Deployment %s/%s of provider %s is not available", deploymentList.Items[i].Namespace, deploymentList.Items[i].Name, provider.InstanceName()))
+			}
+		}
+	}
+	return errList
+}
+
+// checkNoRollouts checks that there are no MachineDeployments or KubeadmControlPlanes rolling out.
+func checkNoRollouts(c client.Client) []error {
+	errList := []error{}
+
+	mdList := &clusterv1.MachineDeploymentList{}
+	if err := c.List(ctx, mdList); err != nil && !meta.IsNoMatchError(err) && !apierrors.IsNotFound(err) {
+		errList = append(errList, errors.Wrap(err, "failed to list MachineDeployments"))
+	}
+	for _, md := range mdList.Items {
+		if md.Status.ObservedGeneration < md.Generation ||
+			(md.Spec.Replicas != nil && (md.Status.UpdatedReplicas != *md.Spec.Replicas || md.Status.Replicas != *md.Spec.Replicas)) {
+			errList = append(errList, errors.Errorf("MachineDeployment %s/%s of Cluster %s is rolling out", md.Namespace, md.Name, md.Spec.ClusterName))
+		}
+	}
+
+	// NOTE: KubeadmControlPlane is checked only if the KubeadmControlPlane provider is installed.
+	kcpList := &controlplanev1.KubeadmControlPlaneList{}
+	if err := c.List(ctx, kcpList); err != nil && !meta.IsNoMatchError(err) && !apierrors.IsNotFound(err) {
+		errList = append(errList, errors.Wrap(err, "failed to list KubeadmControlPlanes"))
+	}
+	for _, kcp := range kcpList.Items {
+		if kcp.Status.ObservedGeneration < kcp.Generation ||
+			(kcp.Spec.Replicas != nil && (kcp.Status.UpdatedReplicas != *kcp.Spec.Replicas || kcp.Status.Replicas != *kcp.Spec.Replicas)) {
+			errList = append(errList, errors.Errorf("KubeadmControlPlane %s/%s of Cluster %s is rolling out", kcp.Namespace, kcp.Name, kcp.Labels[clusterv1.ClusterLabelName]))
+		}
+	}
+	return errList
+}
+
+// checkWebhooksReachable checks that the services backing the webhooks and the conversion webhooks installed by
+// clusterctl have ready endpoints.
+func checkWebhooksReachable(c client.Client) []error {
+	// Collects the services backing webhooks, with the name of the objects using them.
+	services := map[client.ObjectKey][]string{}
+	addService := func(namespace, name, user string) {
+		key := client.ObjectKey{Namespace: namespace, Name: name}
+		services[key] = append(services[key], user)
+	}
+
+	errList := []error{}
+	validatingWebhookList := &admissionregistrationv1.ValidatingWebhookConfigurationList{}
+	if err := c.List(ctx, validatingWebhookList, client.HasLabels{clusterctlv1.ClusterctlLabelName}); err != nil {
+		errList = append(errList, errors.Wrap(err, "failed to list ValidatingWebhookConfigurations"))
+	}
+	for _, webhookConfiguration := range validatingWebhookList.Items {
+		for _, webhook := range webhookConfiguration.Webhooks {
+			if webhook.ClientConfig.Service != nil {
+				addService(webhook.ClientConfig.Service.Namespace, webhook.ClientConfig.Service.Name, "ValidatingWebhookConfiguration "+webhookConfiguration.Name)
+			}
+		}
+	}
+
+	mutatingWebhookList := &admissionregistrationv1.MutatingWebhookConfigurationList{}
+	if err := c.List(ctx, mutatingWebhookList, client.HasLabels{clusterctlv1.ClusterctlLabelName}); err != nil {
+		errList = append(errList, errors.Wrap(err, "failed to list MutatingWebhookConfigurations"))
+	}
+	for _, webhookConfiguration := range mutatingWebhookList.Items {
+		for _, webhook := range webhookConfiguration.Webhooks {
+			if webhook.ClientConfig.Service != nil {
+				addService(webhook.ClientConfig.Service.Namespace, webhook.ClientConfig.Service.Name, "MutatingWebhookConfiguration "+webhookConfiguration.Name)
+			}
+		}
+	}
+
+	crdList := &apiextensionsv1.CustomResourceDefinitionList{}
+	if err := c.List(ctx, crdList, client.HasLabels{clusterctlv1.ClusterctlLabelName}); err != nil {
+		errList = append(errList, errors.Wrap(err, "failed to list CustomResourceDefinitions"))
+	}
+	for _, crd := range crdList.Items {
+		conversion := crd.Spec.Conversion
+		if conversion == nil || conversion.Strategy != apiextensionsv1.WebhookConverter || conversion.Webhook == nil ||
+			conversion.Webhook.ClientConfig == nil || conversion.Webhook.ClientConfig.Service == nil {
+			continue
+		}
+		addService(conversion.Webhook.ClientConfig.Service.Namespace, conversion.Webhook.ClientConfig.Service.Name, "CustomResourceDefinition "+crd.Name)
+	}
+
+	for key, users := range services {
+		endpoints := &corev1.Endpoints{}
+		if err := c.Get(ctx, key, endpoints); err != nil {
+			errList = append(errList, errors.Wrapf(err, "failed to get the endpoints for the webhook service %s used by %s", key, strings.Join(sets.NewString(users...).List(), ", ")))
+			continue
+		}
+		if !hasReadyAddresses(endpoints) {
+			errList = append(errList, errors.Errorf("the webhook service %s used by %s has no ready endpoints", key, strings.Join(sets.NewString(users...).List(), ", ")))
+		}
+	}
+	return errList
+}
+
+func hasReadyAddresses(endpoints *corev1.Endpoints) bool {
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Addresses) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func isDeploymentAvailable(deployment *appsv1.Deployment) bool {
+	for _, c := range deployment.Status.Conditions {
+		if c.Type == appsv1.DeploymentAvailable && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// isDeploymentRolledOut returns true if the latest version of the Deployment is rolled out and available.
+func isDeploymentRolledOut(deployment *appsv1.Deployment) bool {
+	if deployment.Status.ObservedGeneration < deployment.Generation {
+		return false
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	return deployment.Status.UpdatedReplicas == replicas &&
+		deployment.Status.Replicas == replicas &&
+		isDeploymentAvailable(deployment)
+}
+
+// verifyUpgrade checks that the new provider controllers become ready, and that objects of the provider's
+// CustomResourceDefinitions can round-trip through conversion.
+func (u *providerUpgrader) verifyUpgrade(upgradeComponents map[string]repository.Components, timeout time.Duration) error {
+	log := logf.Log
+	log.Info("Verifying the upgrade")
+
+	if timeout == 0 {
+		timeout = DefaultUpgradeVerificationTimeout
+	}
+
+	c, err := u.proxy.NewClient()
+	if err != nil {
+		return err
+	}
+
+	errList := []error{}
+	for _, components := range upgradeComponents {
+		for _, obj := range components.Objs() {
+			if !util.IsDeploymentWithManager(obj) {
+				continue
+			}
+			if err := waitDeploymentRolledOut(c, client.ObjectKey{Namespace: obj.GetNamespace(), Name: obj.GetName()}, timeout); err != nil {
+				errList = append(errList, errors.Wrapf(err, "the controller of provider %s is not ready", components.ManifestLabel()))
+			}
+		}
+	}
+	// NOTE: conversion can't work if the controllers hosting the conversion webhooks are not ready.
+	if len(errList) > 0 {
+		return kerrors.NewAggregate(errList)
+	}
+
+	for _, components := range upgradeComponents {
+		for i := range components.Objs() {
+			obj := components.Objs()[i]
+			if obj.GetKind() != customResourceDefinitionKind {
+				continue
+			}
+			crd := &apiextensionsv1.CustomResourceDefinition{}
+			if err := scheme.Scheme.Convert(&obj, crd, nil); err != nil {
+				errList = append(errList, errors.Wrapf(err, "failed to convert CustomResourceDefinition %s", obj.GetName()))
+				continue
+			}
+			if err := verifyConversion(c, crd); err != nil {
+				errList = append(errList, err)
+			}
+		}
+	}
+	return kerrors.NewAggregate(errList)
+}
+
+// waitDeploymentRolledOut waits for the latest version of a Deployment to be rolled out and available.
+func waitDeploymentRolledOut(c client.Client, key client.ObjectKey, timeout time.Duration) error {
+	var lastErr error
+	if err := wait.PollImmediate(upgradeVerificationInterval, timeout, func() (bool, error) {
+		deployment := &appsv1.Deployment{}
+		if err := c.Get(ctx, key, deployment); err != nil {
+			lastErr = err
+			return false, nil
+		}
+		lastErr = nil
+		return isDeploymentRolledOut(deployment), nil
+	}); err != nil {
+		if lastErr != nil {
+			return errors.Wrapf(lastErr, "failed to get Deployment %s", key)
+		}
+		return errors.Errorf("timed out waiting for Deployment %s to be rolled out", key)
+	}
+	return nil
+}
+
+// verifyConversion reads a sample object of a CustomResourceDefinition with all the served versions, and writes it back
+// in dry run mode, so it round-trips through conversion from and to the storage version.
+func verifyConversion(c client.Client, crd *apiextensionsv1.CustomResourceDefinition) error {
+	storageVersion := storageVersion(crd)
+	servedVersions := []string{}
+	for _, v := range crd.Spec.Versions {
+		if v.Served && v.Name != storageVersion {
+			servedVersions = append(servedVersions, v.Name)
+		}
+	}
+	if storageVersion == "" || len(servedVersions) == 0 {
+		return nil
+	}
+
+	// Gets a sample object, if any.
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(schema.GroupVersionKind{Group: crd.Spec.Group, Version: storageVersion, Kind: crd.Spec.Names.ListKind})
+	if err := c.List(ctx, list, client.Limit(1)); err != nil {
+		return errors.Wrapf(err, "failed to list %s", crd.Spec.Names.Plural)
+	}
+	if len(list.Items) == 0 {
+		return nil
+	}
+	sample := list.Items[0]
+
+	for _, version := range servedVersions {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(schema.GroupVersionKind{Group: crd.Spec.Group, Version: version, Kind: crd.Spec.Names.Kind})
+		if err := c.Get(ctx, client.ObjectKeyFromObject(&sample), obj); err != nil {
+			return errors.Wrapf(err, "failed to convert %s %s/%s to version %s", crd.Spec.Names.Kind, sample.GetNamespace(), sample.GetName(), version)
+		}
+		if err := c.Update(ctx, obj, client.DryRunAll); err != nil && !apierrors.IsConflict(err) {
+			return errors.Wrapf(err, "failed to convert %s %s/%s from version %s", crd.Spec.Names.Kind, sample.GetNamespace(), sample.GetName(), version)
+		}
+	}
+	return nil
+}
+
+// rollbackUpgrade reapplies the previous components of the upgraded providers.
+// NOTE: This is supported only if the upgrade did not change the CustomResourceDefinitions of the providers.
+func (u *providerUpgrader) rollbackUpgrade(upgradePlan *UpgradePlan, previousComponents map[string]repository.Components) error {
+	log := logf.Log
+
+	for _, upgradeItem := range upgradePlan.Providers {
+		components, ok := previousComponents[upgradeItem.InstanceName()]
+		if !ok {
+			continue
+		}
+		log.Info("Rolling back", "Provider", upgradeItem.InstanceName(), "Version", components.Version())
+
+		if err := u.providerComponents.Delete(DeleteOptions{
+			Provider:         upgradeItem.Provider,
+			IncludeNamespace: false,
+			IncludeCRDs:      false,
+		}); err != nil {
+			return err
+		}
+		if err := installComponentsAndUpdateInventory(components, u.providerComponents, u.providerInventory); err != nil {
+			return err
+		}
+	}
+	return nil
+}
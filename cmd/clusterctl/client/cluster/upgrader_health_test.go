@@ -0,0 +1,234 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func fakeProviderDeployment(namespace, name, providerLabel string, available bool) *appsv1.Deployment {
+	status := corev1.ConditionFalse
+	if available {
+		status = corev1.ConditionTrue
+	}
+	return &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Labels:    map[string]string{clusterv1.ProviderLabelName: providerLabel},
+		},
+		Status: appsv1.DeploymentStatus{
+			Conditions: []appsv1.DeploymentCondition{
+				{Type: appsv1.DeploymentAvailable, Status: status},
+			},
+		},
+	}
+}
+
+func fakeWebhookService(namespace, name string, ready bool) []client.Object {
+	webhook := &admissionregistrationv1.ValidatingWebhookConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: admissionregistrationv1.SchemeGroupVersion.String(),
+			Kind:       "ValidatingWebhookConfiguration",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{clusterctlv1.ClusterctlLabelName: ""},
+		},
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{
+			{
+				Name: "validation.cluster.x-k8s.io",
+				ClientConfig: admissionregistrationv1.WebhookClientConfig{
+					Service: &admissionregistrationv1.ServiceReference{Namespace: namespace, Name: name},
+				},
+			},
+		},
+	}
+	endpoints := &corev1.Endpoints{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: corev1.SchemeGroupVersion.String(),
+			Kind:       "Endpoints",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+	}
+	if ready {
+		endpoints.Subsets = []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}}}
+	}
+	return []client.Object{webhook, endpoints}
+}
+
+func Test_providerUpgrader_preflightChecks(t *testing.T) {
+	rollingOutMD := &clusterv1.MachineDeployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "MachineDeployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:  "ns1",
+			Name:       "md1",
+			Generation: 2,
+		},
+		Spec: clusterv1.MachineDeploymentSpec{
+			ClusterName: "cluster1",
+			Replicas:    pointer.Int32Ptr(3),
+		},
+		Status: clusterv1.MachineDeploymentStatus{
+			ObservedGeneration: 2,
+			Replicas:           4,
+			UpdatedReplicas:    1,
+		},
+	}
+
+	tests := []struct {
+		name     string
+		objs     []client.Object
+		wantErrs []string
+	}{
+		{
+			name: "pass if the management cluster is healthy",
+			objs: append([]client.Object{
+				fakeProviderDeployment("infra-system", "infra-controller-manager", "infrastructure-infra", true),
+			}, fakeWebhookService("infra-system", "infra-webhook-service", true)...),
+		},
+		{
+			name: "fail if provider deployments are not available",
+			objs: []client.Object{
+				fakeProviderDeployment("infra-system", "infra-controller-manager", "infrastructure-infra", false),
+			},
+			wantErrs: []string{"Deployment infra-system/infra-controller-manager of provider infra-system/infrastructure-infra is not available"},
+		},
+		{
+			name:     "fail if MachineDeployments are rolling out",
+			objs:     []client.Object{rollingOutMD},
+			wantErrs: []string{"MachineDeployment ns1/md1 of Cluster cluster1 is rolling out"},
+		},
+		{
+			name:     "fail if webhooks are not reachable",
+			objs:     fakeWebhookService("infra-system", "infra-webhook-service", false),
+			wantErrs: []string{"the webhook service infra-system/infra-webhook-service used by ValidatingWebhookConfiguration infra-webhook-service has no ready endpoints"},
+		},
+		{
+			name: "report all the issues at once",
+			objs: append([]client.Object{
+				fakeProviderDeployment("infra-system", "infra-controller-manager", "infrastructure-infra", false),
+				rollingOutMD,
+			}, fakeWebhookService("infra-system", "infra-webhook-service", false)...),
+			wantErrs: []string{
+				"Deployment infra-system/infra-controller-manager of provider infra-system/infrastructure-infra is not available",
+				"MachineDeployment ns1/md1 of Cluster cluster1 is rolling out",
+				"the webhook service infra-system/infra-webhook-service used by ValidatingWebhookConfiguration infra-webhook-service has no ready endpoints",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			proxy := test.NewFakeProxy().
+				WithProviderInventory("cluster-api", clusterctlv1.CoreProviderType, "v1.0.0", "cluster-api-system").
+				WithProviderInventory("infra", clusterctlv1.InfrastructureProviderType, "v2.0.0", "infra-system").
+				WithObjs(tt.objs...)
+
+			u := &providerUpgrader{
+				proxy:             proxy,
+				providerInventory: newInventoryClient(proxy, nil),
+			}
+
+			err := u.preflightChecks()
+			if len(tt.wantErrs) == 0 {
+				g.Expect(err).NotTo(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(HaveOccurred())
+			for _, e := range tt.wantErrs {
+				g.Expect(err.Error()).To(ContainSubstring(e))
+			}
+		})
+	}
+}
+
+func Test_isDeploymentRolledOut(t *testing.T) {
+	available := []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue}}
+
+	tests := []struct {
+		name       string
+		deployment *appsv1.Deployment
+		want       bool
+	}{
+		{
+			name: "rolled out",
+			deployment: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Spec:       appsv1.DeploymentSpec{Replicas: pointer.Int32Ptr(1)},
+				Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 1, UpdatedReplicas: 1, Conditions: available},
+			},
+			want: true,
+		},
+		{
+			name: "the latest generation is not observed yet",
+			deployment: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Spec:       appsv1.DeploymentSpec{Replicas: pointer.Int32Ptr(1)},
+				Status:     appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 1, UpdatedReplicas: 1, Conditions: available},
+			},
+			want: false,
+		},
+		{
+			name: "old replicas still exist",
+			deployment: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Spec:       appsv1.DeploymentSpec{Replicas: pointer.Int32Ptr(1)},
+				Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 1, Conditions: available},
+			},
+			want: false,
+		},
+		{
+			name: "not available",
+			deployment: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Spec:       appsv1.DeploymentSpec{Replicas: pointer.Int32Ptr(1)},
+				Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 1, UpdatedReplicas: 1},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(isDeploymentRolledOut(tt.deployment)).To(Equal(tt.want))
+		})
+	}
+}
@@ -0,0 +1,431 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// UpgradeImpact describes the changes to the CustomResourceDefinitions and to the webhooks of a provider
+// when upgrading to the target version.
+type UpgradeImpact struct {
+	// CRDs lists the CustomResourceDefinitions added, removed or changed by the upgrade.
+	CRDs []CRDUpgradeImpact `json:"crds,omitempty"`
+
+	// Webhooks lists the webhook configurations added, removed or changed by the upgrade.
+	Webhooks []WebhookUpgradeImpact `json:"webhooks,omitempty"`
+}
+
+// RequiresStorageVersionMigration returns true if at least one CustomResourceDefinition has objects stored with
+// a version that is removed by the upgrade.
+func (i *UpgradeImpact) RequiresStorageVersionMigration() bool {
+	if i == nil {
+		return false
+	}
+	for _, crd := range i.CRDs {
+		if crd.RequiresStorageVersionMigration {
+			return true
+		}
+	}
+	return false
+}
+
+// CRDUpgradeImpact describes the changes to a CustomResourceDefinition when upgrading a provider.
+type CRDUpgradeImpact struct {
+	// Name of the CustomResourceDefinition.
+	Name string `json:"name"`
+
+	// Added is true if the CustomResourceDefinition does not exist in the current version.
+	Added bool `json:"added,omitempty"`
+
+	// Removed is true if the CustomResourceDefinition does not exist in the target version.
+	// NOTE: CustomResourceDefinitions are preserved during upgrades.
+	Removed bool `json:"removed,omitempty"`
+
+	// AddedVersions lists the served versions added by the upgrade.
+	AddedVersions []string `json:"addedVersions,omitempty"`
+
+	// RemovedVersions lists the served versions removed by the upgrade.
+	RemovedVersions []string `json:"removedVersions,omitempty"`
+
+	// CurrentStorageVersion is the storage version in the current version.
+	CurrentStorageVersion string `json:"currentStorageVersion,omitempty"`
+
+	// TargetStorageVersion is the storage version in the target version.
+	TargetStorageVersion string `json:"targetStorageVersion,omitempty"`
+
+	// StoredVersions lists the versions objects are currently stored with, as reported by the CustomResourceDefinition status.
+	StoredVersions []string `json:"storedVersions,omitempty"`
+
+	// DroppedFields lists the fields removed from the schema of versions existing both in the current and in the
+	// target version, in the form version:.path.to.field.
+	DroppedFields []string `json:"droppedFields,omitempty"`
+
+	// ConversionWebhookChanged is true if the conversion strategy or the conversion webhook are changed by the upgrade.
+	ConversionWebhookChanged bool `json:"conversionWebhookChanged,omitempty"`
+
+	// RequiresStorageVersionMigration is true if there are objects stored with a version that is removed by the upgrade;
+	// those objects must be migrated to the current storage version before upgrading.
+	RequiresStorageVersionMigration bool `json:"requiresStorageVersionMigration,omitempty"`
+}
+
+// WebhookUpgradeImpact describes the changes to a webhook configuration when upgrading a provider.
+type WebhookUpgradeImpact struct {
+	// Kind of the webhook configuration, ValidatingWebhookConfiguration or MutatingWebhookConfiguration.
+	Kind string `json:"kind"`
+
+	// Name of the webhook configuration.
+	Name string `json:"name"`
+
+	// Added is true if the webhook configuration does not exist in the current version.
+	Added bool `json:"added,omitempty"`
+
+	// Removed is true if the webhook configuration does not exist in the target version.
+	Removed bool `json:"removed,omitempty"`
+
+	// ChangedWebhooks lists the webhooks added, removed or changed in the webhook configuration.
+	ChangedWebhooks []string `json:"changedWebhooks,omitempty"`
+}
+
+// getUpgradeImpact returns the changes to CustomResourceDefinitions and webhooks when upgrading the provider
+// from the objects existing in the management cluster to the objects in the target components.
+func getUpgradeImpact(c client.Client, providerLabel string, targetObjs []unstructured.Unstructured) (*UpgradeImpact, error) {
+	impact := &UpgradeImpact{}
+
+	// Compare the CustomResourceDefinitions.
+	targetCRDs := map[string]bool{}
+	for _, o := range targetObjs {
+		if o.GetKind() != customResourceDefinitionKind {
+			continue
+		}
+		targetCRD := &apiextensionsv1.CustomResourceDefinition{}
+		if err := scheme.Scheme.Convert(&o, targetCRD, nil); err != nil {
+			return nil, errors.Wrapf(err, "failed to convert CustomResourceDefinition %s", o.GetName())
+		}
+		targetCRDs[targetCRD.Name] = true
+
+		currentCRD := &apiextensionsv1.CustomResourceDefinition{}
+		if err := c.Get(ctx, client.ObjectKey{Name: targetCRD.Name}, currentCRD); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, errors.Wrapf(err, "failed to get CustomResourceDefinition %s", targetCRD.Name)
+			}
+			currentCRD = nil
+		}
+
+		if crdImpact := crdUpgradeImpact(currentCRD, targetCRD); crdImpact != nil {
+			impact.CRDs = append(impact.CRDs, *crdImpact)
+		}
+	}
+
+	currentCRDs := &apiextensionsv1.CustomResourceDefinitionList{}
+	if err := c.List(ctx, currentCRDs, client.MatchingLabels{clusterv1.ProviderLabelName: providerLabel}); err != nil {
+		return nil, errors.Wrap(err, "failed to list CustomResourceDefinitions")
+	}
+	for i := range currentCRDs.Items {
+		if !targetCRDs[currentCRDs.Items[i].Name] {
+			impact.CRDs = append(impact.CRDs, *crdUpgradeImpact(&currentCRDs.Items[i], nil))
+		}
+	}
+	sort.Slice(impact.CRDs, func(i, j int) bool { return impact.CRDs[i].Name < impact.CRDs[j].Name })
+
+	// Compare the webhook configurations.
+	currentWebhooks := map[string]map[string]webhookSettings{}
+	validatingWebhooks := &admissionregistrationv1.ValidatingWebhookConfigurationList{}
+	if err := c.List(ctx, validatingWebhooks, client.MatchingLabels{clusterv1.ProviderLabelName: providerLabel}); err != nil {
+		return nil, errors.Wrap(err, "failed to list ValidatingWebhookConfigurations")
+	}
+	for _, w := range validatingWebhooks.Items {
+		currentWebhooks[validatingWebhookConfigurationKind+"/"+w.Name] = validatingWebhookSettings(w.Webhooks)
+	}
+	mutatingWebhooks := &admissionregistrationv1.MutatingWebhookConfigurationList{}
+	if err := c.List(ctx, mutatingWebhooks, client.MatchingLabels{clusterv1.ProviderLabelName: providerLabel}); err != nil {
+		return nil, errors.Wrap(err, "failed to list MutatingWebhookConfigurations")
+	}
+	for _, w := range mutatingWebhooks.Items {
+		currentWebhooks[mutatingWebhookConfigurationKind+"/"+w.Name] = mutatingWebhookSettings(w.Webhooks)
+	}
+
+	targetWebhooks := map[string]map[string]webhookSettings{}
+	for _, o := range targetObjs {
+		switch o.GetKind() {
+		case validatingWebhookConfigurationKind:
+			w := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+			if err := scheme.Scheme.Convert(&o, w, nil); err != nil {
+				return nil, errors.Wrapf(err, "failed to convert ValidatingWebhookConfiguration %s", o.GetName())
+			}
+			targetWebhooks[validatingWebhookConfigurationKind+"/"+w.Name] = validatingWebhookSettings(w.Webhooks)
+		case mutatingWebhookConfigurationKind:
+			w := &admissionregistrationv1.MutatingWebhookConfiguration{}
+			if err := scheme.Scheme.Convert(&o, w, nil); err != nil {
+				return nil, errors.Wrapf(err, "failed to convert MutatingWebhookConfiguration %s", o.GetName())
+			}
+			targetWebhooks[mutatingWebhookConfigurationKind+"/"+w.Name] = mutatingWebhookSettings(w.Webhooks)
+		}
+	}
+	impact.Webhooks = webhookUpgradeImpacts(currentWebhooks, targetWebhooks)
+
+	return impact, nil
+}
+
+// crdUpgradeImpact returns the changes between the current and the target CustomResourceDefinition, if any;
+// current is nil for CustomResourceDefinitions added by the upgrade, target is nil for CustomResourceDefinitions
+// removed by the upgrade.
+func crdUpgradeImpact(current, target *apiextensionsv1.CustomResourceDefinition) *CRDUpgradeImpact {
+	if current == nil {
+		return &CRDUpgradeImpact{
+			Name:                 target.Name,
+			Added:                true,
+			AddedVersions:        servedVersions(target).List(),
+			TargetStorageVersion: storageVersion(target),
+		}
+	}
+	if target == nil {
+		return &CRDUpgradeImpact{
+			Name:                  current.Name,
+			Removed:               true,
+			CurrentStorageVersion: storageVersion(current),
+			StoredVersions:        current.Status.StoredVersions,
+		}
+	}
+
+	currentServed := servedVersions(current)
+	targetServed := servedVersions(target)
+	impact := &CRDUpgradeImpact{
+		Name:                  target.Name,
+		AddedVersions:         targetServed.Difference(currentServed).List(),
+		RemovedVersions:       currentServed.Difference(targetServed).List(),
+		CurrentStorageVersion: storageVersion(current),
+		TargetStorageVersion:  storageVersion(target),
+		StoredVersions:        current.Status.StoredVersions,
+	}
+
+	// Objects stored with a version not existing anymore in the target CustomResourceDefinition must be migrated,
+	// otherwise the API server rejects the update of the CustomResourceDefinition.
+	targetVersions := sets.NewString()
+	for _, v := range target.Spec.Versions {
+		targetVersions.Insert(v.Name)
+	}
+	for _, v := range current.Status.StoredVersions {
+		if !targetVersions.Has(v) {
+			impact.RequiresStorageVersionMigration = true
+		}
+	}
+
+	// Detect fields dropped from versions existing in both the current and the target CustomResourceDefinition.
+	for _, cv := range current.Spec.Versions {
+		for _, tv := range target.Spec.Versions {
+			if cv.Name != tv.Name || cv.Schema == nil || tv.Schema == nil {
+				continue
+			}
+			targetFields := schemaFields(tv.Schema.OpenAPIV3Schema, "", sets.NewString())
+			for _, f := range schemaFields(cv.Schema.OpenAPIV3Schema, "", sets.NewString()).List() {
+				if !targetFields.Has(f) {
+					impact.DroppedFields = append(impact.DroppedFields, cv.Name+":"+f)
+				}
+			}
+		}
+	}
+
+	impact.ConversionWebhookChanged = !reflect.DeepEqual(conversionWebhook(current), conversionWebhook(target))
+
+	if len(impact.AddedVersions) == 0 && len(impact.RemovedVersions) == 0 && impact.CurrentStorageVersion == impact.TargetStorageVersion &&
+		len(impact.DroppedFields) == 0 && !impact.ConversionWebhookChanged && !impact.RequiresStorageVersionMigration {
+		return nil
+	}
+	return impact
+}
+
+func servedVersions(crd *apiextensionsv1.CustomResourceDefinition) sets.String {
+	versions := sets.NewString()
+	for _, v := range crd.Spec.Versions {
+		if v.Served {
+			versions.Insert(v.Name)
+		}
+	}
+	return versions
+}
+
+func storageVersion(crd *apiextensionsv1.CustomResourceDefinition) string {
+	for _, v := range crd.Spec.Versions {
+		if v.Storage {
+			return v.Name
+		}
+	}
+	return ""
+}
+
+// schemaFields returns the paths of all the fields defined in a schema.
+func schemaFields(schema *apiextensionsv1.JSONSchemaProps, prefix string, fields sets.String) sets.String {
+	if schema == nil {
+		return fields
+	}
+	for name := range schema.Properties {
+		p := schema.Properties[name]
+		path := prefix + "." + name
+		fields.Insert(path)
+		schemaFields(&p, path, fields)
+	}
+	if schema.Items != nil && schema.Items.Schema != nil {
+		schemaFields(schema.Items.Schema, prefix+"[]", fields)
+	}
+	if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+		schemaFields(schema.AdditionalProperties.Schema, prefix+"{}", fields)
+	}
+	return fields
+}
+
+// conversionWebhook returns the conversion settings of a CustomResourceDefinition, ignoring fields set
+// at runtime, e.g. the CA bundle injected by cert-manager.
+func conversionWebhook(crd *apiextensionsv1.CustomResourceDefinition) *apiextensionsv1.CustomResourceConversion {
+	if crd.Spec.Conversion == nil {
+		return nil
+	}
+	conversion := crd.Spec.Conversion.DeepCopy()
+	if conversion.Webhook != nil {
+		if conversion.Webhook.ClientConfig != nil {
+			conversion.Webhook.ClientConfig.CABundle = nil
+			if conversion.Webhook.ClientConfig.Service != nil {
+				// The API server defaults the port.
+				conversion.Webhook.ClientConfig.Service.Port = nil
+			}
+		}
+		sort.Strings(conversion.Webhook.ConversionReviewVersions)
+	}
+	return conversion
+}
+
+// webhookSettings are the settings of a webhook compared when computing the upgrade impact.
+type webhookSettings struct {
+	Rules        admissionregistrationv1.RuleWithOperations
+	ClientConfig admissionregistrationv1.WebhookClientConfig
+}
+
+// validatingWebhookSettings returns the settings of each webhook, ignoring fields set at runtime or defaulted by the API server.
+func validatingWebhookSettings(webhooks []admissionregistrationv1.ValidatingWebhook) map[string]webhookSettings {
+	settings := map[string]webhookSettings{}
+	for _, w := range webhooks {
+		settings[w.Name] = webhookSettings{
+			Rules:        mergeWebhookRules(w.Rules),
+			ClientConfig: webhookClientConfig(w.ClientConfig),
+		}
+	}
+	return settings
+}
+
+// mutatingWebhookSettings returns the settings of each webhook, ignoring fields set at runtime or defaulted by the API server.
+func mutatingWebhookSettings(webhooks []admissionregistrationv1.MutatingWebhook) map[string]webhookSettings {
+	settings := map[string]webhookSettings{}
+	for _, w := range webhooks {
+		settings[w.Name] = webhookSettings{
+			Rules:        mergeWebhookRules(w.Rules),
+			ClientConfig: webhookClientConfig(w.ClientConfig),
+		}
+	}
+	return settings
+}
+
+// webhookClientConfig returns the client config of a webhook, ignoring the CA bundle injected by cert-manager
+// and the service port when set to the value defaulted by the API server.
+func webhookClientConfig(clientConfig admissionregistrationv1.WebhookClientConfig) admissionregistrationv1.WebhookClientConfig {
+	config := *clientConfig.DeepCopy()
+	config.CABundle = nil
+	if config.Service != nil && config.Service.Port != nil && *config.Service.Port == 443 {
+		config.Service.Port = nil
+	}
+	return config
+}
+
+// mergeWebhookRules merges all the rules of a webhook into a single rule, so rules can be compared.
+func mergeWebhookRules(rules []admissionregistrationv1.RuleWithOperations) admissionregistrationv1.RuleWithOperations {
+	operations, groups, versions, resources := sets.NewString(), sets.NewString(), sets.NewString(), sets.NewString()
+	for _, r := range rules {
+		for _, o := range r.Operations {
+			operations.Insert(string(o))
+		}
+		groups.Insert(r.APIGroups...)
+		versions.Insert(r.APIVersions...)
+		resources.Insert(r.Resources...)
+	}
+	merged := admissionregistrationv1.RuleWithOperations{
+		Rule: admissionregistrationv1.Rule{
+			APIGroups:   groups.List(),
+			APIVersions: versions.List(),
+			Resources:   resources.List(),
+		},
+	}
+	for _, o := range operations.List() {
+		merged.Operations = append(merged.Operations, admissionregistrationv1.OperationType(o))
+	}
+	return merged
+}
+
+// webhookUpgradeImpacts returns the changes between the current and the target webhook configurations, identified by kind/name.
+func webhookUpgradeImpacts(current, target map[string]map[string]webhookSettings) []WebhookUpgradeImpact {
+	keys := sets.NewString()
+	for k := range current {
+		keys.Insert(k)
+	}
+	for k := range target {
+		keys.Insert(k)
+	}
+
+	var impacts []WebhookUpgradeImpact
+	for _, key := range keys.List() {
+		kind, name := splitWebhookKey(key)
+		currentSettings, inCurrent := current[key]
+		targetSettings, inTarget := target[key]
+		switch {
+		case !inCurrent:
+			impacts = append(impacts, WebhookUpgradeImpact{Kind: kind, Name: name, Added: true})
+		case !inTarget:
+			impacts = append(impacts, WebhookUpgradeImpact{Kind: kind, Name: name, Removed: true})
+		default:
+			changed := sets.NewString()
+			for w, c := range currentSettings {
+				if t, ok := targetSettings[w]; !ok || !reflect.DeepEqual(c, t) {
+					changed.Insert(w)
+				}
+			}
+			for w := range targetSettings {
+				if _, ok := currentSettings[w]; !ok {
+					changed.Insert(w)
+				}
+			}
+			if changed.Len() > 0 {
+				impacts = append(impacts, WebhookUpgradeImpact{Kind: kind, Name: name, ChangedWebhooks: changed.List()})
+			}
+		}
+	}
+	return impacts
+}
+
+func splitWebhookKey(key string) (string, string) {
+	split := strings.SplitN(key, "/", 2)
+	return split[0], split[1]
+}
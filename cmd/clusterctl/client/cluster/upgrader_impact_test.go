@@ -0,0 +1,274 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/scheme"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+)
+
+type fakeCRDVersion struct {
+	name    string
+	storage bool
+	fields  []string
+}
+
+func fakeCRD(name string, storedVersions []string, versions ...fakeCRDVersion) *apiextensionsv1.CustomResourceDefinition {
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apiextensionsv1.SchemeGroupVersion.String(),
+			Kind:       customResourceDefinitionKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{clusterv1.ProviderLabelName: "infrastructure-infra"},
+		},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "infrastructure.cluster.x-k8s.io",
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Kind:     "InfraMachine",
+				ListKind: "InfraMachineList",
+				Plural:   "inframachines",
+			},
+			Scope: apiextensionsv1.NamespaceScoped,
+		},
+		Status: apiextensionsv1.CustomResourceDefinitionStatus{
+			StoredVersions: storedVersions,
+		},
+	}
+	for _, v := range versions {
+		properties := map[string]apiextensionsv1.JSONSchemaProps{}
+		for _, f := range v.fields {
+			properties[f] = apiextensionsv1.JSONSchemaProps{Type: "string"}
+		}
+		crd.Spec.Versions = append(crd.Spec.Versions, apiextensionsv1.CustomResourceDefinitionVersion{
+			Name:    v.name,
+			Served:  true,
+			Storage: v.storage,
+			Schema: &apiextensionsv1.CustomResourceValidation{
+				OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+					Type: "object",
+					Properties: map[string]apiextensionsv1.JSONSchemaProps{
+						"spec": {Type: "object", Properties: properties},
+					},
+				},
+			},
+		})
+	}
+	return crd
+}
+
+func Test_crdUpgradeImpact(t *testing.T) {
+	name := "inframachines.infrastructure.cluster.x-k8s.io"
+
+	tests := []struct {
+		name    string
+		current *apiextensionsv1.CustomResourceDefinition
+		target  *apiextensionsv1.CustomResourceDefinition
+		want    *CRDUpgradeImpact
+	}{
+		{
+			name:    "no changes",
+			current: fakeCRD(name, []string{"v1alpha3"}, fakeCRDVersion{name: "v1alpha3", storage: true, fields: []string{"foo"}}),
+			target:  fakeCRD(name, nil, fakeCRDVersion{name: "v1alpha3", storage: true, fields: []string{"foo"}}),
+			want:    nil,
+		},
+		{
+			name:   "CRD added",
+			target: fakeCRD(name, nil, fakeCRDVersion{name: "v1alpha4", storage: true}),
+			want: &CRDUpgradeImpact{
+				Name:                 name,
+				Added:                true,
+				AddedVersions:        []string{"v1alpha4"},
+				TargetStorageVersion: "v1alpha4",
+			},
+		},
+		{
+			name: "new storage version, old version still existing",
+			current: fakeCRD(name, []string{"v1alpha3"},
+				fakeCRDVersion{name: "v1alpha3", storage: true, fields: []string{"foo", "bar"}}),
+			target: fakeCRD(name, nil,
+				fakeCRDVersion{name: "v1alpha3", fields: []string{"foo"}},
+				fakeCRDVersion{name: "v1alpha4", storage: true, fields: []string{"foo"}}),
+			want: &CRDUpgradeImpact{
+				Name:                  name,
+				AddedVersions:         []string{"v1alpha4"},
+				RemovedVersions:       []string{},
+				CurrentStorageVersion: "v1alpha3",
+				TargetStorageVersion:  "v1alpha4",
+				StoredVersions:        []string{"v1alpha3"},
+				DroppedFields:         []string{"v1alpha3:.spec.bar"},
+			},
+		},
+		{
+			name: "stored version removed",
+			current: fakeCRD(name, []string{"v1alpha2", "v1alpha3"},
+				fakeCRDVersion{name: "v1alpha2"},
+				fakeCRDVersion{name: "v1alpha3", storage: true}),
+			target: fakeCRD(name, nil,
+				fakeCRDVersion{name: "v1alpha3"},
+				fakeCRDVersion{name: "v1alpha4", storage: true}),
+			want: &CRDUpgradeImpact{
+				Name:                            name,
+				AddedVersions:                   []string{"v1alpha4"},
+				RemovedVersions:                 []string{"v1alpha2"},
+				CurrentStorageVersion:           "v1alpha3",
+				TargetStorageVersion:            "v1alpha4",
+				StoredVersions:                  []string{"v1alpha2", "v1alpha3"},
+				RequiresStorageVersionMigration: true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got := crdUpgradeImpact(tt.current, tt.target)
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_webhookUpgradeImpacts(t *testing.T) {
+	g := NewWithT(t)
+
+	settings := func(resource, service string) webhookSettings {
+		return webhookSettings{
+			Rules: admissionregistrationv1.RuleWithOperations{
+				Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create},
+				Rule:       admissionregistrationv1.Rule{Resources: []string{resource}},
+			},
+			ClientConfig: admissionregistrationv1.WebhookClientConfig{
+				Service: &admissionregistrationv1.ServiceReference{Namespace: "infra-system", Name: service},
+			},
+		}
+	}
+
+	current := map[string]map[string]webhookSettings{
+		"ValidatingWebhookConfiguration/unchanged": {"a": settings("a", "webhook-service")},
+		"ValidatingWebhookConfiguration/changed":   {"a": settings("a", "webhook-service"), "b": settings("b", "webhook-service"), "c": settings("c", "webhook-service"), "e": settings("e", "webhook-service")},
+		"MutatingWebhookConfiguration/removed":     {"a": settings("a", "webhook-service")},
+	}
+	target := map[string]map[string]webhookSettings{
+		"ValidatingWebhookConfiguration/unchanged": {"a": settings("a", "webhook-service")},
+		"ValidatingWebhookConfiguration/changed":   {"a": settings("a", "webhook-service"), "b": settings("bb", "webhook-service"), "d": settings("d", "webhook-service"), "e": settings("e", "new-webhook-service")},
+		"MutatingWebhookConfiguration/added":       {"a": settings("a", "webhook-service")},
+	}
+
+	g.Expect(webhookUpgradeImpacts(current, target)).To(Equal([]WebhookUpgradeImpact{
+		{Kind: "MutatingWebhookConfiguration", Name: "added", Added: true},
+		{Kind: "MutatingWebhookConfiguration", Name: "removed", Removed: true},
+		{Kind: "ValidatingWebhookConfiguration", Name: "changed", ChangedWebhooks: []string{"b", "c", "d", "e"}},
+	}))
+}
+
+func Test_webhookClientConfig(t *testing.T) {
+	port := func(p int32) *int32 { return &p }
+
+	tests := []struct {
+		name          string
+		current       admissionregistrationv1.WebhookClientConfig
+		target        admissionregistrationv1.WebhookClientConfig
+		wantUnchanged bool
+	}{
+		{
+			name: "ignores the CA bundle and the defaulted port",
+			current: admissionregistrationv1.WebhookClientConfig{
+				Service:  &admissionregistrationv1.ServiceReference{Namespace: "infra-system", Name: "webhook-service", Port: port(443)},
+				CABundle: []byte("ca"),
+			},
+			target: admissionregistrationv1.WebhookClientConfig{
+				Service: &admissionregistrationv1.ServiceReference{Namespace: "infra-system", Name: "webhook-service"},
+			},
+			wantUnchanged: true,
+		},
+		{
+			name: "detects a changed service port",
+			current: admissionregistrationv1.WebhookClientConfig{
+				Service: &admissionregistrationv1.ServiceReference{Namespace: "infra-system", Name: "webhook-service", Port: port(443)},
+			},
+			target: admissionregistrationv1.WebhookClientConfig{
+				Service: &admissionregistrationv1.ServiceReference{Namespace: "infra-system", Name: "webhook-service", Port: port(9443)},
+			},
+			wantUnchanged: false,
+		},
+		{
+			name: "detects a changed service path",
+			current: admissionregistrationv1.WebhookClientConfig{
+				Service: &admissionregistrationv1.ServiceReference{Namespace: "infra-system", Name: "webhook-service", Path: pointer.StringPtr("/validate-v1alpha3")},
+			},
+			target: admissionregistrationv1.WebhookClientConfig{
+				Service: &admissionregistrationv1.ServiceReference{Namespace: "infra-system", Name: "webhook-service", Path: pointer.StringPtr("/validate-v1alpha4")},
+			},
+			wantUnchanged: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			if tt.wantUnchanged {
+				g.Expect(webhookClientConfig(tt.current)).To(Equal(webhookClientConfig(tt.target)))
+				return
+			}
+			g.Expect(webhookClientConfig(tt.current)).NotTo(Equal(webhookClientConfig(tt.target)))
+		})
+	}
+}
+
+func Test_getUpgradeImpact(t *testing.T) {
+	g := NewWithT(t)
+
+	current := fakeCRD("inframachines.infrastructure.cluster.x-k8s.io", []string{"v1alpha2", "v1alpha3"},
+		fakeCRDVersion{name: "v1alpha2"},
+		fakeCRDVersion{name: "v1alpha3", storage: true})
+	removed := fakeCRD("infraclusters.infrastructure.cluster.x-k8s.io", []string{"v1alpha3"},
+		fakeCRDVersion{name: "v1alpha3", storage: true})
+
+	target := fakeCRD("inframachines.infrastructure.cluster.x-k8s.io", nil,
+		fakeCRDVersion{name: "v1alpha3"},
+		fakeCRDVersion{name: "v1alpha4", storage: true})
+	targetObj := unstructured.Unstructured{}
+	g.Expect(scheme.Scheme.Convert(target, &targetObj, nil)).To(Succeed())
+
+	c, err := test.NewFakeProxy().WithObjs(current).NewClient()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// CustomResourceDefinitions created by clusterctl from unstructured objects can be listed as well.
+	removedObj := &unstructured.Unstructured{}
+	g.Expect(scheme.Scheme.Convert(removed, removedObj, nil)).To(Succeed())
+	g.Expect(c.Create(ctx, removedObj)).To(Succeed())
+
+	impact, err := getUpgradeImpact(c, "infrastructure-infra", []unstructured.Unstructured{targetObj})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(impact.RequiresStorageVersionMigration()).To(BeTrue())
+	g.Expect(impact.CRDs).To(HaveLen(2))
+	g.Expect(impact.CRDs[0].Name).To(Equal("infraclusters.infrastructure.cluster.x-k8s.io"))
+	g.Expect(impact.CRDs[0].Removed).To(BeTrue())
+	g.Expect(impact.CRDs[1].Name).To(Equal("inframachines.infrastructure.cluster.x-k8s.io"))
+	g.Expect(impact.CRDs[1].RemovedVersions).To(Equal([]string{"v1alpha2"}))
+	g.Expect(impact.Webhooks).To(BeEmpty())
+}
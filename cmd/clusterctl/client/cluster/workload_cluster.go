@@ -17,15 +17,76 @@ limitations under the License.
 package cluster
 
 import (
+	"crypto/x509"
+	"fmt"
+	"time"
+
 	"github.com/pkg/errors"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/cluster-api/util/certs"
 	utilkubeconfig "sigs.k8s.io/cluster-api/util/kubeconfig"
+	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/cluster-api/version"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// newWorkloadClientset returns a clientset for a workload cluster given its admin kubeconfig; it is a variable so it can be
+// replaced in tests.
+var newWorkloadClientset = func(kubeconfig []byte) (kubernetes.Interface, error) {
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create a REST config from the workload cluster kubeconfig")
+	}
+	restConfig.UserAgent = fmt.Sprintf("clusterctl/%s (%s)", version.Get().GitVersion, version.Get().Platform)
+	return kubernetes.NewForConfig(restConfig)
+}
+
 // WorkloadCluster has methods for fetching kubeconfig of workload cluster from management cluster.
 type WorkloadCluster interface {
 	// GetKubeconfig returns the kubeconfig of the workload cluster.
 	GetKubeconfig(workloadClusterName string, namespace string) (string, error)
+
+	// GetUserKubeconfig returns a kubeconfig for accessing the workload cluster with a short-lived client certificate
+	// signed by the workload cluster CA.
+	GetUserKubeconfig(workloadClusterName string, namespace string, options UserKubeconfigOptions) (string, error)
+
+	// GetServiceAccountKubeconfig returns a kubeconfig for accessing the workload cluster with a short-lived token
+	// for a ServiceAccount in the workload cluster; the ServiceAccount and its ClusterRoleBinding are created if missing.
+	GetServiceAccountKubeconfig(workloadClusterName string, namespace string, options ServiceAccountKubeconfigOptions) (string, error)
+}
+
+// UserKubeconfigOptions carries the options supported by GetUserKubeconfig.
+type UserKubeconfigOptions struct {
+	// User is the name of the user, used as a common name (CN) for the client certificate.
+	User string
+
+	// Groups of the user, used as organizations (O) for the client certificate.
+	Groups []string
+
+	// TTL is the lifespan of the client certificate.
+	TTL time.Duration
+}
+
+// ServiceAccountKubeconfigOptions carries the options supported by GetServiceAccountKubeconfig.
+type ServiceAccountKubeconfigOptions struct {
+	// Name of the ServiceAccount in the workload cluster.
+	Name string
+
+	// Namespace of the ServiceAccount in the workload cluster; the namespace must exist.
+	Namespace string
+
+	// ClusterRole to be bound to the ServiceAccount, if any; the ClusterRole must exist.
+	ClusterRole string
+
+	// TTL is the lifespan of the token.
+	TTL time.Duration
 }
 
 // workloadCluster implements WorkloadCluster.
@@ -56,3 +117,181 @@ func (p *workloadCluster) GetKubeconfig(workloadClusterName string, namespace st
 	}
 	return string(dataBytes), nil
 }
+
+func (p *workloadCluster) GetUserKubeconfig(workloadClusterName string, namespace string, options UserKubeconfigOptions) (string, error) {
+	if options.User == "" {
+		return "", errors.New("the user name must be specified")
+	}
+	if options.TTL <= 0 {
+		return "", errors.New("the TTL must be greater than zero")
+	}
+
+	adminConfig, _, err := p.getAdminKubeconfig(workloadClusterName, namespace)
+	if err != nil {
+		return "", err
+	}
+
+	cs, err := p.proxy.NewClient()
+	if err != nil {
+		return "", err
+	}
+	clusterCA, err := secret.GetFromNamespacedName(ctx, cs, client.ObjectKey{Namespace: namespace, Name: workloadClusterName}, secret.ClusterCA)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the CA for the workload cluster %s/%s", namespace, workloadClusterName)
+	}
+	caCert, err := certs.DecodeCertPEM(clusterCA.Data[secret.TLSCrtDataName])
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to decode the CA certificate for the workload cluster %s/%s", namespace, workloadClusterName)
+	}
+	if caCert == nil {
+		return "", errors.Errorf("the CA certificate for the workload cluster %s/%s is empty", namespace, workloadClusterName)
+	}
+	caKey, err := certs.DecodePrivateKeyPEM(clusterCA.Data[secret.TLSKeyDataName])
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to decode the CA private key for the workload cluster %s/%s", namespace, workloadClusterName)
+	}
+	if caKey == nil {
+		return "", errors.Errorf("the CA private key for the workload cluster %s/%s is empty", namespace, workloadClusterName)
+	}
+
+	cfg := &certs.Config{
+		CommonName:   options.User,
+		Organization: options.Groups,
+		Usages:       []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		Duration:     options.TTL,
+	}
+	clientKey, err := certs.NewPrivateKey()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to create private key")
+	}
+	clientCert, err := cfg.NewSignedCert(clientKey, caCert, caKey)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to sign certificate")
+	}
+
+	return newWorkloadKubeconfig(adminConfig, workloadClusterName, options.User, &api.AuthInfo{
+		ClientKeyData:         certs.EncodePrivateKeyPEM(clientKey),
+		ClientCertificateData: certs.EncodeCertPEM(clientCert),
+	})
+}
+
+func (p *workloadCluster) GetServiceAccountKubeconfig(workloadClusterName string, namespace string, options ServiceAccountKubeconfigOptions) (string, error) {
+	if options.Name == "" || options.Namespace == "" {
+		return "", errors.New("the ServiceAccount name and namespace must be specified")
+	}
+	if options.TTL <= 0 {
+		return "", errors.New("the TTL must be greater than zero")
+	}
+
+	adminConfig, adminKubeconfig, err := p.getAdminKubeconfig(workloadClusterName, namespace)
+	if err != nil {
+		return "", err
+	}
+	cs, err := newWorkloadClientset(adminKubeconfig)
+	if err != nil {
+		return "", err
+	}
+
+	// Ensures the ServiceAccount exists.
+	sa := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      options.Name,
+			Namespace: options.Namespace,
+		},
+	}
+	if _, err := cs.CoreV1().ServiceAccounts(options.Namespace).Create(ctx, sa, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return "", errors.Wrapf(err, "failed to create the ServiceAccount %s/%s in the workload cluster", options.Namespace, options.Name)
+	}
+
+	// Ensures the ServiceAccount is bound to the ClusterRole, if any.
+	if options.ClusterRole != "" {
+		if _, err := cs.RbacV1().ClusterRoles().Get(ctx, options.ClusterRole, metav1.GetOptions{}); err != nil {
+			return "", errors.Wrapf(err, "failed to get the ClusterRole %s in the workload cluster", options.ClusterRole)
+		}
+		binding := &rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: fmt.Sprintf("clusterctl:%s:%s:%s", options.Namespace, options.Name, options.ClusterRole),
+			},
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "ClusterRole",
+				Name:     options.ClusterRole,
+			},
+			Subjects: []rbacv1.Subject{
+				{
+					Kind:      rbacv1.ServiceAccountKind,
+					Name:      options.Name,
+					Namespace: options.Namespace,
+				},
+			},
+		}
+		if _, err := cs.RbacV1().ClusterRoleBindings().Create(ctx, binding, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+			return "", errors.Wrapf(err, "failed to create the ClusterRoleBinding %s in the workload cluster", binding.Name)
+		}
+	}
+
+	// Requests a token for the ServiceAccount, which expires after the TTL.
+	expirationSeconds := int64(options.TTL.Seconds())
+	tokenRequest := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			ExpirationSeconds: &expirationSeconds,
+		},
+	}
+	tokenRequest, err = cs.CoreV1().ServiceAccounts(options.Namespace).CreateToken(ctx, options.Name, tokenRequest, metav1.CreateOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create a token for the ServiceAccount %s/%s in the workload cluster", options.Namespace, options.Name)
+	}
+
+	userName := fmt.Sprintf("system:serviceaccount:%s:%s", options.Namespace, options.Name)
+	return newWorkloadKubeconfig(adminConfig, workloadClusterName, userName, &api.AuthInfo{
+		Token: tokenRequest.Status.Token,
+	})
+}
+
+// getAdminKubeconfig returns the admin kubeconfig of the workload cluster, both parsed and as raw bytes.
+func (p *workloadCluster) getAdminKubeconfig(workloadClusterName string, namespace string) (*api.Config, []byte, error) {
+	data, err := p.GetKubeconfig(workloadClusterName, namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	config, err := clientcmd.Load([]byte(data))
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to parse the kubeconfig for the workload cluster %s/%s", namespace, workloadClusterName)
+	}
+	return config, []byte(data), nil
+}
+
+// newWorkloadKubeconfig returns a kubeconfig for the workload cluster using the given credentials; the cluster
+// endpoint and CA are read from the admin kubeconfig.
+func newWorkloadKubeconfig(adminConfig *api.Config, workloadClusterName, userName string, authInfo *api.AuthInfo) (string, error) {
+	adminContext, ok := adminConfig.Contexts[adminConfig.CurrentContext]
+	if !ok {
+		return "", errors.Errorf("failed to get the current context from the kubeconfig for the workload cluster %s", workloadClusterName)
+	}
+	cluster, ok := adminConfig.Clusters[adminContext.Cluster]
+	if !ok {
+		return "", errors.Errorf("failed to get the cluster from the kubeconfig for the workload cluster %s", workloadClusterName)
+	}
+
+	contextName := fmt.Sprintf("%s@%s", userName, workloadClusterName)
+	config := &api.Config{
+		Clusters: map[string]*api.Cluster{
+			workloadClusterName: cluster,
+		},
+		Contexts: map[string]*api.Context{
+			contextName: {
+				Cluster:  workloadClusterName,
+				AuthInfo: userName,
+			},
+		},
+		AuthInfos: map[string]*api.AuthInfo{
+			userName: authInfo,
+		},
+		CurrentContext: contextName,
+	}
+	out, err := clientcmd.Write(*config)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to serialize the kubeconfig")
+	}
+	return string(out), nil
+}
@@ -17,14 +17,27 @@ limitations under the License.
 package cluster
 
 import (
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/clientcmd"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/cluster-api/util/certs"
 	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func Test_WorkloadCluster_GetKubeconfig(t *testing.T) {
@@ -96,3 +109,198 @@ users:
 		})
 	}
 }
+
+// newWorkloadClusterSecrets returns the kubeconfig and the CA secrets for the test1 workload cluster.
+func newWorkloadClusterSecrets(g *WithT) (*corev1.Secret, *corev1.Secret) {
+	ca := &secret.Certificate{Purpose: secret.ClusterCA}
+	g.Expect(ca.Generate()).To(Succeed())
+	caSecret := ca.AsSecret(client.ObjectKey{Namespace: "test", Name: "test1"}, metav1.OwnerReference{})
+
+	kubeconfig := fmt.Sprintf(`
+clusters:
+- cluster:
+    certificate-authority-data: %s
+    server: https://test-cluster-api:6443
+  name: test1
+contexts:
+- context:
+    cluster: test1
+    user: test1-admin
+  name: test1-admin@test1
+current-context: test1-admin@test1
+kind: Config
+users:
+- name: test1-admin
+  user:
+    token: admin-token
+`, base64.StdEncoding.EncodeToString(ca.KeyPair.Cert))
+
+	kubeconfigSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test1-kubeconfig",
+			Namespace: "test",
+			Labels:    map[string]string{clusterv1.ClusterLabelName: "test1"},
+		},
+		Data: map[string][]byte{
+			secret.KubeconfigDataName: []byte(kubeconfig),
+		},
+	}
+	return kubeconfigSecret, caSecret
+}
+
+func Test_WorkloadCluster_GetUserKubeconfig(t *testing.T) {
+	g := NewWithT(t)
+
+	kubeconfigSecret, caSecret := newWorkloadClusterSecrets(g)
+
+	tests := []struct {
+		name      string
+		proxy     Proxy
+		options   UserKubeconfigOptions
+		expectErr bool
+	}{
+		{
+			name:    "return a kubeconfig with a client certificate signed by the cluster CA",
+			proxy:   test.NewFakeProxy().WithObjs(kubeconfigSecret, caSecret),
+			options: UserKubeconfigOptions{User: "alice", Groups: []string{"developers"}, TTL: time.Hour},
+		},
+		{
+			name:      "return error if the user is not set",
+			proxy:     test.NewFakeProxy().WithObjs(kubeconfigSecret, caSecret),
+			options:   UserKubeconfigOptions{TTL: time.Hour},
+			expectErr: true,
+		},
+		{
+			name:      "return error if the TTL is not set",
+			proxy:     test.NewFakeProxy().WithObjs(kubeconfigSecret, caSecret),
+			options:   UserKubeconfigOptions{User: "alice"},
+			expectErr: true,
+		},
+		{
+			name:      "return error if cannot find the CA secret",
+			proxy:     test.NewFakeProxy().WithObjs(kubeconfigSecret),
+			options:   UserKubeconfigOptions{User: "alice", TTL: time.Hour},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			wc := newWorkloadCluster(tt.proxy)
+			data, err := wc.GetUserKubeconfig("test1", "test", tt.options)
+			if tt.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			config, err := clientcmd.Load([]byte(data))
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(config.CurrentContext).To(Equal("alice@test1"))
+			g.Expect(config.Clusters["test1"].Server).To(Equal("https://test-cluster-api:6443"))
+
+			caCert, err := certs.DecodeCertPEM(caSecret.Data[secret.TLSCrtDataName])
+			g.Expect(err).ToNot(HaveOccurred())
+			cert, err := certs.DecodeCertPEM(config.AuthInfos["alice"].ClientCertificateData)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(cert.Subject.CommonName).To(Equal("alice"))
+			g.Expect(cert.Subject.Organization).To(Equal([]string{"developers"}))
+			g.Expect(cert.NotAfter).To(BeTemporally("<=", time.Now().Add(time.Hour)))
+			g.Expect(cert.CheckSignatureFrom(caCert)).To(Succeed())
+			g.Expect(cert.ExtKeyUsage).To(Equal([]x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}))
+		})
+	}
+}
+
+func Test_WorkloadCluster_GetServiceAccountKubeconfig(t *testing.T) {
+	g := NewWithT(t)
+
+	kubeconfigSecret, _ := newWorkloadClusterSecrets(g)
+
+	viewClusterRole := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "view",
+		},
+	}
+
+	tests := []struct {
+		name             string
+		workloadObjs     []runtime.Object
+		options          ServiceAccountKubeconfigOptions
+		expectErr        bool
+		expectBindingFor string
+	}{
+		{
+			name:         "creates the ServiceAccount and return a kubeconfig with a token",
+			workloadObjs: []runtime.Object{},
+			options:      ServiceAccountKubeconfigOptions{Name: "ci", Namespace: "default", TTL: time.Hour},
+		},
+		{
+			name:             "binds the ServiceAccount to the ClusterRole",
+			workloadObjs:     []runtime.Object{viewClusterRole},
+			options:          ServiceAccountKubeconfigOptions{Name: "ci", Namespace: "default", ClusterRole: "view", TTL: time.Hour},
+			expectBindingFor: "clusterctl:default:ci:view",
+		},
+		{
+			name:         "return error if the ClusterRole does not exist",
+			workloadObjs: []runtime.Object{},
+			options:      ServiceAccountKubeconfigOptions{Name: "ci", Namespace: "default", ClusterRole: "view", TTL: time.Hour},
+			expectErr:    true,
+		},
+		{
+			name:         "return error if the TTL is not set",
+			workloadObjs: []runtime.Object{},
+			options:      ServiceAccountKubeconfigOptions{Name: "ci", Namespace: "default"},
+			expectErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			workloadClientset := fake.NewSimpleClientset(tt.workloadObjs...)
+			var expirationSeconds int64
+			workloadClientset.PrependReactor("create", "serviceaccounts", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() != "token" {
+					return false, nil, nil
+				}
+				tokenRequest := action.(clienttesting.CreateAction).GetObject().(*authenticationv1.TokenRequest)
+				expirationSeconds = *tokenRequest.Spec.ExpirationSeconds
+				tokenRequest.Status.Token = "ci-token"
+				return true, tokenRequest, nil
+			})
+
+			oldNewWorkloadClientset := newWorkloadClientset
+			newWorkloadClientset = func([]byte) (kubernetes.Interface, error) { return workloadClientset, nil }
+			defer func() { newWorkloadClientset = oldNewWorkloadClientset }()
+
+			wc := newWorkloadCluster(test.NewFakeProxy().WithObjs(kubeconfigSecret))
+			data, err := wc.GetServiceAccountKubeconfig("test1", "test", tt.options)
+			if tt.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			config, err := clientcmd.Load([]byte(data))
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(config.CurrentContext).To(Equal("system:serviceaccount:default:ci@test1"))
+			g.Expect(config.AuthInfos["system:serviceaccount:default:ci"].Token).To(Equal("ci-token"))
+			g.Expect(expirationSeconds).To(Equal(int64(3600)))
+
+			_, err = workloadClientset.CoreV1().ServiceAccounts("default").Get(ctx, "ci", metav1.GetOptions{})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			if tt.expectBindingFor != "" {
+				binding, err := workloadClientset.RbacV1().ClusterRoleBindings().Get(ctx, tt.expectBindingFor, metav1.GetOptions{})
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(binding.RoleRef.Name).To(Equal(tt.options.ClusterRole))
+				g.Expect(binding.Subjects).To(HaveLen(1))
+				g.Expect(binding.Subjects[0].Name).To(Equal("ci"))
+			}
+		})
+	}
+}
@@ -45,6 +45,24 @@ func (c *clusterctlClient) GetProvidersConfig() ([]Provider, error) {
 	return rr, nil
 }
 
+func (c *clusterctlClient) GetConfigValues() ([]ConfigValue, error) {
+	values, err := c.configClient.View()
+	if err != nil {
+		return nil, err
+	}
+
+	// ConfigValue is an alias for config.Value; this makes the conversion
+	ret := make([]ConfigValue, len(values))
+	for i, v := range values {
+		ret[i] = ConfigValue(v)
+	}
+	return ret, nil
+}
+
+func (c *clusterctlClient) ValidateConfig() error {
+	return c.configClient.Validate()
+}
+
 func (c *clusterctlClient) GetProviderComponents(provider string, providerType clusterctlv1.ProviderType, options ComponentsOptions) (Components, error) {
 	components, err := c.getComponentsByName(provider, providerType, repository.ComponentsOptions(options))
 	if err != nil {
@@ -126,6 +144,10 @@ type GetClusterTemplateOptions struct {
 	// ConfigMapSource to be used for reading the workload cluster template; only one template source can be used at time.
 	ConfigMapSource *ConfigMapSourceOptions
 
+	// ClusterClassSource to be used for generating a workload cluster with a managed topology based on a ClusterClass
+	// instead of reading a template; only one template source can be used at time.
+	ClusterClassSource *ClusterClassSourceOptions
+
 	// TargetNamespace where the objects describing the workload cluster should be deployed. If unspecified,
 	// the current namespace will be used.
 	TargetNamespace string
@@ -150,8 +172,17 @@ type GetClusterTemplateOptions struct {
 	ListVariablesOnly bool
 
 	// YamlProcessor defines the yaml processor to use for the cluster
-	// template processing. If not defined, SimpleProcessor will be used.
+	// template processing. If not defined, the processor is defined by TemplateProcessor.
 	YamlProcessor Processor
+
+	// TemplateProcessor defines the name of the yaml processor to use for the cluster template processing,
+	// e.g. gotemplate; it is ignored if YamlProcessor is defined. If not defined, the processor configured
+	// for the infrastructure provider will be used, or the SimpleProcessor as a fallback.
+	TemplateProcessor string
+
+	// TemplateValuesFile defines the path of a YAML file with the typed values to be used when processing
+	// the cluster template; supported only by processors using typed values, e.g. gotemplate.
+	TemplateValuesFile string
 }
 
 // numSources return the number of template sources currently set on a GetClusterTemplateOptions.
@@ -166,6 +197,9 @@ func (o *GetClusterTemplateOptions) numSources() int {
 	if o.URLSource != nil {
 		numSources++
 	}
+	if o.ClusterClassSource != nil {
+		numSources++
+	}
 	return numSources
 }
 
@@ -216,6 +250,15 @@ func (c *clusterctlClient) GetClusterTemplate(options GetClusterTemplateOptions)
 		options.ProviderRepositorySource = &ProviderRepositorySourceOptions{}
 	}
 
+	// If a template processor is explicitly requested, create it.
+	if options.YamlProcessor == nil && (options.TemplateProcessor != "" || options.TemplateValuesFile != "") {
+		processor, err := newTemplateProcessor(options.TemplateProcessor, options.TemplateValuesFile)
+		if err != nil {
+			return nil, err
+		}
+		options.YamlProcessor = processor
+	}
+
 	// Gets  the client for the current management cluster
 	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{options.Kubeconfig, options.YamlProcessor})
 	if err != nil {
@@ -258,6 +301,9 @@ func (c *clusterctlClient) GetClusterTemplate(options GetClusterTemplateOptions)
 	if options.URLSource != nil {
 		return c.getTemplateFromURL(clusterClient, *options.URLSource, options.TargetNamespace, options.ListVariablesOnly)
 	}
+	if options.ClusterClassSource != nil {
+		return c.getTemplateFromClusterClass(clusterClient, options)
+	}
 
 	return nil, errors.New("unable to read custom template. Please specify a template source")
 }
@@ -322,6 +368,14 @@ func (c *clusterctlClient) getTemplateFromRepository(cluster cluster.Client, opt
 		return nil, err
 	}
 
+	// If no processor is explicitly requested, use the one configured for the provider, if any.
+	if processor == nil && providerConfig.TemplateProcessor() != "" {
+		processor, err = newTemplateProcessor(providerConfig.TemplateProcessor(), "")
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid template processor for provider %q", providerConfig.ManifestLabel())
+		}
+	}
+
 	repo, err := c.repositoryClientFactory(RepositoryClientFactoryInput{Provider: providerConfig, Processor: processor})
 	if err != nil {
 		return nil, err
@@ -358,6 +412,25 @@ func (c *clusterctlClient) getTemplateFromURL(cluster cluster.Client, source URL
 	return cluster.Template().GetFromURL(source.URL, targetNamespace, listVariablesOnly)
 }
 
+// newTemplateProcessor returns the yaml processor with the given name, using the typed values read from the values file, if any.
+func newTemplateProcessor(name, valuesFile string) (Processor, error) {
+	var values map[string]interface{}
+	if valuesFile != "" {
+		if name == "" {
+			name = yaml.GoTemplateProcessorName
+		}
+		if name != yaml.GoTemplateProcessorName {
+			return nil, errors.Errorf("template values files are supported only by the %q template processor", yaml.GoTemplateProcessorName)
+		}
+		var err error
+		values, err = yaml.ReadValuesFile(valuesFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return yaml.NewProcessor(name, values)
+}
+
 // templateOptionsToVariables injects some of the templateOptions to the configClient so they can be consumed as a variables from the template.
 func (c *clusterctlClient) templateOptionsToVariables(options GetClusterTemplateOptions) error {
 	// the TargetNamespace, if valid, can be used in templates using the ${ NAMESPACE } variable.
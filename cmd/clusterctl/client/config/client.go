@@ -26,6 +26,8 @@ import (
 // 2. The configuration of the providers (name, type and URL of the provider repository)
 // 3. Variables used when installing providers/creating clusters. Variables can be read from the environment or from the config file
 // 4. The configuration about image overrides.
+// The configuration is read from several layers, system, user and project config files, and from the
+// section of the config files for the current kube context; see Reader for more details.
 type Client interface {
 	// CertManager provide access to the cert-manager configurations.
 	CertManager() CertManagerClient
@@ -38,11 +40,20 @@ type Client interface {
 
 	// ImageMeta provide access to to image meta configurations.
 	ImageMeta() ImageMetaClient
+
+	// View returns the effective configuration values, with the layer each value is read from.
+	View() ([]Value, error)
+
+	// Validate checks the configuration, and more specifically the provider URLs, the image overrides and the
+	// cert-manager configuration; all the issues are reported at once.
+	Validate() error
 }
 
 // configClient implements Client.
 type configClient struct {
-	reader Reader
+	reader         Reader
+	kubeconfigPath string
+	kubeContext    string
 }
 
 // ensure configClient implements Client.
@@ -64,6 +75,14 @@ func (c *configClient) ImageMeta() ImageMetaClient {
 	return newImageMetaClient(c.reader)
 }
 
+func (c *configClient) View() ([]Value, error) {
+	r, ok := c.reader.(valuesReader)
+	if !ok {
+		return nil, errors.New("the configuration reader does not support viewing the configuration values")
+	}
+	return r.Values(), nil
+}
+
 // Option is a configuration option supplied to New.
 type Option func(*configClient)
 
@@ -74,6 +93,15 @@ func InjectReader(reader Reader) Option {
 	}
 }
 
+// InjectKubeconfig sets the kubeconfig for the management cluster, so the configuration for its kube context is used;
+// if the context is empty, the current context in the kubeconfig is used.
+func InjectKubeconfig(path, context string) Option {
+	return func(c *configClient) {
+		c.kubeconfigPath = path
+		c.kubeContext = context
+	}
+}
+
 // New returns a Client for interacting with the clusterctl configuration.
 func New(path string, options ...Option) (Client, error) {
 	return newConfigClient(path, options...)
@@ -87,7 +115,7 @@ func newConfigClient(path string, options ...Option) (*configClient, error) {
 
 	// if there is an injected reader, use it, otherwise use a default one
 	if client.reader == nil {
-		client.reader = newViperReader()
+		client.reader = newViperReader(injectKubeconfig(client.kubeconfigPath, client.kubeContext))
 		if err := client.reader.Init(path); err != nil {
 			return nil, errors.Wrap(err, "failed to initialize the configuration reader")
 		}
@@ -97,6 +125,11 @@ func newConfigClient(path string, options ...Option) (*configClient, error) {
 }
 
 // Reader define the behaviours of a configuration reader.
+// The default reader reads the configuration from the following layers, from the lowest to the highest precedence:
+// the system config file (/etc/cluster-api/clusterctl.yaml), the user config file ($HOME/.cluster-api/clusterctl.yaml,
+// or the file passed to Init), the project config file (.cluster-api/clusterctl.yaml in the current working directory)
+// and the section of each config file for the current kube context (contexts.<name>); environment variables
+// and values explicitly set take precedence on the config files.
 type Reader interface {
 	// Init allows to initialize the configuration reader.
 	Init(path string) error
@@ -112,3 +145,20 @@ type Reader interface {
 	// UnmarshalKey reads a configuration value and unmarshals it into the provided value object.
 	UnmarshalKey(key string, value interface{}) error
 }
+
+// Value is a configuration value, with the layer it is read from.
+type Value struct {
+	// Key of the value; nested values are flattened using the dot as a separator, e.g. cert-manager.version.
+	Key string `json:"key"`
+
+	// Value is the string representation of the value.
+	Value string `json:"value"`
+
+	// Source is the layer the value is read from, with the corresponding config file, if any.
+	Source string `json:"source"`
+}
+
+// valuesReader is a Reader that keeps track of the layer each configuration value is read from.
+type valuesReader interface {
+	Values() []Value
+}
@@ -34,6 +34,10 @@ type Provider interface {
 	// URL returns the name of the provider repository.
 	URL() string
 
+	// TemplateProcessor returns the name of the processor to be used for the provider's cluster templates;
+	// if empty, the default processor is used.
+	TemplateProcessor() string
+
 	// SameAs returns true if two providers have the same name and type.
 	// Please note that this uniquely identifies a provider configuration, but not the provider instances in the cluster
 	// because it is possible to create many instances of the same provider.
@@ -50,9 +54,10 @@ type Provider interface {
 
 // provider implements Provider.
 type provider struct {
-	name         string
-	url          string
-	providerType clusterctlv1.ProviderType
+	name              string
+	url               string
+	providerType      clusterctlv1.ProviderType
+	templateProcessor string
 }
 
 // ensure provider implements provider.
@@ -70,6 +75,10 @@ func (p *provider) Type() clusterctlv1.ProviderType {
 	return p.providerType
 }
 
+func (p *provider) TemplateProcessor() string {
+	return p.templateProcessor
+}
+
 func (p *provider) SameAs(other Provider) bool {
 	return p.name == other.Name() && p.providerType == other.Type()
 }
@@ -92,6 +101,17 @@ func NewProvider(name string, url string, ttype clusterctlv1.ProviderType) Provi
 	}
 }
 
+// NewProviderWithTemplateProcessor creates a new Provider with the given input, using the given processor
+// for the provider's cluster templates.
+func NewProviderWithTemplateProcessor(name string, url string, ttype clusterctlv1.ProviderType, templateProcessor string) Provider {
+	return &provider{
+		name:              name,
+		url:               url,
+		providerType:      ttype,
+		templateProcessor: templateProcessor,
+	}
+}
+
 func (p provider) MarshalJSON() ([]byte, error) {
 	dir, file := filepath.Split(p.url)
 	j, err := json.Marshal(struct {
@@ -208,9 +208,10 @@ func (p *providersClient) defaults() []Provider {
 
 // configProvider mirrors config.Provider interface and allows serialization of the corresponding info.
 type configProvider struct {
-	Name string                    `json:"name,omitempty"`
-	URL  string                    `json:"url,omitempty"`
-	Type clusterctlv1.ProviderType `json:"type,omitempty"`
+	Name              string                    `json:"name,omitempty"`
+	URL               string                    `json:"url,omitempty"`
+	Type              clusterctlv1.ProviderType `json:"type,omitempty"`
+	TemplateProcessor string                    `json:"templateProcessor,omitempty"`
 }
 
 func (p *providersClient) List() ([]Provider, error) {
@@ -226,7 +227,7 @@ func (p *providersClient) List() ([]Provider, error) {
 	}
 
 	for _, u := range userDefinedProviders {
-		provider := NewProvider(u.Name, u.URL, u.Type)
+		provider := NewProviderWithTemplateProcessor(u.Name, u.URL, u.Type, u.TemplateProcessor)
 		if err := validateProvider(provider); err != nil {
 			return nil, errors.Wrapf(err, "error validating configuration for the %s with name %s. Please fix the providers value in clusterctl configuration file", provider.Type(), provider.Name())
 		}
@@ -302,5 +303,14 @@ func validateProvider(r Provider) error {
 			clusterctlv1.InfrastructureProviderType,
 			clusterctlv1.ControlPlaneProviderType)
 	}
+
+	if r.TemplateProcessor() != "" {
+		switch r.TemplateProcessor() {
+		case "envsubst", "gotemplate", "overlay":
+			break
+		default:
+			return errors.Errorf("invalid template processor %q. Allowed values are [envsubst, gotemplate, overlay]", r.TemplateProcessor())
+		}
+	}
 	return nil
 }
@@ -214,6 +214,20 @@ func Test_validateProvider(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Pass with a template processor",
+			args: args{
+				r: NewProviderWithTemplateProcessor("foo", "https://something.com", clusterctlv1.InfrastructureProviderType, "gotemplate"),
+			},
+			wantErr: false,
+		},
+		{
+			name: "Fails if template processor is not valid",
+			args: args{
+				r: NewProviderWithTemplateProcessor("foo", "https://something.com", clusterctlv1.InfrastructureProviderType, "bar"),
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -17,34 +17,84 @@ limitations under the License.
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/yaml"
 )
 
 const (
-	// ConfigFolder defines the name of the config folder under $home.
+	// ConfigFolder defines the name of the config folder under $home, and under the project folder.
 	ConfigFolder = ".cluster-api"
+	// SystemConfigFolder defines the system wide config folder.
+	SystemConfigFolder = "/etc/cluster-api"
 	// ConfigName defines the name of the config file under ConfigFolder.
 	ConfigName = "clusterctl"
 	// DownloadConfigFile is the config file when fetching the config from a remote location.
 	DownloadConfigFile = "clusterctl-download.yaml"
+	// ContextsConfigKey defines the name of the top level config key for the configurations that apply to a kube context only.
+	ContextsConfigKey = "contexts"
+	// ProjectConfigEnvVar defines the environment variable to be set to true for reading the project config file.
+	ProjectConfigEnvVar = "CLUSTERCTL_PROJECT_CONFIG"
+)
+
+// Configuration layers, from the lowest to the highest precedence.
+// NOTE: values read from environment variables take precedence on values read from the configuration files,
+// and values explicitly set, e.g. from flags, take precedence on everything else.
+const (
+	// SystemConfigLayer is the configuration read from the system wide config folder.
+	SystemConfigLayer = "system"
+	// UserConfigLayer is the configuration read from the config folder under $home, or from the --config flag.
+	UserConfigLayer = "user"
+	// ProjectConfigLayer is the configuration read from the config folder under the current working directory;
+	// it is read only if the CLUSTERCTL_PROJECT_CONFIG environment variable is set to true, and the --config flag is not used.
+	ProjectConfigLayer = "project"
+	// ContextConfigLayer is the configuration read from the section of the config files for the current kube context.
+	ContextConfigLayer = "context"
+	// EnvConfigLayer is the configuration read from environment variables.
+	EnvConfigLayer = "env"
+	// OverrideConfigLayer is the configuration explicitly set, e.g. from flags.
+	OverrideConfigLayer = "override"
 )
 
 // viperReader implements Reader using viper as backend for reading from environment variables
-// and from a clusterctl config file.
+// and from the clusterctl config files.
 type viperReader struct {
+	// configPaths are the folders where to look for the user config file.
 	configPaths []string
+
+	// systemConfigPaths are the folders where to look for the system wide config file.
+	systemConfigPaths []string
+
+	// projectConfigPaths are the folders where to look for the project config file; it is empty if
+	// the project config file should not be read.
+	projectConfigPaths []string
+
+	// kubeconfigPath and kubeContext identify the kube context the per-context configurations are read for;
+	// if kubeContext is empty, the current context in the kubeconfig is used.
+	kubeconfigPath string
+	kubeContext    string
+
+	// values are the values read from the config files, with the layer each value is read from.
+	values map[string]Value
+
+	// overrides are the values explicitly set.
+	overrides map[string]string
 }
 
 type viperReaderOption func(*viperReader)
@@ -55,10 +105,35 @@ func injectConfigPaths(configPaths []string) viperReaderOption {
 	}
 }
 
+func injectSystemConfigPaths(configPaths []string) viperReaderOption {
+	return func(vr *viperReader) {
+		vr.systemConfigPaths = configPaths
+	}
+}
+
+func injectProjectConfigPaths(configPaths []string) viperReaderOption {
+	return func(vr *viperReader) {
+		vr.projectConfigPaths = configPaths
+	}
+}
+
+func injectKubeconfig(kubeconfigPath, kubeContext string) viperReaderOption {
+	return func(vr *viperReader) {
+		vr.kubeconfigPath = kubeconfigPath
+		vr.kubeContext = kubeContext
+	}
+}
+
 // newViperReader returns a viperReader.
 func newViperReader(opts ...viperReaderOption) Reader {
 	vr := &viperReader{
-		configPaths: []string{filepath.Join(homedir.HomeDir(), ConfigFolder)},
+		configPaths:       []string{filepath.Join(homedir.HomeDir(), ConfigFolder)},
+		systemConfigPaths: []string{SystemConfigFolder},
+	}
+	if projectConfigEnabled() {
+		if wd, err := os.Getwd(); err == nil {
+			vr.projectConfigPaths = []string{filepath.Join(wd, ConfigFolder)}
+		}
 	}
 	for _, o := range opts {
 		o(vr)
@@ -79,6 +154,8 @@ func (v *viperReader) Init(path string) error {
 	viper.AllowEmptyEnv(true)
 	viper.AutomaticEnv()
 
+	// Gets the user config file, either from the path or from the default .cluster-api/clusterctl{.extension} file in home directory.
+	userConfigFile := ""
 	if path != "" {
 		url, err := url.Parse(path)
 		if err != nil {
@@ -101,33 +178,60 @@ func (v *viperReader) Init(path string) error {
 				return err
 			}
 
-			viper.SetConfigFile(downloadConfigFile)
+			userConfigFile = downloadConfigFile
 		default:
 			if _, err := os.Stat(path); err != nil {
 				return errors.Wrap(err, "failed to check if clusterctl config file exists")
 			}
 			// Use path file from the flag.
-			viper.SetConfigFile(path)
+			userConfigFile = path
 		}
 	} else {
-		// Checks if there is a default .cluster-api/clusterctl{.extension} file in home directory
-		if !v.checkDefaultConfig() {
-			// since there is no default config to read from, just skip
-			// reading in config
-			log.V(5).Info("No default config file available")
-			return nil
-		}
-		// Configure viper for reading .cluster-api/clusterctl{.extension} in home directory
-		viper.SetConfigName(ConfigName)
-		for _, p := range v.configPaths {
-			viper.AddConfigPath(p)
+		userConfigFile = findConfigFile(v.configPaths)
+	}
+
+	// Gets the config files for all the layers, from the lowest to the highest precedence.
+	layers := []configLayer{}
+	if f := findConfigFile(v.systemConfigPaths); f != "" {
+		layers = append(layers, configLayer{name: SystemConfigLayer, path: f})
+	}
+	if userConfigFile != "" {
+		layers = append(layers, configLayer{name: UserConfigLayer, path: userConfigFile})
+	}
+	// NOTE: The project config file is not read when the config file is explicitly set with the --config flag,
+	// so the configuration does not depend on the current working directory.
+	if path == "" {
+		if f := findConfigFile(v.projectConfigPaths); f != "" && !sameFile(f, userConfigFile) {
+			layers = append(layers, configLayer{name: ProjectConfigLayer, path: f})
 		}
 	}
 
-	if err := viper.ReadInConfig(); err != nil {
+	if len(layers) == 0 {
+		// since there is no config file to read from, just skip
+		// reading in config
+		log.V(5).Info("No config file available")
+		return nil
+	}
+
+	kubeContext := currentKubeContext(v.kubeconfigPath, v.kubeContext)
+	settings, values, err := mergeConfigLayers(layers, kubeContext)
+	if err != nil {
 		return err
 	}
-	log.V(5).Info("Using configuration", "File", viper.ConfigFileUsed())
+
+	data, err := yaml.Marshal(settings)
+	if err != nil {
+		return errors.Wrap(err, "failed to merge the clusterctl config files")
+	}
+	viper.SetConfigType("yaml")
+	if err := viper.ReadConfig(bytes.NewReader(data)); err != nil {
+		return errors.Wrap(err, "failed to read the merged clusterctl config files")
+	}
+	v.values = values
+
+	for _, l := range layers {
+		log.Info("Using configuration", "Layer", l.name, "File", l.path, "Context", kubeContext)
+	}
 	return nil
 }
 
@@ -170,24 +274,258 @@ func (v *viperReader) Get(key string) (string, error) {
 
 func (v *viperReader) Set(key, value string) {
 	viper.Set(key, value)
+	if v.overrides == nil {
+		v.overrides = map[string]string{}
+	}
+	v.overrides[strings.ToLower(key)] = value
 }
 
 func (v *viperReader) UnmarshalKey(key string, rawval interface{}) error {
 	return viper.UnmarshalKey(key, rawval)
 }
 
+// Values returns the effective configuration values, with the layer each value is read from; nested values
+// are flattened using the dot as a separator, and providers are identified by their manifest label,
+// e.g. providers.infrastructure-aws.url.
+func (v *viperReader) Values() []Value {
+	values := map[string]Value{}
+	for k, value := range v.values {
+		values[k] = value
+	}
+
+	// Environment variables take precedence on top level values read from the config files.
+	replacer := strings.NewReplacer("-", "_")
+	for k := range values {
+		if strings.Contains(k, ".") {
+			continue
+		}
+		if env, ok := os.LookupEnv(strings.ToUpper(replacer.Replace(k))); ok {
+			values[k] = Value{Key: k, Value: env, Source: EnvConfigLayer}
+		}
+	}
+
+	// Values explicitly set take precedence on everything else.
+	for k, value := range v.overrides {
+		values[k] = Value{Key: k, Value: value, Source: OverrideConfigLayer}
+	}
+
+	ret := make([]Value, 0, len(values))
+	for _, value := range values {
+		ret = append(ret, value)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Key < ret[j].Key
+	})
+	return ret
+}
+
+// projectConfigEnabled returns true if the CLUSTERCTL_PROJECT_CONFIG environment variable is set to true.
+func projectConfigEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(ProjectConfigEnvVar))
+	return enabled
+}
+
 // checkDefaultConfig checks the existence of the default config.
 // Returns true if it finds a supported config file in the available config
 // folders.
 func (v *viperReader) checkDefaultConfig() bool {
-	for _, path := range v.configPaths {
+	return findConfigFile(v.configPaths) != ""
+}
+
+// findConfigFile returns the first clusterctl{.extension} file in the given folders, if any.
+func findConfigFile(configPaths []string) string {
+	for _, path := range configPaths {
 		for _, ext := range viper.SupportedExts {
 			f := filepath.Join(path, fmt.Sprintf("%s.%s", ConfigName, ext))
-			_, err := os.Stat(f)
-			if err == nil {
-				return true
+			if _, err := os.Stat(f); err == nil {
+				return f
 			}
 		}
 	}
-	return false
+	return ""
+}
+
+func sameFile(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(aInfo, bInfo)
+}
+
+// currentKubeContext returns the kube context the per-context configurations are read for, if any.
+func currentKubeContext(kubeconfigPath, kubeContext string) string {
+	if kubeContext != "" {
+		return kubeContext
+	}
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfigPath
+	kubeconfig, err := loadingRules.Load()
+	if err != nil {
+		// If the kubeconfig can't be read, per-context configurations do not apply.
+		return ""
+	}
+	return kubeconfig.CurrentContext
+}
+
+// configLayer is a clusterctl config file, with the layer it is read as.
+type configLayer struct {
+	name string
+	path string
+}
+
+func (l configLayer) source() string {
+	return fmt.Sprintf("%s (%s)", l.name, l.path)
+}
+
+// mergeConfigLayers merges the config files for all the layers, and then the sections of the config files
+// for the given kube context, if any; it returns the merged settings, and the values read from the config files
+// with the layer each value is read from.
+func mergeConfigLayers(layers []configLayer, kubeContext string) (map[string]interface{}, map[string]Value, error) {
+	settings := map[string]interface{}{}
+	values := map[string]Value{}
+
+	contexts := make([]map[string]interface{}, len(layers))
+	for i, l := range layers {
+		layerSettings, err := readConfigLayer(l.path)
+		if err != nil {
+			return nil, nil, err
+		}
+		contexts[i], _ = layerSettings[ContextsConfigKey].(map[string]interface{})
+		delete(layerSettings, ContextsConfigKey)
+
+		mergeConfigLayer(settings, layerSettings, l.source(), values)
+	}
+
+	if kubeContext == "" {
+		return settings, values, nil
+	}
+
+	// NOTE: viper keys are case insensitive, so context names are case insensitive as well.
+	for i, l := range layers {
+		contextSettings, ok := contexts[i][strings.ToLower(kubeContext)]
+		if !ok {
+			continue
+		}
+		contextLayerSettings, ok := normalizeConfigValue(contextSettings).(map[string]interface{})
+		if !ok {
+			return nil, nil, errors.Errorf("invalid configuration for the %s context in the clusterctl config file %s: it must be a map", kubeContext, l.path)
+		}
+		delete(contextLayerSettings, ContextsConfigKey)
+
+		source := fmt.Sprintf("%s %s (%s)", ContextConfigLayer, kubeContext, l.path)
+		mergeConfigLayer(settings, contextLayerSettings, source, values)
+	}
+	return settings, values, nil
+}
+
+// readConfigLayer reads a clusterctl config file.
+func readConfigLayer(path string) (map[string]interface{}, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read the clusterctl config file %s", path)
+	}
+
+	// NOTE: top level values are read with Get, because AllSettings splits keys containing dots, e.g. context names.
+	settings := map[string]interface{}{}
+	for _, k := range v.AllKeys() {
+		key := strings.SplitN(k, ".", 2)[0]
+		if _, ok := settings[key]; ok {
+			continue
+		}
+		settings[key] = normalizeConfigValue(v.Get(key))
+	}
+	return settings, nil
+}
+
+// normalizeConfigValue converts the maps in a config value to map[string]interface{}, so the value can be marshaled.
+func normalizeConfigValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for k, v := range value {
+			m[k] = normalizeConfigValue(v)
+		}
+		return m
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for k, v := range value {
+			m[fmt.Sprint(k)] = normalizeConfigValue(v)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(value))
+		for i, v := range value {
+			l[i] = normalizeConfigValue(v)
+		}
+		return l
+	default:
+		return value
+	}
+}
+
+// mergeConfigLayer merges the settings of a config layer into the given settings; nested values are merged,
+// while providers are appended to the providers already defined, so they override the providers with the same
+// name and type.
+func mergeConfigLayer(settings, layerSettings map[string]interface{}, source string, values map[string]Value) {
+	for k, v := range layerSettings {
+		if k == ProvidersConfigKey {
+			providers, ok := v.([]interface{})
+			if !ok {
+				// NOTE: invalid values are preserved, so they are reported when reading the providers.
+				settings[k] = v
+				values[k] = Value{Key: k, Value: configValueString(v), Source: source}
+				continue
+			}
+			current, _ := settings[k].([]interface{})
+			settings[k] = append(current, providers...)
+			for _, p := range providers {
+				provider, _ := p.(map[string]interface{})
+				label := clusterctlv1.ManifestLabel(configValueString(provider["name"]), clusterctlv1.ProviderType(configValueString(provider["type"])))
+				for field, value := range provider {
+					if field == "name" || field == "type" {
+						continue
+					}
+					key := fmt.Sprintf("%s.%s.%s", ProvidersConfigKey, label, field)
+					values[key] = Value{Key: key, Value: configValueString(value), Source: source}
+				}
+			}
+			continue
+		}
+		mergeConfigValue(settings, k, v, k, source, values)
+	}
+}
+
+func mergeConfigValue(settings map[string]interface{}, k string, v interface{}, key, source string, values map[string]Value) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		settings[k] = v
+		values[key] = Value{Key: key, Value: configValueString(v), Source: source}
+		return
+	}
+
+	current, ok := settings[k].(map[string]interface{})
+	if !ok {
+		current = map[string]interface{}{}
+		settings[k] = current
+	}
+	for nestedK, nestedV := range m {
+		mergeConfigValue(current, nestedK, nestedV, key+"."+nestedK, source, values)
+	}
+}
+
+func configValueString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
 }
@@ -26,6 +26,7 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 )
 
 func Test_viperReader_Init(t *testing.T) {
@@ -292,3 +293,202 @@ func Test_viperReader_checkDefaultConfig(t *testing.T) {
 		})
 	}
 }
+
+func Test_viperReader_Layers(t *testing.T) {
+	g := NewWithT(t)
+
+	dir, err := os.MkdirTemp("", "clusterctl")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	writeConfig := func(folder, content string) string {
+		configFolder := filepath.Join(dir, folder)
+		g.Expect(os.MkdirAll(configFolder, os.ModePerm)).To(Succeed())
+		configFile := filepath.Join(configFolder, "clusterctl.yaml")
+		g.Expect(os.WriteFile(configFile, []byte(content), 0600)).To(Succeed())
+		return configFile
+	}
+
+	systemConfig := writeConfig("system", `
+SYSTEM_VAR: system
+USER_VAR: system
+cert-manager:
+  version: v1.1.0
+  timeout: 15m
+providers:
+  - name: my-infra
+    url: https://example.com/system/infrastructure-components.yaml
+    type: InfrastructureProvider
+  - name: my-bootstrap
+    url: https://example.com/system/bootstrap-components.yaml
+    type: BootstrapProvider
+`)
+	userConfig := writeConfig("user", `
+USER_VAR: user
+PROJECT_VAR: user
+cert-manager:
+  version: v1.2.0
+contexts:
+  mgmt.example.com:
+    CONTEXT_VAR: context
+    PROJECT_VAR: context
+    providers:
+      - name: my-infra
+        url: https://example.com/mgmt/infrastructure-components.yaml
+        type: InfrastructureProvider
+`)
+	projectConfig := writeConfig("project", `
+PROJECT_VAR: project
+`)
+
+	tests := []struct {
+		name          string
+		kubeContext   string
+		wantVars      map[string]string
+		wantProviders []configProvider
+		wantInfraURL  string
+		wantValues    []Value
+	}{
+		{
+			name:        "merges the config files",
+			kubeContext: "another-context",
+			wantVars: map[string]string{
+				"SYSTEM_VAR":  "system",
+				"USER_VAR":    "user",
+				"PROJECT_VAR": "project",
+			},
+			wantProviders: []configProvider{
+				{Name: "my-infra", URL: "https://example.com/system/infrastructure-components.yaml", Type: "InfrastructureProvider"},
+				{Name: "my-bootstrap", URL: "https://example.com/system/bootstrap-components.yaml", Type: "BootstrapProvider"},
+			},
+			wantInfraURL: "https://example.com/system/infrastructure-components.yaml",
+			wantValues: []Value{
+				{Key: "cert-manager.timeout", Value: "15m", Source: "system (" + systemConfig + ")"},
+				{Key: "cert-manager.version", Value: "v1.2.0", Source: "user (" + userConfig + ")"},
+				{Key: "project_var", Value: "project", Source: "project (" + projectConfig + ")"},
+				{Key: "providers.bootstrap-my-bootstrap.url", Value: "https://example.com/system/bootstrap-components.yaml", Source: "system (" + systemConfig + ")"},
+				{Key: "providers.infrastructure-my-infra.url", Value: "https://example.com/system/infrastructure-components.yaml", Source: "system (" + systemConfig + ")"},
+				{Key: "system_var", Value: "system", Source: "system (" + systemConfig + ")"},
+				{Key: "user_var", Value: "user", Source: "user (" + userConfig + ")"},
+			},
+		},
+		{
+			name:        "applies the config for the kube context",
+			kubeContext: "mgmt.example.com",
+			wantVars: map[string]string{
+				"SYSTEM_VAR":  "system",
+				"USER_VAR":    "user",
+				"PROJECT_VAR": "context",
+				"CONTEXT_VAR": "context",
+			},
+			wantProviders: []configProvider{
+				{Name: "my-infra", URL: "https://example.com/system/infrastructure-components.yaml", Type: "InfrastructureProvider"},
+				{Name: "my-bootstrap", URL: "https://example.com/system/bootstrap-components.yaml", Type: "BootstrapProvider"},
+				{Name: "my-infra", URL: "https://example.com/mgmt/infrastructure-components.yaml", Type: "InfrastructureProvider"},
+			},
+			wantInfraURL: "https://example.com/mgmt/infrastructure-components.yaml",
+			wantValues: []Value{
+				{Key: "cert-manager.timeout", Value: "15m", Source: "system (" + systemConfig + ")"},
+				{Key: "cert-manager.version", Value: "v1.2.0", Source: "user (" + userConfig + ")"},
+				{Key: "context_var", Value: "context", Source: "context mgmt.example.com (" + userConfig + ")"},
+				{Key: "project_var", Value: "context", Source: "context mgmt.example.com (" + userConfig + ")"},
+				{Key: "providers.bootstrap-my-bootstrap.url", Value: "https://example.com/system/bootstrap-components.yaml", Source: "system (" + systemConfig + ")"},
+				{Key: "providers.infrastructure-my-infra.url", Value: "https://example.com/mgmt/infrastructure-components.yaml", Source: "context mgmt.example.com (" + userConfig + ")"},
+				{Key: "system_var", Value: "system", Source: "system (" + systemConfig + ")"},
+				{Key: "user_var", Value: "user", Source: "user (" + userConfig + ")"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			v := newViperReader(
+				injectSystemConfigPaths([]string{filepath.Join(dir, "system")}),
+				injectConfigPaths([]string{filepath.Join(dir, "user")}),
+				injectProjectConfigPaths([]string{filepath.Join(dir, "project")}),
+				injectKubeconfig("", tt.kubeContext),
+			)
+			g.Expect(v.Init("")).To(Succeed())
+
+			for k, want := range tt.wantVars {
+				got, err := v.Get(k)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(got).To(Equal(want), k)
+			}
+
+			providers := []configProvider{}
+			g.Expect(v.UnmarshalKey(ProvidersConfigKey, &providers)).To(Succeed())
+			g.Expect(providers).To(Equal(tt.wantProviders))
+
+			g.Expect(v.(*viperReader).Values()).To(Equal(tt.wantValues))
+
+			// Providers defined in layers with higher precedence override the providers with the same name and type.
+			p, err := newProvidersClient(v).Get("my-infra", clusterctlv1.InfrastructureProviderType)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(p.URL()).To(Equal(tt.wantInfraURL))
+		})
+	}
+}
+
+func Test_viperReader_ProjectLayer(t *testing.T) {
+	t.Run("the project config file is read only if enabled", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(os.Unsetenv(ProjectConfigEnvVar)).To(Succeed())
+		g.Expect(newViperReader().(*viperReader).projectConfigPaths).To(BeEmpty())
+
+		g.Expect(os.Setenv(ProjectConfigEnvVar, "true")).To(Succeed())
+		defer os.Unsetenv(ProjectConfigEnvVar)
+
+		wd, err := os.Getwd()
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(newViperReader().(*viperReader).projectConfigPaths).To(ConsistOf(filepath.Join(wd, ConfigFolder)))
+	})
+	t.Run("the project config file is not read if the config file is explicitly set", func(t *testing.T) {
+		g := NewWithT(t)
+
+		dir, err := os.MkdirTemp("", "clusterctl")
+		g.Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		configFile := filepath.Join(dir, "clusterctl.yaml")
+		g.Expect(os.WriteFile(configFile, []byte("USER_VAR: user\n"), 0600)).To(Succeed())
+		projectFolder := filepath.Join(dir, "project")
+		g.Expect(os.MkdirAll(projectFolder, os.ModePerm)).To(Succeed())
+		g.Expect(os.WriteFile(filepath.Join(projectFolder, "clusterctl.yaml"), []byte("PROJECT_VAR: project\n"), 0600)).To(Succeed())
+
+		v := newViperReader(
+			injectSystemConfigPaths(nil),
+			injectProjectConfigPaths([]string{projectFolder}),
+		)
+		g.Expect(v.Init(configFile)).To(Succeed())
+
+		g.Expect(v.(*viperReader).Values()).To(Equal([]Value{
+			{Key: "user_var", Value: "user", Source: "user (" + configFile + ")"},
+		}))
+	})
+}
+
+func Test_viperReader_ValuesFromEnvAndOverrides(t *testing.T) {
+	g := NewWithT(t)
+
+	dir, err := os.MkdirTemp("", "clusterctl")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	configFile := filepath.Join(dir, "clusterctl.yaml")
+	g.Expect(os.WriteFile(configFile, []byte("ENV_VAR: file\nOVERRIDE_VAR: file\n"), 0600)).To(Succeed())
+
+	g.Expect(os.Setenv("ENV_VAR", "env")).To(Succeed())
+	defer os.Unsetenv("ENV_VAR")
+
+	v := &viperReader{}
+	g.Expect(v.Init(configFile)).To(Succeed())
+	v.Set("OVERRIDE_VAR", "override")
+
+	g.Expect(v.Values()).To(Equal([]Value{
+		{Key: "env_var", Value: "env", Source: EnvConfigLayer},
+		{Key: "override_var", Value: "override", Source: OverrideConfigLayer},
+	}))
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution/reference"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/util/container"
+)
+
+func (c *configClient) Validate() error {
+	sources := map[string]string{}
+	if r, ok := c.reader.(valuesReader); ok {
+		for _, v := range r.Values() {
+			sources[v.Key] = v.Source
+		}
+	}
+	// withSource adds to an error the layer the value with the given key is read from, if known.
+	withSource := func(err error, key string) error {
+		if source, ok := sources[key]; ok {
+			return errors.Wrapf(err, "%s, from %s", key, source)
+		}
+		return errors.Wrap(err, key)
+	}
+
+	errList := []error{}
+	errList = append(errList, c.validateProviders(withSource)...)
+	errList = append(errList, c.validateImages(withSource)...)
+	errList = append(errList, c.validateCertManager(withSource)...)
+	return kerrors.NewAggregate(errList)
+}
+
+func (c *configClient) validateProviders(withSource func(error, string) error) []error {
+	userDefinedProviders := []configProvider{}
+	if err := c.reader.UnmarshalKey(ProvidersConfigKey, &userDefinedProviders); err != nil {
+		return []error{errors.Wrap(err, "failed to unmarshal providers from the clusterctl configuration file")}
+	}
+
+	errList := []error{}
+	for _, u := range userDefinedProviders {
+		provider := NewProviderWithTemplateProcessor(u.Name, u.URL, u.Type, u.TemplateProcessor)
+		key := ProvidersConfigKey + "." + clusterctlv1.ManifestLabel(u.Name, u.Type) + ".url"
+		if err := validateProvider(provider); err != nil {
+			errList = append(errList, withSource(err, key))
+			continue
+		}
+		if err := validateProviderURL(provider); err != nil {
+			errList = append(errList, withSource(err, key))
+		}
+	}
+	return errList
+}
+
+// validateProviderURL checks the provider URL can be used for reading from one of the supported repository types.
+// NOTE: the URL is checked without accessing the repository.
+func validateProviderURL(p Provider) error {
+	rURL, err := url.Parse(p.URL())
+	if err != nil {
+		return errors.Wrap(err, "error parsing provider URL")
+	}
+
+	switch rURL.Scheme {
+	case "https":
+		if rURL.Host == "" {
+			return errors.Errorf("invalid provider URL %q: the host is missing", p.URL())
+		}
+		urlSplit := strings.Split(strings.TrimPrefix(rURL.Path, "/"), "/")
+		if rURL.Host == "github.com" && (len(urlSplit) < 5 || urlSplit[2] != "releases") {
+			return errors.Errorf("invalid provider URL %q: GitHub URLs should be in the form https://github.com/{owner}/{repository}/releases/{latest|version}/{components.yaml}", p.URL())
+		}
+		if rURL.Path == "" || strings.HasSuffix(rURL.Path, "/") {
+			return errors.Errorf("invalid provider URL %q: the URL should point to the components file", p.URL())
+		}
+	case "oci":
+		if rURL.Host == "" {
+			return errors.Errorf("invalid provider URL %q: the registry is missing", p.URL())
+		}
+	case "file", "":
+		localPath := rURL.Path
+		if !filepath.IsAbs(localPath) {
+			return errors.Errorf("invalid provider URL %q: local paths must be absolute", p.URL())
+		}
+		urlSplit := strings.Split(strings.TrimPrefix(localPath, "/"), "/")
+		if len(urlSplit) < 3 {
+			return errors.Errorf("invalid provider URL %q: local paths should be in the form {basepath}/{provider-label}/{version}/{components.yaml}", p.URL())
+		}
+		if urlSplit[len(urlSplit)-3] != p.ManifestLabel() {
+			return errors.Errorf("invalid provider URL %q: local paths should be in the form {basepath}/%s/{version}/{components.yaml}", p.URL(), p.ManifestLabel())
+		}
+		if urlSplit[len(urlSplit)-2] != "latest" {
+			if _, err := version.ParseSemantic(urlSplit[len(urlSplit)-2]); err != nil {
+				return errors.Errorf("invalid provider URL %q: the version %q is not a semantic version", p.URL(), urlSplit[len(urlSplit)-2])
+			}
+		}
+	default:
+		return errors.Errorf("invalid provider URL %q: the %q scheme is not supported, use https, oci or a local path", p.URL(), rURL.Scheme)
+	}
+	return nil
+}
+
+func (c *configClient) validateImages(withSource func(error, string) error) []error {
+	var meta map[string]imageMeta
+	if err := c.reader.UnmarshalKey(imagesConfigKey, &meta); err != nil {
+		return []error{errors.Wrap(err, "failed to unmarshal image override configurations")}
+	}
+
+	errList := []error{}
+	for component, m := range meta {
+		key := imagesConfigKey + "." + component
+		if m.Repository == "" && m.Tag == "" {
+			errList = append(errList, withSource(errors.New("the image override does not set a repository or a tag"), key))
+			continue
+		}
+		if m.Repository != "" {
+			// NOTE: the repository is checked by appending an image name, like when the override is applied.
+			if _, err := reference.WithName(path.Join(strings.TrimSuffix(m.Repository, "/"), "image")); err != nil {
+				errList = append(errList, withSource(errors.Errorf("invalid repository %q", m.Repository), key+".repository"))
+			}
+		}
+		if m.Tag != "" && !container.ImageTagIsValid(m.Tag) {
+			errList = append(errList, withSource(errors.Errorf("invalid tag %q", m.Tag), key+".tag"))
+		}
+	}
+	return errList
+}
+
+func (c *configClient) validateCertManager(withSource func(error, string) error) []error {
+	userCertManager := &configCertManager{}
+	if err := c.reader.UnmarshalKey(CertManagerConfigKey, &userCertManager); err != nil {
+		return []error{errors.Wrap(err, "failed to unmarshal certManager from the clusterctl configuration file")}
+	}
+
+	errList := []error{}
+	if userCertManager.URL != "" {
+		if _, err := url.Parse(userCertManager.URL); err != nil {
+			errList = append(errList, withSource(errors.Wrap(err, "error parsing cert-manager URL"), CertManagerConfigKey+".url"))
+		}
+	}
+	if userCertManager.Version != "" {
+		if _, err := version.ParseSemantic(userCertManager.Version); err != nil {
+			errList = append(errList, withSource(errors.Errorf("invalid version %q: it must be a semantic version", userCertManager.Version), CertManagerConfigKey+".version"))
+		}
+	}
+	if userCertManager.Timeout != "" {
+		timeout, err := time.ParseDuration(userCertManager.Timeout)
+		if err != nil || timeout <= 0 {
+			errList = append(errList, withSource(errors.Errorf("invalid timeout %q: it must be a positive duration, e.g. 10m", userCertManager.Timeout), CertManagerConfigKey+".timeout"))
+		}
+	}
+	return errList
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+)
+
+func Test_configClient_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		reader   Reader
+		wantErrs []string
+	}{
+		{
+			name: "pass for a valid configuration",
+			reader: test.NewFakeReader().
+				WithProvider("my-infra", clusterctlv1.InfrastructureProviderType, "https://github.com/my-org/my-infra/releases/latest/infrastructure-components.yaml").
+				WithProvider("my-bootstrap", clusterctlv1.BootstrapProviderType, "oci://registry.example.com/my-bootstrap").
+				WithProvider("my-control-plane", clusterctlv1.ControlPlaneProviderType, "/repo/control-plane-my-control-plane/v1.0.0/control-plane-components.yaml").
+				WithImageMeta("all", "registry.example.com/mirror", "").
+				WithImageMeta("cert-manager/cert-manager-controller", "", "v1.2.0").
+				WithCertManager("https://example.com/cert-manager.yaml", "v1.2.0", "15m"),
+		},
+		{
+			name:   "pass for an empty configuration",
+			reader: test.NewFakeReader(),
+		},
+		{
+			name: "fail for invalid provider URLs",
+			reader: test.NewFakeReader().
+				WithProvider("a", clusterctlv1.InfrastructureProviderType, "https://github.com/my-org/a/infrastructure-components.yaml").
+				WithProvider("b", clusterctlv1.InfrastructureProviderType, "http://example.com/b/infrastructure-components.yaml").
+				WithProvider("c", clusterctlv1.InfrastructureProviderType, "repo/infrastructure-c/v1.0.0/infrastructure-components.yaml").
+				WithProvider("d", clusterctlv1.InfrastructureProviderType, "/repo/infrastructure-e/v1.0.0/infrastructure-components.yaml").
+				WithProvider("e", clusterctlv1.InfrastructureProviderType, "/repo/infrastructure-e/foo/infrastructure-components.yaml").
+				WithProvider("cluster-api", clusterctlv1.InfrastructureProviderType, "https://example.com/core-components.yaml"),
+			wantErrs: []string{
+				"providers.infrastructure-a.url: invalid provider URL",
+				"providers.infrastructure-b.url: invalid provider URL \"http://example.com/b/infrastructure-components.yaml\": the \"http\" scheme is not supported",
+				"providers.infrastructure-c.url: invalid provider URL \"repo/infrastructure-c/v1.0.0/infrastructure-components.yaml\": local paths must be absolute",
+				"providers.infrastructure-d.url: invalid provider URL \"/repo/infrastructure-e/v1.0.0/infrastructure-components.yaml\": local paths should be in the form {basepath}/infrastructure-d/{version}/{components.yaml}",
+				"providers.infrastructure-e.url: invalid provider URL \"/repo/infrastructure-e/foo/infrastructure-components.yaml\": the version \"foo\" is not a semantic version",
+				"providers.infrastructure-cluster-api.url: name cluster-api must be used with the CoreProvider type",
+			},
+		},
+		{
+			name: "fail for invalid image overrides",
+			reader: test.NewFakeReader().
+				WithImageMeta("all", "Registry.example.com/UPPERCASE", "").
+				WithImageMeta("cert-manager", "", "v1.2.0+build").
+				WithImageMeta("cluster-api", "", ""),
+			wantErrs: []string{
+				"images.all.repository: invalid repository \"Registry.example.com/UPPERCASE\"",
+				"images.cert-manager.tag: invalid tag \"v1.2.0+build\"",
+				"images.cluster-api: the image override does not set a repository or a tag",
+			},
+		},
+		{
+			name: "fail for invalid cert-manager configuration",
+			reader: test.NewFakeReader().
+				WithCertManager("", "latest", "-1m"),
+			wantErrs: []string{
+				"cert-manager.version: invalid version \"latest\": it must be a semantic version",
+				"cert-manager.timeout: invalid timeout \"-1m\": it must be a positive duration",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c, err := New("", InjectReader(tt.reader))
+			g.Expect(err).NotTo(HaveOccurred())
+
+			err = c.Validate()
+			if len(tt.wantErrs) == 0 {
+				g.Expect(err).NotTo(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(HaveOccurred())
+			for _, e := range tt.wantErrs {
+				g.Expect(err.Error()).To(ContainSubstring(e))
+			}
+		})
+	}
+}
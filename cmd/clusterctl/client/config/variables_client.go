@@ -19,6 +19,15 @@ package config
 const (
 	// GitHubTokenVariable defines a variable hosting the GitHub access token.
 	GitHubTokenVariable = "github-token"
+
+	// GitLabAccessTokenVariable defines a variable hosting the GitLab access token used for GitLab repositories.
+	GitLabAccessTokenVariable = "gitlab-access-token"
+
+	// OCIUsernameVariable defines a variable hosting the username used for authenticating to OCI registries.
+	OCIUsernameVariable = "oci-username"
+
+	// OCIPasswordVariable defines a variable hosting the password or token used for authenticating to OCI registries.
+	OCIPasswordVariable = "oci-password"
 )
 
 // VariablesClient has methods to work with environment variables and with variables defined in the clusterctl configuration file.
@@ -0,0 +1,369 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
+	yamlprocessor "sigs.k8s.io/cluster-api/cmd/clusterctl/client/yamlprocessor"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// ClusterClassSourceOptions defines the options to be used for generating a workload cluster with a managed topology
+// based on a ClusterClass, instead of reading a cluster template.
+type ClusterClassSourceOptions struct {
+	// Class is the name of a ClusterClass existing in the target namespace of the management cluster, or the
+	// path or the URL of a YAML file containing a ClusterClass and its templates. If unspecified, the class
+	// will be read from the values file.
+	Class string
+
+	// ValuesFile is the path of a YAML file with the values to be used for the cluster topology, if any;
+	// values set in the options take precedence over values read from the file.
+	ValuesFile string
+
+	// MachineDeployments defines the MachineDeployment topologies for the workload cluster. If unspecified,
+	// the MachineDeployment topologies will be read from the values file, or one MachineDeployment topology will
+	// be generated for each MachineDeploymentClass in the ClusterClass.
+	MachineDeployments []MachineDeploymentTopologyOptions
+
+	// ApplyClass forces the creation of the ClusterClass and of its templates in the management cluster before
+	// generating the workload cluster; it can be used only when reading the ClusterClass from a file.
+	ApplyClass bool
+}
+
+// MachineDeploymentTopologyOptions defines a MachineDeployment topology for a workload cluster generated from a ClusterClass.
+type MachineDeploymentTopologyOptions struct {
+	// Name of the MachineDeployment topology.
+	Name string `json:"name"`
+
+	// Class is the name of the MachineDeploymentClass in the ClusterClass.
+	Class string `json:"class"`
+
+	// Replicas is the number of worker machines. If unspecified, the worker machine count will be used.
+	Replicas *int `json:"replicas,omitempty"`
+}
+
+// ClusterTopologyValues defines the values for generating a workload cluster from a ClusterClass; it is read
+// from the values file.
+type ClusterTopologyValues struct {
+	// Class is the name of a ClusterClass existing in the management cluster, or the path or the URL of a
+	// YAML file containing a ClusterClass and its templates.
+	Class string `json:"class,omitempty"`
+
+	// Version is the Kubernetes version of the workload cluster.
+	Version string `json:"version,omitempty"`
+
+	// ControlPlane defines the values for the control plane.
+	ControlPlane ControlPlaneTopologyValues `json:"controlPlane,omitempty"`
+
+	// MachineDeployments defines the MachineDeployment topologies for the workload cluster.
+	MachineDeployments []MachineDeploymentTopologyOptions `json:"machineDeployments,omitempty"`
+}
+
+// ControlPlaneTopologyValues defines the values for the control plane of a workload cluster generated from a ClusterClass.
+type ControlPlaneTopologyValues struct {
+	// Replicas is the number of control plane machines.
+	Replicas *int `json:"replicas,omitempty"`
+}
+
+// readClusterTopologyValues reads the values for generating a workload cluster from a ClusterClass from a YAML file.
+func readClusterTopologyValues(path string) (*ClusterTopologyValues, error) {
+	values := &ClusterTopologyValues{}
+	if path == "" {
+		return values, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the values file %q", path)
+	}
+	if err := yaml.UnmarshalStrict(raw, values); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the values file %q", path)
+	}
+	return values, nil
+}
+
+// getTemplateFromClusterClass returns a workload cluster template with a Cluster with a managed topology based on a ClusterClass.
+func (c *clusterctlClient) getTemplateFromClusterClass(clusterClient cluster.Client, options GetClusterTemplateOptions) (Template, error) {
+	source := *options.ClusterClassSource
+	if options.ListVariablesOnly {
+		return nil, errors.New("listing variables is not supported when generating a workload cluster from a ClusterClass")
+	}
+
+	values, err := readClusterTopologyValues(source.ValuesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	class := source.Class
+	if class == "" {
+		class = values.Class
+	}
+	if class == "" {
+		return nil, errors.New("the ClusterClass must be specified, either in the options or in the values file")
+	}
+
+	clusterClass, err := c.getClusterClass(clusterClient, class, options.TargetNamespace, source.ApplyClass)
+	if err != nil {
+		return nil, err
+	}
+
+	topology, err := c.clusterTopology(clusterClass, source, values, options)
+	if err != nil {
+		return nil, err
+	}
+
+	workloadCluster := &clusterv1.Cluster{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "Cluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      options.ClusterName,
+			Namespace: options.TargetNamespace,
+		},
+		Spec: clusterv1.ClusterSpec{
+			Topology: topology,
+		},
+	}
+	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(workloadCluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert the Cluster to unstructured")
+	}
+	// Drops the empty status and creationTimestamp from the generated Cluster.
+	delete(raw, "status")
+	unstructured.RemoveNestedField(raw, "metadata", "creationTimestamp")
+
+	rawYaml, err := utilyaml.FromUnstructured([]unstructured.Unstructured{{Object: raw}})
+	if err != nil {
+		return nil, err
+	}
+
+	return repository.NewTemplate(repository.TemplateInput{
+		RawArtifact:           rawYaml,
+		ConfigVariablesClient: c.configClient.Variables(),
+		Processor:             yamlprocessor.NewSimpleProcessor(),
+		TargetNamespace:       options.TargetNamespace,
+	})
+}
+
+// getClusterClass returns the ClusterClass with the given name from the management cluster, or reads it from a file or
+// an URL, eventually creating the ClusterClass and its templates in the management cluster.
+func (c *clusterctlClient) getClusterClass(clusterClient cluster.Client, class, targetNamespace string, applyClass bool) (*clusterv1.ClusterClass, error) {
+	// If the class is not a file or an URL, reads the ClusterClass from the management cluster.
+	// NOTE: Kubernetes object names can't contain slashes, so any value with a slash is considered a path or an URL.
+	if _, err := os.Stat(class); err != nil && !strings.Contains(class, "/") {
+		if applyClass {
+			return nil, errors.Errorf("the ClusterClass %q can't be applied because it is not read from a file", class)
+		}
+
+		cs, err := clusterClient.Proxy().NewClient()
+		if err != nil {
+			return nil, err
+		}
+		clusterClass := &clusterv1.ClusterClass{}
+		key := client.ObjectKey{Namespace: targetNamespace, Name: class}
+		if err := cs.Get(context.TODO(), key, clusterClass); err != nil {
+			return nil, errors.Wrapf(err, "failed to get ClusterClass %s", key)
+		}
+		return clusterClass, nil
+	}
+
+	template, err := clusterClient.Template().GetFromURL(class, targetNamespace, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var clusterClass *clusterv1.ClusterClass
+	for _, obj := range template.Objs() {
+		if obj.GroupVersionKind().GroupKind() != clusterv1.GroupVersion.WithKind("ClusterClass").GroupKind() {
+			continue
+		}
+		if clusterClass != nil {
+			return nil, errors.Errorf("invalid ClusterClass file %q: only one ClusterClass is supported", class)
+		}
+		clusterClass = &clusterv1.ClusterClass{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, clusterClass); err != nil {
+			return nil, errors.Wrapf(err, "failed to convert ClusterClass %s", obj.GetName())
+		}
+	}
+	if clusterClass == nil {
+		return nil, errors.Errorf("invalid ClusterClass file %q: the file does not contain a ClusterClass", class)
+	}
+
+	if applyClass {
+		if err := applyObjs(clusterClient, template.Objs()); err != nil {
+			return nil, err
+		}
+	}
+	return clusterClass, nil
+}
+
+// applyObjs creates the given objects in the management cluster, or updates them if they already exist.
+func applyObjs(clusterClient cluster.Client, objs []unstructured.Unstructured) error {
+	log := logf.Log
+
+	cs, err := clusterClient.Proxy().NewClient()
+	if err != nil {
+		return err
+	}
+
+	for i := range objs {
+		obj := objs[i]
+		log.Info("Applying", "Kind", obj.GetKind(), "Object", fmt.Sprintf("%s/%s", obj.GetNamespace(), obj.GetName()))
+
+		current := &unstructured.Unstructured{}
+		current.SetGroupVersionKind(obj.GroupVersionKind())
+		if err := cs.Get(context.TODO(), client.ObjectKeyFromObject(&obj), current); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return errors.Wrapf(err, "failed to get %s %s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+			}
+			if err := cs.Create(context.TODO(), &obj); err != nil {
+				return errors.Wrapf(err, "failed to create %s %s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+			}
+			continue
+		}
+
+		obj.SetResourceVersion(current.GetResourceVersion())
+		if err := cs.Patch(context.TODO(), &obj, client.Merge); err != nil {
+			return errors.Wrapf(err, "failed to patch %s %s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+		}
+	}
+	return nil
+}
+
+// clusterTopology returns the topology for a workload cluster based on the given ClusterClass; values set in the options take
+// precedence over values read from the values file, which take precedence over the values from the clusterctl configuration.
+func (c *clusterctlClient) clusterTopology(clusterClass *clusterv1.ClusterClass, source ClusterClassSourceOptions, values *ClusterTopologyValues, options GetClusterTemplateOptions) (*clusterv1.Topology, error) {
+	topology := &clusterv1.Topology{
+		Class: clusterClass.Name,
+	}
+
+	// Sets the Kubernetes version.
+	topology.Version = options.KubernetesVersion
+	if topology.Version == "" {
+		topology.Version = values.Version
+	}
+	if topology.Version == "" {
+		v, err := c.configClient.Variables().Get("KUBERNETES_VERSION")
+		if err != nil {
+			return nil, errors.New("the Kubernetes version must be specified, either in the options, in the values file or with the KUBERNETES_VERSION variable")
+		}
+		topology.Version = v
+	}
+
+	// Sets the number of control plane machines.
+	switch {
+	case options.ControlPlaneMachineCount != nil:
+		replicas := int(*options.ControlPlaneMachineCount)
+		topology.ControlPlane.Replicas = &replicas
+	case values.ControlPlane.Replicas != nil:
+		topology.ControlPlane.Replicas = values.ControlPlane.Replicas
+	default:
+		replicas, err := c.machineCountVariable("CONTROL_PLANE_MACHINE_COUNT")
+		if err != nil {
+			return nil, err
+		}
+		topology.ControlPlane.Replicas = replicas
+	}
+
+	// Sets the MachineDeployment topologies, checking the classes against the ClusterClass.
+	machineDeployments := source.MachineDeployments
+	if len(machineDeployments) == 0 {
+		machineDeployments = values.MachineDeployments
+	}
+	if len(machineDeployments) == 0 {
+		for i, mdClass := range clusterClass.Spec.Workers.MachineDeployments {
+			machineDeployments = append(machineDeployments, MachineDeploymentTopologyOptions{
+				Name:  fmt.Sprintf("md-%d", i),
+				Class: mdClass.Class,
+			})
+		}
+	}
+
+	mdClasses := sets.NewString()
+	for _, mdClass := range clusterClass.Spec.Workers.MachineDeployments {
+		mdClasses.Insert(mdClass.Class)
+	}
+	names := sets.NewString()
+	for _, md := range machineDeployments {
+		if md.Name == "" {
+			return nil, errors.New("invalid MachineDeployment topology: the name must be specified")
+		}
+		if names.Has(md.Name) {
+			return nil, errors.Errorf("invalid MachineDeployment topology %q: the name must be unique", md.Name)
+		}
+		names.Insert(md.Name)
+		if !mdClasses.Has(md.Class) {
+			return nil, errors.Errorf("invalid MachineDeployment topology %q: the class %q is not defined in the ClusterClass %s; valid classes are %v", md.Name, md.Class, clusterClass.Name, mdClasses.List())
+		}
+
+		replicas := md.Replicas
+		if replicas == nil {
+			if options.WorkerMachineCount != nil {
+				count := int(*options.WorkerMachineCount)
+				replicas = &count
+			} else {
+				var err error
+				if replicas, err = c.machineCountVariable("WORKER_MACHINE_COUNT"); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if topology.Workers == nil {
+			topology.Workers = &clusterv1.WorkersTopology{}
+		}
+		topology.Workers.MachineDeployments = append(topology.Workers.MachineDeployments, clusterv1.MachineDeploymentTopology{
+			Name:     md.Name,
+			Class:    md.Class,
+			Replicas: replicas,
+		})
+	}
+
+	return topology, nil
+}
+
+// machineCountVariable returns the value of a machine count variable.
+// NOTE: machine count variables are always set by templateOptionsToVariables, eventually with a default value.
+func (c *clusterctlClient) machineCountVariable(name string) (*int, error) {
+	v, err := c.configClient.Variables().Get(name)
+	if err != nil {
+		return nil, err
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, errors.Errorf("invalid value for %s set", name)
+	}
+	return &i, nil
+}
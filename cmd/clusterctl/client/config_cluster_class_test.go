@@ -0,0 +1,305 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_clusterctlClient_GetClusterTemplate_withClusterClass(t *testing.T) {
+	g := NewWithT(t)
+
+	tmpDir, err := os.MkdirTemp("", "cc")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tmpDir)
+
+	// ClusterClass and templates on a file
+	classPath := filepath.Join(tmpDir, "cluster-class.yaml")
+	g.Expect(os.WriteFile(classPath, []byte(`apiVersion: cluster.x-k8s.io/v1alpha4
+kind: ClusterClass
+metadata:
+  name: file-class
+spec:
+  workers:
+    machineDeployments:
+    - class: default-worker
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: file-class-template
+`), 0600)).To(Succeed())
+
+	// Values on a file
+	valuesPath := filepath.Join(tmpDir, "values.yaml")
+	g.Expect(os.WriteFile(valuesPath, []byte(`version: v1.21.1
+controlPlane:
+  replicas: 3
+machineDeployments:
+- name: md-gpu
+  class: gpu-worker
+  replicas: 1
+`), 0600)).To(Succeed())
+
+	// ClusterClass in the management cluster
+	clusterClass := &clusterv1.ClusterClass{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clusterv1.GroupVersion.String(),
+			Kind:       "ClusterClass",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      "quick-start",
+		},
+		Spec: clusterv1.ClusterClassSpec{
+			Workers: clusterv1.WorkersClass{
+				MachineDeployments: []clusterv1.MachineDeploymentClass{
+					{Class: "default-worker"},
+					{Class: "gpu-worker"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		options GetClusterTemplateOptions
+		want    *clusterv1.Topology
+		wantErr bool
+	}{
+		{
+			name: "generates a MachineDeployment topology for each class in the ClusterClass",
+			options: GetClusterTemplateOptions{
+				ClusterClassSource:       &ClusterClassSourceOptions{Class: "quick-start"},
+				KubernetesVersion:        "v1.21.2",
+				ControlPlaneMachineCount: pointer.Int64Ptr(3),
+				WorkerMachineCount:       pointer.Int64Ptr(2),
+			},
+			want: &clusterv1.Topology{
+				Class:        "quick-start",
+				Version:      "v1.21.2",
+				ControlPlane: clusterv1.ControlPlaneTopology{Replicas: intPtr(3)},
+				Workers: &clusterv1.WorkersTopology{
+					MachineDeployments: []clusterv1.MachineDeploymentTopology{
+						{Name: "md-0", Class: "default-worker", Replicas: intPtr(2)},
+						{Name: "md-1", Class: "gpu-worker", Replicas: intPtr(2)},
+					},
+				},
+			},
+		},
+		{
+			name: "uses the MachineDeployment topologies from the options",
+			options: GetClusterTemplateOptions{
+				ClusterClassSource: &ClusterClassSourceOptions{
+					Class: "quick-start",
+					MachineDeployments: []MachineDeploymentTopologyOptions{
+						{Name: "md-a", Class: "gpu-worker", Replicas: intPtr(5)},
+						{Name: "md-b", Class: "gpu-worker"},
+					},
+				},
+				KubernetesVersion: "v1.21.2",
+			},
+			want: &clusterv1.Topology{
+				Class:        "quick-start",
+				Version:      "v1.21.2",
+				ControlPlane: clusterv1.ControlPlaneTopology{Replicas: intPtr(1)},
+				Workers: &clusterv1.WorkersTopology{
+					MachineDeployments: []clusterv1.MachineDeploymentTopology{
+						{Name: "md-a", Class: "gpu-worker", Replicas: intPtr(5)},
+						{Name: "md-b", Class: "gpu-worker", Replicas: intPtr(0)},
+					},
+				},
+			},
+		},
+		{
+			name: "uses the values file, with options taking precedence",
+			options: GetClusterTemplateOptions{
+				ClusterClassSource: &ClusterClassSourceOptions{Class: "quick-start", ValuesFile: valuesPath},
+				KubernetesVersion:  "v1.21.2",
+			},
+			want: &clusterv1.Topology{
+				Class:        "quick-start",
+				Version:      "v1.21.2",
+				ControlPlane: clusterv1.ControlPlaneTopology{Replicas: intPtr(3)},
+				Workers: &clusterv1.WorkersTopology{
+					MachineDeployments: []clusterv1.MachineDeploymentTopology{
+						{Name: "md-gpu", Class: "gpu-worker", Replicas: intPtr(1)},
+					},
+				},
+			},
+		},
+		{
+			name: "fails if a MachineDeployment class is not defined in the ClusterClass",
+			options: GetClusterTemplateOptions{
+				ClusterClassSource: &ClusterClassSourceOptions{
+					Class:              "quick-start",
+					MachineDeployments: []MachineDeploymentTopologyOptions{{Name: "md-0", Class: "not-defined"}},
+				},
+				KubernetesVersion: "v1.21.2",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if MachineDeployment names are not unique",
+			options: GetClusterTemplateOptions{
+				ClusterClassSource: &ClusterClassSourceOptions{
+					Class: "quick-start",
+					MachineDeployments: []MachineDeploymentTopologyOptions{
+						{Name: "md-0", Class: "default-worker"},
+						{Name: "md-0", Class: "gpu-worker"},
+					},
+				},
+				KubernetesVersion: "v1.21.2",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if the ClusterClass does not exist",
+			options: GetClusterTemplateOptions{
+				ClusterClassSource: &ClusterClassSourceOptions{Class: "not-existing"},
+				KubernetesVersion:  "v1.21.2",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if the Kubernetes version is not set",
+			options: GetClusterTemplateOptions{
+				ClusterClassSource: &ClusterClassSourceOptions{Class: "quick-start"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if applying a ClusterClass not read from a file",
+			options: GetClusterTemplateOptions{
+				ClusterClassSource: &ClusterClassSourceOptions{Class: "quick-start", ApplyClass: true},
+				KubernetesVersion:  "v1.21.2",
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if listing variables",
+			options: GetClusterTemplateOptions{
+				ClusterClassSource: &ClusterClassSourceOptions{Class: "quick-start"},
+				KubernetesVersion:  "v1.21.2",
+				ListVariablesOnly:  true,
+			},
+			wantErr: true,
+		},
+		{
+			name: "reads the ClusterClass from a file",
+			options: GetClusterTemplateOptions{
+				ClusterClassSource: &ClusterClassSourceOptions{Class: classPath},
+				KubernetesVersion:  "v1.21.2",
+			},
+			want: &clusterv1.Topology{
+				Class:        "file-class",
+				Version:      "v1.21.2",
+				ControlPlane: clusterv1.ControlPlaneTopology{Replicas: intPtr(1)},
+				Workers: &clusterv1.WorkersTopology{
+					MachineDeployments: []clusterv1.MachineDeploymentTopology{
+						{Name: "md-0", Class: "default-worker", Replicas: intPtr(0)},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			tt.options.Kubeconfig = Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}
+			tt.options.ClusterName = "test"
+			tt.options.TargetNamespace = "ns1"
+
+			// NOTE: each test uses a new client, because machine count variables are set on the config client.
+			config1 := newFakeConfig()
+			cluster1 := newFakeCluster(cluster.Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}, config1).
+				WithObjs(clusterClass).
+				WithObjs(test.FakeCAPISetupObjects()...)
+			client1 := newFakeClient(config1).
+				WithCluster(cluster1)
+
+			got, err := client1.GetClusterTemplate(tt.options)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			g.Expect(got.Objs()).To(HaveLen(1))
+			gotCluster := &clusterv1.Cluster{}
+			g.Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(got.Objs()[0].Object, gotCluster)).To(Succeed())
+			g.Expect(gotCluster.Name).To(Equal("test"))
+			g.Expect(gotCluster.Namespace).To(Equal("ns1"))
+			g.Expect(gotCluster.Spec.Topology).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_clusterctlClient_GetClusterTemplate_withClusterClassApply(t *testing.T) {
+	g := NewWithT(t)
+
+	tmpDir, err := os.MkdirTemp("", "cc")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tmpDir)
+
+	classPath := filepath.Join(tmpDir, "cluster-class.yaml")
+	g.Expect(os.WriteFile(classPath, []byte(`apiVersion: cluster.x-k8s.io/v1alpha4
+kind: ClusterClass
+metadata:
+  name: file-class
+spec:
+  workers:
+    machineDeployments:
+    - class: default-worker
+`), 0600)).To(Succeed())
+
+	config1 := newFakeConfig()
+	cluster1 := newFakeCluster(cluster.Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}, config1).
+		WithObjs(test.FakeCAPISetupObjects()...)
+	client1 := newFakeClient(config1).
+		WithCluster(cluster1)
+
+	_, err = client1.GetClusterTemplate(GetClusterTemplateOptions{
+		Kubeconfig:         Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+		ClusterClassSource: &ClusterClassSourceOptions{Class: classPath, ApplyClass: true},
+		ClusterName:        "test",
+		TargetNamespace:    "ns1",
+		KubernetesVersion:  "v1.21.2",
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// The ClusterClass must be created in the target namespace.
+	c, err := cluster1.Proxy().NewClient()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "ns1", Name: "file-class"}, &clusterv1.ClusterClass{})).To(Succeed())
+}
+
+func intPtr(i int) *int {
+	return &i
+}
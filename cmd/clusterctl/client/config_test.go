@@ -784,7 +784,7 @@ func newFakeClientWithoutCluster(configClient config.Client) *fakeClient {
 			if _, ok := fake.repositories[input.Provider.ManifestLabel()]; !ok {
 				return nil, errors.Errorf("Repository for kubeconfig %q does not exist.", input.Provider.ManifestLabel())
 			}
-			return fake.repositories[input.Provider.ManifestLabel()], nil
+			return withRepositoryProcessor(fake.repositories[input.Provider.ManifestLabel()], input.Processor), nil
 		}),
 	)
 	if err != nil {
@@ -990,3 +990,88 @@ type errReader struct{}
 func (e *errReader) Read(p []byte) (n int, err error) {
 	return 0, errors.New("read error")
 }
+
+func Test_clusterctlClient_GetClusterTemplate_withTemplateProcessor(t *testing.T) {
+	g := NewWithT(t)
+
+	rawTemplate := []byte("apiVersion: v1\n" +
+		"kind: Cluster\n" +
+		"metadata:\n" +
+		"  name: {{ .CLUSTER_NAME }}\n" +
+		"  namespace: ns3")
+
+	tmpDir, err := os.MkdirTemp("", "cc")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tmpDir)
+
+	valuesFile := filepath.Join(tmpDir, "values.yaml")
+	g.Expect(os.WriteFile(valuesFile, []byte("CLUSTER_NAME: from-values\n"), 0600)).To(Succeed())
+
+	// The infrastructure provider is configured to use the Go template processor.
+	infraProvider := config.NewProviderWithTemplateProcessor(infraProviderConfig.Name(), infraProviderConfig.URL(), infraProviderConfig.Type(), "gotemplate")
+	config1 := newFakeConfig().
+		WithProvider(infraProvider)
+
+	repository1 := newFakeRepository(infraProvider, config1).
+		WithPaths("root", "components").
+		WithDefaultVersion("v3.0.0").
+		WithFile("v3.0.0", "cluster-template.yaml", rawTemplate)
+
+	client := newFakeClientWithoutCluster(config1).
+		WithRepository(repository1)
+
+	tests := []struct {
+		name               string
+		templateProcessor  string
+		templateValuesFile string
+		wantYaml           []byte
+		wantErr            bool
+	}{
+		{
+			name:     "uses the template processor configured for the provider",
+			wantYaml: templateYAML("ns1", "test"),
+		},
+		{
+			name:               "uses typed values from the values file",
+			templateValuesFile: valuesFile,
+			wantYaml:           templateYAML("ns1", "from-values"),
+		},
+		{
+			name:              "fails for an invalid template processor",
+			templateProcessor: "invalid",
+			wantErr:           true,
+		},
+		{
+			name:               "fails if the values file is used with a processor without typed values",
+			templateProcessor:  "envsubst",
+			templateValuesFile: valuesFile,
+			wantErr:            true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := client.GetClusterTemplate(GetClusterTemplateOptions{
+				ProviderRepositorySource: &ProviderRepositorySourceOptions{
+					InfrastructureProvider: "infra:v3.0.0",
+				},
+				ClusterName:              "test",
+				TargetNamespace:          "ns1",
+				ControlPlaneMachineCount: pointer.Int64Ptr(1),
+				TemplateProcessor:        tt.templateProcessor,
+				TemplateValuesFile:       tt.templateValuesFile,
+			})
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got.Variables()).To(Equal([]string{"CLUSTER_NAME"}))
+
+			gotYaml, err := got.Yaml()
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(gotYaml).To(Equal(tt.wantYaml))
+		})
+	}
+}
@@ -17,6 +17,9 @@ limitations under the License.
 package client
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
@@ -53,8 +56,16 @@ type DeleteOptions struct {
 
 	// IncludeCRDs forces the deletion of the provider's CRDs (and of all the related objects).
 	IncludeCRDs bool
+
+	// Force skips the check preventing the deletion of providers when objects depending on them,
+	// e.g. the AWSClusters and AWSMachines for the AWS infrastructure provider, still exist.
+	Force bool
 }
 
+// maxDependentObjectsPerKind is the max number of dependent objects listed for each kind in the report
+// returned when a provider can't be deleted.
+const maxDependentObjectsPerKind = 5
+
 func (c *clusterctlClient) Delete(options DeleteOptions) error {
 	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.Kubeconfig})
 	if err != nil {
@@ -110,6 +121,13 @@ func (c *clusterctlClient) Delete(options DeleteOptions) error {
 		}
 	}
 
+	// Unless forced, prevent the deletion of providers when objects depending on them still exist.
+	if !options.Force {
+		if err := checkDependentObjects(clusterClient, providersToDelete); err != nil {
+			return err
+		}
+	}
+
 	// Delete the selected providers
 	for _, provider := range providersToDelete {
 		if err := clusterClient.ProviderComponents().Delete(cluster.DeleteOptions{Provider: provider, IncludeNamespace: options.IncludeNamespace, IncludeCRDs: options.IncludeCRDs}); err != nil {
@@ -120,6 +138,49 @@ func (c *clusterctlClient) Delete(options DeleteOptions) error {
 	return nil
 }
 
+// checkDependentObjects returns an error reporting the objects depending on the given providers, if any.
+func checkDependentObjects(clusterClient cluster.Client, providers []clusterctlv1.Provider) error {
+	report := &strings.Builder{}
+	for _, provider := range providers {
+		objs, err := clusterClient.ProviderComponents().DependentObjects(provider)
+		if err != nil {
+			return err
+		}
+		if len(objs) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(report, "\n- %s:", provider.Name)
+		kinds := []string{}
+		objsByKind := map[string][]string{}
+		for _, obj := range objs {
+			if _, ok := objsByKind[obj.GetKind()]; !ok {
+				kinds = append(kinds, obj.GetKind())
+			}
+			objsByKind[obj.GetKind()] = append(objsByKind[obj.GetKind()], fmt.Sprintf("%s/%s", obj.GetNamespace(), obj.GetName()))
+		}
+		for _, kind := range kinds {
+			names := objsByKind[kind]
+			fmt.Fprintf(report, "\n  - %d %s: %s", len(names), kind, strings.Join(truncateList(names, maxDependentObjectsPerKind), ", "))
+		}
+	}
+
+	if report.Len() == 0 {
+		return nil
+	}
+	return errors.Errorf("unable to delete the selected providers because the following objects depend on them; "+
+		"delete the objects first, or use the force option to delete the providers anyway:%s", report.String())
+}
+
+// truncateList returns the first maxItems items in the list, adding a summary of the remaining ones.
+func truncateList(list []string, maxItems int) []string {
+	if len(list) <= maxItems {
+		return list
+	}
+	truncated := append([]string{}, list[:maxItems]...)
+	return append(truncated, fmt.Sprintf("and %d more", len(list)-maxItems))
+}
+
 func appendProviders(list []clusterctlv1.Provider, providerType clusterctlv1.ProviderType, names ...string) []clusterctlv1.Provider {
 	for _, name := range names {
 		if name == "" {
@@ -17,12 +17,18 @@ limitations under the License.
 package client
 
 import (
+	"fmt"
 	"testing"
 
 	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	fakebootstrap "sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test/providers/bootstrap"
 )
 
 var namespace = "foobar"
@@ -136,6 +142,105 @@ func Test_clusterctlClient_Delete(t *testing.T) {
 	}
 }
 
+func Test_clusterctlClient_Delete_DependentObjects(t *testing.T) {
+	kubeconfig := Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}
+
+	tests := []struct {
+		name          string
+		options       DeleteOptions
+		wantProviders sets.String
+		wantErr       bool
+	}{
+		{
+			name: "Refuses to delete a provider with dependent objects",
+			options: DeleteOptions{
+				Kubeconfig:         kubeconfig,
+				BootstrapProviders: []string{bootstrapProviderConfig.Name()},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Refuses to delete all the providers if one of them has dependent objects",
+			options: DeleteOptions{
+				Kubeconfig: kubeconfig,
+				DeleteAll:  true,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Deletes a provider without dependent objects",
+			options: DeleteOptions{
+				Kubeconfig:   kubeconfig,
+				CoreProvider: capiProviderConfig.Name(),
+			},
+			wantProviders: sets.NewString(
+				clusterctlv1.ManifestLabel(bootstrapProviderConfig.Name(), bootstrapProviderConfig.Type()),
+				clusterctlv1.ManifestLabel(controlPlaneProviderConfig.Name(), controlPlaneProviderConfig.Type()),
+				clusterctlv1.ManifestLabel(infraProviderConfig.Name(), infraProviderConfig.Type())),
+		},
+		{
+			name: "Deletes a provider with dependent objects if forced",
+			options: DeleteOptions{
+				Kubeconfig:         kubeconfig,
+				BootstrapProviders: []string{bootstrapProviderConfig.Name()},
+				Force:              true,
+			},
+			wantProviders: sets.NewString(
+				capiProviderConfig.Name(),
+				clusterctlv1.ManifestLabel(controlPlaneProviderConfig.Name(), controlPlaneProviderConfig.Type()),
+				clusterctlv1.ManifestLabel(infraProviderConfig.Name(), infraProviderConfig.Type())),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			client := fakeClusterForDelete()
+			bootstrapManifestLabel := clusterctlv1.ManifestLabel(bootstrapProviderConfig.Name(), bootstrapProviderConfig.Type())
+			bootstrapConfigCRD := test.FakeNamespacedCustomResourceDefinition(fakebootstrap.GroupVersion.Group, "GenericBootstrapConfig", fakebootstrap.GroupVersion.Version)
+			bootstrapConfigCRD.Labels[clusterv1.ProviderLabelName] = bootstrapManifestLabel
+			bootstrapConfig := &fakebootstrap.GenericBootstrapConfig{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: fakebootstrap.GroupVersion.String(),
+					Kind:       "GenericBootstrapConfig",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "ns1",
+					Name:      "machine1",
+				},
+			}
+			proxy := client.clusters[cluster.Kubeconfig(kubeconfig)].Proxy().(*test.FakeProxy)
+			proxy.WithObjs(bootstrapConfigCRD, bootstrapConfig)
+
+			err := client.Delete(tt.options)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring("GenericBootstrapConfig: ns1/machine1"))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			c, err := proxy.NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+			gotProviders := &clusterctlv1.ProviderList{}
+			g.Expect(c.List(ctx, gotProviders)).To(Succeed())
+
+			gotProvidersSet := sets.NewString()
+			for _, gotProvider := range gotProviders.Items {
+				gotProvidersSet.Insert(gotProvider.Name)
+			}
+			g.Expect(gotProvidersSet).To(Equal(tt.wantProviders))
+		})
+	}
+}
+
+func Test_truncateList(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(truncateList([]string{"a", "b"}, 2)).To(Equal([]string{"a", "b"}))
+	g.Expect(truncateList([]string{"a", "b", "c", "d"}, 2)).To(Equal([]string{"a", "b", "and 2 more"}))
+}
+
 // clusterctl client for a management cluster with capi and bootstrap provider.
 func fakeClusterForDelete() *fakeClient {
 	config1 := newFakeConfig().
@@ -156,6 +261,15 @@ func fakeClusterForDelete() *fakeClient {
 	cluster1.fakeProxy.WithProviderInventory(controlPlaneProviderConfig.Name(), controlPlaneProviderConfig.Type(), "v1.0.0", namespace)
 	cluster1.fakeProxy.WithProviderInventory(infraProviderConfig.Name(), infraProviderConfig.Type(), "v1.0.0", namespace)
 	cluster1.fakeProxy.WithFakeCAPISetup()
+	// Adds the inventory CRD, so it is not created as an unstructured object that the fake client can't list as a typed CRD.
+	cluster1.fakeProxy.WithObjs(&apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("providers.%s", clusterctlv1.GroupVersion.Group)},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{Name: clusterctlv1.GroupVersion.Version, Storage: true},
+			},
+		},
+	})
 
 	client := newFakeClient(config1).
 		// fake repository for capi, bootstrap, controlplane and infra provider (matching provider's config)
@@ -17,7 +17,11 @@ limitations under the License.
 package client
 
 import (
+	"time"
+
 	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
 )
 
 // GetKubeconfigOptions carries all the options supported by GetKubeconfig.
@@ -31,9 +35,36 @@ type GetKubeconfigOptions struct {
 
 	// WorkloadClusterName is the name of the workload cluster.
 	WorkloadClusterName string
+
+	// User, if set, is the name of the user for which a kubeconfig with a short-lived client certificate signed by
+	// the workload cluster CA should be generated, instead of returning the admin kubeconfig.
+	User string
+
+	// Groups of the user; they are used only if User is set.
+	Groups []string
+
+	// ServiceAccount, if set, is the name of the ServiceAccount in the workload cluster for which a kubeconfig with
+	// a short-lived token should be generated, instead of returning the admin kubeconfig.
+	// The ServiceAccount is created if it does not exist.
+	ServiceAccount string
+
+	// ServiceAccountNamespace is the namespace of the ServiceAccount in the workload cluster. If empty, the default
+	// namespace will be used.
+	ServiceAccountNamespace string
+
+	// ClusterRole, if set, is the name of the ClusterRole in the workload cluster to be bound to the ServiceAccount;
+	// it is used only if ServiceAccount is set.
+	ClusterRole string
+
+	// TTL is the lifespan of the generated client certificate or token.
+	TTL time.Duration
 }
 
 func (c *clusterctlClient) GetKubeconfig(options GetKubeconfigOptions) (string, error) {
+	if err := validateGetKubeconfigOptions(options); err != nil {
+		return "", err
+	}
+
 	// gets access to the management cluster
 	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.Kubeconfig})
 	if err != nil {
@@ -56,5 +87,40 @@ func (c *clusterctlClient) GetKubeconfig(options GetKubeconfigOptions) (string,
 		options.Namespace = currentNamespace
 	}
 
-	return clusterClient.WorkloadCluster().GetKubeconfig(options.WorkloadClusterName, options.Namespace)
+	switch {
+	case options.User != "":
+		return clusterClient.WorkloadCluster().GetUserKubeconfig(options.WorkloadClusterName, options.Namespace, cluster.UserKubeconfigOptions{
+			User:   options.User,
+			Groups: options.Groups,
+			TTL:    options.TTL,
+		})
+	case options.ServiceAccount != "":
+		if options.ServiceAccountNamespace == "" {
+			options.ServiceAccountNamespace = metav1.NamespaceDefault
+		}
+		return clusterClient.WorkloadCluster().GetServiceAccountKubeconfig(options.WorkloadClusterName, options.Namespace, cluster.ServiceAccountKubeconfigOptions{
+			Name:        options.ServiceAccount,
+			Namespace:   options.ServiceAccountNamespace,
+			ClusterRole: options.ClusterRole,
+			TTL:         options.TTL,
+		})
+	default:
+		return clusterClient.WorkloadCluster().GetKubeconfig(options.WorkloadClusterName, options.Namespace)
+	}
+}
+
+func validateGetKubeconfigOptions(options GetKubeconfigOptions) error {
+	if options.User != "" && options.ServiceAccount != "" {
+		return errors.New("the user and the service account options are mutually exclusive")
+	}
+	if len(options.Groups) > 0 && options.User == "" {
+		return errors.New("the groups can be set only when generating a kubeconfig for a user")
+	}
+	if (options.ServiceAccountNamespace != "" || options.ClusterRole != "") && options.ServiceAccount == "" {
+		return errors.New("the service account namespace and the cluster role can be set only when generating a kubeconfig for a service account")
+	}
+	if (options.User != "" || options.ServiceAccount != "") && options.TTL <= 0 {
+		return errors.New("the TTL must be greater than zero")
+	}
+	return nil
 }
@@ -18,6 +18,7 @@ package client
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
@@ -50,6 +51,30 @@ func Test_clusterctlClient_GetKubeconfig(t *testing.T) {
 			options:   GetKubeconfigOptions{Kubeconfig: Kubeconfig(kubeconfig)},
 			expectErr: true,
 		},
+		{
+			name:      "returns error if both user and service account are set",
+			client:    badClient,
+			options:   GetKubeconfigOptions{Kubeconfig: Kubeconfig(kubeconfig), Namespace: "ns1", User: "alice", ServiceAccount: "ci", TTL: time.Hour},
+			expectErr: true,
+		},
+		{
+			name:      "returns error if groups are set without user",
+			client:    badClient,
+			options:   GetKubeconfigOptions{Kubeconfig: Kubeconfig(kubeconfig), Namespace: "ns1", Groups: []string{"developers"}},
+			expectErr: true,
+		},
+		{
+			name:      "returns error if cluster role is set without service account",
+			client:    badClient,
+			options:   GetKubeconfigOptions{Kubeconfig: Kubeconfig(kubeconfig), Namespace: "ns1", ClusterRole: "view"},
+			expectErr: true,
+		},
+		{
+			name:      "returns error if the TTL is not set",
+			client:    badClient,
+			options:   GetKubeconfigOptions{Kubeconfig: Kubeconfig(kubeconfig), Namespace: "ns1", User: "alice"},
+			expectErr: true,
+		},
 	}
 
 	for _, tt := range tests {
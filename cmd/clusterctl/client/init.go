@@ -65,6 +65,14 @@ type InitOptions struct {
 	// WaitProviderTimeout sets the timeout per provider wait installation
 	WaitProviderTimeout time.Duration
 
+	// ProvidersFile is the path of a file defining the desired set of providers for the management cluster (see ProvidersConfiguration).
+	// When set, init converges the management cluster to the file, by installing missing providers and by upgrading or downgrading
+	// providers with a different version; this field can't be used together with the other fields defining providers.
+	ProvidersFile string
+
+	// DeleteUnlistedProviders instructs init to delete the installed providers that are not included in the ProvidersFile.
+	DeleteUnlistedProviders bool
+
 	// SkipTemplateProcess allows for skipping the call to the template processor, including also variable replacement in the component YAML.
 	// NOTE this works only if the rawYaml is a valid yaml by itself, like e.g when using envsubst/the simple processor.
 	skipTemplateProcess bool
@@ -90,6 +98,14 @@ func (c *clusterctlClient) Init(options InitOptions) ([]Components, error) {
 		return nil, err
 	}
 
+	// If a providers file is specified, converge the management cluster to the desired set of providers.
+	if options.ProvidersFile != "" {
+		if err := validateProvidersFileOptions(options); err != nil {
+			return nil, err
+		}
+		return c.initFromProvidersFile(clusterClient, options)
+	}
+
 	// checks if the cluster already contains a Core provider.
 	// if not we consider this the first time init is executed, and thus we enforce the installation of a core provider,
 	// a bootstrap provider and a control-plane provider (if not already explicitly requested by the user)
@@ -157,10 +173,32 @@ func (c *clusterctlClient) InitImages(options InitOptions) ([]string, error) {
 		return nil, err
 	}
 
-	// checks if the cluster already contains a Core provider.
-	// if not we consider this the first time init is executed, and thus we enforce the installation of a core provider,
-	// a bootstrap provider and a control-plane provider (if not already explicitly requested by the user)
-	c.addDefaultProviders(clusterClient, &options)
+	// If a providers file is specified, list the images for the providers in the file; otherwise, checks if the cluster
+	// already contains a Core provider. If not we consider this the first time init is executed, and thus we enforce the
+	// installation of a core provider, a bootstrap provider and a control-plane provider (if not already explicitly requested by the user).
+	if options.ProvidersFile != "" {
+		if err := validateProvidersFileOptions(options); err != nil {
+			return nil, err
+		}
+		providersConfig, err := loadProvidersConfiguration(options.ProvidersFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range providersConfig.desiredProviders() {
+			switch p.providerType {
+			case clusterctlv1.CoreProviderType:
+				options.CoreProvider = p.String()
+			case clusterctlv1.BootstrapProviderType:
+				options.BootstrapProviders = append(options.BootstrapProviders, p.String())
+			case clusterctlv1.ControlPlaneProviderType:
+				options.ControlPlaneProviders = append(options.ControlPlaneProviders, p.String())
+			case clusterctlv1.InfrastructureProviderType:
+				options.InfrastructureProviders = append(options.InfrastructureProviders, p.String())
+			}
+		}
+	} else {
+		c.addDefaultProviders(clusterClient, &options)
+	}
 
 	// skip variable parsing when listing images
 	options.skipTemplateProcess = true
@@ -186,6 +224,18 @@ func (c *clusterctlClient) InitImages(options InitOptions) ([]string, error) {
 	return images, nil
 }
 
+// validateProvidersFileOptions ensures that providers are defined only in the providers file.
+func validateProvidersFileOptions(options InitOptions) error {
+	if options.CoreProvider != "" ||
+		len(options.BootstrapProviders) > 0 ||
+		len(options.ControlPlaneProviders) > 0 ||
+		len(options.InfrastructureProviders) > 0 ||
+		options.TargetNamespace != "" {
+		return errors.New("providers and target namespace can't be specified when using a providers file, please define them in the file")
+	}
+	return nil
+}
+
 func (c *clusterctlClient) setupInstaller(cluster cluster.Client, options InitOptions) (cluster.ProviderInstaller, error) {
 	installer := cluster.ProviderInstaller()
 
@@ -0,0 +1,342 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gobuffalo/flect"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	"sigs.k8s.io/yaml"
+)
+
+// featureGateVariables defines the variables for feature gates not following the EXP_<FEATURE_GATE> naming convention.
+var featureGateVariables = map[string]string{
+	"ClusterTopology": "CLUSTER_TOPOLOGY",
+}
+
+// ProvidersConfiguration defines the desired set of providers for a management cluster; it is read from the file
+// passed to init with InitOptions.ProvidersFile.
+type ProvidersConfiguration struct {
+	// Core is the core provider; it is required.
+	Core *ProviderConfiguration `json:"core"`
+
+	// Bootstrap is the list of bootstrap providers.
+	Bootstrap []ProviderConfiguration `json:"bootstrap,omitempty"`
+
+	// ControlPlane is the list of control plane providers.
+	ControlPlane []ProviderConfiguration `json:"controlPlane,omitempty"`
+
+	// Infrastructure is the list of infrastructure providers.
+	Infrastructure []ProviderConfiguration `json:"infrastructure,omitempty"`
+
+	// Variables to be used when processing the provider components, e.g. credentials; they override the values
+	// defined in environment variables or in the clusterctl configuration file.
+	// NOTE: as with environment variables, variables are shared by all the providers.
+	Variables map[string]string `json:"variables,omitempty"`
+
+	// FeatureGates to be enabled or disabled for all the providers, e.g. MachinePool: true; each feature gate is
+	// translated into the corresponding variable, e.g. EXP_MACHINE_POOL.
+	FeatureGates map[string]bool `json:"featureGates,omitempty"`
+}
+
+// ProviderConfiguration defines the desired state for a provider.
+type ProviderConfiguration struct {
+	// Name of the provider, e.g. aws.
+	Name string `json:"name"`
+
+	// Version of the provider, e.g. v0.5.0. If unspecified, the latest release is installed, and installed providers
+	// are not upgraded nor downgraded.
+	Version string `json:"version,omitempty"`
+
+	// Namespace where the provider should be deployed. If unspecified, the provider components' default namespace is used
+	// for new providers, and the current namespace is kept for installed providers.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// ParseProvidersConfiguration parses and validates a providers configuration.
+func ParseProvidersConfiguration(raw []byte) (*ProvidersConfiguration, error) {
+	providersConfig := &ProvidersConfiguration{}
+	if err := yaml.UnmarshalStrict(raw, providersConfig); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the providers configuration")
+	}
+
+	var errs []error
+	if providersConfig.Core == nil {
+		errs = append(errs, errors.New("core: the core provider must be set"))
+	}
+	names := map[string]bool{}
+	for _, p := range providersConfig.desiredProviders() {
+		field := fmt.Sprintf("%s %q", p.providerType, p.Name)
+		if err := validateDNS1123Label(p.Name); err != nil {
+			errs = append(errs, errors.Wrapf(err, "%s: invalid name", field))
+		}
+		if p.Version != "" {
+			if _, err := version.ParseSemantic(p.Version); err != nil {
+				errs = append(errs, errors.Wrapf(err, "%s: invalid version %q", field, p.Version))
+			}
+		}
+		if p.Namespace != "" {
+			if err := validateDNS1123Label(p.Namespace); err != nil {
+				errs = append(errs, errors.Wrapf(err, "%s: invalid namespace %q", field, p.Namespace))
+			}
+		}
+		if names[clusterctlv1.ManifestLabel(p.Name, p.providerType)] {
+			errs = append(errs, errors.Errorf("%s: is defined more than once", field))
+		}
+		names[clusterctlv1.ManifestLabel(p.Name, p.providerType)] = true
+	}
+	if len(errs) > 0 {
+		return nil, errors.Wrap(kerrors.NewAggregate(errs), "invalid providers configuration")
+	}
+	return providersConfig, nil
+}
+
+// desiredProvider is a provider in a providers configuration, with its type.
+type desiredProvider struct {
+	ProviderConfiguration
+	providerType clusterctlv1.ProviderType
+}
+
+func (d desiredProvider) String() string {
+	if d.Version == "" {
+		return d.Name
+	}
+	return fmt.Sprintf("%s:%s", d.Name, d.Version)
+}
+
+// desiredProviders returns all the providers in the configuration, starting from the core provider.
+func (p *ProvidersConfiguration) desiredProviders() []desiredProvider {
+	ret := []desiredProvider{}
+	if p.Core != nil {
+		ret = append(ret, desiredProvider{ProviderConfiguration: *p.Core, providerType: clusterctlv1.CoreProviderType})
+	}
+	for _, c := range p.Bootstrap {
+		ret = append(ret, desiredProvider{ProviderConfiguration: c, providerType: clusterctlv1.BootstrapProviderType})
+	}
+	for _, c := range p.ControlPlane {
+		ret = append(ret, desiredProvider{ProviderConfiguration: c, providerType: clusterctlv1.ControlPlaneProviderType})
+	}
+	for _, c := range p.Infrastructure {
+		ret = append(ret, desiredProvider{ProviderConfiguration: c, providerType: clusterctlv1.InfrastructureProviderType})
+	}
+	return ret
+}
+
+// variables returns the variables defined in the configuration, including the variables for the feature gates;
+// explicit variables take precedence over feature gates.
+func (p *ProvidersConfiguration) variables() map[string]string {
+	ret := map[string]string{}
+	for name, enabled := range p.FeatureGates {
+		ret[featureGateVariable(name)] = fmt.Sprintf("%t", enabled)
+	}
+	for k, v := range p.Variables {
+		ret[k] = v
+	}
+	return ret
+}
+
+// featureGateVariable returns the variable controlling a feature gate, e.g. EXP_MACHINE_POOL for MachinePool.
+func featureGateVariable(featureGate string) string {
+	if v, ok := featureGateVariables[featureGate]; ok {
+		return v
+	}
+	return "EXP_" + strings.ToUpper(flect.Underscore(featureGate))
+}
+
+// providersConfigurationPlan defines the actions required to converge a management cluster to a providers configuration.
+type providersConfigurationPlan struct {
+	// install is the list of providers to be installed.
+	install []desiredProvider
+
+	// upgrade is the list of providers to be upgraded or downgraded.
+	upgrade []cluster.UpgradeItem
+
+	// unlisted is the list of installed providers not included in the configuration.
+	unlisted []clusterctlv1.Provider
+}
+
+// planProvidersConfiguration compares a providers configuration with the providers installed in a management cluster,
+// and returns the actions required to converge the management cluster to the configuration.
+func planProvidersConfiguration(providersConfig *ProvidersConfiguration, installedProviders []clusterctlv1.Provider) (*providersConfigurationPlan, error) {
+	plan := &providersConfigurationPlan{}
+	listed := map[string]bool{}
+	for _, desired := range providersConfig.desiredProviders() {
+		var installed *clusterctlv1.Provider
+		for i := range installedProviders {
+			p := &installedProviders[i]
+			if p.ProviderName == desired.Name && p.GetProviderType() == desired.providerType {
+				installed = p
+				break
+			}
+		}
+
+		if installed == nil {
+			plan.install = append(plan.install, desired)
+			continue
+		}
+		listed[installed.InstanceName()] = true
+
+		if desired.Namespace != "" && desired.Namespace != installed.Namespace {
+			return nil, errors.Errorf("the %s provider %q is installed in the %q namespace, while the providers configuration requires the %q namespace; providers can't be moved to another namespace, please delete the provider first",
+				desired.providerType, desired.Name, installed.Namespace, desired.Namespace)
+		}
+
+		if desired.Version != "" && !sameVersion(desired.Version, installed.Version) {
+			plan.upgrade = append(plan.upgrade, cluster.UpgradeItem{
+				Provider:    *installed,
+				NextVersion: desired.Version,
+			})
+		}
+	}
+
+	for _, p := range installedProviders {
+		if !listed[p.InstanceName()] {
+			plan.unlisted = append(plan.unlisted, p)
+		}
+	}
+	sort.Slice(plan.unlisted, func(i, j int) bool {
+		return plan.unlisted[i].InstanceName() < plan.unlisted[j].InstanceName()
+	})
+	return plan, nil
+}
+
+// sameVersion returns true if two versions are the same, no matter of the v prefix.
+func sameVersion(a, b string) bool {
+	va, err := version.ParseSemantic(a)
+	if err != nil {
+		return a == b
+	}
+	vb, err := version.ParseSemantic(b)
+	if err != nil {
+		return a == b
+	}
+	return va.String() == vb.String()
+}
+
+// loadProvidersConfiguration reads and parses the providers configuration file.
+func loadProvidersConfiguration(path string) (*ProvidersConfiguration, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the providers configuration file %q", path)
+	}
+	providersConfig, err := ParseProvidersConfiguration(raw)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load %q", path)
+	}
+	return providersConfig, nil
+}
+
+// initFromProvidersFile converges the management cluster to the providers configuration file specified in the options:
+// missing providers are installed, providers with a different version are upgraded or downgraded, and, if requested,
+// providers not included in the configuration are deleted.
+func (c *clusterctlClient) initFromProvidersFile(clusterClient cluster.Client, options InitOptions) ([]Components, error) {
+	log := logf.Log
+
+	providersConfig, err := loadProvidersConfiguration(options.ProvidersFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Variables defined in the configuration take precedence over environment variables and the clusterctl configuration file.
+	for k, v := range providersConfig.variables() {
+		c.configClient.Variables().Set(k, v)
+	}
+
+	log.Info("Fetching providers")
+	installedProviders, err := clusterClient.ProviderInventory().List()
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := planProvidersConfiguration(providersConfig, installedProviders.Items)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.install) == 0 && len(plan.upgrade) == 0 && (len(plan.unlisted) == 0 || !options.DeleteUnlistedProviders) {
+		for _, p := range plan.unlisted {
+			log.Info("Skipping provider not included in the providers configuration", "Provider", p.InstanceName())
+		}
+		log.Info("The management cluster is already in the desired state")
+		return nil, nil
+	}
+
+	// Upgrades or downgrades the installed providers with a version different than the desired one; this is done
+	// before installing new providers, so the latter are validated against the target state of the management cluster.
+	if len(plan.upgrade) > 0 {
+		if err := clusterClient.CertManager().EnsureLatestVersion(); err != nil {
+			return nil, err
+		}
+		if err := clusterClient.ProviderUpgrader().ApplyCustomPlan(cluster.UpgradeOptions{}, plan.upgrade...); err != nil {
+			return nil, err
+		}
+	}
+
+	// Installs the missing providers.
+	var components []Components
+	if len(plan.install) > 0 {
+		installer := clusterClient.ProviderInstaller()
+		for _, p := range plan.install {
+			addOptions := addToInstallerOptions{
+				installer:       installer,
+				targetNamespace: p.Namespace,
+			}
+			if err := c.addToInstaller(addOptions, p.providerType, p.String()); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := installer.Validate(); err != nil {
+			return nil, err
+		}
+		if err := clusterClient.CertManager().EnsureInstalled(); err != nil {
+			return nil, err
+		}
+
+		installOpts := cluster.InstallOptions{
+			WaitProviders:       options.WaitProviders,
+			WaitProviderTimeout: options.WaitProviderTimeout,
+		}
+		installed, err := installer.Install(installOpts)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range installed {
+			components = append(components, i)
+		}
+	}
+
+	// Deletes or reports the providers not included in the configuration; their CRDs and namespaces are preserved.
+	for _, p := range plan.unlisted {
+		if !options.DeleteUnlistedProviders {
+			log.Info("Skipping provider not included in the providers configuration", "Provider", p.InstanceName())
+			continue
+		}
+		if err := clusterClient.ProviderComponents().Delete(cluster.DeleteOptions{Provider: p}); err != nil {
+			return nil, err
+		}
+	}
+	return components, nil
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+)
+
+func TestParseProvidersConfiguration(t *testing.T) {
+	tests := []struct {
+		name          string
+		raw           string
+		wantProviders []string
+		wantVariables map[string]string
+		wantErr       bool
+	}{
+		{
+			name: "parses the providers configuration",
+			raw: `core:
+  name: cluster-api
+  version: v0.4.0
+bootstrap:
+- name: kubeadm
+controlPlane:
+- name: kubeadm
+  version: v0.4.0
+infrastructure:
+- name: aws
+  version: v0.7.0
+  namespace: capa-system
+variables:
+  AWS_B64ENCODED_CREDENTIALS: foo
+  EXP_CLUSTER_RESOURCE_SET: "false"
+featureGates:
+  MachinePool: true
+  ClusterResourceSet: true
+  ClusterTopology: false
+`,
+			wantProviders: []string{"cluster-api:v0.4.0", "kubeadm", "kubeadm:v0.4.0", "aws:v0.7.0"},
+			wantVariables: map[string]string{
+				"AWS_B64ENCODED_CREDENTIALS": "foo",
+				"EXP_MACHINE_POOL":           "true",
+				"EXP_CLUSTER_RESOURCE_SET":   "false",
+				"CLUSTER_TOPOLOGY":           "false",
+			},
+		},
+		{
+			name: "fails for unknown fields",
+			raw: `core:
+  name: cluster-api
+  vers: v0.4.0
+`,
+			wantErr: true,
+		},
+		{
+			name: "fails if the core provider is not set",
+			raw: `infrastructure:
+- name: aws
+`,
+			wantErr: true,
+		},
+		{
+			name: "fails for invalid providers",
+			raw: `core:
+  name: cluster-api
+  version: latest
+infrastructure:
+- name: aws
+  namespace: CAPA
+- name: aws
+- name: ""
+`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := ParseProvidersConfiguration([]byte(tt.raw))
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			providers := []string{}
+			for _, p := range got.desiredProviders() {
+				providers = append(providers, p.String())
+			}
+			g.Expect(providers).To(Equal(tt.wantProviders))
+			g.Expect(got.variables()).To(Equal(tt.wantVariables))
+		})
+	}
+}
+
+func Test_planProvidersConfiguration(t *testing.T) {
+	installed := func(name string, providerType clusterctlv1.ProviderType, version, namespace string) clusterctlv1.Provider {
+		return clusterctlv1.Provider{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      clusterctlv1.ManifestLabel(name, providerType),
+			},
+			ProviderName: name,
+			Type:         string(providerType),
+			Version:      version,
+		}
+	}
+	installedProviders := []clusterctlv1.Provider{
+		installed("cluster-api", clusterctlv1.CoreProviderType, "v0.4.0", "capi-system"),
+		installed("kubeadm", clusterctlv1.BootstrapProviderType, "v0.4.0", "capi-kubeadm-bootstrap-system"),
+		installed("kubeadm", clusterctlv1.ControlPlaneProviderType, "v0.4.0", "capi-kubeadm-control-plane-system"),
+		installed("docker", clusterctlv1.InfrastructureProviderType, "v0.4.0", "capd-system"),
+	}
+
+	tests := []struct {
+		name         string
+		config       *ProvidersConfiguration
+		installed    []clusterctlv1.Provider
+		wantInstall  []string
+		wantUpgrade  []string
+		wantUnlisted []string
+		wantErr      bool
+	}{
+		{
+			name: "installs all the providers in an empty management cluster",
+			config: &ProvidersConfiguration{
+				Core:           &ProviderConfiguration{Name: "cluster-api", Version: "v0.4.0"},
+				Infrastructure: []ProviderConfiguration{{Name: "aws", Namespace: "capa-system"}},
+			},
+			installed:   nil,
+			wantInstall: []string{"cluster-api:v0.4.0", "aws"},
+		},
+		{
+			name: "does nothing if the management cluster is already in the desired state",
+			config: &ProvidersConfiguration{
+				Core:           &ProviderConfiguration{Name: "cluster-api", Version: "0.4.0"},
+				Bootstrap:      []ProviderConfiguration{{Name: "kubeadm"}},
+				ControlPlane:   []ProviderConfiguration{{Name: "kubeadm", Version: "v0.4.0"}},
+				Infrastructure: []ProviderConfiguration{{Name: "docker", Namespace: "capd-system"}},
+			},
+			installed: installedProviders,
+		},
+		{
+			name: "installs, upgrades, downgrades and reports unlisted providers",
+			config: &ProvidersConfiguration{
+				Core:           &ProviderConfiguration{Name: "cluster-api", Version: "v0.4.1"},
+				Bootstrap:      []ProviderConfiguration{{Name: "kubeadm", Version: "v0.3.9"}},
+				Infrastructure: []ProviderConfiguration{{Name: "aws"}},
+			},
+			installed:    installedProviders,
+			wantInstall:  []string{"aws"},
+			wantUpgrade:  []string{"capi-system/cluster-api:v0.4.1", "capi-kubeadm-bootstrap-system/bootstrap-kubeadm:v0.3.9"},
+			wantUnlisted: []string{"capd-system/infrastructure-docker", "capi-kubeadm-control-plane-system/control-plane-kubeadm"},
+		},
+		{
+			name: "fails if an installed provider should be moved to another namespace",
+			config: &ProvidersConfiguration{
+				Core: &ProviderConfiguration{Name: "cluster-api", Namespace: "foo"},
+			},
+			installed: installedProviders,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := planProvidersConfiguration(tt.config, tt.installed)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			install := []string{}
+			for _, p := range got.install {
+				install = append(install, p.String())
+			}
+			upgrade := []string{}
+			for _, u := range got.upgrade {
+				upgrade = append(upgrade, u.InstanceName()+":"+u.NextVersion)
+			}
+			unlisted := []string{}
+			for _, p := range got.unlisted {
+				unlisted = append(unlisted, p.InstanceName())
+			}
+			g.Expect(install).To(ConsistOf(tt.wantInstall))
+			g.Expect(upgrade).To(ConsistOf(tt.wantUpgrade))
+			g.Expect(unlisted).To(Equal(append([]string{}, tt.wantUnlisted...)))
+		})
+	}
+}
@@ -0,0 +1,415 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
+	yaml "sigs.k8s.io/cluster-api/cmd/clusterctl/client/yamlprocessor"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/util"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+const (
+	// mirrorCertManagerName is the name of the folder where the cert-manager components are stored in a mirror bundle.
+	mirrorCertManagerName = "cert-manager"
+
+	// mirrorImagesFile is the name of the file listing the images required by a mirror bundle.
+	mirrorImagesFile = "images.yaml"
+
+	// mirrorDefaultComponentsFile is the name used for the components YAML file when it can't be inferred from the provider URL.
+	mirrorDefaultComponentsFile = "components.yaml"
+)
+
+// MirrorOptions carries the options supported by Mirror.
+type MirrorOptions struct {
+	// Directory where the bundle is written; files are stored using the layout of a local provider repository,
+	// {directory}/{provider-label}/{version}/{file}.
+	Directory string
+
+	// CoreProvider version (e.g. cluster-api:v0.3.0) to add to the bundle; if unspecified, the
+	// cluster-api core provider's latest release is used.
+	CoreProvider string
+
+	// BootstrapProviders and versions (e.g. kubeadm:v0.3.0) to add to the bundle; if unspecified, the
+	// kubeadm bootstrap provider's latest release is used.
+	BootstrapProviders []string
+
+	// ControlPlaneProviders and versions (e.g. kubeadm:v0.3.0) to add to the bundle; if unspecified, the
+	// kubeadm control plane provider's latest release is used.
+	ControlPlaneProviders []string
+
+	// InfrastructureProviders and versions (e.g. aws:v0.5.0) to add to the bundle.
+	InfrastructureProviders []string
+
+	// Flavors of the cluster templates to add to the bundle for the infrastructure providers, in addition to
+	// the default cluster template.
+	Flavors []string
+
+	// SkipCertManager excludes the cert-manager components from the bundle.
+	SkipCertManager bool
+}
+
+// MirrorBundle describes the content of a mirror bundle.
+type MirrorBundle struct {
+	// Directory where the bundle is stored.
+	Directory string
+
+	// Providers stored in the bundle.
+	Providers []MirroredProvider
+
+	// CertManager is the cert-manager version stored in the bundle, if any.
+	CertManager *MirroredProvider
+
+	// Images required by the components stored in the bundle.
+	Images []MirroredImage
+}
+
+// MirroredProvider describes a provider stored in a mirror bundle.
+type MirroredProvider struct {
+	// Name of the provider.
+	Name string
+
+	// Type of the provider; empty for cert-manager.
+	Type clusterctlv1.ProviderType
+
+	// Version of the provider.
+	Version string
+
+	// URL of the components YAML file in the bundle, to be used in the clusterctl configuration file.
+	URL string
+
+	// Files stored in the bundle for this provider, relative to the provider version folder.
+	Files []string
+}
+
+// MirroredImage describes an image required by the components stored in a mirror bundle.
+type MirroredImage struct {
+	// Source is the image as defined in the components YAML file.
+	Source string `json:"source"`
+
+	// Target is the image after applying the image overrides defined in the clusterctl configuration file.
+	Target string `json:"target"`
+}
+
+// SourceImages returns the list of images to be pulled for building the bundle.
+func (b *MirrorBundle) SourceImages() []string {
+	images := make([]string, 0, len(b.Images))
+	for _, i := range b.Images {
+		images = append(images, i.Source)
+	}
+	return images
+}
+
+// ClusterctlConfig returns a clusterctl configuration file that uses the providers and the cert-manager stored in the bundle.
+func (b *MirrorBundle) ClusterctlConfig() ([]byte, error) {
+	type providerConfig struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+		Type string `json:"type"`
+	}
+	type certManagerConfig struct {
+		URL     string `json:"url"`
+		Version string `json:"version"`
+	}
+
+	c := struct {
+		Providers   []providerConfig   `json:"providers"`
+		CertManager *certManagerConfig `json:"cert-manager,omitempty"`
+	}{}
+	for _, p := range b.Providers {
+		c.Providers = append(c.Providers, providerConfig{Name: p.Name, URL: p.URL, Type: string(p.Type)})
+	}
+	if b.CertManager != nil {
+		c.CertManager = &certManagerConfig{URL: b.CertManager.URL, Version: b.CertManager.Version}
+	}
+
+	out, err := sigsyaml.Marshal(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate the clusterctl configuration file")
+	}
+	return out, nil
+}
+
+// Mirror downloads the providers components, metadata and cluster templates, as well as the cert-manager components, into
+// a bundle that can be used as a local repository in air-gapped environments, and lists the required images.
+func (c *clusterctlClient) Mirror(options MirrorOptions) (*MirrorBundle, error) {
+	log := logf.Log
+
+	if options.Directory == "" {
+		return nil, errors.New("invalid arguments: please provide a directory for the mirror bundle")
+	}
+	directory, err := filepath.Abs(options.Directory)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the absolute path for %s", options.Directory)
+	}
+
+	// Mirror the same default providers added by init on an empty management cluster.
+	if options.CoreProvider == "" {
+		options.CoreProvider = config.ClusterAPIProviderName
+	}
+	if len(options.BootstrapProviders) == 0 {
+		options.BootstrapProviders = append(options.BootstrapProviders, config.KubeadmBootstrapProviderName)
+	}
+	if len(options.ControlPlaneProviders) == 0 {
+		options.ControlPlaneProviders = append(options.ControlPlaneProviders, config.KubeadmControlPlaneProviderName)
+	}
+
+	bundle := &MirrorBundle{Directory: directory}
+	images := map[string]MirroredImage{}
+
+	providers := []struct {
+		providerType clusterctlv1.ProviderType
+		names        []string
+	}{
+		{clusterctlv1.CoreProviderType, []string{options.CoreProvider}},
+		{clusterctlv1.BootstrapProviderType, options.BootstrapProviders},
+		{clusterctlv1.ControlPlaneProviderType, options.ControlPlaneProviders},
+		{clusterctlv1.InfrastructureProviderType, options.InfrastructureProviders},
+	}
+	for _, p := range providers {
+		for _, provider := range p.names {
+			// It is possible to opt-out from the default bootstrap/control-plane providers using '-' as a provider name (NoopProvider).
+			if provider == NoopProvider {
+				if p.providerType == clusterctlv1.CoreProviderType {
+					return nil, errors.New("the '-' value can not be used for the core provider")
+				}
+				continue
+			}
+
+			mirrored, err := c.mirrorProvider(directory, provider, p.providerType, options.Flavors, images)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to mirror the %q provider", provider)
+			}
+			log.Info("Mirrored provider", "Provider", mirrored.Name, "Type", mirrored.Type, "Version", mirrored.Version)
+			bundle.Providers = append(bundle.Providers, *mirrored)
+		}
+	}
+
+	if !options.SkipCertManager {
+		mirrored, err := c.mirrorCertManager(directory, images)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to mirror cert-manager")
+		}
+		log.Info("Mirrored cert-manager", "Version", mirrored.Version)
+		bundle.CertManager = mirrored
+	}
+
+	for _, i := range images {
+		bundle.Images = append(bundle.Images, i)
+	}
+	sort.Slice(bundle.Images, func(i, j int) bool {
+		return bundle.Images[i].Source < bundle.Images[j].Source
+	})
+
+	imagesYaml, err := sigsyaml.Marshal(bundle.Images)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate the list of images")
+	}
+	if err := writeMirrorFile(directory, mirrorImagesFile, imagesYaml); err != nil {
+		return nil, err
+	}
+
+	return bundle, nil
+}
+
+// mirrorProvider stores the components, the metadata and the cluster templates of a provider into the bundle.
+func (c *clusterctlClient) mirrorProvider(directory, provider string, providerType clusterctlv1.ProviderType, flavors []string, images map[string]MirroredImage) (*MirroredProvider, error) {
+	log := logf.Log
+
+	// Parse the abbreviated syntax for name[:version]
+	name, version, err := parseProviderName(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	providerConfig, err := c.configClient.Providers().Get(name, providerType)
+	if err != nil {
+		return nil, err
+	}
+
+	repositoryClient, err := c.repositoryClientFactory(RepositoryClientFactoryInput{Provider: providerConfig})
+	if err != nil {
+		return nil, err
+	}
+
+	// If the version is not specified, use the default version for the repository, e.g. latest.
+	if version == "" {
+		version = repositoryClient.DefaultVersion()
+	}
+
+	versionDirectory := filepath.Join(directory, providerConfig.ManifestLabel(), version)
+	mirrored := &MirroredProvider{
+		Name:    providerConfig.Name(),
+		Type:    providerConfig.Type(),
+		Version: version,
+	}
+
+	// Store the components YAML file, without variable substitution, and inspect the images required by the provider.
+	components, err := repositoryClient.Components().Raw(repository.ComponentsOptions{Version: version})
+	if err != nil {
+		return nil, err
+	}
+	if err := inspectMirrorImages(components, func(image string) (string, error) {
+		return c.configClient.ImageMeta().AlterImage(providerConfig.ManifestLabel(), image)
+	}, images); err != nil {
+		return nil, err
+	}
+
+	componentsFile := mirrorComponentsFile(providerConfig.URL())
+	if err := writeMirrorFile(versionDirectory, componentsFile, components); err != nil {
+		return nil, err
+	}
+	mirrored.URL = filepath.Join(versionDirectory, componentsFile)
+	mirrored.Files = append(mirrored.Files, componentsFile)
+
+	// Store the metadata file, required for resolving versions and contracts in the local repository.
+	metadata, err := repositoryClient.Metadata(version).Get()
+	if err != nil {
+		return nil, err
+	}
+	metadata.APIVersion = clusterctlv1.GroupVersion.String()
+	metadata.Kind = "Metadata"
+	metadataYaml, err := sigsyaml.Marshal(metadata)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate the metadata file")
+	}
+	if err := writeMirrorFile(versionDirectory, "metadata.yaml", metadataYaml); err != nil {
+		return nil, err
+	}
+	mirrored.Files = append(mirrored.Files, "metadata.yaml")
+
+	// Store the cluster templates; templates are expected to exist for the infrastructure providers only.
+	if providerType != clusterctlv1.InfrastructureProviderType {
+		return mirrored, nil
+	}
+
+	// NOTE: templates are stored using the naming convention of the default yaml processor.
+	processor := yaml.NewSimpleProcessor()
+	for _, flavor := range append([]string{""}, flavors...) {
+		template, err := repositoryClient.Templates(version).Raw(flavor)
+		if err != nil {
+			// The default cluster template is optional, while explicitly requested flavors must exist.
+			if flavor == "" {
+				log.V(1).Info("Skipping the default cluster template", "Provider", providerConfig.ManifestLabel(), "Version", version, "Reason", err.Error())
+				continue
+			}
+			return nil, err
+		}
+
+		templateFile := processor.GetTemplateName(version, flavor)
+		if err := writeMirrorFile(versionDirectory, templateFile, template); err != nil {
+			return nil, err
+		}
+		mirrored.Files = append(mirrored.Files, templateFile)
+	}
+
+	return mirrored, nil
+}
+
+// mirrorCertManager stores the cert-manager components into the bundle.
+func (c *clusterctlClient) mirrorCertManager(directory string, images map[string]MirroredImage) (*MirroredProvider, error) {
+	certManagerConfig, err := c.configClient.CertManager().Get()
+	if err != nil {
+		return nil, err
+	}
+
+	// Given that cert manager components yaml are stored in a repository like providers components yaml,
+	// we are using the same machinery to retrieve the file by using a fake provider object using
+	// the cert manager repository url.
+	certManagerFakeProvider := config.NewProvider(mirrorCertManagerName, certManagerConfig.URL(), "")
+	repositoryClient, err := c.repositoryClientFactory(RepositoryClientFactoryInput{Provider: certManagerFakeProvider})
+	if err != nil {
+		return nil, err
+	}
+
+	components, err := repositoryClient.Components().Raw(repository.ComponentsOptions{Version: certManagerConfig.Version()})
+	if err != nil {
+		return nil, err
+	}
+	if err := inspectMirrorImages(components, func(image string) (string, error) {
+		return c.configClient.ImageMeta().AlterImage(config.CertManagerImageComponent, image)
+	}, images); err != nil {
+		return nil, err
+	}
+
+	versionDirectory := filepath.Join(directory, mirrorCertManagerName, certManagerConfig.Version())
+	componentsFile := mirrorComponentsFile(certManagerConfig.URL())
+	if err := writeMirrorFile(versionDirectory, componentsFile, components); err != nil {
+		return nil, err
+	}
+
+	return &MirroredProvider{
+		Name:    mirrorCertManagerName,
+		Version: certManagerConfig.Version(),
+		URL:     filepath.Join(versionDirectory, componentsFile),
+		Files:   []string{componentsFile},
+	}, nil
+}
+
+// inspectMirrorImages adds the images required by a components YAML file to the list of images, along with the
+// corresponding image after applying image overrides.
+func inspectMirrorImages(components []byte, alterImageFunc func(image string) (string, error), images map[string]MirroredImage) error {
+	objs, err := utilyaml.ToUnstructured(components)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse yaml")
+	}
+
+	sources, err := util.InspectImages(objs)
+	if err != nil {
+		return errors.Wrap(err, "failed to detect required images")
+	}
+
+	for _, source := range sources {
+		target, err := alterImageFunc(source)
+		if err != nil {
+			return errors.Wrapf(err, "failed to apply image overrides to %s", source)
+		}
+		images[source] = MirroredImage{Source: source, Target: target}
+	}
+	return nil
+}
+
+// mirrorComponentsFile returns the name of the components YAML file, inferred from the provider URL when possible.
+func mirrorComponentsFile(providerURL string) string {
+	name := path.Base(providerURL)
+	if !strings.HasSuffix(name, ".yaml") {
+		return mirrorDefaultComponentsFile
+	}
+	return name
+}
+
+func writeMirrorFile(directory, name string, content []byte) error {
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		return errors.Wrapf(err, "failed to create directory %s", directory)
+	}
+	if err := ioutil.WriteFile(filepath.Join(directory, name), content, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write %s", filepath.Join(directory, name))
+	}
+	return nil
+}
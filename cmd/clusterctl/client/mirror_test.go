@@ -0,0 +1,197 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/repository"
+	"sigs.k8s.io/yaml"
+)
+
+func Test_clusterctlClient_Mirror(t *testing.T) {
+	certManagerComponentsYAML := []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: cert-manager
+  namespace: cert-manager
+spec:
+  template:
+    spec:
+      containers:
+      - image: quay.io/jetstack/cert-manager-controller:v1.4.0
+        name: cert-manager
+`)
+
+	type args struct {
+		options MirrorOptions
+	}
+	tests := []struct {
+		name            string
+		args            args
+		wantProviders   []string
+		wantFiles       []string
+		wantImages      []MirroredImage
+		wantCertManager bool
+		wantErr         bool
+	}{
+		{
+			name: "mirrors default providers, infrastructure provider with templates and cert-manager",
+			args: args{
+				options: MirrorOptions{
+					InfrastructureProviders: []string{"infra:v3.0.0"},
+				},
+			},
+			wantProviders: []string{"cluster-api:v1.0.0", "kubeadm:v2.0.0", "kubeadm:v2.0.0", "infra:v3.0.0"},
+			wantFiles: []string{
+				"cluster-api/v1.0.0/components.yaml",
+				"cluster-api/v1.0.0/metadata.yaml",
+				"bootstrap-kubeadm/v2.0.0/components.yaml",
+				"control-plane-kubeadm/v2.0.0/components.yaml",
+				"infrastructure-infra/v3.0.0/components.yaml",
+				"infrastructure-infra/v3.0.0/metadata.yaml",
+				"infrastructure-infra/v3.0.0/cluster-template.yaml",
+				"cert-manager/v1.4.0/cert-manager.yaml",
+				"images.yaml",
+			},
+			wantImages: []MirroredImage{
+				{
+					Source: "k8s.gcr.io/cluster-api-aws/cluster-api-aws-controller:v0.5.3",
+					Target: "registry.example.com/cluster-api-aws-controller:v0.5.3",
+				},
+				{
+					Source: "quay.io/jetstack/cert-manager-controller:v1.4.0",
+					Target: "registry.example.com/cert-manager-controller:v1.4.0",
+				},
+			},
+			wantCertManager: true,
+		},
+		{
+			name: "mirrors selected providers without cert-manager",
+			args: args{
+				options: MirrorOptions{
+					CoreProvider:          "cluster-api:v1.1.0",
+					BootstrapProviders:    []string{"-"},
+					ControlPlaneProviders: []string{"-"},
+					SkipCertManager:       true,
+				},
+			},
+			wantProviders: []string{"cluster-api:v1.1.0"},
+			wantFiles: []string{
+				"cluster-api/v1.1.0/components.yaml",
+				"cluster-api/v1.1.0/metadata.yaml",
+				"images.yaml",
+			},
+			wantImages:      nil,
+			wantCertManager: false,
+		},
+		{
+			name: "fails if a requested flavor does not exist",
+			args: args{
+				options: MirrorOptions{
+					InfrastructureProviders: []string{"infra:v3.0.0"},
+					Flavors:                 []string{"does-not-exist"},
+					SkipCertManager:         true,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "fails if the noop provider is used for the core provider",
+			args: args{
+				options: MirrorOptions{
+					CoreProvider: "-",
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			dir, err := ioutil.TempDir("", "clusterctl-mirror")
+			g.Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			config1 := fakeConfig(
+				[]config.Provider{capiProviderConfig, bootstrapProviderConfig, controlPlaneProviderConfig, infraProviderConfig},
+				map[string]string{},
+			)
+			config1.fakeReader.WithImageMeta("all", "registry.example.com", "")
+
+			repositories := fakeRepositories(config1, nil)
+			certManagerRepository := newFakeRepository(config.NewProvider("cert-manager", config.CertManagerDefaultURL, ""), config1).
+				WithPaths("root", "components.yaml").
+				WithDefaultVersion(config.CertManagerDefaultVersion).
+				WithFile(config.CertManagerDefaultVersion, "components.yaml", certManagerComponentsYAML)
+			client := fakeClusterCtlClient(config1, append(repositories, certManagerRepository), nil)
+
+			options := tt.args.options
+			options.Directory = dir
+			got, err := client.Mirror(options)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			gotProviders := []string{}
+			for _, p := range got.Providers {
+				gotProviders = append(gotProviders, p.Name+":"+p.Version)
+				g.Expect(p.URL).To(BeAnExistingFile())
+			}
+			g.Expect(gotProviders).To(Equal(tt.wantProviders))
+
+			for _, f := range tt.wantFiles {
+				g.Expect(filepath.Join(dir, f)).To(BeAnExistingFile())
+			}
+			g.Expect(got.Images).To(Equal(tt.wantImages))
+			g.Expect(got.CertManager != nil).To(Equal(tt.wantCertManager))
+
+			// The metadata file can be read by a local repository.
+			metadata := &clusterctlv1.Metadata{}
+			content, err := ioutil.ReadFile(filepath.Join(dir, got.Providers[0].Name, got.Providers[0].Version, "metadata.yaml"))
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(yaml.Unmarshal(content, metadata)).To(Succeed())
+			g.Expect(metadata.Kind).To(Equal("Metadata"))
+			g.Expect(metadata.ReleaseSeries).NotTo(BeEmpty())
+
+			// The clusterctl configuration file points to the bundle.
+			clusterctlConfig, err := got.ClusterctlConfig()
+			g.Expect(err).NotTo(HaveOccurred())
+			for _, p := range got.Providers {
+				g.Expect(string(clusterctlConfig)).To(ContainSubstring(p.URL))
+			}
+			if tt.wantCertManager {
+				g.Expect(string(clusterctlConfig)).To(ContainSubstring("cert-manager:"))
+			}
+
+			// Components files in the bundle are usable by a local repository.
+			for _, p := range got.Providers {
+				_, err := repository.New(config.NewProvider(p.Name, p.URL, p.Type), config1)
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	}
+}
@@ -18,6 +18,9 @@ package client
 
 import (
 	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
 
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
 )
@@ -38,6 +41,21 @@ type MoveOptions struct {
 
 	// DryRun means the move action is a dry run, no real action will be performed
 	DryRun bool
+
+	// Resume means the move action resumes an interrupted move from the last checkpoint.
+	Resume bool
+
+	// Rollback means the move action reverts an interrupted move, deleting the objects already created in the target
+	// management cluster and unpausing the Clusters in the source management cluster.
+	Rollback bool
+
+	// ClusterNames restricts the move action to the Clusters with the given names, in the form name or namespace/name.
+	// Objects shared with Clusters not being moved, e.g. a ClusterResourceSet applied to many Clusters, are copied
+	// to the target management cluster without being deleted from the source management cluster.
+	ClusterNames []string
+
+	// LabelSelector restricts the move action to the Clusters matching the given label selector.
+	LabelSelector string
 }
 
 // BackupOptions holds options supported by backup.
@@ -50,8 +68,8 @@ type BackupOptions struct {
 	// namespace will be used.
 	Namespace string
 
-	// Directory defines the local directory to store the cluster objects
-	Directory string
+	// File defines the path of the backup archive to write the cluster objects to.
+	File string
 }
 
 // RestoreOptions holds options supported by restore.
@@ -60,11 +78,21 @@ type RestoreOptions struct {
 	// default rules for kubeconfig discovery will be used.
 	ToKubeconfig Kubeconfig
 
-	// Directory defines the local directory to restore cluster objects from
-	Directory string
+	// File defines the path of the backup archive to restore cluster objects from.
+	File string
 }
 
 func (c *clusterctlClient) Move(options MoveOptions) error {
+	if options.Resume && options.Rollback {
+		return errors.New("resume and rollback cannot be used together")
+	}
+	if options.DryRun && (options.Resume || options.Rollback) {
+		return errors.New("resume and rollback cannot be used together with dry run")
+	}
+	if (options.Resume || options.Rollback) && (len(options.ClusterNames) > 0 || options.LabelSelector != "") {
+		return errors.New("resume and rollback cannot be used together with a Cluster selection, the selection of the interrupted move is used")
+	}
+
 	// Get the client for interacting with the source management cluster.
 	fromCluster, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.FromKubeconfig})
 	if err != nil {
@@ -109,7 +137,59 @@ func (c *clusterctlClient) Move(options MoveOptions) error {
 		options.Namespace = currentNamespace
 	}
 
-	return fromCluster.ObjectMover().Move(options.Namespace, toCluster, options.DryRun)
+	switch {
+	case options.Resume:
+		return fromCluster.ObjectMover().ResumeMove(options.Namespace, toCluster)
+	case options.Rollback:
+		return fromCluster.ObjectMover().RollbackMove(options.Namespace, toCluster)
+	default:
+		selection := cluster.ClusterSelection{
+			Names:         options.ClusterNames,
+			LabelSelector: options.LabelSelector,
+		}
+		return fromCluster.ObjectMover().Move(options.Namespace, toCluster, options.DryRun, selection)
+	}
+}
+
+func (c *clusterctlClient) PlanMove(options MoveOptions) (*MovePlan, error) {
+	if options.Resume || options.Rollback {
+		return nil, errors.New("resume and rollback cannot be used when planning a move")
+	}
+
+	// Get the client for interacting with the source management cluster.
+	fromCluster, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.FromKubeconfig})
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure this command only runs against management clusters with the current Cluster API contract.
+	if err := fromCluster.ProviderInventory().CheckCAPIContract(); err != nil {
+		return nil, err
+	}
+
+	// Ensures the custom resource definitions required by clusterctl are in place.
+	if err := fromCluster.ProviderInventory().EnsureCustomResourceDefinitions(); err != nil {
+		return nil, err
+	}
+
+	// If the option specifying the Namespace is empty, try to detect it.
+	if options.Namespace == "" {
+		currentNamespace, err := fromCluster.Proxy().CurrentNamespace()
+		if err != nil {
+			return nil, err
+		}
+		options.Namespace = currentNamespace
+	}
+
+	selection := cluster.ClusterSelection{
+		Names:         options.ClusterNames,
+		LabelSelector: options.LabelSelector,
+	}
+	plan, err := fromCluster.ObjectMover().PlanMove(options.Namespace, selection)
+	if err != nil {
+		return nil, err
+	}
+	return (*MovePlan)(plan), nil
 }
 
 func (c *clusterctlClient) Backup(options BackupOptions) error {
@@ -138,11 +218,11 @@ func (c *clusterctlClient) Backup(options BackupOptions) error {
 		options.Namespace = currentNamespace
 	}
 
-	if _, err := os.Stat(options.Directory); os.IsNotExist(err) {
+	if _, err := os.Stat(filepath.Dir(options.File)); os.IsNotExist(err) {
 		return err
 	}
 
-	return fromCluster.ObjectMover().Backup(options.Namespace, options.Directory)
+	return fromCluster.ObjectMover().Backup(options.Namespace, options.File)
 }
 
 func (c *clusterctlClient) Restore(options RestoreOptions) error {
@@ -162,9 +242,9 @@ func (c *clusterctlClient) Restore(options RestoreOptions) error {
 		return err
 	}
 
-	if _, err := os.Stat(options.Directory); os.IsNotExist(err) {
+	if _, err := os.Stat(options.File); os.IsNotExist(err) {
 		return err
 	}
 
-	return toCluster.ObjectMover().Restore(toCluster, options.Directory)
+	return toCluster.ObjectMover().Restore(toCluster, options.File)
 }
@@ -19,6 +19,7 @@ package client
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -54,6 +55,65 @@ func Test_clusterctlClient_Move(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "does not return error when resuming a move",
+			fields: fields{
+				client: fakeClientForMove(), // core v1.0.0 (v1.0.1 available), infra v2.0.0 (v2.0.1 available)
+			},
+			args: args{
+				options: MoveOptions{
+					FromKubeconfig: Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+					ToKubeconfig:   Kubeconfig{Path: "kubeconfig", Context: "worker-context"},
+					Resume:         true,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "returns an error if resume and rollback are used together",
+			fields: fields{
+				client: fakeClientForMove(), // core v1.0.0 (v1.0.1 available), infra v2.0.0 (v2.0.1 available)
+			},
+			args: args{
+				options: MoveOptions{
+					FromKubeconfig: Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+					ToKubeconfig:   Kubeconfig{Path: "kubeconfig", Context: "worker-context"},
+					Resume:         true,
+					Rollback:       true,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "does not return error when moving selected clusters",
+			fields: fields{
+				client: fakeClientForMove(), // core v1.0.0 (v1.0.1 available), infra v2.0.0 (v2.0.1 available)
+			},
+			args: args{
+				options: MoveOptions{
+					FromKubeconfig: Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+					ToKubeconfig:   Kubeconfig{Path: "kubeconfig", Context: "worker-context"},
+					ClusterNames:   []string{"foo"},
+					LabelSelector:  "env=dev",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "returns an error if resume is used together with a cluster selection",
+			fields: fields{
+				client: fakeClientForMove(), // core v1.0.0 (v1.0.1 available), infra v2.0.0 (v2.0.1 available)
+			},
+			args: args{
+				options: MoveOptions{
+					FromKubeconfig: Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+					ToKubeconfig:   Kubeconfig{Path: "kubeconfig", Context: "worker-context"},
+					Resume:         true,
+					ClusterNames:   []string{"foo"},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "returns an error if from cluster client is not found",
 			fields: fields{
@@ -96,6 +156,76 @@ func Test_clusterctlClient_Move(t *testing.T) {
 	}
 }
 
+func Test_clusterctlClient_PlanMove(t *testing.T) {
+	type fields struct {
+		client *fakeClient
+	}
+	// These tests are checking the PlanMove scaffolding
+	// The internal library handles the plan logic and tests can be found there
+	type args struct {
+		options MoveOptions
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "does not return error if cluster client is found",
+			fields: fields{
+				client: fakeClientForMove(), // core v1.0.0 (v1.0.1 available), infra v2.0.0 (v2.0.1 available)
+			},
+			args: args{
+				options: MoveOptions{
+					FromKubeconfig: Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+					DryRun:         true,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "returns an error if resume is requested",
+			fields: fields{
+				client: fakeClientForMove(), // core v1.0.0 (v1.0.1 available), infra v2.0.0 (v2.0.1 available)
+			},
+			args: args{
+				options: MoveOptions{
+					FromKubeconfig: Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+					Resume:         true,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "returns an error if from cluster client is not found",
+			fields: fields{
+				client: fakeClientForMove(), // core v1.0.0 (v1.0.1 available), infra v2.0.0 (v2.0.1 available)
+			},
+			args: args{
+				options: MoveOptions{
+					FromKubeconfig: Kubeconfig{Path: "kubeconfig", Context: "does-not-exist"},
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			plan, err := tt.fields.client.PlanMove(tt.args.options)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(plan).NotTo(BeNil())
+		})
+	}
+}
+
 func Test_clusterctlClient_Backup(t *testing.T) {
 	dir, err := ioutil.TempDir("/tmp", "cluster-api")
 	if err != nil {
@@ -125,7 +255,7 @@ func Test_clusterctlClient_Backup(t *testing.T) {
 			args: args{
 				options: BackupOptions{
 					FromKubeconfig: Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
-					Directory:      dir,
+					File:           filepath.Join(dir, "backup.tar.gz"),
 				},
 			},
 			wantErr: false,
@@ -138,7 +268,7 @@ func Test_clusterctlClient_Backup(t *testing.T) {
 			args: args{
 				options: BackupOptions{
 					FromKubeconfig: Kubeconfig{Path: "kubeconfig", Context: "does-not-exist"},
-					Directory:      dir,
+					File:           filepath.Join(dir, "backup.tar.gz"),
 				},
 			},
 			wantErr: true,
@@ -166,6 +296,11 @@ func Test_clusterctlClient_Restore(t *testing.T) {
 	}
 	defer os.RemoveAll(dir)
 
+	file := filepath.Join(dir, "backup.tar.gz")
+	if err := ioutil.WriteFile(file, nil, 0600); err != nil {
+		t.Error(err)
+	}
+
 	type fields struct {
 		client *fakeClient
 	}
@@ -188,7 +323,7 @@ func Test_clusterctlClient_Restore(t *testing.T) {
 			args: args{
 				options: RestoreOptions{
 					ToKubeconfig: Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
-					Directory:    dir,
+					File:         file,
 				},
 			},
 			wantErr: false,
@@ -201,7 +336,7 @@ func Test_clusterctlClient_Restore(t *testing.T) {
 			args: args{
 				options: RestoreOptions{
 					ToKubeconfig: Kubeconfig{Path: "kubeconfig", Context: "does-not-exist"},
-					Directory:    dir,
+					File:         file,
 				},
 			},
 			wantErr: true,
@@ -255,14 +390,26 @@ type fakeObjectMover struct {
 	restoerErr error
 }
 
-func (f *fakeObjectMover) Move(namespace string, toCluster cluster.Client, dryRun bool) error {
+func (f *fakeObjectMover) Move(namespace string, toCluster cluster.Client, dryRun bool, selection cluster.ClusterSelection) error {
+	return f.moveErr
+}
+
+func (f *fakeObjectMover) PlanMove(namespace string, selection cluster.ClusterSelection) (*cluster.MovePlan, error) {
+	return &cluster.MovePlan{}, f.moveErr
+}
+
+func (f *fakeObjectMover) ResumeMove(namespace string, toCluster cluster.Client) error {
+	return f.moveErr
+}
+
+func (f *fakeObjectMover) RollbackMove(namespace string, toCluster cluster.Client) error {
 	return f.moveErr
 }
 
-func (f *fakeObjectMover) Backup(namespace string, directory string) error {
+func (f *fakeObjectMover) Backup(namespace string, file string) error {
 	return f.backupErr
 }
 
-func (f *fakeObjectMover) Restore(toCluster cluster.Client, directory string) error {
+func (f *fakeObjectMover) Restore(toCluster cluster.Client, file string) error {
 	return f.restoerErr
 }
@@ -18,6 +18,7 @@ package repository
 
 import (
 	"net/url"
+	"strings"
 
 	"github.com/pkg/errors"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
@@ -31,6 +32,9 @@ import (
 type Client interface {
 	config.Provider
 
+	// DefaultVersion returns the default provider version returned by the repository.
+	DefaultVersion() string
+
 	// GetVersion return the list of versions that are available in a provider repository
 	GetVersions() ([]string, error)
 
@@ -56,6 +60,10 @@ type repositoryClient struct {
 // ensure repositoryClient implements Client.
 var _ Client = &repositoryClient{}
 
+func (c *repositoryClient) DefaultVersion() string {
+	return c.repository.DefaultVersion()
+}
+
 func (c *repositoryClient) GetVersions() ([]string, error) {
 	return c.repository.GetVersions()
 }
@@ -164,6 +172,33 @@ func repositoryFactory(providerConfig config.Provider, configVariablesClient con
 		return repo, err
 	}
 
+	// if the url is a GitLab generic package registry
+	if rURL.Scheme == httpsScheme && isGitLabRepositoryPath(strings.Split(strings.TrimPrefix(rURL.EscapedPath(), "/"), "/")) {
+		repo, err := newGitLabRepository(providerConfig, configVariablesClient)
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating the GitLab repository client")
+		}
+		return repo, err
+	}
+
+	// if the url is any other HTTPS server
+	if rURL.Scheme == httpsScheme {
+		repo, err := newHTTPRepository(providerConfig, configVariablesClient)
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating the HTTPS repository client")
+		}
+		return repo, err
+	}
+
+	// if the url is an OCI registry
+	if rURL.Scheme == ociScheme {
+		repo, err := newOCIRepository(providerConfig, configVariablesClient)
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating the OCI repository client")
+		}
+		return repo, err
+	}
+
 	// if the url is a local filesystem repository
 	if rURL.Scheme == "file" || rURL.Scheme == "" {
 		repo, err := newLocalRepository(providerConfig, configVariablesClient)
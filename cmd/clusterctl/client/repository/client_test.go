@@ -73,6 +73,49 @@ func Test_newRepositoryClient_LocalFileSystemRepository(t *testing.T) {
 	}
 }
 
+func Test_repositoryFactory(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider config.Provider
+		want     Repository
+		wantErr  bool
+	}{
+		{
+			name:     "GitLab generic package registry",
+			provider: config.NewProvider("foo", "https://gitlab.example.com/api/v4/projects/group%2Fproject/packages/generic/infrastructure-foo/v1.0.0/infrastructure-components.yaml", clusterctlv1.InfrastructureProviderType),
+			want:     &gitLabRepository{},
+		},
+		{
+			name:     "HTTPS server",
+			provider: config.NewProvider("foo", "https://example.com/repo/infrastructure-foo/v1.0.0/infrastructure-components.yaml", clusterctlv1.InfrastructureProviderType),
+			want:     &httpRepository{},
+		},
+		{
+			name:     "OCI registry",
+			provider: config.NewProvider("foo", "oci://registry.example.com/infrastructure-foo:v1.0.0", clusterctlv1.InfrastructureProviderType),
+			want:     &ociRepository{},
+		},
+		{
+			name:     "unsupported scheme",
+			provider: config.NewProvider("foo", "ftp://example.com/repo/infrastructure-foo/v1.0.0/infrastructure-components.yaml", clusterctlv1.InfrastructureProviderType),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := repositoryFactory(tt.provider, test.NewFakeVariableClient())
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(BeAssignableToTypeOf(tt.want))
+		})
+	}
+}
+
 func Test_newRepositoryClient_YamlProcessor(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -0,0 +1,233 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+)
+
+const (
+	gitLabAPIPrefix            = "api/v4/projects"
+	gitLabPackagesGenericPath  = "packages/generic"
+	gitLabTokenHeader          = "PRIVATE-TOKEN"
+	gitLabNextPageHeader       = "X-Next-Page"
+	gitLabPackagesPageSize     = 100
+	gitLabPackageTypeGeneric   = "generic"
+	gitLabRepositoryURLPattern = "https://{host}/api/v4/projects/{projectSlug}/packages/generic/{packageName}/{version}/{components.yaml}"
+)
+
+// gitLabRepository provides support for providers hosted in the generic package registry of a GitLab project.
+//
+// Each provider version must be published as a version of a generic package containing the metadata.yaml file as well
+// as the components YAML file and the cluster templates, if any; the repository URL must be in the form
+// https://{host}/api/v4/projects/{projectSlug}/packages/generic/{packageName}/{version}/{components.yaml}, where
+// {projectSlug} is the URL encoded path of the project (e.g. group%2Fproject) or the project ID.
+//
+// The GitLab access token, if any, is read from the gitlab-access-token variable.
+type gitLabRepository struct {
+	providerConfig        config.Provider
+	configVariablesClient config.VariablesClient
+	httpClient            *http.Client
+	host                  string
+	projectSlug           string
+	packageName           string
+	defaultVersion        string
+	componentsPath        string
+	token                 string
+}
+
+var _ Repository = &gitLabRepository{}
+
+type gitLabRepositoryOption func(*gitLabRepository)
+
+func injectGitLabHTTPClient(c *http.Client) gitLabRepositoryOption {
+	return func(g *gitLabRepository) {
+		g.httpClient = c
+	}
+}
+
+// DefaultVersion returns defaultVersion field of gitLabRepository struct.
+func (g *gitLabRepository) DefaultVersion() string {
+	return g.defaultVersion
+}
+
+// RootPath returns the empty string as it is not applicable to GitLab repositories.
+func (g *gitLabRepository) RootPath() string {
+	return ""
+}
+
+// ComponentsPath returns componentsPath field of gitLabRepository struct.
+func (g *gitLabRepository) ComponentsPath() string {
+	return g.componentsPath
+}
+
+// GetFile returns a file for a given provider version.
+func (g *gitLabRepository) GetFile(version, fileName string) ([]byte, error) {
+	if version == "" {
+		version = g.defaultVersion
+	}
+
+	fileURL := fmt.Sprintf("https://%s/%s/%s/%s/%s/%s/%s", g.host, gitLabAPIPrefix, g.projectSlug, gitLabPackagesGenericPath, url.PathEscape(g.packageName), url.PathEscape(version), strings.TrimPrefix(fileName, "/"))
+	if content, ok := cacheFiles[fileURL]; ok {
+		return content, nil
+	}
+
+	content, err := httpGet(g.httpClient, fileURL, g.header())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download file %q from GitLab package %s version %s", fileName, g.packageName, version)
+	}
+
+	cacheFiles[fileURL] = content
+	return content, nil
+}
+
+// GetVersions returns the list of versions of the generic package in the GitLab project.
+func (g *gitLabRepository) GetVersions() ([]string, error) {
+	cacheID := fmt.Sprintf("%s/%s/%s", g.host, g.projectSlug, g.packageName)
+	if versions, ok := cacheVersions[cacheID]; ok {
+		return versions, nil
+	}
+
+	versions := []string{}
+	page := "1"
+	for page != "" {
+		query := url.Values{}
+		query.Set("package_type", gitLabPackageTypeGeneric)
+		query.Set("package_name", g.packageName)
+		query.Set("per_page", fmt.Sprintf("%d", gitLabPackagesPageSize))
+		query.Set("page", page)
+		packagesURL := fmt.Sprintf("https://%s/%s/%s/packages?%s", g.host, gitLabAPIPrefix, g.projectSlug, query.Encode())
+
+		content, header, err := httpGetWithHeaders(g.httpClient, packagesURL, g.header())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the list of versions of GitLab package %s", g.packageName)
+		}
+
+		packages := []struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		}{}
+		if err := json.Unmarshal(content, &packages); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the list of versions of GitLab package %s", g.packageName)
+		}
+
+		for _, p := range packages {
+			// NB. GitLab matches package names by prefix, so it is required to filter on the exact name.
+			if p.Name != g.packageName {
+				continue
+			}
+			if _, err := version.ParseSemantic(p.Version); err != nil {
+				// Discard versions that are not a valid semantic versions (the user can point explicitly to such versions).
+				continue
+			}
+			versions = append(versions, p.Version)
+		}
+
+		page = header.Get(gitLabNextPageHeader)
+	}
+
+	cacheVersions[cacheID] = versions
+	return versions, nil
+}
+
+// header returns the headers to be added to each request to the GitLab API.
+func (g *gitLabRepository) header() http.Header {
+	header := http.Header{}
+	if g.token != "" {
+		header.Set(gitLabTokenHeader, g.token)
+	}
+	return header
+}
+
+// newGitLabRepository returns a gitLabRepository implementation.
+func newGitLabRepository(providerConfig config.Provider, configVariablesClient config.VariablesClient, opts ...gitLabRepositoryOption) (*gitLabRepository, error) {
+	if configVariablesClient == nil {
+		return nil, errors.New("invalid arguments: configVariablesClient can't be nil")
+	}
+
+	rURL, err := url.Parse(providerConfig.URL())
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid url")
+	}
+
+	if rURL.Scheme != httpsScheme {
+		return nil, errors.Errorf("invalid url: a GitLab repository url should be in the form %s", gitLabRepositoryURLPattern)
+	}
+
+	// NB. the escaped path is used because the project slug is URL encoded, and it could contain an encoded slash.
+	urlSplit := strings.Split(strings.TrimPrefix(rURL.EscapedPath(), "/"), "/")
+	if !isGitLabRepositoryPath(urlSplit) {
+		return nil, errors.Errorf("invalid url: a GitLab repository url should be in the form %s", gitLabRepositoryURLPattern)
+	}
+
+	packageName, err := url.PathUnescape(urlSplit[6])
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid url: invalid package name")
+	}
+	defaultVersion, err := url.PathUnescape(urlSplit[7])
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid url: invalid version")
+	}
+	componentsPath := strings.Join(urlSplit[8:], "/")
+
+	repo := &gitLabRepository{
+		providerConfig:        providerConfig,
+		configVariablesClient: configVariablesClient,
+		httpClient:            newHTTPClient(),
+		host:                  rURL.Host,
+		projectSlug:           urlSplit[3],
+		packageName:           packageName,
+		defaultVersion:        defaultVersion,
+		componentsPath:        componentsPath,
+	}
+
+	// process gitLabRepositoryOptions
+	for _, o := range opts {
+		o(repo)
+	}
+
+	if token, err := configVariablesClient.Get(config.GitLabAccessTokenVariable); err == nil {
+		repo.token = token
+	}
+
+	if defaultVersion == latestVersionTag {
+		repo.defaultVersion, err = latestContractRelease(repo, clusterv1.GroupVersion.Version)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get GitLab latest version")
+		}
+	}
+
+	return repo, nil
+}
+
+// isGitLabRepositoryPath returns true if the path of a repository url matches the path of a GitLab generic package, i.e.
+// api/v4/projects/{projectSlug}/packages/generic/{packageName}/{version}/{components.yaml}.
+func isGitLabRepositoryPath(urlSplit []string) bool {
+	return len(urlSplit) >= 9 &&
+		strings.Join(urlSplit[0:3], "/") == gitLabAPIPrefix &&
+		strings.Join(urlSplit[4:6], "/") == gitLabPackagesGenericPath &&
+		urlSplit[3] != "" && urlSplit[6] != "" && urlSplit[7] != "" && urlSplit[len(urlSplit)-1] != ""
+}
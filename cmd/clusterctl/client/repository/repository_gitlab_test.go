@@ -0,0 +1,137 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+)
+
+func Test_gitLabRepository_newGitLabRepository(t *testing.T) {
+	tests := []struct {
+		name               string
+		url                string
+		wantHost           string
+		wantProjectSlug    string
+		wantPackageName    string
+		wantDefaultVersion string
+		wantComponentsPath string
+		wantErr            bool
+	}{
+		{
+			name:               "can create a new GitLab repository",
+			url:                "https://gitlab.example.com/api/v4/projects/group%2Fproject/packages/generic/infrastructure-foo/v1.0.0/infrastructure-components.yaml",
+			wantHost:           "gitlab.example.com",
+			wantProjectSlug:    "group%2Fproject",
+			wantPackageName:    "infrastructure-foo",
+			wantDefaultVersion: "v1.0.0",
+			wantComponentsPath: "infrastructure-components.yaml",
+		},
+		{
+			name:               "can create a new GitLab repository with a project ID",
+			url:                "https://gitlab.example.com/api/v4/projects/42/packages/generic/infrastructure-foo/v1.0.0/infrastructure-components.yaml",
+			wantHost:           "gitlab.example.com",
+			wantProjectSlug:    "42",
+			wantPackageName:    "infrastructure-foo",
+			wantDefaultVersion: "v1.0.0",
+			wantComponentsPath: "infrastructure-components.yaml",
+		},
+		{
+			name:    "fails if the url is not a generic package",
+			url:     "https://gitlab.example.com/api/v4/projects/42/packages/maven/infrastructure-foo/v1.0.0/infrastructure-components.yaml",
+			wantErr: true,
+		},
+		{
+			name:    "fails if the components file is missing",
+			url:     "https://gitlab.example.com/api/v4/projects/42/packages/generic/infrastructure-foo/v1.0.0",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := newGitLabRepository(config.NewProvider("foo", tt.url, clusterctlv1.InfrastructureProviderType), test.NewFakeVariableClient())
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got.host).To(Equal(tt.wantHost))
+			g.Expect(got.projectSlug).To(Equal(tt.wantProjectSlug))
+			g.Expect(got.packageName).To(Equal(tt.wantPackageName))
+			g.Expect(got.DefaultVersion()).To(Equal(tt.wantDefaultVersion))
+			g.Expect(got.ComponentsPath()).To(Equal(tt.wantComponentsPath))
+		})
+	}
+}
+
+func Test_gitLabRepository_latest(t *testing.T) {
+	g := NewWithT(t)
+
+	mux := http.NewServeMux()
+	// NB. the mux matches the decoded path, so the project slug group%2Fproject is matched as group/project.
+	mux.HandleFunc("/api/v4/projects/group/project/packages", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(gitLabTokenHeader) != "my-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("package_type") != "generic" || r.URL.Query().Get("package_name") != "infrastructure-foo" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		// Return two pages of results, including packages with a similar name.
+		if r.URL.Query().Get("page") == "1" {
+			w.Header().Set(gitLabNextPageHeader, "2")
+			fmt.Fprint(w, `[{"name":"infrastructure-foo","version":"v1.0.0"},{"name":"infrastructure-foobar","version":"v9.0.0"}]`)
+			return
+		}
+		fmt.Fprint(w, `[{"name":"infrastructure-foo","version":"v1.1.0"},{"name":"infrastructure-foo","version":"foo"}]`)
+	})
+	mux.HandleFunc("/api/v4/projects/group/project/packages/generic/infrastructure-foo/v1.1.0/infrastructure-components.yaml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "components")
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	repoURL := fmt.Sprintf("https://%s/api/v4/projects/group%%2Fproject/packages/generic/infrastructure-foo/latest/infrastructure-components.yaml", strings.TrimPrefix(server.URL, "https://"))
+	repo, err := newGitLabRepository(
+		config.NewProvider("foo", repoURL, clusterctlv1.InfrastructureProviderType),
+		test.NewFakeVariableClient().WithVar(config.GitLabAccessTokenVariable, "my-token"),
+		injectGitLabHTTPClient(server.Client()),
+	)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	versions, err := repo.GetVersions()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(versions).To(ConsistOf("v1.0.0", "v1.1.0"))
+
+	g.Expect(repo.DefaultVersion()).To(Equal("v1.1.0"))
+
+	content, err := repo.GetFile("", repo.ComponentsPath())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(Equal("components"))
+}
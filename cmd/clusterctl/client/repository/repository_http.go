@@ -0,0 +1,261 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// httpVersionsFile is the name of the file listing the versions available in a HTTPS repository.
+	httpVersionsFile = "versions.yaml"
+
+	// httpTimeout is the timeout used for all the requests to HTTPS, GitLab and OCI repositories.
+	httpTimeout = 30 * time.Second
+
+	// maxHTTPResponseSize is the max size of a file downloaded from HTTPS, GitLab and OCI repositories.
+	maxHTTPResponseSize = 100 * 1024 * 1024
+)
+
+// httpRepository provides support for providers hosted on a plain HTTPS server.
+//
+// Files must be served according to the following layout:
+// https://{host}/{basepath}/{version}/{components.yaml}
+//
+// (1): {version} must obey the syntax and semantics of the "Semantic Versioning"
+// specification (http://semver.org/); however, "latest" is also an acceptable value
+// if the server hosts the list of versions.
+// (2): the list of versions available can be served as a YAML list in https://{host}/{basepath}/versions.yaml;
+// if this file is not available, only the version in the repository URL can be used.
+//
+// Each version must contain the metadata.yaml file as well as the components YAML file and the cluster templates, if any.
+type httpRepository struct {
+	providerConfig        config.Provider
+	configVariablesClient config.VariablesClient
+	httpClient            *http.Client
+	baseURL               string
+	defaultVersion        string
+	componentsPath        string
+}
+
+var _ Repository = &httpRepository{}
+
+type httpRepositoryOption func(*httpRepository)
+
+func injectHTTPClient(c *http.Client) httpRepositoryOption {
+	return func(r *httpRepository) {
+		r.httpClient = c
+	}
+}
+
+// DefaultVersion returns defaultVersion field of httpRepository struct.
+func (r *httpRepository) DefaultVersion() string {
+	return r.defaultVersion
+}
+
+// RootPath returns the empty string as it is not applicable to HTTPS repositories.
+func (r *httpRepository) RootPath() string {
+	return ""
+}
+
+// ComponentsPath returns componentsPath field of httpRepository struct.
+func (r *httpRepository) ComponentsPath() string {
+	return r.componentsPath
+}
+
+// GetFile returns a file for a given provider version.
+func (r *httpRepository) GetFile(version, fileName string) ([]byte, error) {
+	if version == "" {
+		version = r.defaultVersion
+	}
+
+	fileURL := fmt.Sprintf("%s/%s/%s", r.baseURL, version, strings.TrimPrefix(fileName, "/"))
+	if content, ok := cacheFiles[fileURL]; ok {
+		return content, nil
+	}
+
+	content, err := httpGet(r.httpClient, fileURL, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download file %q for version %s", fileName, version)
+	}
+
+	cacheFiles[fileURL] = content
+	return content, nil
+}
+
+// GetVersions returns the list of versions that are available in a provider repository.
+func (r *httpRepository) GetVersions() ([]string, error) {
+	versionsURL := fmt.Sprintf("%s/%s", r.baseURL, httpVersionsFile)
+	if versions, ok := cacheVersions[versionsURL]; ok {
+		return versions, nil
+	}
+
+	content, err := httpGet(r.httpClient, versionsURL, nil)
+	if err != nil {
+		// If the server does not host the list of versions, only the version in the repository URL is available.
+		if hasHTTPStatus(err, http.StatusNotFound) && r.defaultVersion != latestVersionTag {
+			return []string{r.defaultVersion}, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get repository versions")
+	}
+
+	allVersions := []string{}
+	if err := yaml.Unmarshal(content, &allVersions); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", versionsURL)
+	}
+
+	versions := []string{}
+	for _, v := range allVersions {
+		if _, err := version.ParseSemantic(v); err != nil {
+			// Discard versions that are not a valid semantic versions (the user can point explicitly to such versions).
+			continue
+		}
+		versions = append(versions, v)
+	}
+
+	cacheVersions[versionsURL] = versions
+	return versions, nil
+}
+
+// newHTTPRepository returns a httpRepository implementation.
+func newHTTPRepository(providerConfig config.Provider, configVariablesClient config.VariablesClient, opts ...httpRepositoryOption) (*httpRepository, error) {
+	if configVariablesClient == nil {
+		return nil, errors.New("invalid arguments: configVariablesClient can't be nil")
+	}
+
+	rURL, err := url.Parse(providerConfig.URL())
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid url")
+	}
+
+	if rURL.Scheme != httpsScheme {
+		return nil, errors.New("invalid url: a HTTPS repository url should start with https://")
+	}
+
+	// Extracts basepath, version and componentsPath from the url
+	// NB. format is https://{host}/{basepath}/{version}/{components.yaml}
+	urlSplit := strings.Split(strings.TrimPrefix(rURL.Path, "/"), "/")
+	if len(urlSplit) < 2 || urlSplit[len(urlSplit)-1] == "" {
+		return nil, errors.New("invalid url: a HTTPS repository url should be in the form https://{host}/{basepath}/{version}/{components.yaml}")
+	}
+
+	componentsPath := urlSplit[len(urlSplit)-1]
+	defaultVersion := urlSplit[len(urlSplit)-2]
+	if defaultVersion != latestVersionTag {
+		if _, err := version.ParseSemantic(defaultVersion); err != nil {
+			return nil, errors.Errorf("invalid version: %q. Version must obey the syntax and semantics of the \"Semantic Versioning\" specification (http://semver.org/) and url format https://{host}/{basepath}/{version}/{components.yaml}", defaultVersion)
+		}
+	}
+
+	baseURL := url.URL{
+		Scheme: rURL.Scheme,
+		Host:   rURL.Host,
+		Path:   path.Join("/", path.Join(urlSplit[:len(urlSplit)-2]...)),
+	}
+
+	repo := &httpRepository{
+		providerConfig:        providerConfig,
+		configVariablesClient: configVariablesClient,
+		httpClient:            newHTTPClient(),
+		baseURL:               strings.TrimSuffix(baseURL.String(), "/"),
+		defaultVersion:        defaultVersion,
+		componentsPath:        componentsPath,
+	}
+
+	// process httpRepositoryOptions
+	for _, o := range opts {
+		o(repo)
+	}
+
+	if defaultVersion == latestVersionTag {
+		repo.defaultVersion, err = latestContractRelease(repo, clusterv1.GroupVersion.Version)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get latest version")
+		}
+	}
+
+	return repo, nil
+}
+
+// newHTTPClient returns the http client used for HTTPS, GitLab and OCI repositories.
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: httpTimeout,
+	}
+}
+
+// httpStatusError is returned when a HTTP request completes with an unexpected status code.
+type httpStatusError struct {
+	url        string
+	statusCode int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("request to %s failed with status %d (%s)", e.url, e.statusCode, http.StatusText(e.statusCode))
+}
+
+// hasHTTPStatus returns true if the error is caused by a HTTP request completed with the given status code.
+func hasHTTPStatus(err error, statusCode int) bool {
+	var statusErr *httpStatusError
+	return errors.As(err, &statusErr) && statusErr.statusCode == statusCode
+}
+
+// httpGet performs a GET request and returns the response body; an error is returned if the response status is not 200.
+func httpGet(client *http.Client, rawURL string, header http.Header) ([]byte, error) {
+	content, _, err := httpGetWithHeaders(client, rawURL, header)
+	return content, err
+}
+
+// httpGetWithHeaders performs a GET request and returns the response body and headers; an error is returned if the
+// response status is not 200.
+func httpGetWithHeaders(client *http.Client, rawURL string, header http.Header) ([]byte, http.Header, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to create request to %s", rawURL)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to send request to %s", rawURL)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.Header, &httpStatusError{url: rawURL, statusCode: resp.StatusCode}
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponseSize))
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to read response from %s", rawURL)
+	}
+	return content, resp.Header, nil
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+)
+
+func Test_httpRepository_newHTTPRepository(t *testing.T) {
+	tests := []struct {
+		name               string
+		url                string
+		wantBaseURL        string
+		wantDefaultVersion string
+		wantComponentsPath string
+		wantErr            bool
+	}{
+		{
+			name:               "can create a new HTTPS repository",
+			url:                "https://example.com/repo/infrastructure-foo/v1.0.0/infrastructure-components.yaml",
+			wantBaseURL:        "https://example.com/repo/infrastructure-foo",
+			wantDefaultVersion: "v1.0.0",
+			wantComponentsPath: "infrastructure-components.yaml",
+		},
+		{
+			name:               "can create a new HTTPS repository without basepath",
+			url:                "https://example.com/v1.0.0/infrastructure-components.yaml",
+			wantBaseURL:        "https://example.com",
+			wantDefaultVersion: "v1.0.0",
+			wantComponentsPath: "infrastructure-components.yaml",
+		},
+		{
+			name:    "fails if the scheme is not https",
+			url:     "http://example.com/repo/v1.0.0/infrastructure-components.yaml",
+			wantErr: true,
+		},
+		{
+			name:    "fails if the version is not valid",
+			url:     "https://example.com/repo/foo/infrastructure-components.yaml",
+			wantErr: true,
+		},
+		{
+			name:    "fails if the components file is missing",
+			url:     "https://example.com/repo/v1.0.0/",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := newHTTPRepository(config.NewProvider("foo", tt.url, clusterctlv1.InfrastructureProviderType), test.NewFakeVariableClient())
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got.baseURL).To(Equal(tt.wantBaseURL))
+			g.Expect(got.DefaultVersion()).To(Equal(tt.wantDefaultVersion))
+			g.Expect(got.ComponentsPath()).To(Equal(tt.wantComponentsPath))
+			g.Expect(got.RootPath()).To(Equal(""))
+		})
+	}
+}
+
+func Test_httpRepository_latest(t *testing.T) {
+	g := NewWithT(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repo/versions.yaml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "- v1.0.0\n- v1.1.0\n- v1.2.0-beta.0\n- foo\n")
+	})
+	mux.HandleFunc("/repo/v1.1.0/infrastructure-components.yaml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "components")
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	repo, err := newHTTPRepository(
+		config.NewProvider("foo", server.URL+"/repo/latest/infrastructure-components.yaml", clusterctlv1.InfrastructureProviderType),
+		test.NewFakeVariableClient(),
+		injectHTTPClient(server.Client()),
+	)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	versions, err := repo.GetVersions()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(versions).To(ConsistOf("v1.0.0", "v1.1.0", "v1.2.0-beta.0"))
+
+	// latest resolves to the latest release, ignoring pre-releases.
+	g.Expect(repo.DefaultVersion()).To(Equal("v1.1.0"))
+
+	content, err := repo.GetFile("", repo.ComponentsPath())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(Equal("components"))
+
+	_, err = repo.GetFile("v1.0.0", repo.ComponentsPath())
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_httpRepository_GetVersions_withoutIndex(t *testing.T) {
+	g := NewWithT(t)
+
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+
+	repo, err := newHTTPRepository(
+		config.NewProvider("foo", server.URL+"/repo/v1.0.0/infrastructure-components.yaml", clusterctlv1.InfrastructureProviderType),
+		test.NewFakeVariableClient(),
+		injectHTTPClient(server.Client()),
+	)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// If the server does not host the list of versions, only the version in the url is available.
+	versions, err := repo.GetVersions()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(versions).To(ConsistOf("v1.0.0"))
+
+	// latest cannot be used if the server does not host the list of versions.
+	_, err = newHTTPRepository(
+		config.NewProvider("foo", server.URL+"/other/latest/infrastructure-components.yaml", clusterctlv1.InfrastructureProviderType),
+		test.NewFakeVariableClient(),
+		injectHTTPClient(server.Client()),
+	)
+	g.Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,439 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+)
+
+const (
+	ociScheme = "oci"
+
+	ociManifestMediaType       = "application/vnd.oci.image.manifest.v1+json"
+	dockerManifestMediaType    = "application/vnd.docker.distribution.manifest.v2+json"
+	ociTitleAnnotation         = "org.opencontainers.image.title"
+	ociRepositoryURLPattern    = "oci://{registry}/{repository}:{version}[/{components.yaml}]"
+	ociAuthenticateHeader      = "WWW-Authenticate"
+	ociAuthorizationHeader     = "Authorization"
+	ociPullScopeFormat         = "repository:%s:pull"
+	ociDigestAlgorithmSHA256   = "sha256"
+	ociLinkNextRelationPattern = `<([^>]+)>;\s*rel="?next"?`
+)
+
+var ociLinkNextRelationRegex = regexp.MustCompile(ociLinkNextRelationPattern)
+
+// ociRepository provides support for providers published as OCI artifacts in a container registry.
+//
+// Each provider version must be published as an artifact tagged with the version, with one layer for each file,
+// i.e. the metadata.yaml file as well as the components YAML file and the cluster templates, if any; the name of each
+// file must be stored in the org.opencontainers.image.title annotation of the corresponding layer, as done
+// e.g. by oras push. The repository URL must be in the form oci://{registry}/{repository}:{version}[/{components.yaml}];
+// if the components YAML file is not specified, the default name for the provider type is used,
+// e.g. infrastructure-components.yaml.
+//
+// The credentials for the registry, if any, are read from the oci-username and oci-password variables.
+type ociRepository struct {
+	providerConfig        config.Provider
+	configVariablesClient config.VariablesClient
+	httpClient            *http.Client
+	registry              string
+	repository            string
+	defaultVersion        string
+	componentsPath        string
+	username              string
+	password              string
+	authorization         string
+}
+
+var _ Repository = &ociRepository{}
+
+type ociRepositoryOption func(*ociRepository)
+
+func injectOCIHTTPClient(c *http.Client) ociRepositoryOption {
+	return func(r *ociRepository) {
+		r.httpClient = c
+	}
+}
+
+// ociManifest is the subset of an OCI image manifest used by clusterctl.
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+// ociDescriptor is the subset of an OCI content descriptor used by clusterctl.
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// DefaultVersion returns defaultVersion field of ociRepository struct.
+func (r *ociRepository) DefaultVersion() string {
+	return r.defaultVersion
+}
+
+// RootPath returns the empty string as it is not applicable to OCI repositories.
+func (r *ociRepository) RootPath() string {
+	return ""
+}
+
+// ComponentsPath returns componentsPath field of ociRepository struct.
+func (r *ociRepository) ComponentsPath() string {
+	return r.componentsPath
+}
+
+// GetFile returns a file for a given provider version.
+func (r *ociRepository) GetFile(version, fileName string) ([]byte, error) {
+	if version == "" {
+		version = r.defaultVersion
+	}
+	fileName = strings.TrimPrefix(fileName, "/")
+
+	cacheID := fmt.Sprintf("%s/%s:%s:%s", r.registry, r.repository, version, fileName)
+	if content, ok := cacheFiles[cacheID]; ok {
+		return content, nil
+	}
+
+	manifest, err := r.getManifest(version)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the manifest for %s/%s:%s", r.registry, r.repository, version)
+	}
+
+	var layer *ociDescriptor
+	for i := range manifest.Layers {
+		if manifest.Layers[i].Annotations[ociTitleAnnotation] == fileName {
+			layer = &manifest.Layers[i]
+			break
+		}
+	}
+	if layer == nil {
+		return nil, errors.Errorf("failed to get file %q from %s/%s:%s", fileName, r.registry, r.repository, version)
+	}
+
+	content, _, err := r.get(fmt.Sprintf("https://%s/v2/%s/blobs/%s", r.registry, r.repository, layer.Digest), "")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download file %q from %s/%s:%s", fileName, r.registry, r.repository, version)
+	}
+	if err := verifyOCIDigest(layer.Digest, content); err != nil {
+		return nil, errors.Wrapf(err, "failed to verify file %q from %s/%s:%s", fileName, r.registry, r.repository, version)
+	}
+
+	cacheFiles[cacheID] = content
+	return content, nil
+}
+
+// GetVersions returns the list of tags of the OCI repository that are valid semantic versions.
+func (r *ociRepository) GetVersions() ([]string, error) {
+	cacheID := fmt.Sprintf("%s/%s", r.registry, r.repository)
+	if versions, ok := cacheVersions[cacheID]; ok {
+		return versions, nil
+	}
+
+	versions := []string{}
+	tagsURL := fmt.Sprintf("https://%s/v2/%s/tags/list", r.registry, r.repository)
+	for tagsURL != "" {
+		content, header, err := r.get(tagsURL, "")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the list of tags for %s/%s", r.registry, r.repository)
+		}
+
+		tags := struct {
+			Tags []string `json:"tags"`
+		}{}
+		if err := json.Unmarshal(content, &tags); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the list of tags for %s/%s", r.registry, r.repository)
+		}
+
+		for _, tag := range tags.Tags {
+			if _, err := version.ParseSemantic(tag); err != nil {
+				// Discard tags that are not a valid semantic versions (the user can point explicitly to such tags).
+				continue
+			}
+			versions = append(versions, tag)
+		}
+
+		// Follow the link to the next page, if any.
+		tagsURL = ""
+		if m := ociLinkNextRelationRegex.FindStringSubmatch(header.Get("Link")); m != nil {
+			next, err := url.Parse(m[1])
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid link to the next page of tags for %s/%s", r.registry, r.repository)
+			}
+			tagsURL = (&url.URL{Scheme: httpsScheme, Host: r.registry}).ResolveReference(next).String()
+		}
+	}
+
+	cacheVersions[cacheID] = versions
+	return versions, nil
+}
+
+// getManifest returns the manifest of the artifact with the given tag.
+func (r *ociRepository) getManifest(tag string) (*ociManifest, error) {
+	content, _, err := r.get(fmt.Sprintf("https://%s/v2/%s/manifests/%s", r.registry, r.repository, tag), strings.Join([]string{ociManifestMediaType, dockerManifestMediaType}, ", "))
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &ociManifest{}
+	if err := json.Unmarshal(content, manifest); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the manifest")
+	}
+	return manifest, nil
+}
+
+// get performs a GET request to the registry; if the registry requires authentication, the request is
+// authenticated according to the challenge returned by the registry and then retried.
+func (r *ociRepository) get(rawURL string, accept string) ([]byte, http.Header, error) {
+	header := http.Header{}
+	if accept != "" {
+		header.Set("Accept", accept)
+	}
+	if r.authorization != "" {
+		header.Set(ociAuthorizationHeader, r.authorization)
+	}
+
+	content, respHeader, err := httpGetWithHeaders(r.httpClient, rawURL, header)
+	if err == nil || !hasHTTPStatus(err, http.StatusUnauthorized) {
+		return content, respHeader, err
+	}
+
+	if err := r.authenticate(respHeader.Get(ociAuthenticateHeader)); err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to authenticate to %s", r.registry)
+	}
+	header.Set(ociAuthorizationHeader, r.authorization)
+	return httpGetWithHeaders(r.httpClient, rawURL, header)
+}
+
+// authenticate gets the authorization for the registry according to the challenge in the WWW-Authenticate header,
+// supporting both the Basic and the Bearer (token) authentication schemes.
+func (r *ociRepository) authenticate(challenge string) error {
+	scheme, params := parseOCIAuthChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if r.username == "" && r.password == "" {
+			return errors.Errorf("the registry requires credentials; please set the %s and %s variables", config.OCIUsernameVariable, config.OCIPasswordVariable)
+		}
+		r.authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(r.username+":"+r.password))
+		return nil
+	case "bearer":
+		realm, ok := params["realm"]
+		if !ok {
+			return errors.New("the authentication challenge does not define a realm")
+		}
+		tokenURL, err := url.Parse(realm)
+		if err != nil {
+			return errors.Wrapf(err, "invalid realm %q", realm)
+		}
+
+		query := tokenURL.Query()
+		if service, ok := params["service"]; ok {
+			query.Set("service", service)
+		}
+		scope, ok := params["scope"]
+		if !ok {
+			scope = fmt.Sprintf(ociPullScopeFormat, r.repository)
+		}
+		query.Set("scope", scope)
+		tokenURL.RawQuery = query.Encode()
+
+		header := http.Header{}
+		if r.username != "" || r.password != "" {
+			header.Set(ociAuthorizationHeader, "Basic "+base64.StdEncoding.EncodeToString([]byte(r.username+":"+r.password)))
+		}
+		content, err := httpGet(r.httpClient, tokenURL.String(), header)
+		if err != nil {
+			return errors.Wrap(err, "failed to get a token")
+		}
+
+		token := struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}{}
+		if err := json.Unmarshal(content, &token); err != nil {
+			return errors.Wrap(err, "failed to parse the token")
+		}
+		if token.Token == "" {
+			token.Token = token.AccessToken
+		}
+		if token.Token == "" {
+			return errors.New("the token service returned an empty token")
+		}
+		r.authorization = "Bearer " + token.Token
+		return nil
+	default:
+		return errors.Errorf("unsupported authentication scheme %q", scheme)
+	}
+}
+
+// parseOCIAuthChallenge parses a WWW-Authenticate header, e.g. Bearer realm="https://auth.example.com/token",service="registry.example.com".
+func parseOCIAuthChallenge(challenge string) (string, map[string]string) {
+	params := map[string]string{}
+	challenge = strings.TrimSpace(challenge)
+	i := strings.Index(challenge, " ")
+	if i < 0 {
+		return challenge, params
+	}
+	scheme := challenge[:i]
+	rest := challenge[i+1:]
+
+	for rest != "" {
+		rest = strings.TrimLeft(rest, " ,")
+		eq := strings.Index(rest, "=")
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:eq]))
+		rest = rest[eq+1:]
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			end := strings.Index(rest, ",")
+			if end < 0 {
+				value, rest = rest, ""
+			} else {
+				value, rest = rest[:end], rest[end+1:]
+			}
+		}
+		params[key] = value
+	}
+	return scheme, params
+}
+
+// verifyOCIDigest checks that the content matches the digest of the corresponding descriptor.
+func verifyOCIDigest(digest string, content []byte) error {
+	split := strings.SplitN(digest, ":", 2)
+	if len(split) != 2 || split[0] != ociDigestAlgorithmSHA256 {
+		return errors.Errorf("unsupported digest %q", digest)
+	}
+	sum := sha256.Sum256(content)
+	if hex.EncodeToString(sum[:]) != split[1] {
+		return errors.Errorf("digest mismatch, expected %s", digest)
+	}
+	return nil
+}
+
+// defaultComponentsPath returns the default name of the components YAML file for a provider type.
+func defaultComponentsPath(providerType clusterctlv1.ProviderType) string {
+	switch providerType {
+	case clusterctlv1.CoreProviderType:
+		return "core-components.yaml"
+	case clusterctlv1.BootstrapProviderType:
+		return "bootstrap-components.yaml"
+	case clusterctlv1.ControlPlaneProviderType:
+		return "control-plane-components.yaml"
+	case clusterctlv1.InfrastructureProviderType:
+		return "infrastructure-components.yaml"
+	default:
+		return "components.yaml"
+	}
+}
+
+// newOCIRepository returns an ociRepository implementation.
+func newOCIRepository(providerConfig config.Provider, configVariablesClient config.VariablesClient, opts ...ociRepositoryOption) (*ociRepository, error) {
+	if configVariablesClient == nil {
+		return nil, errors.New("invalid arguments: configVariablesClient can't be nil")
+	}
+
+	rURL, err := url.Parse(providerConfig.URL())
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid url")
+	}
+
+	if rURL.Scheme != ociScheme || rURL.Host == "" {
+		return nil, errors.Errorf("invalid url: an OCI repository url should be in the form %s", ociRepositoryURLPattern)
+	}
+
+	// Extracts repository, version and componentsPath from the url; the version is the part of the url path
+	// following the first colon (repository names cannot contain colons).
+	urlSplit := strings.Split(strings.TrimPrefix(rURL.Path, "/"), "/")
+	tagIndex := -1
+	for i, s := range urlSplit {
+		if strings.Contains(s, ":") {
+			tagIndex = i
+			break
+		}
+	}
+	if tagIndex < 0 {
+		return nil, errors.Errorf("invalid url: an OCI repository url should be in the form %s", ociRepositoryURLPattern)
+	}
+
+	componentsPath := strings.Join(urlSplit[tagIndex+1:], "/")
+	if componentsPath == "" {
+		componentsPath = defaultComponentsPath(providerConfig.Type())
+	}
+
+	nameAndTag := strings.SplitN(urlSplit[tagIndex], ":", 2)
+	repositoryPath := append([]string{}, urlSplit[:tagIndex]...)
+	repository := strings.Join(append(repositoryPath, nameAndTag[0]), "/")
+	defaultVersion := nameAndTag[1]
+	if nameAndTag[0] == "" || defaultVersion == "" {
+		return nil, errors.Errorf("invalid url: an OCI repository url should be in the form %s", ociRepositoryURLPattern)
+	}
+
+	repo := &ociRepository{
+		providerConfig:        providerConfig,
+		configVariablesClient: configVariablesClient,
+		httpClient:            newHTTPClient(),
+		registry:              rURL.Host,
+		repository:            repository,
+		defaultVersion:        defaultVersion,
+		componentsPath:        componentsPath,
+	}
+
+	// process ociRepositoryOptions
+	for _, o := range opts {
+		o(repo)
+	}
+
+	if username, err := configVariablesClient.Get(config.OCIUsernameVariable); err == nil {
+		repo.username = username
+	}
+	if password, err := configVariablesClient.Get(config.OCIPasswordVariable); err == nil {
+		repo.password = password
+	}
+
+	if defaultVersion == latestVersionTag {
+		repo.defaultVersion, err = latestContractRelease(repo, clusterv1.GroupVersion.Version)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get OCI latest version")
+		}
+	}
+
+	return repo, nil
+}
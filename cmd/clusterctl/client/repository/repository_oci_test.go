@@ -0,0 +1,220 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+)
+
+func Test_ociRepository_newOCIRepository(t *testing.T) {
+	tests := []struct {
+		name               string
+		url                string
+		providerType       clusterctlv1.ProviderType
+		wantRegistry       string
+		wantRepository     string
+		wantDefaultVersion string
+		wantComponentsPath string
+		wantErr            bool
+	}{
+		{
+			name:               "can create a new OCI repository",
+			url:                "oci://registry.example.com/org/infrastructure-foo:v1.0.0",
+			providerType:       clusterctlv1.InfrastructureProviderType,
+			wantRegistry:       "registry.example.com",
+			wantRepository:     "org/infrastructure-foo",
+			wantDefaultVersion: "v1.0.0",
+			wantComponentsPath: "infrastructure-components.yaml",
+		},
+		{
+			name:               "can create a new OCI repository with a registry port and an explicit components file",
+			url:                "oci://registry.example.com:5000/infrastructure-foo:v1.0.0/components.yaml",
+			providerType:       clusterctlv1.InfrastructureProviderType,
+			wantRegistry:       "registry.example.com:5000",
+			wantRepository:     "infrastructure-foo",
+			wantDefaultVersion: "v1.0.0",
+			wantComponentsPath: "components.yaml",
+		},
+		{
+			name:               "uses the default components file for the provider type",
+			url:                "oci://registry.example.com/org/control-plane-foo:v1.0.0",
+			providerType:       clusterctlv1.ControlPlaneProviderType,
+			wantRegistry:       "registry.example.com",
+			wantRepository:     "org/control-plane-foo",
+			wantDefaultVersion: "v1.0.0",
+			wantComponentsPath: "control-plane-components.yaml",
+		},
+		{
+			name:         "fails if the tag is missing",
+			url:          "oci://registry.example.com/org/infrastructure-foo",
+			providerType: clusterctlv1.InfrastructureProviderType,
+			wantErr:      true,
+		},
+		{
+			name:         "fails if the tag is empty",
+			url:          "oci://registry.example.com/org/infrastructure-foo:",
+			providerType: clusterctlv1.InfrastructureProviderType,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := newOCIRepository(config.NewProvider("foo", tt.url, tt.providerType), test.NewFakeVariableClient())
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got.registry).To(Equal(tt.wantRegistry))
+			g.Expect(got.repository).To(Equal(tt.wantRepository))
+			g.Expect(got.DefaultVersion()).To(Equal(tt.wantDefaultVersion))
+			g.Expect(got.ComponentsPath()).To(Equal(tt.wantComponentsPath))
+		})
+	}
+}
+
+func Test_ociRepository_latest(t *testing.T) {
+	g := NewWithT(t)
+
+	components := []byte("components")
+	componentsDigest := fmt.Sprintf("sha256:%x", sha256.Sum256(components))
+	tampered := []byte("tampered")
+
+	var serverURL string
+	mux := http.NewServeMux()
+
+	// Token service, issuing a token to authenticated users only.
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "user" || password != "pass" || r.URL.Query().Get("scope") != "repository:org/infrastructure-foo:pull" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"token":"my-token"}`)
+	})
+
+	// Registry API, requiring a token issued by the token service.
+	authorized := func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Header.Get("Authorization") != "Bearer my-token" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry.example.com"`, serverURL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return false
+		}
+		return true
+	}
+	mux.HandleFunc("/v2/org/infrastructure-foo/tags/list", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(w, r) {
+			return
+		}
+		// Return two pages of results.
+		if r.URL.Query().Get("last") == "" {
+			w.Header().Set("Link", `</v2/org/infrastructure-foo/tags/list?last=v1.0.0&n=2>; rel="next"`)
+			fmt.Fprint(w, `{"name":"org/infrastructure-foo","tags":["latest","v1.0.0"]}`)
+			return
+		}
+		fmt.Fprint(w, `{"name":"org/infrastructure-foo","tags":["v1.1.0","v1.2.0"]}`)
+	})
+	mux.HandleFunc("/v2/org/infrastructure-foo/manifests/", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(w, r) {
+			return
+		}
+		tag := strings.TrimPrefix(r.URL.Path, "/v2/org/infrastructure-foo/manifests/")
+		digest := componentsDigest
+		if tag == "v1.2.0" {
+			digest = fmt.Sprintf("sha256:%s", hex.EncodeToString(make([]byte, 32)))
+		}
+		manifest := ociManifest{
+			Layers: []ociDescriptor{
+				{
+					MediaType:   "application/vnd.oci.image.layer.v1.tar",
+					Digest:      digest,
+					Size:        int64(len(components)),
+					Annotations: map[string]string{ociTitleAnnotation: "infrastructure-components.yaml"},
+				},
+			},
+		}
+		w.Header().Set("Content-Type", ociManifestMediaType)
+		_ = json.NewEncoder(w).Encode(manifest)
+	})
+	mux.HandleFunc("/v2/org/infrastructure-foo/blobs/", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(w, r) {
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, componentsDigest) {
+			_, _ = w.Write(components)
+			return
+		}
+		_, _ = w.Write(tampered)
+	})
+
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+	serverURL = server.URL
+
+	repo, err := newOCIRepository(
+		config.NewProvider("foo", fmt.Sprintf("oci://%s/org/infrastructure-foo:v1.1.0", strings.TrimPrefix(server.URL, "https://")), clusterctlv1.InfrastructureProviderType),
+		test.NewFakeVariableClient().WithVar(config.OCIUsernameVariable, "user").WithVar(config.OCIPasswordVariable, "pass"),
+		injectOCIHTTPClient(server.Client()),
+	)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	versions, err := repo.GetVersions()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(versions).To(ConsistOf("v1.0.0", "v1.1.0", "v1.2.0"))
+
+	content, err := repo.GetFile("", repo.ComponentsPath())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(content).To(Equal(components))
+
+	// Fails if the file does not exist.
+	_, err = repo.GetFile("v1.1.0", "metadata.yaml")
+	g.Expect(err).To(HaveOccurred())
+
+	// Fails if the content does not match the digest in the manifest.
+	_, err = repo.GetFile("v1.2.0", repo.ComponentsPath())
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_parseOCIAuthChallenge(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, params := parseOCIAuthChallenge(`Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:org/foo:pull,push"`)
+	g.Expect(scheme).To(Equal("Bearer"))
+	g.Expect(params).To(Equal(map[string]string{
+		"realm":   "https://auth.example.com/token",
+		"service": "registry.example.com",
+		"scope":   "repository:org/foo:pull,push",
+	}))
+
+	scheme, params = parseOCIAuthChallenge(`Basic realm=registry`)
+	g.Expect(scheme).To(Equal("Basic"))
+	g.Expect(params).To(Equal(map[string]string{"realm": "registry"}))
+}
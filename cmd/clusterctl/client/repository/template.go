@@ -40,6 +40,10 @@ type Template interface {
 	// This value is derived from the template YAML.
 	VariableMap() map[string]*string
 
+	// VariablesSchema defines the type and the constraints of the template variables, if any.
+	// This value is read from the variables schema file stored next to the template.
+	VariablesSchema() *VariablesSchema
+
 	// TargetNamespace where the template objects will be installed.
 	TargetNamespace() string
 
@@ -54,6 +58,7 @@ type Template interface {
 type template struct {
 	variables       []string
 	variableMap     map[string]*string
+	variablesSchema *VariablesSchema
 	targetNamespace string
 	objs            []unstructured.Unstructured
 }
@@ -69,6 +74,10 @@ func (t *template) VariableMap() map[string]*string {
 	return t.variableMap
 }
 
+func (t *template) VariablesSchema() *VariablesSchema {
+	return t.variablesSchema
+}
+
 func (t *template) TargetNamespace() string {
 	return t.targetNamespace
 }
@@ -88,6 +97,9 @@ type TemplateInput struct {
 	Processor             yaml.Processor
 	TargetNamespace       string
 	SkipTemplateProcess   bool
+
+	// VariablesSchema to validate the variable values against before processing the template; optional.
+	VariablesSchema *VariablesSchema
 }
 
 // NewTemplate returns a new objects embedding a cluster template YAML file.
@@ -106,10 +118,16 @@ func NewTemplate(input TemplateInput) (Template, error) {
 		return &template{
 			variables:       variables,
 			variableMap:     variableMap,
+			variablesSchema: input.VariablesSchema,
 			targetNamespace: input.TargetNamespace,
 		}, nil
 	}
 
+	// Validates all the variable values before processing, so all the problems are reported at once.
+	if err := input.VariablesSchema.Validate(variableMap, input.ConfigVariablesClient.Get); err != nil {
+		return nil, err
+	}
+
 	processedYaml, err := input.Processor.Process(input.RawArtifact, input.ConfigVariablesClient.Get)
 	if err != nil {
 		return nil, err
@@ -132,6 +150,7 @@ func NewTemplate(input TemplateInput) (Template, error) {
 	return &template{
 		variables:       variables,
 		variableMap:     variableMap,
+		variablesSchema: input.VariablesSchema,
 		targetNamespace: input.TargetNamespace,
 		objs:            objs,
 	}, nil
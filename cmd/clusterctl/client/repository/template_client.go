@@ -26,6 +26,7 @@ import (
 // TemplateClient has methods to work with cluster templates hosted on a provider repository.
 // Templates are yaml files to be used for creating a guest cluster.
 type TemplateClient interface {
+	Raw(flavor string) ([]byte, error)
 	Get(flavor, targetNamespace string, listVariablesOnly bool) (Template, error)
 }
 
@@ -62,20 +63,75 @@ func newTemplateClient(input TemplateClientInput) *templateClient {
 	}
 }
 
+// Raw returns the template for the flavor specified, without any processing.
+// In case the template does not exists, an error is returned.
+func (c *templateClient) Raw(flavor string) ([]byte, error) {
+	return c.getRawBytes(flavor)
+}
+
 // Get return the template for the flavor specified.
 // In case the template does not exists, an error is returned.
 // Get assumes the following naming convention for templates: cluster-template[-<flavor_name>].yaml.
 func (c *templateClient) Get(flavor, targetNamespace string, skipTemplateProcess bool) (Template, error) {
-	log := logf.Log
-
 	if targetNamespace == "" {
 		return nil, errors.New("invalid arguments: please provide a targetNamespace")
 	}
 
+	rawArtifact, err := c.getRawBytes(flavor)
+	if err != nil {
+		return nil, err
+	}
+
+	// If the processor requires other files referenced by the template, e.g. bases and patches, resolve them.
+	if resolver, ok := c.processor.(yaml.Resolver); ok {
+		rawArtifact, err = resolver.Resolve(rawArtifact, c.getFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve the cluster template for provider %q", c.provider.ManifestLabel())
+		}
+	}
+
+	variablesSchema, err := c.getVariablesSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTemplate(TemplateInput{
+		RawArtifact:           rawArtifact,
+		ConfigVariablesClient: c.configVariablesClient,
+		Processor:             c.processor,
+		TargetNamespace:       targetNamespace,
+		SkipTemplateProcess:   skipTemplateProcess,
+		VariablesSchema:       variablesSchema,
+	})
+}
+
+// getVariablesSchema returns the variables schema stored next to the templates, if any.
+func (c *templateClient) getVariablesSchema() (*VariablesSchema, error) {
+	rawSchema, err := c.getFile(VariablesSchemaFile)
+	if err != nil {
+		// The variables schema is optional, so templates without a schema are processed as usual.
+		logf.Log.V(5).Info("Variables schema not found", "File", VariablesSchemaFile, "Provider", c.provider.ManifestLabel(), "Version", c.version)
+		return nil, nil
+	}
+
+	variablesSchema, err := ParseVariablesSchema(rawSchema)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %q from provider's repository %q", VariablesSchemaFile, c.provider.ManifestLabel())
+	}
+	return variablesSchema, nil
+}
+
+func (c *templateClient) getRawBytes(flavor string) ([]byte, error) {
+	return c.getFile(c.processor.GetTemplateName(c.version, flavor))
+}
+
+// getFile returns a file from the provider repository, reading the local override file if it exists.
+func (c *templateClient) getFile(name string) ([]byte, error) {
+	log := logf.Log
+
 	version := c.version
-	name := c.processor.GetTemplateName(version, flavor)
 
-	// read the component YAML, reading the local override file if it exists, otherwise read from the provider repository
+	// read the file, reading the local override file if it exists, otherwise read from the provider repository
 	rawArtifact, err := getLocalOverride(&newOverrideInput{
 		configVariablesClient: c.configVariablesClient,
 		provider:              c.provider,
@@ -95,12 +151,5 @@ func (c *templateClient) Get(flavor, targetNamespace string, skipTemplateProcess
 	} else {
 		log.V(1).Info("Using", "Override", name, "Provider", c.provider.ManifestLabel(), "Version", version)
 	}
-
-	return NewTemplate(TemplateInput{
-		rawArtifact,
-		c.configVariablesClient,
-		c.processor,
-		targetNamespace,
-		skipTemplateProcess,
-	})
+	return rawArtifact, nil
 }
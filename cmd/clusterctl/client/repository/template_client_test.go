@@ -218,3 +218,108 @@ func Test_templates_Get(t *testing.T) {
 		})
 	}
 }
+
+func Test_templates_Raw(t *testing.T) {
+	g := NewWithT(t)
+
+	f := newTemplateClient(
+		TemplateClientInput{
+			version:  "v1.0",
+			provider: config.NewProvider("p1", "", clusterctlv1.InfrastructureProviderType),
+			repository: NewMemoryRepository().
+				WithPaths("root", "").
+				WithDefaultVersion("v1.0").
+				WithFile("v1.0", "cluster-template-prod.yaml", templateMapYaml),
+			configVariablesClient: test.NewFakeVariableClient(),
+			processor:             yaml.NewSimpleProcessor(),
+		},
+	)
+
+	// Raw returns the template as is, without variable substitution.
+	got, err := f.Raw("prod")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got).To(Equal(templateMapYaml))
+
+	_, err = f.Raw("")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_templates_GetWithResolver(t *testing.T) {
+	g := NewWithT(t)
+
+	f := newTemplateClient(
+		TemplateClientInput{
+			version:  "v1.0",
+			provider: config.NewProvider("p1", "", clusterctlv1.InfrastructureProviderType),
+			repository: NewMemoryRepository().
+				WithPaths("root", "").
+				WithDefaultVersion("v1.0").
+				WithFile("v1.0", "cluster-template.yaml", []byte("kind: Kustomization\nresources:\n- base/cluster.yaml\ncommonLabels:\n  env: test\n")).
+				WithFile("v1.0", "base/cluster.yaml", templateMapYaml),
+			configVariablesClient: test.NewFakeVariableClient().WithVar(variableName, variableValue),
+			processor:             yaml.NewOverlayProcessor(),
+		},
+	)
+
+	// Files referenced by the kustomization are read from the repository, then variables are processed.
+	got, err := f.Get("", "ns1", false)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(got.Variables()).To(Equal([]string{variableName}))
+	g.Expect(got.Objs()).To(HaveLen(1))
+	g.Expect(got.Objs()[0].GetLabels()).To(HaveKeyWithValue("env", "test"))
+	g.Expect(got.Objs()[0].GetNamespace()).To(Equal("ns1"))
+}
+
+func Test_templates_GetWithVariablesSchema(t *testing.T) {
+	schema := []byte(fmt.Sprintf("variables:\n- name: %s\n  type: integer\n  description: A number.\n", variableName))
+
+	tests := []struct {
+		name          string
+		value         string
+		listVariables bool
+		wantErr       bool
+	}{
+		{
+			name:  "pass if the value is valid",
+			value: "3",
+		},
+		{
+			name:    "fails if the value is invalid",
+			value:   "three",
+			wantErr: true,
+		},
+		{
+			name:          "does not validate values when listing variables",
+			value:         "three",
+			listVariables: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			f := newTemplateClient(
+				TemplateClientInput{
+					version:  "v1.0",
+					provider: config.NewProvider("p1", "", clusterctlv1.InfrastructureProviderType),
+					repository: NewMemoryRepository().
+						WithPaths("root", "").
+						WithDefaultVersion("v1.0").
+						WithFile("v1.0", "cluster-template.yaml", templateMapYaml).
+						WithFile("v1.0", VariablesSchemaFile, schema),
+					configVariablesClient: test.NewFakeVariableClient().WithVar(variableName, tt.value),
+					processor:             yaml.NewSimpleProcessor(),
+				},
+			)
+
+			got, err := f.Get("", "ns1", tt.listVariables)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got.VariablesSchema()).NotTo(BeNil())
+			g.Expect(got.VariablesSchema().Get(variableName).Description).To(Equal("A number."))
+		})
+	}
+}
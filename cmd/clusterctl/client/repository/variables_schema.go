@@ -0,0 +1,235 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// VariablesSchemaFile is the name of the file defining the variables schema, stored next to the cluster templates.
+const VariablesSchemaFile = "clusterctl-variables.yaml"
+
+// VariableType defines the type of a template variable.
+type VariableType string
+
+const (
+	// StringVariableType is the type for variables with string values; this is the default.
+	StringVariableType = VariableType("string")
+
+	// IntegerVariableType is the type for variables with integer values.
+	IntegerVariableType = VariableType("integer")
+
+	// NumberVariableType is the type for variables with numeric values, e.g. 1.5.
+	NumberVariableType = VariableType("number")
+
+	// BooleanVariableType is the type for variables with boolean values, e.g. true.
+	BooleanVariableType = VariableType("boolean")
+)
+
+// VariablesSchema defines the variables expected by the cluster templates of a provider.
+type VariablesSchema struct {
+	// Variables is the list of variable definitions.
+	Variables []VariableDefinition `json:"variables"`
+}
+
+// VariableDefinition defines the type and the constraints of a template variable.
+type VariableDefinition struct {
+	// Name of the variable, e.g. CONTROL_PLANE_MACHINE_COUNT.
+	Name string `json:"name"`
+
+	// Type of the variable; if not set, string is assumed.
+	Type VariableType `json:"type,omitempty"`
+
+	// Description of the variable.
+	Description string `json:"description,omitempty"`
+
+	// Enum is the list of the allowed values, if any.
+	Enum []string `json:"enum,omitempty"`
+
+	// Pattern is a regular expression that values must match, if set.
+	Pattern string `json:"pattern,omitempty"`
+
+	// Minimum is the minimum value for integer and number variables, if set.
+	Minimum *float64 `json:"minimum,omitempty"`
+
+	// Maximum is the maximum value for integer and number variables, if set.
+	Maximum *float64 `json:"maximum,omitempty"`
+
+	// Required is true if a value must be provided for the variable, even if the template defines a default value.
+	Required bool `json:"required,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// ParseVariablesSchema parses and validates a variables schema.
+func ParseVariablesSchema(rawSchema []byte) (*VariablesSchema, error) {
+	schema := &VariablesSchema{}
+	if err := yaml.UnmarshalStrict(rawSchema, schema); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the variables schema")
+	}
+
+	var errs []error
+	names := map[string]bool{}
+	for i := range schema.Variables {
+		v := &schema.Variables[i]
+		if v.Name == "" {
+			errs = append(errs, errors.Errorf("variables[%d]: name must be set", i))
+			continue
+		}
+		if names[v.Name] {
+			errs = append(errs, errors.Errorf("variable %s: is defined more than once", v.Name))
+		}
+		names[v.Name] = true
+
+		switch v.Type {
+		case "":
+			v.Type = StringVariableType
+		case StringVariableType, IntegerVariableType, NumberVariableType, BooleanVariableType:
+		default:
+			errs = append(errs, errors.Errorf("variable %s: invalid type %q. Allowed values are [%s, %s, %s, %s]",
+				v.Name, v.Type, StringVariableType, IntegerVariableType, NumberVariableType, BooleanVariableType))
+		}
+
+		if v.Pattern != "" {
+			pattern, err := regexp.Compile(v.Pattern)
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "variable %s: invalid pattern", v.Name))
+			}
+			v.pattern = pattern
+		}
+
+		if (v.Minimum != nil || v.Maximum != nil) && v.Type != IntegerVariableType && v.Type != NumberVariableType {
+			errs = append(errs, errors.Errorf("variable %s: minimum and maximum can be set only for %s and %s variables", v.Name, IntegerVariableType, NumberVariableType))
+		}
+		if v.Minimum != nil && v.Maximum != nil && *v.Minimum > *v.Maximum {
+			errs = append(errs, errors.Errorf("variable %s: minimum must be less than or equal to maximum", v.Name))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Wrap(kerrors.NewAggregate(errs), "invalid variables schema")
+	}
+	return schema, nil
+}
+
+// Get returns the definition of a variable, if any.
+func (s *VariablesSchema) Get(name string) *VariableDefinition {
+	if s == nil {
+		return nil
+	}
+	for i := range s.Variables {
+		if s.Variables[i].Name == name {
+			return &s.Variables[i]
+		}
+	}
+	return nil
+}
+
+// Validate checks the values of the variables used by a template against the schema, and it returns an
+// error reporting all the invalid values. Variables not used by the template, as well as variables not defined
+// in the schema, are ignored; values for variables without a value are defaulted using the template
+// default, if any.
+func (s *VariablesSchema) Validate(variableMap map[string]*string, getValue func(string) (string, error)) error {
+	if s == nil {
+		return nil
+	}
+
+	var errs []error
+	for i := range s.Variables {
+		v := &s.Variables[i]
+		defaultValue, ok := variableMap[v.Name]
+		if !ok {
+			continue
+		}
+
+		value, err := getValue(v.Name)
+		if err != nil {
+			if v.Required {
+				errs = append(errs, errors.Errorf("%s: a value is required", v.Name))
+				continue
+			}
+			if defaultValue == nil {
+				// Variables without a value and without a default are reported by the processor.
+				continue
+			}
+			value = *defaultValue
+		}
+
+		if err := v.validate(value); err != nil {
+			errs = append(errs, errors.Wrapf(err, "%s: invalid value %q", v.Name, value))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Wrap(kerrors.NewAggregate(errs), "invalid values for the template variables")
+	}
+	return nil
+}
+
+func (v *VariableDefinition) validate(value string) error {
+	var number *float64
+	switch v.Type {
+	case IntegerVariableType:
+		i, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		f := float64(i)
+		number = &f
+	case NumberVariableType:
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		number = &f
+	case BooleanVariableType:
+		if _, err := strconv.ParseBool(strings.TrimSpace(value)); err != nil {
+			return errors.New("must be a boolean")
+		}
+	}
+
+	if number != nil {
+		if v.Minimum != nil && *number < *v.Minimum {
+			return errors.Errorf("must be greater than or equal to %s", strconv.FormatFloat(*v.Minimum, 'f', -1, 64))
+		}
+		if v.Maximum != nil && *number > *v.Maximum {
+			return errors.Errorf("must be less than or equal to %s", strconv.FormatFloat(*v.Maximum, 'f', -1, 64))
+		}
+	}
+
+	if len(v.Enum) > 0 {
+		found := false
+		for _, e := range v.Enum {
+			if e == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("must be one of [%s]", strings.Join(v.Enum, ", "))
+		}
+	}
+
+	if v.pattern != nil && !v.pattern.MatchString(value) {
+		return errors.Errorf("must match the pattern %q", v.Pattern)
+	}
+	return nil
+}
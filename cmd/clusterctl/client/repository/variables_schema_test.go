@@ -0,0 +1,197 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+)
+
+func TestParseVariablesSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		want    []VariableType
+		wantErr bool
+	}{
+		{
+			name: "parses the schema and defaults the type",
+			schema: `variables:
+- name: CLUSTER_NAME
+  description: The name of the cluster.
+- name: CONTROL_PLANE_MACHINE_COUNT
+  type: integer
+  minimum: 1
+  maximum: 7
+`,
+			want: []VariableType{StringVariableType, IntegerVariableType},
+		},
+		{
+			name: "fails for unknown fields",
+			schema: `variables:
+- name: CLUSTER_NAME
+  descr: The name of the cluster.
+`,
+			wantErr: true,
+		},
+		{
+			name: "fails for invalid definitions",
+			schema: `variables:
+- name: A
+  type: list
+- name: B
+  pattern: "[a-z"
+- name: C
+  minimum: 1
+- name: D
+  type: number
+  minimum: 2
+  maximum: 1
+- name: D
+`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := ParseVariablesSchema([]byte(tt.schema))
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			types := []VariableType{}
+			for _, v := range got.Variables {
+				types = append(types, v.Type)
+			}
+			g.Expect(types).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestVariablesSchema_Validate(t *testing.T) {
+	g := NewWithT(t)
+
+	schema, err := ParseVariablesSchema([]byte(`variables:
+- name: CONTROL_PLANE_MACHINE_COUNT
+  type: integer
+  minimum: 1
+- name: WORKER_MACHINE_COUNT
+  type: integer
+  maximum: 10
+- name: SPOT_INSTANCES
+  type: boolean
+- name: INSTANCE_TYPE
+  enum: ["small", "large"]
+- name: CLUSTER_NAME
+  pattern: "^[a-z0-9-]+$"
+- name: SSH_KEY
+  required: true
+- name: NOT_USED
+  required: true
+`))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	def := func(s string) *string { return &s }
+
+	tests := []struct {
+		name        string
+		variableMap map[string]*string
+		values      map[string]string
+		wantErrs    []string
+	}{
+		{
+			name: "valid values",
+			variableMap: map[string]*string{
+				"CONTROL_PLANE_MACHINE_COUNT": nil,
+				"WORKER_MACHINE_COUNT":        def("3"),
+				"INSTANCE_TYPE":               def("small"),
+				"CLUSTER_NAME":                nil,
+				"SSH_KEY":                     nil,
+				"NOT_DEFINED":                 nil,
+			},
+			values: map[string]string{
+				"CONTROL_PLANE_MACHINE_COUNT": "3",
+				"CLUSTER_NAME":                "my-cluster",
+				"SSH_KEY":                     "key",
+				"NOT_DEFINED":                 "foo",
+			},
+		},
+		{
+			name: "reports all the invalid values at once",
+			variableMap: map[string]*string{
+				"CONTROL_PLANE_MACHINE_COUNT": nil,
+				"WORKER_MACHINE_COUNT":        def("30"),
+				"SPOT_INSTANCES":              nil,
+				"INSTANCE_TYPE":               nil,
+				"CLUSTER_NAME":                nil,
+				"SSH_KEY":                     def("key"),
+			},
+			values: map[string]string{
+				"CONTROL_PLANE_MACHINE_COUNT": "two",
+				"SPOT_INSTANCES":              "maybe",
+				"INSTANCE_TYPE":               "medium",
+				"CLUSTER_NAME":                "My_Cluster",
+			},
+			wantErrs: []string{
+				`CONTROL_PLANE_MACHINE_COUNT: invalid value "two": must be an integer`,
+				`WORKER_MACHINE_COUNT: invalid value "30": must be less than or equal to 10`,
+				`SPOT_INSTANCES: invalid value "maybe": must be a boolean`,
+				`INSTANCE_TYPE: invalid value "medium": must be one of [small, large]`,
+				`CLUSTER_NAME: invalid value "My_Cluster": must match the pattern "^[a-z0-9-]+$"`,
+				`SSH_KEY: a value is required`,
+			},
+		},
+		{
+			name: "validates the minimum",
+			variableMap: map[string]*string{
+				"CONTROL_PLANE_MACHINE_COUNT": nil,
+			},
+			values: map[string]string{
+				"CONTROL_PLANE_MACHINE_COUNT": "0",
+			},
+			wantErrs: []string{
+				`CONTROL_PLANE_MACHINE_COUNT: invalid value "0": must be greater than or equal to 1`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			variablesClient := test.NewFakeVariableClient()
+			for k, v := range tt.values {
+				variablesClient.WithVar(k, v)
+			}
+
+			err := schema.Validate(tt.variableMap, variablesClient.Get)
+			if len(tt.wantErrs) == 0 {
+				g.Expect(err).NotTo(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(HaveOccurred())
+			for _, e := range tt.wantErrs {
+				g.Expect(err.Error()).To(ContainSubstring(e))
+			}
+		})
+	}
+}
@@ -19,12 +19,20 @@ package client
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/alpha"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/util"
+	logf "sigs.k8s.io/cluster-api/cmd/clusterctl/log"
 )
 
+// rolloutStatusInterval is the interval between two checks of the rollout status when watching a rollout.
+var rolloutStatusInterval = 2 * time.Second
+
 // RolloutOptions carries the base set of options supported by rollout command.
 type RolloutOptions struct {
 	// Kubeconfig defines the kubeconfig to use for accessing the management cluster. If empty,
@@ -41,6 +49,18 @@ type RolloutOptions struct {
 	// Revision number to rollback to when issuing the undo command.
 	// Revision number of a specific revision when issuing the history command.
 	ToRevision int64
+
+	// DiffRevision is the revision number to compare with when issuing the history command; the diff
+	// is computed against the revision specified by ToRevision or, if unspecified, against the latest revision.
+	DiffRevision int64
+
+	// Watch defines if the status command should wait for the rollout to complete; if false, the current
+	// status is reported and the command exits.
+	Watch bool
+
+	// Timeout is the maximum time to wait for the rollout to complete when issuing the status command with
+	// Watch enabled; zero means no timeout.
+	Timeout time.Duration
 }
 
 func (c *clusterctlClient) RolloutRestart(options RolloutOptions) error {
@@ -111,6 +131,138 @@ func (c *clusterctlClient) RolloutUndo(options RolloutOptions) error {
 	return nil
 }
 
+func (c *clusterctlClient) RolloutStatus(options RolloutOptions) error {
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return err
+	}
+	objRefs, err := getObjectRefs(clusterClient, options)
+	if err != nil {
+		return err
+	}
+	for _, ref := range objRefs {
+		if err := c.rolloutStatus(clusterClient, ref, options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rolloutStatus reports the rollout status of a resource, and, if required, it waits for the rollout to complete.
+func (c *clusterctlClient) rolloutStatus(clusterClient cluster.Client, ref corev1.ObjectReference, options RolloutOptions) error {
+	log := logf.Log
+
+	lastMessage := ""
+	condition := func() (bool, error) {
+		message, done, err := c.alphaClient.Rollout().ObjectStatusViewer(clusterClient.Proxy(), ref)
+		if err != nil {
+			return false, err
+		}
+		// Report the status only when it changes, so watching a rollout does not flood the output.
+		if message != lastMessage {
+			log.Info(message)
+			lastMessage = message
+		}
+		return done || !options.Watch, nil
+	}
+
+	var err error
+	if options.Timeout > 0 {
+		err = wait.PollImmediate(rolloutStatusInterval, options.Timeout, condition)
+	} else {
+		err = wait.PollImmediateInfinite(rolloutStatusInterval, condition)
+	}
+	if err == wait.ErrWaitTimeout {
+		return errors.Errorf("timed out waiting for the rollout of %s/%s to complete", ref.Kind, ref.Name)
+	}
+	return err
+}
+
+// RolloutHistory describes the rollout history of a cluster-api resource.
+type RolloutHistory struct {
+	// Object is the reference to the cluster-api resource.
+	Object corev1.ObjectReference
+
+	// Revisions of the resource, sorted by revision number; if a revision number is specified
+	// with RolloutOptions.ToRevision, only that revision is included.
+	Revisions []RolloutRevision
+
+	// Diff between the revision specified with RolloutOptions.DiffRevision and the selected revision, if requested.
+	Diff string
+}
+
+func (c *clusterctlClient) RolloutHistory(options RolloutOptions) ([]RolloutHistory, error) {
+	clusterClient, err := c.clusterClientFactory(ClusterClientFactoryInput{Kubeconfig: options.Kubeconfig})
+	if err != nil {
+		return nil, err
+	}
+	if options.ToRevision < 0 || options.DiffRevision < 0 {
+		return nil, errors.New("revision number cannot be negative")
+	}
+	objRefs, err := getObjectRefs(clusterClient, options)
+	if err != nil {
+		return nil, err
+	}
+
+	histories := make([]RolloutHistory, 0, len(objRefs))
+	for _, ref := range objRefs {
+		revisions, err := c.alphaClient.Rollout().ObjectHistoryViewer(clusterClient.Proxy(), ref)
+		if err != nil {
+			return nil, err
+		}
+		history, err := rolloutHistory(ref, revisions, options)
+		if err != nil {
+			return nil, err
+		}
+		histories = append(histories, *history)
+	}
+	return histories, nil
+}
+
+// rolloutHistory filters the revisions of a resource according to the options, and computes the diff, if requested.
+func rolloutHistory(ref corev1.ObjectReference, revisions []alpha.RolloutRevision, options RolloutOptions) (*RolloutHistory, error) {
+	history := &RolloutHistory{
+		Object: ref,
+	}
+	if len(revisions) == 0 {
+		return nil, errors.Errorf("no rollout history found for %s/%s", ref.Kind, ref.Name)
+	}
+
+	findRevision := func(revision int64) (*alpha.RolloutRevision, error) {
+		for i := range revisions {
+			if revisions[i].Revision == revision {
+				return &revisions[i], nil
+			}
+		}
+		return nil, errors.Errorf("unable to find revision %d for %s/%s", revision, ref.Kind, ref.Name)
+	}
+
+	// Select the revision to show; if no revision is specified, all the revisions are shown, and the latest one is used for the diff.
+	selected := &revisions[len(revisions)-1]
+	if options.ToRevision > 0 {
+		var err error
+		if selected, err = findRevision(options.ToRevision); err != nil {
+			return nil, err
+		}
+		history.Revisions = []RolloutRevision{RolloutRevision(*selected)}
+	} else {
+		for _, r := range revisions {
+			history.Revisions = append(history.Revisions, RolloutRevision(r))
+		}
+	}
+
+	if options.DiffRevision > 0 {
+		from, err := findRevision(options.DiffRevision)
+		if err != nil {
+			return nil, err
+		}
+		if history.Diff, err = alpha.DiffRevisions(*from, *selected); err != nil {
+			return nil, err
+		}
+	}
+	return history, nil
+}
+
 func getObjectRefs(clusterClient cluster.Client, options RolloutOptions) ([]corev1.ObjectReference, error) {
 	// If the option specifying the Namespace is empty, try to detect it.
 	if options.Namespace == "" {
@@ -18,11 +18,15 @@ package client
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/alpha"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/cluster"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
 )
@@ -220,3 +224,191 @@ func Test_clusterctlClient_RolloutResume(t *testing.T) {
 		})
 	}
 }
+
+func Test_clusterctlClient_RolloutStatus(t *testing.T) {
+	tests := genericTestCases()
+	additionalTests := []rolloutTest{
+		{
+			name: "do not return error if the rollout is not completed and watch is disabled",
+			fields: fields{
+				client: fakeClientForRollout(),
+			},
+			args: args{
+				options: RolloutOptions{
+					Kubeconfig: Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+					Resources:  []string{"machinedeployment/md-1"},
+					Namespace:  "default",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "return error if the rollout does not complete before the timeout",
+			fields: fields{
+				client: fakeClientForRollout(),
+			},
+			args: args{
+				options: RolloutOptions{
+					Kubeconfig: Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+					Resources:  []string{"machinedeployment/md-1"},
+					Namespace:  "default",
+					Watch:      true,
+					Timeout:    50 * time.Millisecond,
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	tests = append(tests, additionalTests...)
+
+	defer func(interval time.Duration) { rolloutStatusInterval = interval }(rolloutStatusInterval)
+	rolloutStatusInterval = 10 * time.Millisecond
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := tt.fields.client.RolloutStatus(tt.args.options)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+	}
+}
+
+func Test_clusterctlClient_RolloutHistory(t *testing.T) {
+	tests := genericTestCases()
+	additionalTests := []rolloutTest{
+		{
+			name: "return error if the machinedeployment has no rollout history",
+			fields: fields{
+				client: fakeClientForRollout(),
+			},
+			args: args{
+				options: RolloutOptions{
+					Kubeconfig: Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"},
+					Resources:  []string{"machinedeployment/md-1"},
+					Namespace:  "default",
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	tests = append(tests, additionalTests...)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			_, err := tt.fields.client.RolloutHistory(tt.args.options)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+		})
+	}
+}
+
+func Test_rolloutHistory(t *testing.T) {
+	revision := func(revision int64, version string) alpha.RolloutRevision {
+		return alpha.RolloutRevision{
+			Revision: revision,
+			Version:  pointer.StringPtr(version),
+			Template: clusterv1.MachineTemplateSpec{
+				Spec: clusterv1.MachineSpec{
+					Version: pointer.StringPtr(version),
+				},
+			},
+		}
+	}
+	revisions := []alpha.RolloutRevision{
+		revision(1, "v1.19.1"),
+		revision(2, "v1.19.3"),
+		revision(3, "v1.20.1"),
+	}
+	ref := corev1.ObjectReference{Kind: "machinedeployment", Name: "md-1", Namespace: "default"}
+
+	tests := []struct {
+		name          string
+		revisions     []alpha.RolloutRevision
+		options       RolloutOptions
+		wantRevisions []int64
+		wantDiff      []string
+		wantErr       bool
+	}{
+		{
+			name:          "all the revisions",
+			revisions:     revisions,
+			options:       RolloutOptions{},
+			wantRevisions: []int64{1, 2, 3},
+		},
+		{
+			name:          "a specific revision",
+			revisions:     revisions,
+			options:       RolloutOptions{ToRevision: 2},
+			wantRevisions: []int64{2},
+		},
+		{
+			name:          "diff against the latest revision",
+			revisions:     revisions,
+			options:       RolloutOptions{DiffRevision: 1},
+			wantRevisions: []int64{1, 2, 3},
+			wantDiff:      []string{"--- revision 1", "+++ revision 3", "-  version: v1.19.1", "+  version: v1.20.1"},
+		},
+		{
+			name:          "diff against a specific revision",
+			revisions:     revisions,
+			options:       RolloutOptions{ToRevision: 2, DiffRevision: 1},
+			wantRevisions: []int64{2},
+			wantDiff:      []string{"--- revision 1", "+++ revision 2", "-  version: v1.19.1", "+  version: v1.19.3"},
+		},
+		{
+			name:      "return error if the revision does not exist",
+			revisions: revisions,
+			options:   RolloutOptions{ToRevision: 4},
+			wantErr:   true,
+		},
+		{
+			name:      "return error if the revision to diff with does not exist",
+			revisions: revisions,
+			options:   RolloutOptions{DiffRevision: 4},
+			wantErr:   true,
+		},
+		{
+			name:      "return error if there are no revisions",
+			revisions: nil,
+			options:   RolloutOptions{},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := rolloutHistory(ref, tt.revisions, tt.options)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+
+			gotRevisions := []int64{}
+			for _, r := range got.Revisions {
+				gotRevisions = append(gotRevisions, r.Revision)
+			}
+			g.Expect(gotRevisions).To(Equal(tt.wantRevisions))
+
+			if len(tt.wantDiff) == 0 {
+				g.Expect(got.Diff).To(BeEmpty())
+			}
+			for _, d := range tt.wantDiff {
+				g.Expect(got.Diff).To(ContainSubstring(d + "\n"))
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ package tree
 import (
 	"strconv"
 
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -30,6 +31,10 @@ const (
 	// e.g. control plane for KCP.
 	ObjectMetaNameAnnotation = "tree.cluster.x-k8s.io.io/meta-name"
 
+	// ObjectSummaryAnnotation contains a short summary of the object status that should be shown in the presentation layer,
+	// e.g. the number of healthy machines for a MachineHealthCheck.
+	ObjectSummaryAnnotation = "tree.cluster.x-k8s.io.io/summary"
+
 	// VirtualObjectAnnotation documents that the object does not correspond to any real object, but instead is
 	// a virtual object introduced to provide a better representation of the cluster status, e.g. workers.
 	VirtualObjectAnnotation = "tree.cluster.x-k8s.io.io/virtual-object"
@@ -49,6 +54,10 @@ const (
 
 	// GroupItemsSeparator is the separator used in the GroupItemsAnnotation.
 	GroupItemsSeparator = ", "
+
+	// EchoItemsAnnotation contains the list of kind/name for the children objects not added to the tree because
+	// they are an echo of the object, e.g. the infrastructure machine of a machine with the same ready condition.
+	EchoItemsAnnotation = "tree.cluster.x-k8s.io.io/echo-items"
 )
 
 // GetMetaName returns the object meta name that should be used for the object in the presentation layer, if defined.
@@ -59,6 +68,24 @@ func GetMetaName(obj client.Object) string {
 	return ""
 }
 
+// GetObjectSummary returns the short summary of the object status that should be shown in the presentation layer, if defined.
+func GetObjectSummary(obj client.Object) string {
+	if val, ok := getAnnotation(obj, ObjectSummaryAnnotation); ok {
+		return val
+	}
+	return ""
+}
+
+// IsManagedByTopology returns true if the object is managed as part of a cluster topology, e.g. a
+// MachineDeployment created from a ClusterClass.
+func IsManagedByTopology(obj client.Object) bool {
+	if obj == nil {
+		return false
+	}
+	_, ok := obj.GetLabels()[clusterv1.ClusterTopologyLabelName]
+	return ok
+}
+
 // IsGroupingObject returns true in case the object is responsible to trigger the grouping action
 // when adding the object's children. e.g. A control-plane object, could be responsible of grouping
 // the control-plane machines while added as a children objects.
@@ -86,6 +113,15 @@ func GetGroupItems(obj client.Object) string {
 	return ""
 }
 
+// GetEchoItems return the list of kind/name for the children objects not added to the tree because
+// they are an echo of the object.
+func GetEchoItems(obj client.Object) string {
+	if val, ok := getAnnotation(obj, EchoItemsAnnotation); ok {
+		return val
+	}
+	return ""
+}
+
 // IsVirtualObject return true if the object does not correspond to any real object, but instead it is
 // a virtual object introduced to provide a better representation of the cluster status.
 func IsVirtualObject(obj client.Object) bool {
@@ -18,10 +18,17 @@ package tree
 
 import (
 	"context"
+	"fmt"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/controllers/external"
+	addonsv1 "sigs.k8s.io/cluster-api/exp/addons/api/v1alpha4"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -64,6 +71,14 @@ func Discovery(ctx context.Context, c client.Client, namespace, name string, opt
 		tree.Add(cluster, clusterInfra, ObjectMetaName("ClusterInfrastructure"))
 	}
 
+	// Adds the ClusterClass, if the cluster topology is managed using a ClusterClass.
+	if cluster.Spec.Topology != nil {
+		clusterClass := &clusterv1.ClusterClass{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Spec.Topology.Class}, clusterClass); err == nil {
+			tree.Add(cluster, clusterClass)
+		}
+	}
+
 	// Adds control plane
 	controlPLane, err := external.Get(ctx, c, cluster.Spec.ControlPlaneRef, cluster.Namespace)
 	if err == nil {
@@ -97,19 +112,34 @@ func Discovery(ctx context.Context, c client.Client, namespace, name string, opt
 		addMachineFunc(controlPLane, cp)
 	}
 
-	if len(machinesList.Items) == len(controlPlaneMachines) {
-		return tree, nil
+	// Adds ClusterResourceSets applied to the cluster.
+	if err := addClusterResourceSets(ctx, c, tree, cluster); err != nil {
+		return nil, err
 	}
 
-	workers := VirtualObject(cluster.Namespace, "WorkerGroup", "Workers")
-	tree.Add(cluster, workers)
+	// Adds MachineHealthChecks targeting the cluster.
+	if err := addMachineHealthChecks(ctx, c, tree, cluster); err != nil {
+		return nil, err
+	}
 
-	// Adds worker machines.
 	machinesDeploymentList, err := getMachineDeploymentsInCluster(ctx, c, cluster.Namespace, cluster.Name)
 	if err != nil {
 		return nil, err
 	}
 
+	machinePoolList, err := getMachinePoolsInCluster(ctx, c, cluster.Namespace, cluster.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(machinesList.Items) == len(controlPlaneMachines) && len(machinesDeploymentList.Items) == 0 && len(machinePoolList.Items) == 0 {
+		return tree, nil
+	}
+
+	workers := VirtualObject(cluster.Namespace, "WorkerGroup", "Workers")
+	tree.Add(cluster, workers)
+
+	// Adds worker machines.
 	machineSetList, err := getMachineSetsInCluster(ctx, c, cluster.Namespace, cluster.Name)
 	if err != nil {
 		return nil, err
@@ -130,6 +160,22 @@ func Discovery(ctx context.Context, c client.Client, namespace, name string, opt
 		}
 	}
 
+	// Adds machine pools.
+	for i := range machinePoolList.Items {
+		mp := &machinePoolList.Items[i]
+		_, visible := tree.Add(workers, mp)
+
+		if visible {
+			if machinePoolInfra, err := external.Get(ctx, c, &mp.Spec.Template.Spec.InfrastructureRef, cluster.Namespace); err == nil {
+				tree.Add(mp, machinePoolInfra, ObjectMetaName("MachinePoolInfrastructure"), NoEcho(true))
+			}
+
+			if machinePoolBootstrap, err := external.Get(ctx, c, mp.Spec.Template.Spec.Bootstrap.ConfigRef, cluster.Namespace); err == nil {
+				tree.Add(mp, machinePoolBootstrap, ObjectMetaName("BootstrapConfig"), NoEcho(true))
+			}
+		}
+	}
+
 	// Handles orphan machines.
 	if len(machineMap) < len(machinesList.Items) {
 		other := VirtualObject(cluster.Namespace, "OtherGroup", "Other")
@@ -147,6 +193,90 @@ func Discovery(ctx context.Context, c client.Client, namespace, name string, opt
 	return tree, nil
 }
 
+// addClusterResourceSets adds the ClusterResourceSets applied to the cluster, with the status of each resource
+// as reported by the ClusterResourceSetBinding.
+func addClusterResourceSets(ctx context.Context, c client.Client, tree *ObjectTree, cluster *clusterv1.Cluster) error {
+	binding := &addonsv1.ClusterResourceSetBinding{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Name}, binding); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if len(binding.Spec.Bindings) == 0 {
+		return nil
+	}
+
+	clusterResourceSets := VirtualObject(cluster.Namespace, "ClusterResourceSetGroup", "ClusterResourceSets")
+	tree.Add(cluster, clusterResourceSets)
+
+	for _, b := range binding.Spec.Bindings {
+		crs := &addonsv1.ClusterResourceSet{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: b.ClusterResourceSetName}, crs); err != nil {
+			continue
+		}
+
+		_, visible := tree.Add(clusterResourceSets, crs, GroupingObject(true))
+		if !visible {
+			continue
+		}
+
+		for i := range b.Resources {
+			tree.Add(crs, resourceBindingObject(crs, &b.Resources[i]), NoEcho(true))
+		}
+	}
+	return nil
+}
+
+// resourceBindingObject returns an object representing a resource of a ClusterResourceSet, with a ready
+// condition reporting if the resource has been applied to the cluster.
+func resourceBindingObject(crs *addonsv1.ClusterResourceSet, resource *addonsv1.ResourceBinding) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind(resource.Kind)
+	obj.SetNamespace(crs.Namespace)
+	obj.SetName(resource.Name)
+	// NB. The same resource can be part of many ClusterResourceSets, so the object gets a unique ID to avoid conflicts.
+	obj.SetUID(types.UID(fmt.Sprintf("%s, Kind=%s, %s/%s", crs.GetUID(), resource.Kind, crs.Namespace, resource.Name)))
+
+	ready := conditions.FalseCondition(clusterv1.ReadyCondition, addonsv1.ApplyFailedReason, clusterv1.ConditionSeverityWarning, "The resource has not been applied to the cluster")
+	if resource.Applied {
+		ready = conditions.TrueCondition(clusterv1.ReadyCondition)
+	}
+	if resource.LastAppliedTime != nil {
+		ready.LastTransitionTime = *resource.LastAppliedTime
+	}
+	objToSetter(obj).SetConditions(clusterv1.Conditions{*ready})
+	return obj
+}
+
+// addMachineHealthChecks adds the MachineHealthChecks targeting the cluster, with a summary of the remediation status.
+func addMachineHealthChecks(ctx context.Context, c client.Client, tree *ObjectTree, cluster *clusterv1.Cluster) error {
+	machineHealthCheckList := &clusterv1.MachineHealthCheckList{}
+	if err := c.List(ctx, machineHealthCheckList, client.InNamespace(cluster.Namespace)); err != nil {
+		return err
+	}
+
+	var machineHealthChecks []*clusterv1.MachineHealthCheck
+	for i := range machineHealthCheckList.Items {
+		if machineHealthCheckList.Items[i].Spec.ClusterName == cluster.Name {
+			machineHealthChecks = append(machineHealthChecks, &machineHealthCheckList.Items[i])
+		}
+	}
+	if len(machineHealthChecks) == 0 {
+		return nil
+	}
+
+	group := VirtualObject(cluster.Namespace, "MachineHealthCheckGroup", "MachineHealthChecks")
+	tree.Add(cluster, group)
+
+	for _, mhc := range machineHealthChecks {
+		summary := fmt.Sprintf("%d/%d healthy, %d remediations allowed", mhc.Status.CurrentHealthy, mhc.Status.ExpectedMachines, mhc.Status.RemediationsAllowed)
+		tree.Add(group, mhc, ObjectSummary(summary))
+	}
+	return nil
+}
+
 func getMachinesInCluster(ctx context.Context, c client.Client, namespace, name string) (*clusterv1.MachineList, error) {
 	if name == "" {
 		return nil, nil
@@ -177,6 +307,21 @@ func getMachineDeploymentsInCluster(ctx context.Context, c client.Client, namesp
 	return machineDeploymentList, nil
 }
 
+func getMachinePoolsInCluster(ctx context.Context, c client.Client, namespace, name string) (*expv1.MachinePoolList, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	machinePoolList := &expv1.MachinePoolList{}
+	labels := map[string]string{clusterv1.ClusterLabelName: name}
+
+	if err := c.List(ctx, machinePoolList, client.InNamespace(namespace), client.MatchingLabels(labels)); err != nil {
+		return nil, err
+	}
+
+	return machinePoolList, nil
+}
+
 func getMachineSetsInCluster(ctx context.Context, c client.Client, namespace, name string) (*clusterv1.MachineSetList, error) {
 	if name == "" {
 		return nil, nil
@@ -22,8 +22,12 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+	addonsv1 "sigs.k8s.io/cluster-api/exp/addons/api/v1alpha4"
+	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha4"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -268,6 +272,59 @@ func Test_Discovery(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Discovery with machine pools, cluster resource sets, machine health checks and cluster class",
+			args: args{
+				discoverOptions: DiscoverOptions{},
+				objs:            fakeClusterWithAddons(),
+			},
+			wantTree: map[string][]string{
+				// Cluster should be parent of InfrastructureCluster, ClusterClass, ControlPlane, ClusterResourceSets, MachineHealthChecks and WorkerNodes
+				"cluster.x-k8s.io/v1alpha4, Kind=Cluster, ns1/cluster1": {
+					"infrastructure.cluster.x-k8s.io/v1alpha4, Kind=GenericInfrastructureCluster, ns1/cluster1",
+					"cluster.x-k8s.io/v1alpha4, Kind=ClusterClass, ns1/class1",
+					"controlplane.cluster.x-k8s.io/v1alpha4, Kind=GenericControlPlane, ns1/cp",
+					"virtual.cluster.x-k8s.io/v1alpha4, ns1/ClusterResourceSets",
+					"virtual.cluster.x-k8s.io/v1alpha4, ns1/MachineHealthChecks",
+					"virtual.cluster.x-k8s.io/v1alpha4, ns1/Workers",
+				},
+				// ClusterResourceSets should have a cluster resource set
+				"virtual.cluster.x-k8s.io/v1alpha4, ns1/ClusterResourceSets": {
+					"addons.cluster.x-k8s.io/v1alpha4, Kind=ClusterResourceSet, ns1/crs1",
+				},
+				// ClusterResourceSet should have the resources not applied (no echo)
+				"addons.cluster.x-k8s.io/v1alpha4, Kind=ClusterResourceSet, ns1/crs1": {
+					"addons.cluster.x-k8s.io/v1alpha4, Kind=ClusterResourceSet, ns1/crs1, Kind=Secret, ns1/s1",
+				},
+				// MachineHealthChecks should have a machine health check
+				"virtual.cluster.x-k8s.io/v1alpha4, ns1/MachineHealthChecks": {
+					"cluster.x-k8s.io/v1alpha4, Kind=MachineHealthCheck, ns1/mhc1",
+				},
+				// Workers should have a machine pool
+				"virtual.cluster.x-k8s.io/v1alpha4, ns1/Workers": {
+					"cluster.x-k8s.io/v1alpha4, Kind=MachinePool, ns1/mp1",
+				},
+			},
+			wantNodeCheck: map[string]nodeCheck{
+				// ClusterResourceSet should be a grouping object
+				"addons.cluster.x-k8s.io/v1alpha4, Kind=ClusterResourceSet, ns1/crs1": func(g *WithT, obj client.Object) {
+					g.Expect(IsGroupingObject(obj)).To(BeTrue())
+				},
+				// Resources not applied should not be ready
+				"addons.cluster.x-k8s.io/v1alpha4, Kind=ClusterResourceSet, ns1/crs1, Kind=Secret, ns1/s1": func(g *WithT, obj client.Object) {
+					g.Expect(GetReadyCondition(obj)).ToNot(BeNil())
+					g.Expect(GetReadyCondition(obj).Status).To(BeEquivalentTo("False"))
+				},
+				// MachineHealthCheck should have a summary
+				"cluster.x-k8s.io/v1alpha4, Kind=MachineHealthCheck, ns1/mhc1": func(g *WithT, obj client.Object) {
+					g.Expect(GetObjectSummary(obj)).To(Equal("2/3 healthy, 1 remediations allowed"))
+				},
+				// Machine pool should be managed by topology
+				"cluster.x-k8s.io/v1alpha4, Kind=MachinePool, ns1/mp1": func(g *WithT, obj client.Object) {
+					g.Expect(IsManagedByTopology(obj)).To(BeTrue())
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -303,3 +360,51 @@ func Test_Discovery(t *testing.T) {
 		})
 	}
 }
+
+func fakeClusterWithAddons() []client.Object {
+	objs := test.NewFakeCluster("ns1", "cluster1").
+		WithControlPlane(
+			test.NewFakeControlPlane("cp").
+				WithMachines(
+					test.NewFakeMachine("cp1"),
+				),
+		).
+		WithMachinePools(
+			test.NewFakeMachinePool("mp1"),
+		).
+		Objs()
+
+	for _, o := range objs {
+		switch obj := o.(type) {
+		case *clusterv1.Cluster:
+			obj.Spec.Topology = &clusterv1.Topology{Class: "class1", Version: "v1.21.2"}
+		case *expv1.MachinePool:
+			obj.Labels[clusterv1.ClusterTopologyLabelName] = ""
+		}
+	}
+	objs = append(objs, &clusterv1.ClusterClass{
+		TypeMeta:   metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "ClusterClass"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "class1", UID: "cluster.x-k8s.io/v1alpha4, Kind=ClusterClass, ns1/class1"},
+	})
+
+	cluster := test.SelectClusterObj(objs, "ns1", "cluster1")
+	crsObjs := test.NewFakeClusterResourceSet("ns1", "crs1").WithSecret("s1").WithSecret("s2").ApplyToCluster(cluster).Objs()
+	for _, o := range crsObjs {
+		if binding, ok := o.(*addonsv1.ClusterResourceSetBinding); ok {
+			for _, b := range binding.Spec.Bindings {
+				for i := range b.Resources {
+					b.Resources[i].Applied = b.Resources[i].Name == "s2"
+				}
+			}
+		}
+	}
+	objs = append(objs, crsObjs...)
+
+	objs = append(objs, &clusterv1.MachineHealthCheck{
+		TypeMeta:   metav1.TypeMeta{APIVersion: clusterv1.GroupVersion.String(), Kind: "MachineHealthCheck"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "mhc1", UID: "cluster.x-k8s.io/v1alpha4, Kind=MachineHealthCheck, ns1/mhc1"},
+		Spec:       clusterv1.MachineHealthCheckSpec{ClusterName: "cluster1"},
+		Status:     clusterv1.MachineHealthCheckStatus{ExpectedMachines: 3, CurrentHealthy: 2, RemediationsAllowed: 1},
+	})
+	return objs
+}
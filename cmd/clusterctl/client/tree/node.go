@@ -0,0 +1,141 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tree
+
+import (
+	"sort"
+	"strings"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ObjectNode is a serializable representation of an object in an ObjectTree and of its children, e.g. for
+// printing the tree in JSON or YAML format.
+// NOTE: the ObjectNode schema is consumed by external tools, so changes should be backward compatible.
+type ObjectNode struct {
+	// APIVersion of the object; virtual objects have the virtual.cluster.x-k8s.io API group.
+	APIVersion string `json:"apiVersion"`
+
+	// Kind of the object; group objects have the kind of the grouped objects, with the Group suffix.
+	Kind string `json:"kind"`
+
+	// Namespace of the object.
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the object; it is empty for group objects, use GroupItems instead.
+	Name string `json:"name,omitempty"`
+
+	// MetaName is the name used for the object in the presentation layer, e.g. ControlPlane, if any.
+	MetaName string `json:"metaName,omitempty"`
+
+	// Virtual is true if the object does not correspond to any real object, e.g. Workers.
+	Virtual bool `json:"virtual,omitempty"`
+
+	// ManagedByTopology is true if the object is managed as part of a cluster topology.
+	ManagedByTopology bool `json:"managedByTopology,omitempty"`
+
+	// Summary is a short summary of the object status, e.g. the number of healthy machines for a MachineHealthCheck.
+	Summary string `json:"summary,omitempty"`
+
+	// Deleting is true if the object is being deleted.
+	Deleting bool `json:"deleting,omitempty"`
+
+	// Ready is the ready condition of the object, if any.
+	Ready *clusterv1.Condition `json:"ready,omitempty"`
+
+	// OtherConditions are the conditions of the object except the ready condition, sorted by type.
+	OtherConditions []clusterv1.Condition `json:"otherConditions,omitempty"`
+
+	// ShowConditions is true if the presentation layer should show all the conditions for the object.
+	ShowConditions bool `json:"showConditions,omitempty"`
+
+	// Grouping is true if the children of the object with the same ready condition are grouped.
+	Grouping bool `json:"grouping,omitempty"`
+
+	// Group is true if the object represents a group of sibling objects with the same ready condition.
+	Group bool `json:"group,omitempty"`
+
+	// GroupItems is the list of names for the objects included in a group object.
+	GroupItems []string `json:"groupItems,omitempty"`
+
+	// EchoItems is the list of kind/name for the children objects not included in the tree because their
+	// ready condition is an echo of the object's ready condition.
+	EchoItems []string `json:"echoItems,omitempty"`
+
+	// Children of the object, sorted by kind and name.
+	Children []ObjectNode `json:"children,omitempty"`
+}
+
+// ToObjectNode returns a serializable representation of the object tree, starting from the root.
+func (od ObjectTree) ToObjectNode() ObjectNode {
+	return od.toObjectNode(od.root)
+}
+
+func (od ObjectTree) toObjectNode(obj client.Object) ObjectNode {
+	node := ObjectNode{
+		APIVersion:        obj.GetObjectKind().GroupVersionKind().GroupVersion().String(),
+		Kind:              obj.GetObjectKind().GroupVersionKind().Kind,
+		Namespace:         obj.GetNamespace(),
+		Name:              obj.GetName(),
+		MetaName:          GetMetaName(obj),
+		Virtual:           IsVirtualObject(obj),
+		ManagedByTopology: IsManagedByTopology(obj),
+		Summary:           GetObjectSummary(obj),
+		Deleting:          !obj.GetDeletionTimestamp().IsZero(),
+		ShowConditions:    IsShowConditionsObject(obj),
+		Grouping:          IsGroupingObject(obj),
+		Group:             IsGroupObject(obj),
+		GroupItems:        splitItems(GetGroupItems(obj)),
+		EchoItems:         splitItems(GetEchoItems(obj)),
+	}
+
+	// NOTE: group objects gets a random name to avoid conflicts, which is not relevant for the consumers.
+	if node.Group {
+		node.Name = ""
+	}
+
+	if ready := GetReadyCondition(obj); ready != nil {
+		node.Ready = ready.DeepCopy()
+	}
+	for _, c := range GetOtherConditions(obj) {
+		node.OtherConditions = append(node.OtherConditions, *c)
+	}
+
+	for _, child := range od.GetObjectsByParent(obj.GetUID()) {
+		node.Children = append(node.Children, od.toObjectNode(child))
+	}
+	sort.Slice(node.Children, func(i, j int) bool {
+		return node.Children[i].sortKey() < node.Children[j].sortKey()
+	})
+	return node
+}
+
+func (n ObjectNode) sortKey() string {
+	name := n.Name
+	if n.Group && len(n.GroupItems) > 0 {
+		name = n.GroupItems[0]
+	}
+	return n.Kind + "/" + name
+}
+
+func splitItems(items string) []string {
+	if items == "" {
+		return nil
+	}
+	return strings.Split(items, GroupItemsSeparator)
+}
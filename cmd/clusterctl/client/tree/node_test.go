@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tree
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+func Test_ToObjectNode(t *testing.T) {
+	g := NewWithT(t)
+
+	root := fakeCluster("my-cluster",
+		withClusterCondition(conditions.FalseCondition(clusterv1.ReadyCondition, "Reason", clusterv1.ConditionSeverityWarning, "")),
+		withClusterCondition(conditions.TrueCondition(clusterv1.InfrastructureReadyCondition)),
+		withClusterAnnotation(ShowObjectConditionsAnnotation, "True"),
+	)
+	objectTree := NewObjectTree(root, ObjectTreeOptions{})
+
+	workers := VirtualObject("ns", "WorkerGroup", "Workers")
+	objectTree.Add(root, workers, GroupingObject(true))
+	for _, name := range []string{"m2", "m1"} {
+		objectTree.Add(workers, fakeMachine(name, withMachineCondition(conditions.TrueCondition(clusterv1.ReadyCondition))))
+	}
+	m3 := fakeMachine("m3", withMachineCondition(conditions.FalseCondition(clusterv1.ReadyCondition, "Reason", clusterv1.ConditionSeverityInfo, "")))
+	objectTree.Add(workers, m3)
+	objectTree.Add(m3, fakeMachine("m3-echo", withMachineCondition(conditions.TrueCondition(clusterv1.ReadyCondition))), NoEcho(true))
+
+	node := objectTree.ToObjectNode()
+	g.Expect(node.Kind).To(Equal("Cluster"))
+	g.Expect(node.Name).To(Equal("my-cluster"))
+	g.Expect(node.Ready).ToNot(BeNil())
+	g.Expect(node.Ready.Reason).To(Equal("Reason"))
+	g.Expect(node.OtherConditions).To(HaveLen(1))
+	g.Expect(node.OtherConditions[0].Type).To(Equal(clusterv1.InfrastructureReadyCondition))
+	g.Expect(node.ShowConditions).To(BeTrue())
+	g.Expect(node.Children).To(HaveLen(1))
+
+	workersNode := node.Children[0]
+	g.Expect(workersNode.Virtual).To(BeTrue())
+	g.Expect(workersNode.Grouping).To(BeTrue())
+	g.Expect(workersNode.Children).To(HaveLen(2))
+
+	g.Expect(workersNode.Children[0].Kind).To(Equal("Machine"))
+	g.Expect(workersNode.Children[0].Name).To(Equal("m3"))
+	g.Expect(workersNode.Children[0].EchoItems).To(Equal([]string{"Machine/m3-echo"}))
+	g.Expect(workersNode.Children[0].Children).To(BeEmpty())
+
+	g.Expect(workersNode.Children[1].Kind).To(Equal("MachineGroup"))
+	g.Expect(workersNode.Children[1].Name).To(BeEmpty())
+	g.Expect(workersNode.Children[1].Group).To(BeTrue())
+	g.Expect(workersNode.Children[1].GroupItems).To(Equal([]string{"m1", "m2"}))
+}
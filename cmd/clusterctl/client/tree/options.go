@@ -25,6 +25,7 @@ type addObjectOptions struct {
 	MetaName       string
 	GroupingObject bool
 	NoEcho         bool
+	Summary        string
 }
 
 func (o *addObjectOptions) ApplyOptions(opts []AddObjectOption) *addObjectOptions {
@@ -60,3 +61,12 @@ type NoEcho bool
 func (n NoEcho) ApplyToAdd(options *addObjectOptions) {
 	options.NoEcho = bool(n)
 }
+
+// The ObjectSummary option defines a short summary of the object status that should be shown in the presentation
+// layer, e.g. the number of healthy machines for a MachineHealthCheck.
+type ObjectSummary string
+
+// ApplyToAdd applies the given options.
+func (n ObjectSummary) ApplyToAdd(options *addObjectOptions) {
+	options.Summary = string(n)
+}
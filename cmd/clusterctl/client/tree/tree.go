@@ -85,6 +85,8 @@ func (od ObjectTree) Add(parent, obj client.Object, opts ...AddObjectOption) (ad
 	// return early.
 	if addOpts.NoEcho && !od.options.DisableNoEcho {
 		if (objReady != nil && objReady.Status == corev1.ConditionTrue) || hasSameReadyStatusSeverityAndReason(parentReady, objReady) {
+			// Keep track of the hidden object in the EchoItemsAnnotation of the parent.
+			addEchoItem(parent, obj)
 			return false, false
 		}
 	}
@@ -95,6 +97,12 @@ func (od ObjectTree) Add(parent, obj client.Object, opts ...AddObjectOption) (ad
 		addAnnotation(obj, ObjectMetaNameAnnotation, addOpts.MetaName)
 	}
 
+	// If it is requested to show a summary of the object status in the presentation layer, add
+	// the ObjectSummaryAnnotation to signal this to the presentation layer.
+	if addOpts.Summary != "" {
+		addAnnotation(obj, ObjectSummaryAnnotation, addOpts.Summary)
+	}
+
 	// If it is requested that this object and its sibling should be grouped in case the ready condition
 	// has the same Status, Severity and Reason, process all the sibling nodes.
 	if IsGroupingObject(parent) {
@@ -213,6 +221,16 @@ func createGroupNode(sibling client.Object, siblingReady *clusterv1.Condition, o
 	return groupNode
 }
 
+func addEchoItem(parent client.Object, obj client.Object) {
+	item := fmt.Sprintf("%s/%s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName())
+	items := []string{item}
+	if echoItems := GetEchoItems(parent); echoItems != "" {
+		items = append(strings.Split(echoItems, GroupItemsSeparator), item)
+	}
+	sort.Strings(items)
+	addAnnotation(parent, EchoItemsAnnotation, strings.Join(items, GroupItemsSeparator))
+}
+
 func readyStatusSeverityAndReasonUID(obj client.Object) string {
 	ready := GetReadyCondition(obj)
 	if ready == nil {
@@ -18,6 +18,7 @@ package client
 
 import (
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -100,6 +101,25 @@ type ApplyUpgradeOptions struct {
 
 	// InfrastructureProviders instance and versions (e.g. capa-system/aws:v0.5.0) to upgrade to. This field can be used as alternative to Contract.
 	InfrastructureProviders []string
+
+	// MigrateStorageVersions migrates the objects stored with versions that are removed from the CustomResourceDefinitions
+	// by the upgrade before applying the new CustomResourceDefinitions; if not set, the upgrade fails when a migration is required.
+	MigrateStorageVersions bool
+
+	// SkipPreflightChecks skips the checks on the health of the management cluster before the upgrade, e.g. provider
+	// controllers not available, MachineDeployments or control planes rolling out, webhooks not reachable.
+	SkipPreflightChecks bool
+
+	// SkipVerification skips waiting for the upgraded provider controllers to become ready and checking that objects
+	// of the provider's CustomResourceDefinitions can be converted across all the served versions.
+	SkipVerification bool
+
+	// VerificationTimeout is the time to wait for the upgraded provider controllers to become ready. If unspecified, 5 minutes are used.
+	VerificationTimeout time.Duration
+
+	// RollbackOnFailure reinstalls the previous versions of the upgraded providers if the verification fails;
+	// the rollback is not supported if the upgrade changes the CustomResourceDefinitions of the providers.
+	RollbackOnFailure bool
 }
 
 func (c *clusterctlClient) ApplyUpgrade(options ApplyUpgradeOptions) error {
@@ -132,6 +152,14 @@ func (c *clusterctlClient) ApplyUpgrade(options ApplyUpgradeOptions) error {
 		return err
 	}
 
+	upgradeOptions := cluster.UpgradeOptions{
+		MigrateStorageVersions: options.MigrateStorageVersions,
+		SkipPreflightChecks:    options.SkipPreflightChecks,
+		SkipVerification:       options.SkipVerification,
+		VerificationTimeout:    options.VerificationTimeout,
+		RollbackOnFailure:      options.RollbackOnFailure,
+	}
+
 	// Check if the user want a custom upgrade
 	isCustomUpgrade := options.CoreProvider != "" ||
 		len(options.BootstrapProviders) > 0 ||
@@ -163,11 +191,11 @@ func (c *clusterctlClient) ApplyUpgrade(options ApplyUpgradeOptions) error {
 		}
 
 		// Execute the upgrade using the custom upgrade items
-		return clusterClient.ProviderUpgrader().ApplyCustomPlan(upgradeItems...)
+		return clusterClient.ProviderUpgrader().ApplyCustomPlan(upgradeOptions, upgradeItems...)
 	}
 
 	// Otherwise we are upgrading a whole management cluster according to a clusterctl generated upgrade plan.
-	return clusterClient.ProviderUpgrader().ApplyPlan(options.Contract)
+	return clusterClient.ProviderUpgrader().ApplyPlan(upgradeOptions, options.Contract)
 }
 
 func addUpgradeItems(upgradeItems []cluster.UpgradeItem, providerType clusterctlv1.ProviderType, providers ...string) ([]cluster.UpgradeItem, error) {
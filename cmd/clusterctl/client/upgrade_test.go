@@ -17,12 +17,16 @@ limitations under the License.
 package client
 
 import (
+	"fmt"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	. "github.com/onsi/gomega"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	clusterctlv1 "sigs.k8s.io/cluster-api/cmd/clusterctl/api/v1alpha3"
@@ -305,6 +309,206 @@ func Test_clusterctlClient_ApplyUpgrade(t *testing.T) {
 	}
 }
 
+func Test_clusterctlClient_ApplyUpgrade_PreflightAndVerification(t *testing.T) {
+	kubeconfig := Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}
+
+	tests := []struct {
+		name                string
+		deploymentAvailable bool
+		targetComponents    []byte
+		options             ApplyUpgradeOptions
+		wantErr             string
+		wantInfraVersion    string
+	}{
+		{
+			name:                "fails the preflight checks if a provider controller is not available",
+			deploymentAvailable: false,
+			targetComponents:    infraComponentsWithManagerYAML("infra-system", false),
+			options:             ApplyUpgradeOptions{SkipVerification: true},
+			wantErr:             "preflight checks failed",
+			wantInfraVersion:    "v2.0.0",
+		},
+		{
+			name:                "upgrades if the preflight checks are skipped",
+			deploymentAvailable: false,
+			targetComponents:    infraComponentsWithManagerYAML("infra-system", false),
+			options:             ApplyUpgradeOptions{SkipPreflightChecks: true, SkipVerification: true},
+			wantInfraVersion:    "v2.0.1",
+		},
+		{
+			name:                "reports the verification failure without rolling back",
+			deploymentAvailable: true,
+			targetComponents:    infraComponentsWithManagerYAML("infra-system", false),
+			options:             ApplyUpgradeOptions{VerificationTimeout: 10 * time.Millisecond},
+			wantErr:             "failed to verify the upgrade",
+			wantInfraVersion:    "v2.0.1",
+		},
+		{
+			name:                "rolls back if the verification fails",
+			deploymentAvailable: true,
+			targetComponents:    infraComponentsWithManagerYAML("infra-system", false),
+			options:             ApplyUpgradeOptions{VerificationTimeout: 10 * time.Millisecond, RollbackOnFailure: true},
+			wantErr:             "providers have been rolled back to the previous versions",
+			wantInfraVersion:    "v2.0.0",
+		},
+		{
+			name:                "does not roll back if the upgrade changes the CustomResourceDefinitions",
+			deploymentAvailable: true,
+			targetComponents:    infraComponentsWithManagerYAML("infra-system", true),
+			options:             ApplyUpgradeOptions{VerificationTimeout: 10 * time.Millisecond, RollbackOnFailure: true},
+			wantErr:             "providers have not been rolled back because the upgrade changed the CustomResourceDefinitions of infra-system/infrastructure-infra",
+			wantInfraVersion:    "v2.0.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			client := fakeClientForUpgradeVerification(tt.deploymentAvailable, tt.targetComponents)
+
+			options := tt.options
+			options.Kubeconfig = kubeconfig
+			options.InfrastructureProviders = []string{"infra-system/infra:v2.0.1"}
+			err := client.ApplyUpgrade(options)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+
+			c, err := client.clusters[cluster.Kubeconfig(kubeconfig)].Proxy().NewClient()
+			g.Expect(err).NotTo(HaveOccurred())
+			gotProviders := &clusterctlv1.ProviderList{}
+			g.Expect(c.List(ctx, gotProviders)).To(Succeed())
+
+			gotInfraVersion := ""
+			for _, p := range gotProviders.Items {
+				if p.Type == string(clusterctlv1.InfrastructureProviderType) {
+					gotInfraVersion = p.Version
+				}
+			}
+			g.Expect(gotInfraVersion).To(Equal(tt.wantInfraVersion))
+		})
+	}
+}
+
+// fakeClientForUpgradeVerification returns a fake client for a management cluster with the core provider v1.0.0 and the
+// infra provider v2.0.0, whose controller Deployment is available or not; the infra provider can be upgraded to v2.0.1
+// using the given components.
+func fakeClientForUpgradeVerification(deploymentAvailable bool, targetComponents []byte) *fakeClient {
+	core := config.NewProvider("cluster-api", "https://somewhere.com", clusterctlv1.CoreProviderType)
+	infra := config.NewProvider("infra", "https://somewhere.com", clusterctlv1.InfrastructureProviderType)
+
+	config1 := newFakeConfig().
+		WithProvider(core).
+		WithProvider(infra)
+
+	repository1 := newFakeRepository(core, config1).
+		WithPaths("root", "components.yaml").
+		WithDefaultVersion("v1.0.0").
+		WithFile("v1.0.0", "components.yaml", componentsYAML("ns1")).
+		WithVersions("v1.0.0").
+		WithMetadata("v1.0.0", &clusterctlv1.Metadata{
+			ReleaseSeries: []clusterctlv1.ReleaseSeries{
+				{Major: 1, Minor: 0, Contract: test.CurrentCAPIContract},
+			},
+		})
+	repository2 := newFakeRepository(infra, config1).
+		WithPaths("root", "components.yaml").
+		WithDefaultVersion("v2.0.0").
+		WithFile("v2.0.0", "components.yaml", infraComponentsWithManagerYAML("infra-system", false)).
+		WithFile("v2.0.1", "components.yaml", targetComponents).
+		WithVersions("v2.0.0", "v2.0.1").
+		WithMetadata("v2.0.1", &clusterctlv1.Metadata{
+			ReleaseSeries: []clusterctlv1.ReleaseSeries{
+				{Major: 2, Minor: 0, Contract: test.CurrentCAPIContract},
+			},
+		})
+
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "infra-system",
+			Name:      "infra-controller-manager",
+			Labels: map[string]string{
+				clusterctlv1.ClusterctlLabelName: "",
+				clusterv1.ProviderLabelName:      clusterctlv1.ManifestLabel(infra.Name(), infra.Type()),
+			},
+		},
+	}
+	if deploymentAvailable {
+		deployment.Status.Conditions = []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue}}
+	}
+
+	cluster1 := newFakeCluster(cluster.Kubeconfig{Path: "kubeconfig", Context: "mgmt-context"}, config1).
+		WithRepository(repository1).
+		WithRepository(repository2).
+		WithProviderInventory(core.Name(), core.Type(), "v1.0.0", "cluster-api-system").
+		WithProviderInventory(infra.Name(), infra.Type(), "v2.0.0", "infra-system").
+		WithObjs(test.FakeCAPISetupObjects()...).
+		WithObjs(deployment)
+
+	client := newFakeClient(config1).
+		WithRepository(repository1).
+		WithRepository(repository2).
+		WithCluster(cluster1)
+
+	return client
+}
+
+// infraComponentsWithManagerYAML defines a namespace and a controller Deployment, and optionally a CustomResourceDefinition.
+func infraComponentsWithManagerYAML(namespace string, withCRD bool) []byte {
+	components := fmt.Sprintf(`---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: %[1]s
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: infra-controller-manager
+  namespace: %[1]s
+spec:
+  selector:
+    matchLabels:
+      control-plane: controller-manager
+  template:
+    metadata:
+      labels:
+        control-plane: controller-manager
+    spec:
+      containers:
+      - name: manager
+        image: registry.k8s.io/infra-controller:latest
+`, namespace)
+	if withCRD {
+		components += `---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: infraclusters.infrastructure.cluster.x-k8s.io
+spec:
+  group: infrastructure.cluster.x-k8s.io
+  names:
+    kind: InfraCluster
+    listKind: InfraClusterList
+    plural: infraclusters
+    singular: infracluster
+  scope: Namespaced
+  versions:
+  - name: v1alpha4
+    served: true
+    storage: true
+`
+	}
+	return []byte(components)
+}
+
 func fakeClientForUpgrade() *fakeClient {
 	core := config.NewProvider("cluster-api", "https://somewhere.com", clusterctlv1.CoreProviderType)
 	infra := config.NewProvider("infra", "https://somewhere.com", clusterctlv1.InfrastructureProviderType)
@@ -0,0 +1,208 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yamlprocessor
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// goTemplateFuncs returns the helper functions available in Go templates; helpers are a subset of the
+// sprig library (https://masterminds.github.io/sprig/), with the same names and semantics.
+func goTemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		// Defaults.
+		"default":  defaultFunc,
+		"required": requiredFunc,
+		"empty":    empty,
+		"coalesce": coalesce,
+		"ternary":  ternary,
+
+		// Strings.
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"title":      strings.Title,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"quote":      func(v interface{}) string { return strconv.Quote(toString(v)) },
+		"squote":     func(v interface{}) string { return "'" + toString(v) + "'" },
+		"indent":     indent,
+		"nindent":    func(spaces int, s string) string { return "\n" + indent(spaces, s) },
+		"join":       join,
+		"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+		"b64enc":     func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"b64dec":     b64dec,
+
+		// Conversions.
+		"toString": toString,
+		"toYaml":   toYaml,
+		"int":      toInt,
+		"bool":     toBool,
+
+		// Lists and dictionaries.
+		"list": func(v ...interface{}) []interface{} { return v },
+		"dict": dict,
+	}
+}
+
+// defaultFunc returns the given value if not empty, the default value otherwise; e.g. {{ .REPLICAS | default 3 }}.
+func defaultFunc(d interface{}, given ...interface{}) interface{} {
+	if len(given) == 0 || empty(given[0]) {
+		return d
+	}
+	return given[0]
+}
+
+// requiredFunc returns an error with the given message if the value is empty.
+func requiredFunc(msg string, v interface{}) (interface{}, error) {
+	if empty(v) {
+		return nil, errors.New(msg)
+	}
+	return v, nil
+}
+
+// empty returns true if the given value is the zero value for its type.
+func empty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return rv.IsZero()
+	}
+}
+
+// coalesce returns the first non empty value.
+func coalesce(v ...interface{}) interface{} {
+	for _, i := range v {
+		if !empty(i) {
+			return i
+		}
+	}
+	return nil
+}
+
+// ternary returns the first value if the condition is true, the second value otherwise.
+func ternary(vt, vf interface{}, condition bool) interface{} {
+	if condition {
+		return vt
+	}
+	return vf
+}
+
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+func join(sep string, v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return toString(v)
+	}
+	items := make([]string, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		items = append(items, toString(rv.Index(i).Interface()))
+	}
+	return strings.Join(items, sep)
+}
+
+func b64dec(s string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decode base64 value")
+	}
+	return string(b), nil
+}
+
+func toString(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// toYaml returns the YAML representation of the given value, without the trailing new line.
+func toYaml(v interface{}) (string, error) {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to convert value to yaml")
+	}
+	return strings.TrimSuffix(string(b), "\n"), nil
+}
+
+func toInt(v interface{}) (int, error) {
+	switch t := v.(type) {
+	case int:
+		return t, nil
+	case int64:
+		return int(t), nil
+	case float64:
+		return int(t), nil
+	default:
+		i, err := strconv.Atoi(strings.TrimSpace(toString(v)))
+		if err != nil {
+			return 0, errors.Errorf("failed to convert %q to int", toString(v))
+		}
+		return i, nil
+	}
+}
+
+func toBool(v interface{}) (bool, error) {
+	if b, ok := v.(bool); ok {
+		return b, nil
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(toString(v)))
+	if err != nil {
+		return false, errors.Errorf("failed to convert %q to bool", toString(v))
+	}
+	return b, nil
+}
+
+func dict(v ...interface{}) (map[string]interface{}, error) {
+	if len(v)%2 != 0 {
+		return nil, errors.New("dict requires an even number of arguments")
+	}
+	d := make(map[string]interface{}, len(v)/2)
+	for i := 0; i < len(v); i += 2 {
+		d[toString(v[i])] = v[i+1]
+	}
+	return d, nil
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yamlprocessor
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/pkg/errors"
+)
+
+// GoTemplateProcessor is a yaml processor that uses Go text/template for processing templates.
+// Variables are referenced as top level fields, e.g. {{ .CLUSTER_NAME }}, and the templates can use
+// a subset of the sprig helpers, e.g. {{ .WORKER_MACHINE_COUNT | default 3 }}.
+// Values for variables are read from the typed values passed to the processor, if any, and then
+// from the variables client, e.g. OS environment variables or the clusterctl config file.
+// See https://pkg.go.dev/text/template for more details.
+type GoTemplateProcessor struct {
+	values map[string]interface{}
+}
+
+var _ Processor = &GoTemplateProcessor{}
+
+// NewGoTemplateProcessor returns a new Go template processor using the given typed values.
+func NewGoTemplateProcessor(values map[string]interface{}) *GoTemplateProcessor {
+	return &GoTemplateProcessor{
+		values: values,
+	}
+}
+
+// GetTemplateName returns the name of the template that the Go template processor
+// uses. It follows the cluster template naming convention of
+// "cluster-template<-flavor>.yaml".
+func (tp *GoTemplateProcessor) GetTemplateName(version, flavor string) string {
+	return NewSimpleProcessor().GetTemplateName(version, flavor)
+}
+
+// GetVariables returns a list of the variables specified in the template.
+func (tp *GoTemplateProcessor) GetVariables(rawArtifact []byte) ([]string, error) {
+	variables, err := tp.GetVariableMap(rawArtifact)
+	if err != nil {
+		return nil, err
+	}
+	varNames := make([]string, 0, len(variables))
+	for k := range variables {
+		varNames = append(varNames, k)
+	}
+	sort.Strings(varNames)
+	return varNames, nil
+}
+
+// GetVariableMap returns a map of the variables specified in the template with their
+// default values, as defined using the default helper.
+func (tp *GoTemplateProcessor) GetVariableMap(rawArtifact []byte) (map[string]*string, error) {
+	t, err := tp.parse(rawArtifact)
+	if err != nil {
+		return nil, err
+	}
+
+	variables := map[string]*string{}
+	for _, tmpl := range t.Templates() {
+		if tmpl.Tree == nil || tmpl.Tree.Root == nil {
+			continue
+		}
+		inspectGoTemplateNode(tmpl.Tree.Root, true, variables)
+	}
+	return variables, nil
+}
+
+// Process returns the final yaml generated by executing the template. If there are variables
+// without corresponding values and without default values, it will return the raw yaml along with an error.
+func (tp *GoTemplateProcessor) Process(rawArtifact []byte, variablesClient func(string) (string, error)) ([]byte, error) {
+	t, err := tp.parse(rawArtifact)
+	if err != nil {
+		return rawArtifact, err
+	}
+
+	variables, err := tp.GetVariableMap(rawArtifact)
+	if err != nil {
+		return rawArtifact, err
+	}
+
+	var missingVariables []string
+	data := map[string]interface{}{}
+	for name, defaultValue := range variables {
+		if v, ok := tp.values[name]; ok {
+			data[name] = v
+			continue
+		}
+		if v, err := variablesClient(name); err == nil {
+			data[name] = v
+			continue
+		}
+		// add to missingVariables list if the variable does not exist in the
+		// values/variablesClient AND it does not have a default value
+		if defaultValue == nil {
+			missingVariables = append(missingVariables, name)
+		}
+	}
+
+	if len(missingVariables) > 0 {
+		return rawArtifact, &errMissingVariables{missingVariables}
+	}
+
+	var out bytes.Buffer
+	if err := t.Option("missingkey=zero").Execute(&out, data); err != nil {
+		return rawArtifact, errors.Wrap(err, "failed to execute the template")
+	}
+	return out.Bytes(), nil
+}
+
+func (tp *GoTemplateProcessor) parse(rawArtifact []byte) (*template.Template, error) {
+	t, err := template.New("template").Funcs(goTemplateFuncs()).Parse(string(rawArtifact))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the template")
+	}
+	return t, nil
+}
+
+// inspectGoTemplateNode recursively walks down a template node and tracks the variables, that are the
+// fields of the root data object, and their default values, if any.
+// rootDot is false when walking nodes where the dot does not represent the root data object, e.g. inside range.
+func inspectGoTemplateNode(node parse.Node, rootDot bool, variables map[string]*string) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, ln := range n.Nodes {
+			inspectGoTemplateNode(ln, rootDot, variables)
+		}
+	case *parse.ActionNode:
+		inspectGoTemplateNode(n.Pipe, rootDot, variables)
+	case *parse.IfNode:
+		inspectGoTemplateNode(n.Pipe, rootDot, variables)
+		inspectGoTemplateNode(n.List, rootDot, variables)
+		inspectGoTemplateNode(n.ElseList, rootDot, variables)
+	case *parse.RangeNode:
+		inspectGoTemplateNode(n.Pipe, rootDot, variables)
+		inspectGoTemplateNode(n.List, false, variables)
+		inspectGoTemplateNode(n.ElseList, rootDot, variables)
+	case *parse.WithNode:
+		inspectGoTemplateNode(n.Pipe, rootDot, variables)
+		inspectGoTemplateNode(n.List, false, variables)
+		inspectGoTemplateNode(n.ElseList, rootDot, variables)
+	case *parse.TemplateNode:
+		inspectGoTemplateNode(n.Pipe, rootDot, variables)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		var previous string
+		for _, cmd := range n.Cmds {
+			// Track default values defined with {{ default <value> .VAR }} or {{ .VAR | default <value> }}.
+			if name, value, ok := goTemplateDefault(cmd, previous, rootDot); ok {
+				variables[name] = &value
+			}
+			for _, arg := range cmd.Args {
+				inspectGoTemplateNode(arg, rootDot, variables)
+			}
+			previous = ""
+			if len(cmd.Args) == 1 {
+				previous = goTemplateVariable(cmd.Args[0], rootDot)
+			}
+		}
+	case *parse.ChainNode:
+		inspectGoTemplateNode(n.Node, rootDot, variables)
+	case *parse.FieldNode, *parse.VariableNode:
+		if name := goTemplateVariable(n, rootDot); name != "" {
+			if _, ok := variables[name]; !ok {
+				variables[name] = nil
+			}
+		}
+	}
+}
+
+// goTemplateVariable returns the name of the variable referenced by a node, if any.
+func goTemplateVariable(node parse.Node, rootDot bool) string {
+	switch n := node.(type) {
+	case *parse.FieldNode:
+		if rootDot && len(n.Ident) > 0 {
+			return n.Ident[0]
+		}
+	case *parse.VariableNode:
+		// $ always represents the root data object.
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			return n.Ident[1]
+		}
+	}
+	return ""
+}
+
+// goTemplateDefault returns the variable and the default value defined by a command using the default helper, if any.
+func goTemplateDefault(cmd *parse.CommandNode, piped string, rootDot bool) (string, string, bool) {
+	if len(cmd.Args) < 2 {
+		return "", "", false
+	}
+	if ident, ok := cmd.Args[0].(*parse.IdentifierNode); !ok || ident.Ident != "default" {
+		return "", "", false
+	}
+
+	var value string
+	switch v := cmd.Args[1].(type) {
+	case *parse.StringNode:
+		value = v.Text
+	case *parse.NumberNode:
+		value = v.Text
+	case *parse.BoolNode:
+		value = strconv.FormatBool(v.True)
+	default:
+		return "", "", false
+	}
+
+	name := piped
+	if len(cmd.Args) == 3 {
+		name = goTemplateVariable(cmd.Args[2], rootDot)
+	}
+	if name == "" {
+		return "", "", false
+	}
+	return name, value, true
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yamlprocessor
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/config"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test"
+)
+
+func TestGoTemplateProcessor_GetVariableMap(t *testing.T) {
+	three := "3"
+	foo := "foo"
+	yes := "true"
+	tests := []struct {
+		name    string
+		data    string
+		want    map[string]*string
+		wantErr bool
+	}{
+		{
+			name: "variables referenced as root fields",
+			data: "name: {{ .CLUSTER_NAME }}\nnamespace: {{ $.NAMESPACE }}",
+			want: map[string]*string{"CLUSTER_NAME": nil, "NAMESPACE": nil},
+		},
+		{
+			name: "variables with default values",
+			data: "replicas: {{ .WORKER_MACHINE_COUNT | default 3 }}\nname: {{ default \"foo\" .NAME }}\nenabled: {{ .ENABLED | default true }}",
+			want: map[string]*string{"WORKER_MACHINE_COUNT": &three, "NAME": &foo, "ENABLED": &yes},
+		},
+		{
+			name: "variables used in conditions and ranges",
+			data: "{{ if .HA }}replicas: 3{{ end }}\n{{ range .ZONES }}- {{ .name }} {{ $.REGION }}\n{{ end }}",
+			want: map[string]*string{"HA": nil, "ZONES": nil, "REGION": nil},
+		},
+		{
+			name:    "returns error for invalid templates",
+			data:    "name: {{ .CLUSTER_NAME ",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			p := NewGoTemplateProcessor(nil)
+			actual, err := p.GetVariableMap([]byte(tt.data))
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(actual).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestGoTemplateProcessor_Process(t *testing.T) {
+	type args struct {
+		data                  string
+		values                map[string]interface{}
+		configVariablesClient config.VariablesClient
+	}
+	tests := []struct {
+		name             string
+		args             args
+		want             string
+		wantErr          bool
+		missingVariables []string
+	}{
+		{
+			name: "uses values from the variables client",
+			args: args{
+				data:                  "name: {{ .CLUSTER_NAME | upper }}",
+				configVariablesClient: test.NewFakeVariableClient().WithVar("CLUSTER_NAME", "foo"),
+			},
+			want: "name: FOO",
+		},
+		{
+			name: "typed values take precedence over the variables client",
+			args: args{
+				data: "{{ range .ZONES }}- {{ . }}\n{{ end }}{{ if .HA }}replicas: 3{{ end }}",
+				values: map[string]interface{}{
+					"ZONES": []interface{}{"a", "b"},
+					"HA":    true,
+				},
+				configVariablesClient: test.NewFakeVariableClient().WithVar("HA", "false"),
+			},
+			want: "- a\n- b\nreplicas: 3",
+		},
+		{
+			name: "uses default values",
+			args: args{
+				data:                  "replicas: {{ .WORKER_MACHINE_COUNT | default 3 }}",
+				configVariablesClient: test.NewFakeVariableClient(),
+			},
+			want: "replicas: 3",
+		},
+		{
+			name: "returns error for missing variables",
+			args: args{
+				data:                  "name: {{ .CLUSTER_NAME }} {{ .NAMESPACE }} {{ .REPLICAS | default 1 }}",
+				configVariablesClient: test.NewFakeVariableClient().WithVar("NAMESPACE", "ns"),
+			},
+			wantErr:          true,
+			missingVariables: []string{"CLUSTER_NAME"},
+		},
+		{
+			name: "returns error if the template fails",
+			args: args{
+				data:                  "name: {{ required \"name is required\" .CLUSTER_NAME }}",
+				configVariablesClient: test.NewFakeVariableClient().WithVar("CLUSTER_NAME", ""),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			p := NewGoTemplateProcessor(tt.args.values)
+			got, err := p.Process([]byte(tt.args.data), tt.args.configVariablesClient.Get)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				if len(tt.missingVariables) != 0 {
+					e, ok := err.(*errMissingVariables)
+					g.Expect(ok).To(BeTrue())
+					g.Expect(e.Missing).To(ConsistOf(tt.missingVariables))
+				}
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(string(got)).To(Equal(tt.want))
+		})
+	}
+}
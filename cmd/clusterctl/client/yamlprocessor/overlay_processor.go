@@ -0,0 +1,246 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yamlprocessor
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	kustomizationKind = "Kustomization"
+	kustomizationFile = "kustomization.yaml"
+
+	// maxKustomizationDepth is the max number of nested kustomizations; it prevents infinite loops.
+	maxKustomizationDepth = 10
+)
+
+// Resolver is implemented by processors whose templates reference other files in the same
+// repository, e.g. bases and overlays.
+type Resolver interface {
+	// Resolve returns the template generated by combining the template blob of bytes with the
+	// other files it references, that are read using the given file getter.
+	Resolve(rawArtifact []byte, getFile func(path string) ([]byte, error)) ([]byte, error)
+}
+
+// OverlayProcessor is a yaml processor for templates defined as a Kustomization, with bases and
+// patches read from the same provider repository.
+// NOTE: This is not a kustomize implementation; only the following subset of the Kustomization fields is supported,
+// and Kustomizations using any other field, e.g. patchesStrategicMerge, are rejected:
+//   - resources: files or directories (containing a kustomization.yaml file) to include.
+//   - patchesJson6902: JSON patches, inline or from a file, applied to the target resource.
+//   - commonLabels and commonAnnotations: labels and annotations added to all the resources.
+//
+// Once the Kustomization is resolved, variables in the format ${var} are processed like in the SimpleProcessor.
+type OverlayProcessor struct {
+	SimpleProcessor
+}
+
+var _ Processor = &OverlayProcessor{}
+var _ Resolver = &OverlayProcessor{}
+
+// NewOverlayProcessor returns a new Overlay processor.
+func NewOverlayProcessor() *OverlayProcessor {
+	return &OverlayProcessor{}
+}
+
+type kustomization struct {
+	APIVersion        string                       `json:"apiVersion,omitempty"`
+	Kind              string                       `json:"kind,omitempty"`
+	Resources         []string                     `json:"resources,omitempty"`
+	PatchesJSON6902   []kustomizationJSON6902Patch `json:"patchesJson6902,omitempty"`
+	CommonLabels      map[string]string            `json:"commonLabels,omitempty"`
+	CommonAnnotations map[string]string            `json:"commonAnnotations,omitempty"`
+}
+
+type kustomizationJSON6902Patch struct {
+	Target kustomizationTarget `json:"target"`
+	Path   string              `json:"path,omitempty"`
+	Patch  string              `json:"patch,omitempty"`
+}
+
+type kustomizationTarget struct {
+	Group     string `json:"group,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// Resolve returns the resources generated by the Kustomization, without processing variables.
+// Paths in the Kustomization are relative to the location of the Kustomization itself.
+func (tp *OverlayProcessor) Resolve(rawArtifact []byte, getFile func(path string) ([]byte, error)) ([]byte, error) {
+	objs, err := tp.build(rawArtifact, ".", getFile, 0)
+	if err != nil {
+		return nil, err
+	}
+	return utilyaml.FromUnstructured(objs)
+}
+
+func (tp *OverlayProcessor) build(rawKustomization []byte, dir string, getFile func(path string) ([]byte, error), depth int) ([]unstructured.Unstructured, error) {
+	if depth > maxKustomizationDepth {
+		return nil, errors.New("too many nested kustomizations")
+	}
+
+	k := &kustomization{}
+	if err := yaml.Unmarshal(rawKustomization, k); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the kustomization")
+	}
+	if k.Kind != kustomizationKind {
+		return nil, errors.Errorf("invalid kustomization: kind must be %s", kustomizationKind)
+	}
+	if err := yaml.UnmarshalStrict(rawKustomization, &kustomization{}); err != nil {
+		return nil, errors.Wrap(err, "unsupported kustomization: only resources, patchesJson6902, commonLabels and commonAnnotations can be used")
+	}
+
+	// Collect resources, resolving nested kustomizations if any.
+	objs := []unstructured.Unstructured{}
+	for _, r := range k.Resources {
+		resourcePath := path.Join(dir, r)
+		if !isYamlFile(resourcePath) {
+			resourcePath = path.Join(resourcePath, kustomizationFile)
+		}
+		content, err := getFile(resourcePath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read resource %q", resourcePath)
+		}
+
+		if isKustomization(content) {
+			nested, err := tp.build(content, path.Dir(resourcePath), getFile, depth+1)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to build kustomization %q", resourcePath)
+			}
+			objs = append(objs, nested...)
+			continue
+		}
+
+		resources, err := utilyaml.ToUnstructured(content)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse resource %q", resourcePath)
+		}
+		objs = append(objs, resources...)
+	}
+
+	// Apply JSON patches.
+	for _, p := range k.PatchesJSON6902 {
+		rawPatch := []byte(p.Patch)
+		if p.Path != "" {
+			patchPath := path.Join(dir, p.Path)
+			content, err := getFile(patchPath)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read patch %q", patchPath)
+			}
+			rawPatch = content
+		}
+		if err := applyJSONPatch(objs, p.Target, rawPatch); err != nil {
+			return nil, errors.Wrapf(err, "failed to apply JSON patch to %s %s", p.Target.Kind, p.Target.Name)
+		}
+	}
+
+	// Add common labels and annotations.
+	for i := range objs {
+		if len(k.CommonLabels) > 0 {
+			labels := objs[i].GetLabels()
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			for key, value := range k.CommonLabels {
+				labels[key] = value
+			}
+			objs[i].SetLabels(labels)
+		}
+		if len(k.CommonAnnotations) > 0 {
+			annotations := objs[i].GetAnnotations()
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			for key, value := range k.CommonAnnotations {
+				annotations[key] = value
+			}
+			objs[i].SetAnnotations(annotations)
+		}
+	}
+
+	return objs, nil
+}
+
+// applyJSONPatch applies a JSON patch, in either YAML or JSON format, to the target object.
+func applyJSONPatch(objs []unstructured.Unstructured, target kustomizationTarget, rawPatch []byte) error {
+	patchJSON, err := yaml.YAMLToJSON(rawPatch)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the patch")
+	}
+	patch, err := jsonpatch.DecodePatch(patchJSON)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode the patch")
+	}
+
+	targetGK := schema.GroupKind{Group: target.Group, Kind: target.Kind}
+	for i := range objs {
+		gvk := objs[i].GroupVersionKind()
+		if gvk.GroupKind() != targetGK || objs[i].GetName() != target.Name {
+			continue
+		}
+		if target.Version != "" && gvk.Version != target.Version {
+			continue
+		}
+		if target.Namespace != "" && objs[i].GetNamespace() != target.Namespace {
+			continue
+		}
+
+		original, err := json.Marshal(objs[i].Object)
+		if err != nil {
+			return err
+		}
+		patched, err := patch.Apply(original)
+		if err != nil {
+			return err
+		}
+		return setObject(&objs[i], patched)
+	}
+	return errors.New("failed to find the target")
+}
+
+// setObject replaces the content of an object with the given JSON.
+func setObject(obj *unstructured.Unstructured, content []byte) error {
+	o := map[string]interface{}{}
+	if err := json.Unmarshal(content, &o); err != nil {
+		return err
+	}
+	obj.Object = o
+	return nil
+}
+
+func isYamlFile(p string) bool {
+	return strings.HasSuffix(p, ".yaml") || strings.HasSuffix(p, ".yml")
+}
+
+func isKustomization(content []byte) bool {
+	k := &kustomization{}
+	if err := yaml.Unmarshal(content, k); err != nil {
+		return false
+	}
+	return k.Kind == kustomizationKind
+}
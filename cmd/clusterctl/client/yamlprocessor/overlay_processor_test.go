@@ -0,0 +1,207 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yamlprocessor
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
+)
+
+func TestOverlayProcessor_Resolve(t *testing.T) {
+	files := map[string]string{
+		"base/kustomization.yaml": `kind: Kustomization
+resources:
+- cluster.yaml
+- machines.yaml
+`,
+		"base/cluster.yaml": `apiVersion: cluster.x-k8s.io/v1alpha4
+kind: Cluster
+metadata:
+  name: ${CLUSTER_NAME}
+spec:
+  clusterNetwork:
+    pods:
+      cidrBlocks: ["192.168.0.0/16"]
+`,
+		"base/machines.yaml": `apiVersion: cluster.x-k8s.io/v1alpha4
+kind: MachineDeployment
+metadata:
+  name: ${CLUSTER_NAME}-md-0
+spec:
+  replicas: 1
+`,
+		"patches/cluster.yaml": `apiVersion: cluster.x-k8s.io/v1alpha4
+kind: Cluster
+metadata:
+  name: ${CLUSTER_NAME}
+spec:
+  clusterNetwork:
+    serviceDomain: cluster.local
+`,
+		"unsupported/kustomization.yaml": `kind: Kustomization
+resources:
+- ../base/cluster.yaml
+namePrefix: foo-
+`,
+		"patches/replicas.yaml": `- op: replace
+  path: /spec/replicas
+  value: 3
+`,
+	}
+	getFile := func(path string) ([]byte, error) {
+		if content, ok := files[path]; ok {
+			return []byte(content), nil
+		}
+		return nil, os.ErrNotExist
+	}
+
+	tests := []struct {
+		name          string
+		kustomization string
+		wantErr       bool
+		verify        func(g *WithT, resolved []byte)
+	}{
+		{
+			name: "includes resources from nested kustomizations",
+			kustomization: `kind: Kustomization
+resources:
+- base
+`,
+			verify: func(g *WithT, resolved []byte) {
+				objs, err := utilyaml.ToUnstructured(resolved)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(objs).To(HaveLen(2))
+				g.Expect(objs[0].GetKind()).To(Equal("Cluster"))
+				g.Expect(objs[1].GetKind()).To(Equal("MachineDeployment"))
+			},
+		},
+		{
+			name: "applies patches, labels and annotations",
+			kustomization: `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+- base
+patchesJson6902:
+- target:
+    group: cluster.x-k8s.io
+    kind: MachineDeployment
+    name: ${CLUSTER_NAME}-md-0
+  path: patches/replicas.yaml
+- target:
+    group: cluster.x-k8s.io
+    kind: Cluster
+    name: ${CLUSTER_NAME}
+  patch: |-
+    - op: add
+      path: /spec/paused
+      value: true
+    - op: add
+      path: /spec/clusterNetwork/serviceDomain
+      value: cluster.local
+commonLabels:
+  env: test
+commonAnnotations:
+  owner: foo
+`,
+			verify: func(g *WithT, resolved []byte) {
+				objs, err := utilyaml.ToUnstructured(resolved)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(objs).To(HaveLen(2))
+
+				cluster := objs[0].Object
+				g.Expect(cluster).To(HaveKeyWithValue("spec", HaveKeyWithValue("paused", true)))
+				network := cluster["spec"].(map[string]interface{})["clusterNetwork"].(map[string]interface{})
+				g.Expect(network).To(HaveKey("pods"))
+				g.Expect(network).To(HaveKeyWithValue("serviceDomain", "cluster.local"))
+
+				g.Expect(objs[1].Object).To(HaveKeyWithValue("spec", HaveKeyWithValue("replicas", BeEquivalentTo(3))))
+
+				for _, o := range objs {
+					g.Expect(o.GetLabels()).To(HaveKeyWithValue("env", "test"))
+					g.Expect(o.GetAnnotations()).To(HaveKeyWithValue("owner", "foo"))
+				}
+			},
+		},
+		{
+			name: "fails if a resource does not exist",
+			kustomization: `kind: Kustomization
+resources:
+- does-not-exist.yaml
+`,
+			wantErr: true,
+		},
+		{
+			name: "fails if the patch target does not exist",
+			kustomization: `kind: Kustomization
+resources:
+- base/machines.yaml
+patchesJson6902:
+- target:
+    group: cluster.x-k8s.io
+    kind: Cluster
+    name: ${CLUSTER_NAME}
+  path: patches/replicas.yaml
+`,
+			wantErr: true,
+		},
+		{
+			name: "fails if the kustomization uses strategic merge patches",
+			kustomization: `kind: Kustomization
+resources:
+- base
+patchesStrategicMerge:
+- patches/cluster.yaml
+`,
+			wantErr: true,
+		},
+		{
+			name: "fails if a nested kustomization uses unsupported fields",
+			kustomization: `kind: Kustomization
+resources:
+- unsupported
+`,
+			wantErr: true,
+		},
+		{
+			name: "fails if the template is not a kustomization",
+			kustomization: `kind: Cluster
+`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			p := NewOverlayProcessor()
+			resolved, err := p.Resolve([]byte(tt.kustomization), getFile)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			tt.verify(g, resolved)
+
+			// Variables are processed after the kustomization is resolved.
+			variables, err := p.GetVariables(resolved)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(variables).To(Equal([]string{"CLUSTER_NAME"}))
+		})
+	}
+}
@@ -17,6 +17,14 @@ limitations under the License.
 // Package yamlprocessor implements YAML processing.
 package yamlprocessor
 
+import (
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
 // Processor defines the methods necessary for creating a specific yaml
 // processor.
 type Processor interface {
@@ -36,3 +44,48 @@ type Processor interface {
 	// yaml with values retrieved from the values getter
 	Process([]byte, func(string) (string, error)) ([]byte, error)
 }
+
+// Names of the built-in processors.
+const (
+	// SimpleProcessorName is the name of the SimpleProcessor.
+	SimpleProcessorName = "envsubst"
+
+	// GoTemplateProcessorName is the name of the GoTemplateProcessor.
+	GoTemplateProcessorName = "gotemplate"
+
+	// OverlayProcessorName is the name of the OverlayProcessor.
+	OverlayProcessorName = "overlay"
+)
+
+// ProcessorNames returns the names of the built-in processors.
+func ProcessorNames() []string {
+	return []string{SimpleProcessorName, GoTemplateProcessorName, OverlayProcessorName}
+}
+
+// NewProcessor returns the built-in processor with the given name; if the name is empty, the SimpleProcessor is returned.
+// Values are typed values for variables, and they are used only by the GoTemplateProcessor.
+func NewProcessor(name string, values map[string]interface{}) (Processor, error) {
+	switch name {
+	case "", SimpleProcessorName:
+		return NewSimpleProcessor(), nil
+	case GoTemplateProcessorName:
+		return NewGoTemplateProcessor(values), nil
+	case OverlayProcessorName:
+		return NewOverlayProcessor(), nil
+	default:
+		return nil, errors.Errorf("invalid template processor %q. Allowed values are [%s]", name, strings.Join(ProcessorNames(), ", "))
+	}
+}
+
+// ReadValuesFile reads a YAML file with typed values for variables, e.g. to be used with the GoTemplateProcessor.
+func ReadValuesFile(path string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read values file %s", path)
+	}
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(content, &values); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse values file %s", path)
+	}
+	return values, nil
+}
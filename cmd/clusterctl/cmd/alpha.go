@@ -29,6 +29,7 @@ var alphaCmd = &cobra.Command{
 func init() {
 	// Alpha commands should be added here.
 	alphaCmd.AddCommand(rolloutCmd)
+	alphaCmd.AddCommand(mirrorCmd)
 
 	RootCmd.AddCommand(alphaCmd)
 }
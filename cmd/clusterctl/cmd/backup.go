@@ -26,6 +26,7 @@ type backupOptions struct {
 	fromKubeconfig        string
 	fromKubeconfigContext string
 	namespace             string
+	file                  string
 	directory             string
 }
 
@@ -35,11 +36,14 @@ var backupCmd = &cobra.Command{
 	Use:   "backup",
 	Short: "Backup Cluster API objects and all dependencies from a management cluster.",
 	Long: LongDesc(`
-		Backup Cluster API objects and all dependencies from a management cluster.`),
+		Backup Cluster API objects and all dependencies from a management cluster.
+
+		Objects are saved into a single tar.gz archive, together with a manifest listing the objects in move order,
+		the provider inventory of the management cluster and a SHA-256 checksum for each object.`),
 
 	Example: Examples(`
 		Backup Cluster API objects and all dependencies from a management cluster.
-		clusterctl backup --directory=/tmp/backup-directory`),
+		clusterctl backup --file=/tmp/backup.tar.gz`),
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runBackup()
@@ -53,15 +57,21 @@ func init() {
 		"Context to be used within the kubeconfig file for the source management cluster. If empty, current context will be used.")
 	backupCmd.Flags().StringVarP(&buo.namespace, "namespace", "n", "",
 		"The namespace where the workload cluster is hosted. If unspecified, the current context's namespace is used.")
+	backupCmd.Flags().StringVar(&buo.file, "file", "",
+		"The path of the tar.gz archive to save Cluster API objects to")
 	backupCmd.Flags().StringVar(&buo.directory, "directory", "",
 		"The directory to save Cluster API objects to as yaml files")
+	_ = backupCmd.Flags().MarkDeprecated("directory", "use --file instead; backups are now saved as a single tar.gz archive.")
 
 	RootCmd.AddCommand(backupCmd)
 }
 
 func runBackup() error {
-	if buo.directory == "" {
-		return errors.New("please specify a directory to backup cluster API objects to using the --directory flag")
+	if buo.directory != "" {
+		return errors.New("the --directory flag is not supported anymore, please specify a file to backup cluster API objects to using the --file flag")
+	}
+	if buo.file == "" {
+		return errors.New("please specify a file to backup cluster API objects to using the --file flag")
 	}
 
 	c, err := client.New(cfgFile)
@@ -72,6 +82,6 @@ func runBackup() error {
 	return c.Backup(client.BackupOptions{
 		FromKubeconfig: client.Kubeconfig{Path: buo.fromKubeconfig, Context: buo.fromKubeconfigContext},
 		Namespace:      buo.namespace,
-		Directory:      buo.directory,
+		File:           buo.file,
 	})
 }
@@ -0,0 +1,78 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+)
+
+type configValidateOptions struct {
+	kubeconfig        string
+	kubeconfigContext string
+}
+
+var cvalo = &configValidateOptions{}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Args:  cobra.NoArgs,
+	Short: "Validate the clusterctl configuration.",
+	Long: LongDesc(`
+		Validate the effective clusterctl configuration, and more specifically the provider URLs,
+		the image overrides and the cert-manager configuration.
+
+		The configuration is validated without accessing the provider repositories.`),
+
+	Example: Examples(`
+		# Validates the configuration for the current kube context.
+		clusterctl config validate
+
+		# Validates the configuration for the kube context of another management cluster.
+		clusterctl config validate --kubeconfig-context=mgmt-2`),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigValidate(cfgFile, os.Stdout)
+	},
+}
+
+func init() {
+	configValidateCmd.Flags().StringVar(&cvalo.kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig file to use for selecting the kube context configuration. If unspecified, default discovery rules apply.")
+	configValidateCmd.Flags().StringVar(&cvalo.kubeconfigContext, "kubeconfig-context", "",
+		"Kube context to validate the configuration for. If empty, current context will be used.")
+	configCmd.AddCommand(configValidateCmd)
+}
+
+func runConfigValidate(cfgFile string, out io.Writer) error {
+	c, err := client.New(cfgFile, client.InjectKubeconfig(client.Kubeconfig{Path: cvalo.kubeconfig, Context: cvalo.kubeconfigContext}))
+	if err != nil {
+		return err
+	}
+
+	if err := c.ValidateConfig(); err != nil {
+		return errors.Wrap(err, "invalid clusterctl configuration")
+	}
+
+	fmt.Fprintln(out, "The clusterctl configuration is valid.")
+	return nil
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ConfigViewOutputYaml is an option used to print the configuration values in yaml format.
+	ConfigViewOutputYaml = "yaml"
+	// ConfigViewOutputText is an option used to print the configuration values in text format.
+	ConfigViewOutputText = "text"
+)
+
+var (
+	// ConfigViewOutputs is a list of valid configuration values outputs.
+	ConfigViewOutputs = []string{ConfigViewOutputText, ConfigViewOutputYaml}
+
+	// sensitiveConfigKey matches the keys of configuration values that are hidden unless --show-secrets is used.
+	sensitiveConfigKey = regexp.MustCompile(`(?i)(password|secret|token|credentials)`)
+)
+
+type configViewOptions struct {
+	kubeconfig        string
+	kubeconfigContext string
+	output            string
+	showSecrets       bool
+}
+
+var cvo = &configViewOptions{}
+
+var configViewCmd = &cobra.Command{
+	Use:   "view",
+	Args:  cobra.NoArgs,
+	Short: "Display the effective clusterctl configuration.",
+	Long: LongDesc(`
+		Display the effective clusterctl configuration, and the layer each value is read from.
+
+		The configuration is read from the system (/etc/cluster-api/clusterctl.yaml), user
+		($HOME/.cluster-api/clusterctl.yaml or --config) and project (.cluster-api/clusterctl.yaml in
+		the current directory) config files, and from the section of each config file for the kube context
+		of the management cluster; environment variables take precedence on all the config files.`),
+
+	Example: Examples(`
+		# Displays the effective configuration for the current kube context.
+		clusterctl config view
+
+		# Displays the effective configuration for the kube context of another management cluster.
+		clusterctl config view --kubeconfig-context=mgmt-2
+
+		# Print the effective configuration in yaml format.
+		clusterctl config view -o yaml`),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigView(cfgFile, os.Stdout)
+	},
+}
+
+func init() {
+	configViewCmd.Flags().StringVar(&cvo.kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig file to use for selecting the kube context configuration. If unspecified, default discovery rules apply.")
+	configViewCmd.Flags().StringVar(&cvo.kubeconfigContext, "kubeconfig-context", "",
+		"Kube context to display the configuration for. If empty, current context will be used.")
+	configViewCmd.Flags().StringVarP(&cvo.output, "output", "o", ConfigViewOutputText,
+		fmt.Sprintf("Output format. Valid values: %v.", ConfigViewOutputs))
+	configViewCmd.Flags().BoolVar(&cvo.showSecrets, "show-secrets", false,
+		"Show the values of passwords, secrets, tokens and credentials.")
+	configCmd.AddCommand(configViewCmd)
+}
+
+func runConfigView(cfgFile string, out io.Writer) error {
+	if cvo.output != ConfigViewOutputText && cvo.output != ConfigViewOutputYaml {
+		return errors.Errorf("Invalid output format %q. Valid values: %v.", cvo.output, ConfigViewOutputs)
+	}
+
+	if out == nil {
+		return errors.New("unable to print to nil output writer")
+	}
+
+	c, err := client.New(cfgFile, client.InjectKubeconfig(client.Kubeconfig{Path: cvo.kubeconfig, Context: cvo.kubeconfigContext}))
+	if err != nil {
+		return err
+	}
+
+	values, err := c.GetConfigValues()
+	if err != nil {
+		return err
+	}
+
+	if !cvo.showSecrets {
+		for i := range values {
+			if sensitiveConfigKey.MatchString(values[i].Key) {
+				values[i].Value = "******"
+			}
+		}
+	}
+
+	w := tabwriter.NewWriter(out, 10, 4, 3, ' ', 0)
+
+	switch cvo.output {
+	case ConfigViewOutputText:
+		fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+		for _, v := range values {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", v.Key, v.Value, v.Source)
+		}
+	case ConfigViewOutputYaml:
+		y, err := yaml.Marshal(values)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(w, string(y))
+	}
+	return w.Flush()
+}
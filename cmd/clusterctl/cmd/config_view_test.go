@@ -0,0 +1,84 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_runConfigView(t *testing.T) {
+	g := NewWithT(t)
+
+	tmpDir, err := os.MkdirTemp("", "cc")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "clusterctl.yaml")
+	g.Expect(os.WriteFile(path, []byte(`
+CLUSTER_TOPOLOGY: "true"
+AWS_B64ENCODED_CREDENTIALS: c2VjcmV0
+contexts:
+  mgmt:
+    CLUSTER_TOPOLOGY: "false"
+`), 0600)).To(Succeed())
+
+	tests := []struct {
+		name        string
+		options     configViewOptions
+		wantOutput  []string // regular expressions
+		wantMissing []string
+	}{
+		{
+			name:    "hides secrets",
+			options: configViewOptions{kubeconfigContext: "another", output: ConfigViewOutputText},
+			wantOutput: []string{
+				`cluster_topology\s+true\s+user \(` + regexp.QuoteMeta(path) + `\)`,
+				`aws_b64encoded_credentials\s+\*+\s+user \(` + regexp.QuoteMeta(path) + `\)`,
+			},
+			wantMissing: []string{"c2VjcmV0"},
+		},
+		{
+			name:    "shows secrets and the configuration for the kube context",
+			options: configViewOptions{kubeconfigContext: "mgmt", output: ConfigViewOutputYaml, showSecrets: true},
+			wantOutput: []string{
+				`key: cluster_topology\n  source: context mgmt \(` + regexp.QuoteMeta(path) + `\)\n  value: "false"`,
+				`key: aws_b64encoded_credentials\n  source: user \(` + regexp.QuoteMeta(path) + `\)\n  value: c2VjcmV0`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			*cvo = tt.options
+			buf := bytes.NewBufferString("")
+			g.Expect(runConfigView(path, buf)).To(Succeed())
+			for _, o := range tt.wantOutput {
+				g.Expect(buf.String()).To(MatchRegexp(o))
+			}
+			for _, o := range tt.wantMissing {
+				g.Expect(buf.String()).NotTo(ContainSubstring(o))
+			}
+		})
+	}
+}
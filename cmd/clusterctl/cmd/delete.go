@@ -32,6 +32,7 @@ type deleteOptions struct {
 	includeNamespace        bool
 	includeCRDs             bool
 	deleteAll               bool
+	force                   bool
 }
 
 var dd = &deleteOptions{}
@@ -40,7 +41,10 @@ var deleteCmd = &cobra.Command{
 	Use:   "delete [providers]",
 	Short: "Delete one or more providers from the management cluster.",
 	Long: LongDesc(`
-		Delete one or more providers from the management cluster.`),
+		Delete one or more providers from the management cluster.
+
+		Providers can't be deleted while objects depending on them, e.g. the AWSClusters and AWSMachines
+		for the AWS infrastructure provider, still exist in the management cluster; use --force to skip this check.`),
 
 	Example: Examples(`
 		# Deletes the AWS provider
@@ -72,6 +76,11 @@ var deleteCmd = &cobra.Command{
 		# Cluster API Providers are orphaned and there might be ongoing costs incurred as a result of this.
 		clusterctl delete --infrastructure aws --include-namespace
 
+		# Delete the AWS infrastructure provider even if there are still Clusters depending on it.
+		# Important! As a consequence of this operation, the objects depending on the provider won't be
+		# reconciled anymore, and all the corresponding resources on target clouds are "orphaned".
+		clusterctl delete --infrastructure aws --force
+
 		# Reset the management cluster to its original state
 		# Important! As a consequence of this operation all the corresponding resources on target clouds
 		# are "orphaned" and thus there may be ongoing costs incurred as a result of this.
@@ -104,12 +113,14 @@ func init() {
 
 	deleteCmd.Flags().BoolVar(&dd.deleteAll, "all", false,
 		"Force deletion of all the providers")
+	deleteCmd.Flags().BoolVar(&dd.force, "force", false,
+		"Delete the providers even if objects depending on them, e.g. Clusters, Machines or templates, still exist")
 
 	RootCmd.AddCommand(deleteCmd)
 }
 
 func runDelete() error {
-	c, err := client.New(cfgFile)
+	c, err := client.New(cfgFile, client.InjectKubeconfig(client.Kubeconfig{Path: dd.kubeconfig, Context: dd.kubeconfigContext}))
 	if err != nil {
 		return err
 	}
@@ -136,5 +147,6 @@ func runDelete() error {
 		InfrastructureProviders: dd.infrastructureProviders,
 		ControlPlaneProviders:   dd.controlPlaneProviders,
 		DeleteAll:               dd.deleteAll,
+		Force:                   dd.force,
 	})
 }
@@ -17,6 +17,8 @@ limitations under the License.
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -25,6 +27,7 @@ import (
 	"github.com/fatih/color"
 	"github.com/gobuffalo/flect"
 	"github.com/gosuri/uitable"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/duration"
@@ -32,6 +35,7 @@ import (
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client/tree"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
 )
 
 const (
@@ -58,6 +62,10 @@ type describeClusterOptions struct {
 	showOtherConditions string
 	disableNoEcho       bool
 	disableGrouping     bool
+
+	output        string
+	watch         bool
+	watchInterval time.Duration
 }
 
 var dc = &describeClusterOptions{}
@@ -86,7 +94,13 @@ var describeClusterClusterCmd = &cobra.Command{
 
 		# Describe the cluster named test-1 disabling automatic echo suppression 
         # e.g. show the infrastructure machine objects, no matter if the current state is already reported by the machine's Ready condition.
-		clusterctl describe cluster test-1`),
+		clusterctl describe cluster test-1
+
+		# Describe the cluster named test-1 in JSON format, e.g. for consuming the cluster status from scripts.
+		clusterctl describe cluster test-1 -o json
+
+		# Describe the cluster named test-1 and redraw the tree every time the status of the cluster changes.
+		clusterctl describe cluster test-1 --watch`),
 
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -109,28 +123,99 @@ func init() {
 	describeClusterClusterCmd.Flags().BoolVar(&dc.disableGrouping, "disable-grouping", false,
 		"Disable grouping machines when ready condition has the same Status, Severity and Reason.")
 
+	describeClusterClusterCmd.Flags().StringVarP(&dc.output, "output", "o", "",
+		"Output format; available options are 'yaml' and 'json'. If empty, the cluster status is printed as a tree view.")
+	describeClusterClusterCmd.Flags().BoolVarP(&dc.watch, "watch", "w", false,
+		"Watch the cluster, and print the cluster status again every time it changes.")
+	describeClusterClusterCmd.Flags().DurationVar(&dc.watchInterval, "watch-interval", 5*time.Second,
+		"Interval between two checks for changes of the cluster status. Requires --watch.")
+
 	describeCmd.AddCommand(describeClusterClusterCmd)
 }
 
 func runDescribeCluster(name string) error {
+	if dc.output != "" && dc.output != "yaml" && dc.output != "json" {
+		return errors.Errorf("invalid output format: %s", dc.output)
+	}
+	if dc.watchInterval <= 0 {
+		return errors.New("the --watch-interval flag must be greater than zero")
+	}
+
 	c, err := client.New(cfgFile)
 	if err != nil {
 		return err
 	}
 
-	tree, err := c.DescribeCluster(client.DescribeClusterOptions{
-		Kubeconfig:          client.Kubeconfig{Path: dc.kubeconfig, Context: dc.kubeconfigContext},
-		Namespace:           dc.namespace,
-		ClusterName:         name,
-		ShowOtherConditions: dc.showOtherConditions,
-		DisableNoEcho:       dc.disableNoEcho,
-		DisableGrouping:     dc.disableGrouping,
-	})
+	describe := func() (*tree.ObjectTree, error) {
+		return c.DescribeCluster(client.DescribeClusterOptions{
+			Kubeconfig:          client.Kubeconfig{Path: dc.kubeconfig, Context: dc.kubeconfigContext},
+			Namespace:           dc.namespace,
+			ClusterName:         name,
+			ShowOtherConditions: dc.showOtherConditions,
+			DisableNoEcho:       dc.disableNoEcho,
+			DisableGrouping:     dc.disableGrouping,
+		})
+	}
+
+	tree, err := describe()
 	if err != nil {
 		return err
 	}
+	if !dc.watch {
+		return printDescribeCluster(tree, dc.output)
+	}
+
+	// In watch mode, print the cluster status again every time it changes.
+	// NOTE: the cluster status is compared using the serialized object tree, so changes to the object tree that are
+	// not relevant for the presentation layer, e.g. resourceVersion, do not trigger a new print.
+	var last []byte
+	for {
+		current, err := json.Marshal(tree.ToObjectNode())
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(current, last) {
+			if dc.output == "" {
+				// Clears the screen before redrawing the tree view.
+				fmt.Fprint(color.Error, "\033[H\033[2J")
+			}
+			if err := printDescribeCluster(tree, dc.output); err != nil {
+				return err
+			}
+			last = current
+		}
+
+		time.Sleep(dc.watchInterval)
+		if tree, err = describe(); err != nil {
+			return err
+		}
+	}
+}
 
-	printObjectTree(tree)
+// printDescribeCluster prints the cluster status in the given output format; in watch mode, each print
+// is a separate YAML document or JSON object.
+func printDescribeCluster(tree *tree.ObjectTree, output string) error {
+	switch output {
+	case "":
+		printObjectTree(tree)
+	case "yaml":
+		y, err := yaml.Marshal(tree.ToObjectNode())
+		if err != nil {
+			return err
+		}
+		if dc.watch {
+			fmt.Println("---")
+		}
+		fmt.Print(string(y))
+	case "json":
+		j, err := json.MarshalIndent(tree.ToObjectNode(), "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(j))
+	default:
+		return errors.Errorf("invalid output format: %s", output)
+	}
 	return nil
 }
 
@@ -167,6 +252,11 @@ func addObjectRow(prefix string, tbl *uitable.Table, objectTree *tree.ObjectTree
 		}
 	}
 
+	// If the object has a summary of its status, e.g. MachineHealthChecks, show it when there is no other message.
+	if summary := tree.GetObjectSummary(obj); summary != "" && readyDescriptor.message == "" {
+		readyDescriptor.message = gray.Sprint(summary)
+	}
+
 	// Gets the row name for the object.
 	// NOTE: The object name gets manipulated in order to improve readability.
 	name := getRowName(obj)
@@ -249,6 +339,7 @@ func getChildPrefix(currentPrefix string, childIndex, childCount int) string {
 // - other virtual objects are represented using the object name, e.g. Workers
 // - objects with a meta name are represented as meta name - (kind/name), e.g. ClusterInfrastructure - DockerCluster/test1
 // - other objects are represented as kind/name, e.g.Machine/test1-md-0-779b87ff56-642vs
+// - if the object is managed as part of a cluster topology, a suffix will be added.
 // - if the object is being deleted, a prefix will be added.
 func getRowName(obj ctrlclient.Object) string {
 	if tree.IsGroupObject(obj) {
@@ -270,6 +361,10 @@ func getRowName(obj ctrlclient.Object) string {
 		name = fmt.Sprintf("%s - %s", objectPrefix, gray.Sprintf(name))
 	}
 
+	if tree.IsManagedByTopology(obj) {
+		name = fmt.Sprintf("%s %s", name, gray.Sprint("(topology)"))
+	}
+
 	if !obj.GetDeletionTimestamp().IsZero() {
 		name = fmt.Sprintf("%s %s", red.Sprintf("!! DELETED !!"), name)
 	}
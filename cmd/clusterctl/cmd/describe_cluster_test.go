@@ -54,6 +54,11 @@ func Test_getRowName(t *testing.T) {
 			object: fakeObject("c1", withAnnotation(tree.ObjectMetaNameAnnotation, "MetaName")),
 			expect: "MetaName - Object/c1",
 		},
+		{
+			name:   "Row name for objects managed by topology should have topology suffix",
+			object: fakeObject("c1", withLabel(clusterv1.ClusterTopologyLabelName, "")),
+			expect: "Object/c1 (topology)",
+		},
 		{
 			name:   "Row name for virtual objects should be name",
 			object: fakeObject("c1", withAnnotation(tree.VirtualObjectAnnotation, "True")),
@@ -320,6 +325,17 @@ func withAnnotation(name, value string) func(ctrlclient.Object) {
 	}
 }
 
+func withLabel(name, value string) func(ctrlclient.Object) {
+	return func(c ctrlclient.Object) {
+		if c.GetLabels() == nil {
+			c.SetLabels(map[string]string{})
+		}
+		l := c.GetLabels()
+		l[name] = value
+		c.SetLabels(l)
+	}
+}
+
 func withCondition(c *clusterv1.Condition) func(ctrlclient.Object) {
 	return func(m ctrlclient.Object) {
 		setter := m.(conditions.Setter)
@@ -18,9 +18,13 @@ package cmd
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+	yaml "sigs.k8s.io/cluster-api/cmd/clusterctl/client/yamlprocessor"
 )
 
 type generateClusterOptions struct {
@@ -39,6 +43,13 @@ type generateClusterOptions struct {
 	configMapName      string
 	configMapDataKey   string
 
+	clusterClass       string
+	machineDeployments []string
+	applyClass         bool
+
+	templateProcessor  string
+	templateValuesFile string
+
 	listVariables bool
 }
 
@@ -85,7 +96,21 @@ var generateClusterClusterCmd = &cobra.Command{
 		clusterctl generate cluster my-cluster --from ~/workspace/cluster-template.yaml
 
 		# Prints the list of variables required by the yaml file for creating workload cluster.
-		clusterctl generate cluster my-cluster --list-variables`),
+		clusterctl generate cluster my-cluster --list-variables
+
+		# Generates a yaml file for creating workload clusters using a Go template, with typed values read from a file.
+		clusterctl generate cluster my-cluster --from ~/workspace/cluster-template.yaml \
+			--template-processor gotemplate --values ~/workspace/values.yaml
+
+		# Generates a yaml file for creating a workload cluster with a managed topology based on the
+		# quick-start ClusterClass existing in the management cluster.
+		clusterctl generate cluster my-cluster --from-class quick-start --kubernetes-version=v1.21.2 \
+			--machine-deployment md-0:default-worker:3
+
+		# Generates a yaml file for creating a workload cluster with a managed topology based on a ClusterClass
+		# read from a file, creating the ClusterClass and its templates in the management cluster first.
+		clusterctl generate cluster my-cluster --from-class ~/workspace/cluster-class.yaml --apply-class \
+			--values ~/workspace/topology-values.yaml`),
 
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -127,6 +152,20 @@ func init() {
 	generateClusterClusterCmd.Flags().StringVar(&gc.configMapDataKey, "from-config-map-key", "",
 		fmt.Sprintf("The ConfigMap.Data key where the workload cluster template is hosted. If unspecified, %q will be used", client.DefaultCustomTemplateConfigMapKey))
 
+	// flags for the ClusterClass source
+	generateClusterClusterCmd.Flags().StringVar(&gc.clusterClass, "from-class", "",
+		"The ClusterClass to generate a workload cluster with a managed topology from, instead of reading a template. It can be the name of a ClusterClass in the target namespace, or the path or the URL of a file with a ClusterClass and its templates")
+	generateClusterClusterCmd.Flags().StringSliceVar(&gc.machineDeployments, "machine-deployment", nil,
+		"The MachineDeployment topologies for a workload cluster generated from a ClusterClass, in the <name>:<class>[:<replicas>] format. If unspecified, one MachineDeployment topology for each class in the ClusterClass will be generated")
+	generateClusterClusterCmd.Flags().BoolVar(&gc.applyClass, "apply-class", false,
+		"Creates the ClusterClass and its templates in the management cluster before generating the workload cluster. Supported only when the ClusterClass is read from a file")
+
+	// flags for the template processor
+	generateClusterClusterCmd.Flags().StringVar(&gc.templateProcessor, "template-processor", "",
+		fmt.Sprintf("The processor to be used for the workload cluster template, one of %v. If unspecified, the processor configured for the infrastructure provider will be used, or %q as a fallback.", yaml.ProcessorNames(), yaml.SimpleProcessorName))
+	generateClusterClusterCmd.Flags().StringVar(&gc.templateValuesFile, "values", "",
+		fmt.Sprintf("Path to a YAML file with the typed values to be used for the workload cluster template. Supported only by the %q template processor, or with --from-class for the values of the cluster topology.", yaml.GoTemplateProcessorName))
+
 	// other flags
 	generateClusterClusterCmd.Flags().BoolVar(&gc.listVariables, "list-variables", false,
 		"Returns the list of variables expected by the template instead of the template yaml")
@@ -135,7 +174,7 @@ func init() {
 }
 
 func runGenerateClusterTemplate(cmd *cobra.Command, name string) error {
-	c, err := client.New(cfgFile)
+	c, err := client.New(cfgFile, client.InjectKubeconfig(client.Kubeconfig{Path: gc.kubeconfig, Context: gc.kubeconfigContext}))
 	if err != nil {
 		return err
 	}
@@ -146,6 +185,9 @@ func runGenerateClusterTemplate(cmd *cobra.Command, name string) error {
 		TargetNamespace:   gc.targetNamespace,
 		KubernetesVersion: gc.kubernetesVersion,
 		ListVariablesOnly: gc.listVariables,
+
+		TemplateProcessor:  gc.templateProcessor,
+		TemplateValuesFile: gc.templateValuesFile,
 	}
 
 	if cmd.Flags().Changed("control-plane-machine-count") {
@@ -169,6 +211,23 @@ func runGenerateClusterTemplate(cmd *cobra.Command, name string) error {
 		}
 	}
 
+	if gc.clusterClass != "" {
+		machineDeployments, err := parseMachineDeploymentTopologies(gc.machineDeployments)
+		if err != nil {
+			return err
+		}
+		templateOptions.ClusterClassSource = &client.ClusterClassSourceOptions{
+			Class:              gc.clusterClass,
+			ValuesFile:         gc.templateValuesFile,
+			MachineDeployments: machineDeployments,
+			ApplyClass:         gc.applyClass,
+		}
+		// NOTE: when generating a workload cluster from a ClusterClass, the values file defines the cluster topology.
+		templateOptions.TemplateValuesFile = ""
+	} else if len(gc.machineDeployments) > 0 || gc.applyClass {
+		return errors.New("--machine-deployment and --apply-class can be used only with --from-class")
+	}
+
 	if gc.infrastructureProvider != "" || gc.flavor != "" {
 		templateOptions.ProviderRepositorySource = &client.ProviderRepositorySourceOptions{
 			InfrastructureProvider: gc.infrastructureProvider,
@@ -187,3 +246,27 @@ func runGenerateClusterTemplate(cmd *cobra.Command, name string) error {
 
 	return printYamlOutput(template)
 }
+
+// parseMachineDeploymentTopologies parses MachineDeployment topologies in the <name>:<class>[:<replicas>] format.
+func parseMachineDeploymentTopologies(values []string) ([]client.MachineDeploymentTopologyOptions, error) {
+	machineDeployments := []client.MachineDeploymentTopologyOptions{}
+	for _, v := range values {
+		parts := strings.Split(v, ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+			return nil, errors.Errorf("invalid MachineDeployment topology %q: expected format is <name>:<class>[:<replicas>]", v)
+		}
+		md := client.MachineDeploymentTopologyOptions{
+			Name:  parts[0],
+			Class: parts[1],
+		}
+		if len(parts) == 3 {
+			replicas, err := strconv.Atoi(parts[2])
+			if err != nil || replicas < 0 {
+				return nil, errors.Errorf("invalid MachineDeployment topology %q: replicas must be a number greater or equal than 0", v)
+			}
+			md.Replicas = &replicas
+		}
+		machineDeployments = append(machineDeployments, md)
+	}
+	return machineDeployments, nil
+}
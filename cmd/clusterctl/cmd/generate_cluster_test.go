@@ -0,0 +1,79 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+)
+
+func Test_parseMachineDeploymentTopologies(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    []client.MachineDeploymentTopologyOptions
+		wantErr bool
+	}{
+		{
+			name:   "parses name and class",
+			values: []string{"md-0:default-worker"},
+			want:   []client.MachineDeploymentTopologyOptions{{Name: "md-0", Class: "default-worker"}},
+		},
+		{
+			name:   "parses name, class and replicas",
+			values: []string{"md-0:default-worker:3", "md-1:gpu-worker:0"},
+			want: []client.MachineDeploymentTopologyOptions{
+				{Name: "md-0", Class: "default-worker", Replicas: intPtr(3)},
+				{Name: "md-1", Class: "gpu-worker", Replicas: intPtr(0)},
+			},
+		},
+		{
+			name:    "fails if the class is missing",
+			values:  []string{"md-0"},
+			wantErr: true,
+		},
+		{
+			name:    "fails if the replicas are not a number",
+			values:  []string{"md-0:default-worker:three"},
+			wantErr: true,
+		},
+		{
+			name:    "fails if the replicas are negative",
+			values:  []string{"md-0:default-worker:-1"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := parseMachineDeploymentTopologies(tt.values)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -18,6 +18,7 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
@@ -27,6 +28,13 @@ type getKubeconfigOptions struct {
 	kubeconfig        string
 	kubeconfigContext string
 	namespace         string
+
+	user                    string
+	groups                  []string
+	serviceAccount          string
+	serviceAccountNamespace string
+	clusterRole             string
+	ttl                     time.Duration
 }
 
 var gk = &getKubeconfigOptions{}
@@ -35,14 +43,24 @@ var getKubeconfigCmd = &cobra.Command{
 	Use:   "kubeconfig",
 	Short: "Gets the kubeconfig file for accessing a workload cluster",
 	Long: LongDesc(`
-		Gets the kubeconfig file for accessing a workload cluster`),
+		Gets the kubeconfig file for accessing a workload cluster.
+
+		By default the admin kubeconfig of the workload cluster is returned; use --user to get a kubeconfig with
+		a short-lived client certificate signed by the workload cluster CA, or --service-account to get a kubeconfig
+		with a short-lived token for a ServiceAccount in the workload cluster.`),
 
 	Example: Examples(`
 		# Get the workload cluster's kubeconfig.
 		clusterctl get kubeconfig <name of workload cluster>
 
 		# Get the workload cluster's kubeconfig in a particular namespace.
-		clusterctl get kubeconfig <name of workload cluster> --namespace foo`),
+		clusterctl get kubeconfig <name of workload cluster> --namespace foo
+
+		# Get a kubeconfig for the user alice in the developers group, valid for 8 hours.
+		clusterctl get kubeconfig <name of workload cluster> --user alice --group developers --ttl 8h
+
+		# Get a kubeconfig for the ServiceAccount ci, bound to the view ClusterRole.
+		clusterctl get kubeconfig <name of workload cluster> --service-account ci --cluster-role view`),
 
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -57,6 +75,18 @@ func init() {
 		"Path to the kubeconfig file to use for accessing the management cluster. If unspecified, default discovery rules apply.")
 	getKubeconfigCmd.Flags().StringVar(&gk.kubeconfigContext, "kubeconfig-context", "",
 		"Context to be used within the kubeconfig file. If empty, current context will be used.")
+	getKubeconfigCmd.Flags().StringVar(&gk.user, "user", "",
+		"Name of the user to generate a kubeconfig with a short-lived client certificate for, signed by the workload cluster CA.")
+	getKubeconfigCmd.Flags().StringSliceVar(&gk.groups, "group", nil,
+		"Groups of the user; can be used only with --user.")
+	getKubeconfigCmd.Flags().StringVar(&gk.serviceAccount, "service-account", "",
+		"Name of the ServiceAccount in the workload cluster to generate a kubeconfig with a short-lived token for. The ServiceAccount is created if it does not exist.")
+	getKubeconfigCmd.Flags().StringVar(&gk.serviceAccountNamespace, "service-account-namespace", "",
+		"Namespace of the ServiceAccount in the workload cluster. If unspecified, the default namespace is used.")
+	getKubeconfigCmd.Flags().StringVar(&gk.clusterRole, "cluster-role", "",
+		"Name of an existing ClusterRole in the workload cluster to bind to the ServiceAccount; can be used only with --service-account.")
+	getKubeconfigCmd.Flags().DurationVar(&gk.ttl, "ttl", 24*time.Hour,
+		"Lifespan of the client certificate or token; can be used only with --user or --service-account.")
 	getCmd.AddCommand(getKubeconfigCmd)
 }
 
@@ -70,6 +100,13 @@ func runGetKubeconfig(workloadClusterName string) error {
 		Kubeconfig:          client.Kubeconfig{Path: gk.kubeconfig, Context: gk.kubeconfigContext},
 		WorkloadClusterName: workloadClusterName,
 		Namespace:           gk.namespace,
+
+		User:                    gk.user,
+		Groups:                  gk.groups,
+		ServiceAccount:          gk.serviceAccount,
+		ServiceAccountNamespace: gk.serviceAccountNamespace,
+		ClusterRole:             gk.clusterRole,
+		TTL:                     gk.ttl,
 	}
 
 	out, err := c.GetKubeconfig(options)
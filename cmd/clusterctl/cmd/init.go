@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
 )
@@ -35,6 +36,8 @@ type initOptions struct {
 	listImages              bool
 	waitProviders           bool
 	waitProviderTimeout     int
+	providersFile           string
+	deleteUnlisted          bool
 }
 
 var initOpts = &initOptions{}
@@ -78,6 +81,13 @@ var initCmd = &cobra.Command{
 		# Initialize a management cluster with a custom target namespace for the provider resources.
 		clusterctl init --infrastructure aws --target-namespace foo
 
+		# Converge a management cluster to the set of providers defined in a providers configuration file;
+		# the command can be executed many times, and only the required changes are applied.
+		clusterctl init --config-file providers.yaml
+
+		# Converge a management cluster to a providers configuration file, deleting the providers not included in the file.
+		clusterctl init --config-file providers.yaml --delete-unlisted-providers
+
 		# Lists the container images required for initializing the management cluster.
 		#
 		# Note: This command is a dry-run; it won't perform any action other than printing to screen.
@@ -108,6 +118,11 @@ func init() {
 	initCmd.Flags().IntVar(&initOpts.waitProviderTimeout, "wait-provider-timeout", 5*60,
 		"Wait timeout per provider installation in seconds. This value is ignored if --wait-providers is false")
 
+	initCmd.Flags().StringVarP(&initOpts.providersFile, "config-file", "f", "",
+		"Path to a file defining the desired set of providers for the management cluster. Missing providers are installed, and providers with a different version are upgraded or downgraded. Can't be used together with the flags defining providers.")
+	initCmd.Flags().BoolVar(&initOpts.deleteUnlisted, "delete-unlisted-providers", false,
+		"Delete the providers not included in the file specified with --config-file. The provider's CRDs and namespace are preserved.")
+
 	// TODO: Move this to a sub-command or similar, it shouldn't really be a flag.
 	initCmd.Flags().BoolVar(&initOpts.listImages, "list-images", false,
 		"Lists the container images required for initializing the management cluster (without actually installing the providers)")
@@ -116,7 +131,7 @@ func init() {
 }
 
 func runInit() error {
-	c, err := client.New(cfgFile)
+	c, err := client.New(cfgFile, client.InjectKubeconfig(client.Kubeconfig{Path: initOpts.kubeconfig, Context: initOpts.kubeconfigContext}))
 	if err != nil {
 		return err
 	}
@@ -131,6 +146,12 @@ func runInit() error {
 		LogUsageInstructions:    true,
 		WaitProviders:           initOpts.waitProviders,
 		WaitProviderTimeout:     time.Duration(initOpts.waitProviderTimeout) * time.Second,
+		ProvidersFile:           initOpts.providersFile,
+		DeleteUnlistedProviders: initOpts.deleteUnlisted,
+	}
+
+	if initOpts.deleteUnlisted && initOpts.providersFile == "" {
+		return errors.New("--delete-unlisted-providers can be used only together with --config-file")
 	}
 
 	if initOpts.listImages {
@@ -0,0 +1,177 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+)
+
+const mirrorImagesArchive = "images.tar"
+
+type mirrorOptions struct {
+	directory               string
+	coreProvider            string
+	bootstrapProviders      []string
+	controlPlaneProviders   []string
+	infrastructureProviders []string
+	flavors                 []string
+	skipCertManager         bool
+	listImages              bool
+	pullImages              bool
+	containerRuntime        string
+}
+
+var mirrorOpts = &mirrorOptions{}
+
+var mirrorCmd = &cobra.Command{
+	Use:   "mirror",
+	Short: "Create a bundle of providers and images for air-gapped environments.",
+	Long: LongDesc(`
+		Create a bundle of providers and images for air-gapped environments.
+
+		Downloads the components, the metadata and the cluster templates of the selected providers, as well
+		as the cert-manager components, into a directory using the layout of a local provider repository;
+		the list of the required container images is stored in the images.yaml file in the same directory.
+
+		Image overrides defined in the clusterctl configuration file are applied to the list of images;
+		the same overrides should be used in the air-gapped environment.
+
+		When the command completes, the clusterctl configuration to be used with the bundle is printed to screen.`),
+
+	Example: Examples(`
+		# Create a bundle with the Cluster API core provider, the kubeadm bootstrap and control plane
+		# providers and the given infrastructure provider.
+		clusterctl alpha mirror --infrastructure aws:v0.6.4 --directory ./bundle
+
+		# Create a bundle including additional cluster template flavors.
+		clusterctl alpha mirror --infrastructure aws:v0.6.4 --flavor machinepool --directory ./bundle
+
+		# List the container images required by the bundle.
+		clusterctl alpha mirror --infrastructure aws:v0.6.4 --directory ./bundle --list-images
+
+		# Pull the container images required by the bundle, tag them according to the image overrides
+		# and save them in the images.tar file in the bundle.
+		clusterctl alpha mirror --infrastructure aws:v0.6.4 --directory ./bundle --pull --container-runtime podman`),
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMirror()
+	},
+}
+
+func init() {
+	mirrorCmd.Flags().StringVarP(&mirrorOpts.directory, "directory", "d", "clusterctl-mirror",
+		"The directory where the bundle is created.")
+	mirrorCmd.Flags().StringVar(&mirrorOpts.coreProvider, "core", "",
+		"Core provider version (e.g. cluster-api:v0.3.0) to add to the bundle. If unspecified, Cluster API's latest release is used.")
+	mirrorCmd.Flags().StringSliceVarP(&mirrorOpts.infrastructureProviders, "infrastructure", "i", nil,
+		"Infrastructure providers and versions (e.g. aws:v0.5.0) to add to the bundle.")
+	mirrorCmd.Flags().StringSliceVarP(&mirrorOpts.bootstrapProviders, "bootstrap", "b", nil,
+		"Bootstrap providers and versions (e.g. kubeadm:v0.3.0) to add to the bundle. If unspecified, Kubeadm bootstrap provider's latest release is used.")
+	mirrorCmd.Flags().StringSliceVarP(&mirrorOpts.controlPlaneProviders, "control-plane", "c", nil,
+		"Control plane providers and versions (e.g. kubeadm:v0.3.0) to add to the bundle. If unspecified, the Kubeadm control plane provider's latest release is used.")
+	mirrorCmd.Flags().StringSliceVarP(&mirrorOpts.flavors, "flavor", "f", nil,
+		"Flavors of the cluster templates to add to the bundle for the infrastructure providers, in addition to the default cluster template.")
+	mirrorCmd.Flags().BoolVar(&mirrorOpts.skipCertManager, "skip-cert-manager", false,
+		"Do not add cert-manager to the bundle.")
+	mirrorCmd.Flags().BoolVar(&mirrorOpts.listImages, "list-images", false,
+		"Print the container images required by the bundle instead of the clusterctl configuration.")
+	mirrorCmd.Flags().BoolVar(&mirrorOpts.pullImages, "pull", false,
+		"Pull the container images required by the bundle and save them in the bundle.")
+	mirrorCmd.Flags().StringVar(&mirrorOpts.containerRuntime, "container-runtime", "docker",
+		"The container runtime CLI used to pull the container images, e.g. docker or podman. Used only if --pull is set.")
+}
+
+func runMirror() error {
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	bundle, err := c.Mirror(client.MirrorOptions{
+		Directory:               mirrorOpts.directory,
+		CoreProvider:            mirrorOpts.coreProvider,
+		BootstrapProviders:      mirrorOpts.bootstrapProviders,
+		ControlPlaneProviders:   mirrorOpts.controlPlaneProviders,
+		InfrastructureProviders: mirrorOpts.infrastructureProviders,
+		Flavors:                 mirrorOpts.flavors,
+		SkipCertManager:         mirrorOpts.skipCertManager,
+	})
+	if err != nil {
+		return err
+	}
+
+	if mirrorOpts.pullImages {
+		if err := pullMirrorImages(mirrorOpts.containerRuntime, bundle); err != nil {
+			return err
+		}
+	}
+
+	if mirrorOpts.listImages {
+		for _, i := range bundle.Images {
+			fmt.Println(i.Target)
+		}
+		return nil
+	}
+
+	clusterctlConfig, err := bundle.ClusterctlConfig()
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(clusterctlConfig))
+	return nil
+}
+
+// pullMirrorImages pulls the images required by the bundle using the given container runtime CLI, tags them according
+// to the image overrides and saves them into an archive in the bundle directory.
+func pullMirrorImages(containerRuntime string, bundle *client.MirrorBundle) error {
+	if len(bundle.Images) == 0 {
+		return nil
+	}
+
+	targets := []string{}
+	for _, i := range bundle.Images {
+		if err := runContainerRuntime(containerRuntime, "pull", i.Source); err != nil {
+			return err
+		}
+		if i.Target != i.Source {
+			if err := runContainerRuntime(containerRuntime, "tag", i.Source, i.Target); err != nil {
+				return err
+			}
+		}
+		targets = append(targets, i.Target)
+	}
+
+	args := append([]string{"save", "-o", filepath.Join(bundle.Directory, mirrorImagesArchive)}, targets...)
+	return runContainerRuntime(containerRuntime, args...)
+}
+
+func runContainerRuntime(containerRuntime string, args ...string) error {
+	cmd := exec.Command(containerRuntime, args...) //nolint:gosec
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "failed to run %s %v", containerRuntime, args)
+	}
+	return nil
+}
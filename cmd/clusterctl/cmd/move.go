@@ -17,9 +17,13 @@ limitations under the License.
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+	"sigs.k8s.io/yaml"
 )
 
 type moveOptions struct {
@@ -29,6 +33,11 @@ type moveOptions struct {
 	toKubeconfigContext   string
 	namespace             string
 	dryRun                bool
+	resume                bool
+	rollback              bool
+	clusterNames          []string
+	labelSelector         string
+	output                string
 }
 
 var mo = &moveOptions{}
@@ -43,7 +52,22 @@ var moveCmd = &cobra.Command{
 
 	Example: Examples(`
 		Move Cluster API objects and all dependencies between management clusters.
-		clusterctl move --to-kubeconfig=target-kubeconfig.yaml`),
+		clusterctl move --to-kubeconfig=target-kubeconfig.yaml
+
+		Move only the Cluster named my-cluster and all its dependencies.
+		clusterctl move --to-kubeconfig=target-kubeconfig.yaml --cluster my-cluster
+
+		Move only the Clusters with the label env=dev and all their dependencies.
+		clusterctl move --to-kubeconfig=target-kubeconfig.yaml --selector env=dev
+
+		Print the plan of the move operation as YAML, without performing any action.
+		clusterctl move --dry-run -o yaml
+
+		Resume an interrupted move from the last checkpoint.
+		clusterctl move --to-kubeconfig=target-kubeconfig.yaml --resume
+
+		Rollback an interrupted move, deleting the objects already created in the destination management cluster.
+		clusterctl move --to-kubeconfig=target-kubeconfig.yaml --rollback`),
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runMove()
@@ -63,6 +87,16 @@ func init() {
 		"The namespace where the workload cluster is hosted. If unspecified, the current context's namespace is used.")
 	moveCmd.Flags().BoolVar(&mo.dryRun, "dry-run", false,
 		"Enable dry run, don't really perform the move actions")
+	moveCmd.Flags().BoolVar(&mo.resume, "resume", false,
+		"Resume an interrupted move from the last checkpoint stored in the source management cluster")
+	moveCmd.Flags().BoolVar(&mo.rollback, "rollback", false,
+		"Rollback an interrupted move, deleting the objects already created in the destination management cluster and unpausing the source clusters")
+	moveCmd.Flags().StringVarP(&mo.output, "output", "o", "",
+		"Output format of the plan of the move operation; available options are 'yaml' and 'json'. Requires --dry-run")
+	moveCmd.Flags().StringSliceVar(&mo.clusterNames, "cluster", nil,
+		"Move only the Cluster with the given name and its dependencies; can be repeated. If unspecified, all the Clusters in the namespace are moved.")
+	moveCmd.Flags().StringVarP(&mo.labelSelector, "selector", "l", "",
+		"Move only the Clusters matching the label selector and their dependencies. If unspecified, all the Clusters in the namespace are moved.")
 
 	RootCmd.AddCommand(moveCmd)
 }
@@ -73,15 +107,56 @@ func runMove() error {
 		return errors.New("please specify a target cluster using the --to-kubeconfig flag")
 	}
 
+	if mo.output != "" && !mo.dryRun {
+		return errors.New("the --output flag can be used only together with the --dry-run flag")
+	}
+	if mo.output != "" && mo.output != "yaml" && mo.output != "json" {
+		return errors.Errorf("invalid output format: %s", mo.output)
+	}
+
 	c, err := client.New(cfgFile)
 	if err != nil {
 		return err
 	}
 
-	return c.Move(client.MoveOptions{
+	options := client.MoveOptions{
 		FromKubeconfig: client.Kubeconfig{Path: mo.fromKubeconfig, Context: mo.fromKubeconfigContext},
 		ToKubeconfig:   client.Kubeconfig{Path: mo.toKubeconfig, Context: mo.toKubeconfigContext},
 		Namespace:      mo.namespace,
 		DryRun:         mo.dryRun,
-	})
+		Resume:         mo.resume,
+		Rollback:       mo.rollback,
+		ClusterNames:   mo.clusterNames,
+		LabelSelector:  mo.labelSelector,
+	}
+
+	if mo.output == "" {
+		return c.Move(options)
+	}
+
+	plan, err := c.PlanMove(options)
+	if err != nil {
+		return err
+	}
+	return printMovePlan(plan, mo.output)
+}
+
+func printMovePlan(plan *client.MovePlan, output string) error {
+	switch output {
+	case "yaml":
+		y, err := yaml.Marshal(plan)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(y))
+	case "json":
+		j, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(j))
+	default:
+		return errors.Errorf("invalid output format: %s", output)
+	}
+	return nil
 }
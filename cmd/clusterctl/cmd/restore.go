@@ -25,6 +25,7 @@ import (
 type restoreOptions struct {
 	toKubeconfig        string
 	toKubeconfigContext string
+	file                string
 	directory           string
 }
 
@@ -32,13 +33,16 @@ var ro = &restoreOptions{}
 
 var restoreCmd = &cobra.Command{
 	Use:   "restore",
-	Short: "Restore Cluster API objects from file by glob. Object files are searched in config directory",
+	Short: "Restore Cluster API objects from a backup archive.",
 	Long: LongDesc(`
-		Restore Cluster API objects from file by glob. Object files are searched in the default config directory
-		or in the provided directory.`),
+		Restore Cluster API objects from a backup archive created by clusterctl backup.
+
+		The archive is verified against its manifest before creating any object; partial or corrupted
+		archives are rejected. Please note that the checksums in the manifest detect accidental corruption
+		only, because they are stored in the archive itself; use a trusted location for storing backups.`),
 	Example: Examples(`
-		Restore Cluster API objects from file by glob. Object files are searched in config directory.
-		clusterctl restore my-cluster`),
+		Restore Cluster API objects from a backup archive.
+		clusterctl restore --file=/tmp/backup.tar.gz`),
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runRestore()
@@ -50,15 +54,21 @@ func init() {
 		"Path to the kubeconfig file for the target management cluster to restore objects to. If unspecified, default discovery rules apply.")
 	restoreCmd.Flags().StringVar(&ro.toKubeconfigContext, "kubeconfig-context", "",
 		"Context to be used within the kubeconfig file for the target management cluster. If empty, current context will be used.")
+	restoreCmd.Flags().StringVar(&ro.file, "file", "",
+		"The path of the tar.gz archive to restore Cluster API objects from")
 	restoreCmd.Flags().StringVar(&ro.directory, "directory", "",
 		"The directory to target when restoring Cluster API object yaml files")
+	_ = restoreCmd.Flags().MarkDeprecated("directory", "use --file instead; backups are now saved as a single tar.gz archive.")
 
 	RootCmd.AddCommand(restoreCmd)
 }
 
 func runRestore() error {
-	if ro.directory == "" {
-		return errors.New("please specify a directory to restore cluster API objects from using the --directory flag")
+	if ro.directory != "" {
+		return errors.New("the --directory flag is not supported anymore, please create a new backup using clusterctl backup --file and restore it using the --file flag")
+	}
+	if ro.file == "" {
+		return errors.New("please specify a file to restore cluster API objects from using the --file flag")
 	}
 
 	c, err := client.New(cfgFile)
@@ -68,6 +78,6 @@ func runRestore() error {
 
 	return c.Restore(client.RestoreOptions{
 		ToKubeconfig: client.Kubeconfig{Path: ro.toKubeconfig, Context: ro.toKubeconfigContext},
-		Directory:    ro.directory,
+		File:         ro.file,
 	})
 }
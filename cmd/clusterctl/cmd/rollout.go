@@ -27,6 +27,8 @@ var (
 		Valid resource types include:
 
 		   * machinedeployment
+		   * kubeadmcontrolplane
+		   * machinepool
 		`)
 
 	rolloutExample = Examples(`
@@ -39,8 +41,14 @@ var (
 		# Resume an already paused machinedeployment
 		clusterctl alpha rollout resume machinedeployment/my-md-0
 
+		# View the rollout history of a machinedeployment
+		clusterctl alpha rollout history machinedeployment/my-md-0
+
 		# Rollback a machinedeployment
-		clusterctl alpha rollout undo machinedeployment/my-md-0 --to-revision=3`)
+		clusterctl alpha rollout undo machinedeployment/my-md-0 --to-revision=3
+
+		# Watch the rollout status of a kubeadmcontrolplane
+		clusterctl alpha rollout status kubeadmcontrolplane/my-kcp`)
 
 	rolloutCmd = &cobra.Command{
 		Use:     "rollout SUBCOMMAND",
@@ -56,4 +64,6 @@ func init() {
 	rolloutCmd.AddCommand(rollout.NewCmdRolloutPause(cfgFile))
 	rolloutCmd.AddCommand(rollout.NewCmdRolloutResume(cfgFile))
 	rolloutCmd.AddCommand(rollout.NewCmdRolloutUndo(cfgFile))
+	rolloutCmd.AddCommand(rollout.NewCmdRolloutStatus(cfgFile))
+	rolloutCmd.AddCommand(rollout.NewCmdRolloutHistory(cfgFile))
 }
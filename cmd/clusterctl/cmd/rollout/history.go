@@ -0,0 +1,168 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/client"
+	"sigs.k8s.io/yaml"
+)
+
+// historyOptions is the start of the data required to perform the operation.
+type historyOptions struct {
+	kubeconfig        string
+	kubeconfigContext string
+	resources         []string
+	namespace         string
+	revision          int64
+	diff              int64
+}
+
+var historyOpt = &historyOptions{}
+
+var (
+	historyLong = templates.LongDesc(`
+		View previous rollout revisions of cluster-api resources.
+
+	        For each revision, the infrastructure and bootstrap templates, the Kubernetes version and the change cause,
+	        read from the kubernetes.io/change-cause annotation, are shown. Currently only MachineDeployments support rollout history.`)
+
+	historyExample = templates.Examples(`
+		# View the rollout history of a machinedeployment
+		clusterctl alpha rollout history machinedeployment/my-md-0
+
+		# View the details of revision 3 of a machinedeployment
+		clusterctl alpha rollout history machinedeployment/my-md-0 --revision=3
+
+		# View the changes between revision 2 and revision 3 of a machinedeployment
+		clusterctl alpha rollout history machinedeployment/my-md-0 --revision=3 --diff=2`)
+)
+
+// NewCmdRolloutHistory returns a Command instance for 'rollout history' sub command.
+func NewCmdRolloutHistory(cfgFile string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "history RESOURCE",
+		DisableFlagsInUseLine: true,
+		Short:                 "View the rollout history of a cluster-api resource",
+		Long:                  historyLong,
+		Example:               historyExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runHistory(cfgFile, args)
+		},
+	}
+	cmd.Flags().StringVar(&historyOpt.kubeconfig, "kubeconfig", "",
+		"Path to the kubeconfig file to use for accessing the management cluster. If unspecified, default discovery rules apply.")
+	cmd.Flags().StringVar(&historyOpt.kubeconfigContext, "kubeconfig-context", "",
+		"Context to be used within the kubeconfig file. If empty, current context will be used.")
+	cmd.Flags().StringVar(&historyOpt.namespace, "namespace", "", "Namespace where the resource(s) reside. If unspecified, the defult namespace will be used.")
+	cmd.Flags().Int64Var(&historyOpt.revision, "revision", historyOpt.revision, "See the details of the specified revision. Default to 0 (all the revisions).")
+	cmd.Flags().Int64Var(&historyOpt.diff, "diff", historyOpt.diff, "Show the changes between the specified revision and the one selected with --revision, or the latest revision.")
+
+	return cmd
+}
+
+func runHistory(cfgFile string, args []string) error {
+	historyOpt.resources = args
+
+	c, err := client.New(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	histories, err := c.RolloutHistory(client.RolloutOptions{
+		Kubeconfig:   client.Kubeconfig{Path: historyOpt.kubeconfig, Context: historyOpt.kubeconfigContext},
+		Namespace:    historyOpt.namespace,
+		Resources:    historyOpt.resources,
+		ToRevision:   historyOpt.revision,
+		DiffRevision: historyOpt.diff,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, history := range histories {
+		if err := printHistory(os.Stdout, history, historyOpt.revision > 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printHistory prints the rollout history of a resource; if details are requested, the machine template
+// of each revision is printed as well. If a diff is available, only the diff is printed.
+func printHistory(w io.Writer, history client.RolloutHistory, details bool) error {
+	fmt.Fprintf(w, "%s/%s\n", history.Object.Kind, history.Object.Name)
+	if history.Diff != "" {
+		fmt.Fprintln(w, history.Diff)
+		return nil
+	}
+
+	if details {
+		for _, r := range history.Revisions {
+			fmt.Fprintf(w, "REVISION %d\n", r.Revision)
+			fmt.Fprintf(w, "MachineSet: %s\n", r.MachineSet)
+			fmt.Fprintf(w, "Change-Cause: %s\n", valueOrNone(r.ChangeCause))
+			template, err := yaml.Marshal(r.Template)
+			if err != nil {
+				return errors.Wrapf(err, "failed to marshal the template of revision %d", r.Revision)
+			}
+			fmt.Fprintf(w, "Template:\n%s\n", template)
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 10, 4, 3, ' ', 0)
+	fmt.Fprintln(tw, "REVISION\tMACHINESET\tINFRASTRUCTURE\tBOOTSTRAP\tVERSION\tCHANGE-CAUSE")
+	for _, r := range history.Revisions {
+		bootstrap := "<none>"
+		switch {
+		case r.BootstrapRef != nil:
+			bootstrap = refString(*r.BootstrapRef)
+		case r.BootstrapDataSecretName != nil:
+			bootstrap = fmt.Sprintf("Secret/%s", *r.BootstrapDataSecretName)
+		}
+		version := "<none>"
+		if r.Version != nil {
+			version = *r.Version
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", r.Revision, r.MachineSet, refString(r.InfrastructureRef), bootstrap, version, valueOrNone(r.ChangeCause))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "")
+	return nil
+}
+
+func refString(ref corev1.ObjectReference) string {
+	return fmt.Sprintf("%s/%s", ref.Kind, ref.Name)
+}
+
+func valueOrNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
@@ -40,7 +40,13 @@ var (
 
 	restartExample = templates.Examples(`
 		# Restart a machinedeployment
-		clusterctl alpha rollout restart machinedeployment/my-md-0`)
+		clusterctl alpha rollout restart machinedeployment/my-md-0
+
+		# Restart a kubeadmcontrolplane
+		clusterctl alpha rollout restart kubeadmcontrolplane/my-kcp
+
+		# Restart a machinepool
+		clusterctl alpha rollout restart machinepool/my-mp-0`)
 )
 
 // NewCmdRolloutRestart returns a Command instance for 'rollout restart' sub command.
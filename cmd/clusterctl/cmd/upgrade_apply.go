@@ -17,6 +17,8 @@ limitations under the License.
 package cmd
 
 import (
+	"time"
+
 	"github.com/pkg/errors"
 
 	"github.com/spf13/cobra"
@@ -31,6 +33,11 @@ type upgradeApplyOptions struct {
 	bootstrapProviders      []string
 	controlPlaneProviders   []string
 	infrastructureProviders []string
+	migrateStorageVersions  bool
+	skipPreflightChecks     bool
+	skipVerification        bool
+	verificationTimeout     time.Duration
+	rollbackOnFailure       bool
 }
 
 var ua = &upgradeApplyOptions{}
@@ -50,7 +57,13 @@ var upgradeApplyCmd = &cobra.Command{
 		clusterctl upgrade apply --contract v1alpha4
 
 		# Upgrades only the capa-system/aws provider to the v0.5.0 version.
-		clusterctl upgrade apply --infrastructure capa-system/aws:v0.5.0`),
+		clusterctl upgrade apply --infrastructure capa-system/aws:v0.5.0
+
+		# Upgrades all the providers, migrating the objects stored with API versions removed by the upgrade.
+		clusterctl upgrade apply --contract v1alpha4 --migrate-storage-versions
+
+		# Upgrades all the providers, and rolls back to the previous versions if the upgraded providers don't become ready in 10 minutes.
+		clusterctl upgrade apply --contract v1alpha4 --verification-timeout 10m --rollback-on-failure`),
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runUpgradeApply()
@@ -73,10 +86,20 @@ func init() {
 		"Bootstrap providers instance and versions (e.g. capi-kubeadm-bootstrap-system/kubeadm:v0.3.0) to upgrade to. This flag can be used as alternative to --contract.")
 	upgradeApplyCmd.Flags().StringSliceVarP(&ua.controlPlaneProviders, "control-plane", "c", nil,
 		"ControlPlane providers instance and versions (e.g. capi-kubeadm-control-plane-system/kubeadm:v0.3.0) to upgrade to. This flag can be used as alternative to --contract.")
+	upgradeApplyCmd.Flags().BoolVar(&ua.migrateStorageVersions, "migrate-storage-versions", false,
+		"Migrate the objects stored with API versions removed by the upgrade to the current storage version before applying the new CustomResourceDefinitions. If not set, the upgrade fails when a migration is required.")
+	upgradeApplyCmd.Flags().BoolVar(&ua.skipPreflightChecks, "skip-preflight-checks", false,
+		"Skip the checks on the health of the management cluster before the upgrade, e.g. provider controllers not available, MachineDeployments or control planes rolling out, webhooks not reachable.")
+	upgradeApplyCmd.Flags().BoolVar(&ua.skipVerification, "skip-verification", false,
+		"Skip waiting for the upgraded provider controllers to become ready and checking that existing objects can be converted across all the served API versions.")
+	upgradeApplyCmd.Flags().DurationVar(&ua.verificationTimeout, "verification-timeout", 5*time.Minute,
+		"The time to wait for the upgraded provider controllers to become ready.")
+	upgradeApplyCmd.Flags().BoolVar(&ua.rollbackOnFailure, "rollback-on-failure", false,
+		"Reinstall the previous versions of the upgraded providers if the verification fails. The rollback is not supported if the upgrade changes the providers' CustomResourceDefinitions.")
 }
 
 func runUpgradeApply() error {
-	c, err := client.New(cfgFile)
+	c, err := client.New(cfgFile, client.InjectKubeconfig(client.Kubeconfig{Path: ua.kubeconfig, Context: ua.kubeconfigContext}))
 	if err != nil {
 		return err
 	}
@@ -97,5 +120,10 @@ func runUpgradeApply() error {
 		BootstrapProviders:      ua.bootstrapProviders,
 		ControlPlaneProviders:   ua.controlPlaneProviders,
 		InfrastructureProviders: ua.infrastructureProviders,
+		MigrateStorageVersions:  ua.migrateStorageVersions,
+		SkipPreflightChecks:     ua.skipPreflightChecks,
+		SkipVerification:        ua.skipVerification,
+		VerificationTimeout:     ua.verificationTimeout,
+		RollbackOnFailure:       ua.rollbackOnFailure,
 	})
 }
@@ -19,6 +19,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
@@ -40,6 +41,10 @@ var upgradePlanCmd = &cobra.Command{
 		The upgrade plan command provides a list of recommended target versions for upgrading the
         Cluster API providers in a management cluster.
 
+		For each provider, the plan also describes the impact of the upgrade on CustomResourceDefinitions
+		(served and storage versions, dropped fields, conversion webhooks) and on webhook configurations,
+		and it reports when objects stored with API versions removed by the upgrade must be migrated.
+
 		All the providers should be supporting the same API Version of Cluster API (contract) in order
         to guarantee the proper functioning of the management cluster.
 
@@ -64,7 +69,7 @@ func init() {
 }
 
 func runUpgradePlan() error {
-	c, err := client.New(cfgFile)
+	c, err := client.New(cfgFile, client.InjectKubeconfig(client.Kubeconfig{Path: up.kubeconfig, Context: up.kubeconfigContext}))
 	if err != nil {
 		return err
 	}
@@ -121,11 +126,17 @@ func runUpgradePlan() error {
 		}
 		fmt.Println("")
 
+		requiresStorageVersionMigration := printUpgradeImpact(plan)
+
 		if upgradeAvailable {
 			if plan.Contract == clusterv1.GroupVersion.Version {
 				fmt.Println("You can now apply the upgrade by executing the following command:")
 				fmt.Println("")
-				fmt.Printf("clusterctl upgrade apply --contract %s\n", plan.Contract)
+				if requiresStorageVersionMigration {
+					fmt.Printf("clusterctl upgrade apply --contract %s --migrate-storage-versions\n", plan.Contract)
+				} else {
+					fmt.Printf("clusterctl upgrade apply --contract %s\n", plan.Contract)
+				}
 			} else {
 				fmt.Printf("The current version of clusterctl could not upgrade to %s contract (only %s supported).\n", plan.Contract, clusterv1.GroupVersion.Version)
 			}
@@ -137,3 +148,65 @@ func runUpgradePlan() error {
 
 	return nil
 }
+
+// printUpgradeImpact prints the impact of the upgrade on CustomResourceDefinitions and webhooks, and it
+// returns true if objects stored with versions removed by the upgrade must be migrated.
+func printUpgradeImpact(plan client.UpgradePlan) bool {
+	requiresStorageVersionMigration := false
+	for _, upgradeItem := range plan.Providers {
+		impact := upgradeItem.Impact
+		if impact == nil || (len(impact.CRDs) == 0 && len(impact.Webhooks) == 0) {
+			continue
+		}
+
+		fmt.Printf("Changes when upgrading %s to %s:\n", upgradeItem.InstanceName(), upgradeItem.NextVersion)
+		for _, crd := range impact.CRDs {
+			switch {
+			case crd.Added:
+				fmt.Printf("  - CustomResourceDefinition %s: added\n", crd.Name)
+				continue
+			case crd.Removed:
+				fmt.Printf("  - CustomResourceDefinition %s: no longer included (the CustomResourceDefinition is preserved)\n", crd.Name)
+				continue
+			}
+			fmt.Printf("  - CustomResourceDefinition %s:\n", crd.Name)
+			if len(crd.AddedVersions) > 0 {
+				fmt.Printf("      served versions added: %s\n", strings.Join(crd.AddedVersions, ", "))
+			}
+			if len(crd.RemovedVersions) > 0 {
+				fmt.Printf("      served versions removed: %s\n", strings.Join(crd.RemovedVersions, ", "))
+			}
+			if crd.CurrentStorageVersion != crd.TargetStorageVersion {
+				fmt.Printf("      storage version: %s -> %s\n", crd.CurrentStorageVersion, crd.TargetStorageVersion)
+			}
+			if len(crd.DroppedFields) > 0 {
+				fmt.Printf("      fields dropped: %s\n", strings.Join(crd.DroppedFields, ", "))
+			}
+			if crd.ConversionWebhookChanged {
+				fmt.Println("      conversion webhook changed")
+			}
+			if crd.RequiresStorageVersionMigration {
+				requiresStorageVersionMigration = true
+				fmt.Printf("      WARNING: objects are stored with versions %s; they must be migrated before upgrading\n", strings.Join(crd.StoredVersions, ", "))
+			}
+		}
+		for _, webhook := range impact.Webhooks {
+			switch {
+			case webhook.Added:
+				fmt.Printf("  - %s %s: added\n", webhook.Kind, webhook.Name)
+			case webhook.Removed:
+				fmt.Printf("  - %s %s: removed\n", webhook.Kind, webhook.Name)
+			default:
+				fmt.Printf("  - %s %s: webhooks changed: %s\n", webhook.Kind, webhook.Name, strings.Join(webhook.ChangedWebhooks, ", "))
+			}
+		}
+		fmt.Println("")
+	}
+
+	if requiresStorageVersionMigration {
+		fmt.Println("Objects stored with API versions removed by the upgrade must be migrated to the current storage version;")
+		fmt.Println("use the --migrate-storage-versions flag of clusterctl upgrade apply to migrate them before upgrading.")
+		fmt.Println("")
+	}
+	return requiresStorageVersionMigration
+}
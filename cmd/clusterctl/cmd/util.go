@@ -51,6 +51,24 @@ func stringPtr(s string) *string {
 func printVariablesOutput(template client.Template, options client.GetClusterTemplateOptions) error {
 	// Decorate the variable map for printing
 	variableMap := template.VariableMap()
+	schema := template.VariablesSchema()
+
+	// describeVariable returns the type, the allowed values and the description of a variable defined in the variables schema, if any.
+	describeVariable := func(name string) string {
+		definition := schema.Get(name)
+		if definition == nil {
+			return ""
+		}
+		description := fmt.Sprintf("\t%s", definition.Type)
+		if len(definition.Enum) > 0 {
+			description += fmt.Sprintf(", one of [%s]", strings.Join(definition.Enum, ", "))
+		}
+		if definition.Description != "" {
+			description += fmt.Sprintf("\t%s", definition.Description)
+		}
+		return description
+	}
+
 	var requiredVariables []string
 	var optionalVariables []string
 	for name := range variableMap {
@@ -107,7 +125,8 @@ func printVariablesOutput(template client.Template, options client.GetClusterTem
 			}
 		}
 
-		if variableMap[name] != nil {
+		// Variables declared as required in the variables schema must be set even if there is a default.
+		if definition := schema.Get(name); variableMap[name] != nil && (definition == nil || !definition.Required) {
 			optionalVariables = append(optionalVariables, name)
 		} else {
 			requiredVariables = append(requiredVariables, name)
@@ -116,23 +135,23 @@ func printVariablesOutput(template client.Template, options client.GetClusterTem
 	sort.Strings(requiredVariables)
 	sort.Strings(optionalVariables)
 
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.FilterHTML)
 	if len(requiredVariables) > 0 {
-		fmt.Println("Required Variables:")
+		fmt.Fprintln(w, "Required Variables:")
 		for _, v := range requiredVariables {
-			fmt.Printf("  - %s\n", v)
+			fmt.Fprintf(w, "  - %s%s\n", v, describeVariable(v))
 		}
 	}
 
 	if len(optionalVariables) > 0 {
-		fmt.Println("\nOptional Variables:")
-		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.FilterHTML)
+		fmt.Fprintln(w, "\nOptional Variables:")
 		for _, v := range optionalVariables {
-			fmt.Fprintf(w, "  - %s\t(defaults to %s)\n", v, *variableMap[v])
-		}
-		if err := w.Flush(); err != nil {
-			return err
+			fmt.Fprintf(w, "  - %s\t(defaults to %s)%s\n", v, *variableMap[v], describeVariable(v))
 		}
 	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
 
 	fmt.Println()
 	return nil
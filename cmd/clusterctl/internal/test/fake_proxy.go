@@ -17,6 +17,8 @@ limitations under the License.
 package test
 
 import (
+	"context"
+
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -29,6 +31,7 @@ import (
 	fakecontrolplane "sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test/providers/controlplane"
 	fakeexternal "sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test/providers/external"
 	fakeinfrastructure "sigs.k8s.io/cluster-api/cmd/clusterctl/internal/test/providers/infrastructure"
+	controlplanev1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha4"
 	addonsv1 "sigs.k8s.io/cluster-api/exp/addons/api/v1alpha4"
 	expv1 "sigs.k8s.io/cluster-api/exp/api/v1alpha4"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -50,6 +53,7 @@ func init() {
 	_ = clusterctlv1.AddToScheme(FakeScheme)
 	_ = clusterv1.AddToScheme(FakeScheme)
 	_ = expv1.AddToScheme(FakeScheme)
+	_ = controlplanev1.AddToScheme(FakeScheme)
 	_ = addonsv1.AddToScheme(FakeScheme)
 	_ = apiextensionsv1.AddToScheme(FakeScheme)
 
@@ -75,10 +79,41 @@ func (f *FakeProxy) NewClient() (client.Client, error) {
 	if f.cs != nil {
 		return f.cs, nil
 	}
-	f.cs = fake.NewClientBuilder().WithScheme(FakeScheme).WithObjects(f.objs...).Build()
+	f.cs = &fakeClient{Client: fake.NewClientBuilder().WithScheme(FakeScheme).WithObjects(f.objs...).Build()}
 	return f.cs, nil
 }
 
+// fakeClient wraps the controller-runtime fake client, storing objects created as unstructured with their type
+// when the type is known by the scheme, like the API server does; this allows typed lists of objects that
+// clusterctl creates from unstructured objects, e.g. the CustomResourceDefinitions of the provider components.
+type fakeClient struct {
+	client.Client
+}
+
+func (c *fakeClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return c.Client.Create(ctx, obj, opts...)
+	}
+	gvk := u.GroupVersionKind()
+	typed, err := FakeScheme.New(gvk)
+	if err != nil {
+		// The type is not known by the scheme, e.g. the kind of an external CRD.
+		return c.Client.Create(ctx, obj, opts...)
+	}
+	if err := FakeScheme.Convert(u, typed, nil); err != nil {
+		return err
+	}
+	if err := c.Client.Create(ctx, typed.(client.Object), opts...); err != nil {
+		return err
+	}
+	if err := FakeScheme.Convert(typed, u, nil); err != nil {
+		return err
+	}
+	u.SetGroupVersionKind(gvk)
+	return nil
+}
+
 // ListResources returns all the resources known by the FakeProxy.
 func (f *FakeProxy) ListResources(labels map[string]string, namespaces ...string) ([]unstructured.Unstructured, error) {
 	var ret []unstructured.Unstructured //nolint
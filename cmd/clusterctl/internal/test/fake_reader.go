@@ -34,9 +34,10 @@ type FakeReader struct {
 // configProvider is a mirror of config.Provider, re-implemented here in order to
 // avoid circular dependencies between pkg/client/config and pkg/internal/test.
 type configProvider struct {
-	Name string                    `json:"name,omitempty"`
-	URL  string                    `json:"url,omitempty"`
-	Type clusterctlv1.ProviderType `json:"type,omitempty"`
+	Name              string                    `json:"name,omitempty"`
+	URL               string                    `json:"url,omitempty"`
+	Type              clusterctlv1.ProviderType `json:"type,omitempty"`
+	TemplateProcessor string                    `json:"templateProcessor,omitempty"`
 }
 
 // configCertManager is a mirror of config.CertManager, re-implemented here in order to
@@ -103,6 +104,20 @@ func (f *FakeReader) WithProvider(name string, ttype clusterctlv1.ProviderType,
 	return f
 }
 
+func (f *FakeReader) WithProviderTemplateProcessor(name string, ttype clusterctlv1.ProviderType, url, templateProcessor string) *FakeReader {
+	f.providers = append(f.providers, configProvider{
+		Name:              name,
+		URL:               url,
+		Type:              ttype,
+		TemplateProcessor: templateProcessor,
+	})
+
+	yaml, _ := yaml.Marshal(f.providers)
+	f.variables["providers"] = string(yaml)
+
+	return f
+}
+
 func (f *FakeReader) WithCertManager(url, version, timeout string) *FakeReader {
 	f.certManager = configCertManager{
 		URL:     url,
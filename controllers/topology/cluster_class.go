@@ -20,6 +20,7 @@ import (
 	"context"
 
 	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/util/patch"
@@ -38,6 +39,10 @@ func (r *ClusterReconciler) getClass(ctx context.Context, cluster *clusterv1.Clu
 	// Get ClusterClass.
 	key := client.ObjectKey{Name: cluster.Spec.Topology.Class, Namespace: cluster.Namespace}
 	if err := r.Client.Get(ctx, key, class.clusterClass); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, newTopologyReconcileError(clusterv1.TopologyReconciledClassNotFoundReason,
+				errors.Wrapf(err, "failed to retrieve ClusterClass %q", cluster.Spec.Topology.Class))
+		}
 		return nil, errors.Wrapf(err, "failed to retrieve ClusterClass %q", cluster.Spec.Topology.Class)
 	}
 
@@ -78,6 +83,9 @@ func (r *ClusterReconciler) getClass(ctx context.Context, cluster *clusterv1.Clu
 		}
 	}
 
+	// Copy the MachineHealthCheck definition for the control plane, if any.
+	class.controlPlane.machineHealthCheck = class.clusterClass.Spec.ControlPlane.MachineHealthCheck.DeepCopy()
+
 	// Loop over the machine deployments classes in ClusterClass
 	// and fetch the related templates.
 	for _, mdc := range class.clusterClass.Spec.Workers.MachineDeployments {
@@ -88,6 +96,15 @@ func (r *ClusterReconciler) getClass(ctx context.Context, cluster *clusterv1.Clu
 		// for the MachineDeployment that is created or updated.
 		mdc.Template.Metadata.DeepCopyInto(&mdTopologyClass.metadata)
 
+		// Copy the MachineDeployment fields defined in the class, which can be later overridden
+		// in the Cluster's topology section for the MachineDeployment that is created or updated.
+		mdcCopy := mdc.DeepCopy()
+		mdTopologyClass.failureDomain = mdcCopy.FailureDomain
+		mdTopologyClass.nodeDrainTimeout = mdcCopy.NodeDrainTimeout
+		mdTopologyClass.minReadySeconds = mdcCopy.MinReadySeconds
+		mdTopologyClass.strategy = mdcCopy.Strategy
+		mdTopologyClass.machineHealthCheck = mdcCopy.MachineHealthCheck
+
 		// Get the infrastructure machine template.
 		mdTopologyClass.infrastructureMachineTemplate, err = r.getReference(ctx, mdc.Template.Infrastructure.Ref)
 		if err != nil {
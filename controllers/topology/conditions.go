@@ -0,0 +1,90 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topology
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+// topologyReconcileError is an error which prevents the Cluster topology to be reconciled, carrying the reason
+// to be reported in the TopologyReconciled condition.
+type topologyReconcileError struct {
+	Reason string
+	Err    error
+}
+
+// newTopologyReconcileError returns a topologyReconcileError with the given reason.
+func newTopologyReconcileError(reason string, err error) error {
+	return &topologyReconcileError{Reason: reason, Err: err}
+}
+
+// Error satisfies the error interface.
+func (e *topologyReconcileError) Error() string { return e.Err.Error() }
+
+// Unwrap satisfies the unwrap error interface.
+func (e *topologyReconcileError) Unwrap() error { return e.Err }
+
+// setTopologyReconciledCondition reports on the Cluster the outcome of the reconciliation of the Cluster topology;
+// in case of success, it also reports if the Kubernetes version defined in the topology is not yet applied to all
+// the managed objects because the upgrade is still in progress.
+func setTopologyReconciledCondition(cluster *clusterv1.Cluster, desired *clusterTopologyState, reconcileErr error) error {
+	if reconcileErr != nil {
+		reason := clusterv1.TopologyReconcileFailedReason
+		var topologyErr *topologyReconcileError
+		if errors.As(reconcileErr, &topologyErr) {
+			reason = topologyErr.Reason
+		}
+		conditions.MarkFalse(cluster, clusterv1.TopologyReconciledCondition, reason, clusterv1.ConditionSeverityError, reconcileErr.Error())
+		return nil
+	}
+
+	version := cluster.Spec.Topology.Version
+
+	// If the control plane is not yet at the version defined in the topology, the upgrade is pending.
+	controlPlaneVersion, _, err := unstructured.NestedString(desired.controlPlane.object.UnstructuredContent(), "spec", "version")
+	if err != nil {
+		return errors.Wrapf(err, "failed to get spec.version from %s", desired.controlPlane.object.GetKind())
+	}
+	if controlPlaneVersion != version {
+		conditions.MarkFalse(cluster, clusterv1.TopologyReconciledCondition, clusterv1.TopologyReconciledUpgradePendingReason, clusterv1.ConditionSeverityInfo,
+			"Control plane upgrade to version %s is pending", version)
+		return nil
+	}
+
+	// If one or more MachineDeployments are not yet at the version defined in the topology, the upgrade is pending.
+	var pending []string
+	for name, md := range desired.machineDeployments {
+		if machineDeploymentVersion(md.object) != version {
+			pending = append(pending, name)
+		}
+	}
+	if len(pending) > 0 {
+		sort.Strings(pending)
+		conditions.MarkFalse(cluster, clusterv1.TopologyReconciledCondition, clusterv1.TopologyReconciledUpgradePendingReason, clusterv1.ConditionSeverityInfo,
+			"MachineDeployments upgrade to version %s is pending: [%s]", version, strings.Join(pending, ", "))
+		return nil
+	}
+
+	conditions.MarkTrue(cluster, clusterv1.TopologyReconciledCondition)
+	return nil
+}
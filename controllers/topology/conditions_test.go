@@ -0,0 +1,111 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topology
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+func TestSetTopologyReconciledCondition(t *testing.T) {
+	tests := []struct {
+		name               string
+		reconcileErr       error
+		controlPlane       *unstructured.Unstructured
+		machineDeployments map[string]*machineDeploymentTopologyState
+		wantStatus         corev1.ConditionStatus
+		wantReason         string
+		wantSeverity       clusterv1.ConditionSeverity
+		wantMessage        string
+	}{
+		{
+			name:         "Reports a generic reconcile error",
+			reconcileErr: errors.New("something went wrong"),
+			wantStatus:   corev1.ConditionFalse,
+			wantReason:   clusterv1.TopologyReconcileFailedReason,
+			wantSeverity: clusterv1.ConditionSeverityError,
+			wantMessage:  "something went wrong",
+		},
+		{
+			name:         "Reports the reason of a wrapped topology reconcile error",
+			reconcileErr: errors.Wrap(newTopologyReconcileError(clusterv1.TopologyReconciledClassNotFoundReason, errors.New("class1 not found")), "error reading the ClusterClass"),
+			wantStatus:   corev1.ConditionFalse,
+			wantReason:   clusterv1.TopologyReconciledClassNotFoundReason,
+			wantSeverity: clusterv1.ConditionSeverityError,
+			wantMessage:  "error reading the ClusterClass: class1 not found",
+		},
+		{
+			name:         "Reports the control plane upgrade is pending",
+			controlPlane: newUpgradeTestControlPlane("v1.21.1", "v1.21.1"),
+			wantStatus:   corev1.ConditionFalse,
+			wantReason:   clusterv1.TopologyReconciledUpgradePendingReason,
+			wantSeverity: clusterv1.ConditionSeverityInfo,
+			wantMessage:  "Control plane upgrade to version v1.21.2 is pending",
+		},
+		{
+			name:         "Reports the MachineDeployments upgrade is pending",
+			controlPlane: newUpgradeTestControlPlane("v1.21.2", "v1.21.2"),
+			machineDeployments: map[string]*machineDeploymentTopologyState{
+				"md1": {object: newUpgradeTestMachineDeployment("md1", "v1.21.2", true)},
+				"md2": {object: newUpgradeTestMachineDeployment("md2", "v1.21.1", true)},
+				"md3": {object: newUpgradeTestMachineDeployment("md3", "v1.21.1", true)},
+			},
+			wantStatus:   corev1.ConditionFalse,
+			wantReason:   clusterv1.TopologyReconciledUpgradePendingReason,
+			wantSeverity: clusterv1.ConditionSeverityInfo,
+			wantMessage:  "MachineDeployments upgrade to version v1.21.2 is pending: [md2, md3]",
+		},
+		{
+			name:         "Reports the topology is reconciled",
+			controlPlane: newUpgradeTestControlPlane("v1.21.2", "v1.21.2"),
+			machineDeployments: map[string]*machineDeploymentTopologyState{
+				"md1": {object: newUpgradeTestMachineDeployment("md1", "v1.21.2", true)},
+			},
+			wantStatus: corev1.ConditionTrue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cluster := newUpgradeTestCluster(nil, "md1", "md2", "md3")
+			var desired *clusterTopologyState
+			if tt.reconcileErr == nil {
+				desired = &clusterTopologyState{
+					cluster:            cluster,
+					controlPlane:       &controlPlaneTopologyState{object: tt.controlPlane},
+					machineDeployments: tt.machineDeployments,
+				}
+			}
+
+			g.Expect(setTopologyReconciledCondition(cluster, desired, tt.reconcileErr)).To(Succeed())
+
+			condition := conditions.Get(cluster, clusterv1.TopologyReconciledCondition)
+			g.Expect(condition).ToNot(BeNil())
+			g.Expect(condition.Status).To(Equal(tt.wantStatus))
+			g.Expect(condition.Reason).To(Equal(tt.wantReason))
+			g.Expect(condition.Severity).To(Equal(tt.wantSeverity))
+			g.Expect(condition.Message).To(Equal(tt.wantMessage))
+		})
+	}
+}
@@ -23,12 +23,15 @@ import (
 	"github.com/pkg/errors"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/api/v1alpha4/index"
 	"sigs.k8s.io/cluster-api/controllers/external"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/cluster-api/util/predicates"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -40,7 +43,9 @@ import (
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io;bootstrap.cluster.x-k8s.io;controlplane.cluster.x-k8s.io,resources=*,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusterclasses;machinedeployments,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinehealthchecks,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;patch
 
 // ClusterReconciler reconciles a managed topology for a Cluster object.
 type ClusterReconciler struct {
@@ -53,6 +58,7 @@ type ClusterReconciler struct {
 
 	restConfig      *rest.Config
 	externalTracker external.ObjectTracker
+	recorder        record.EventRecorder
 }
 
 func (r *ClusterReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, options controller.Options) error {
@@ -75,6 +81,7 @@ func (r *ClusterReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manag
 	}
 
 	r.restConfig = mgr.GetConfig()
+	r.recorder = mgr.GetEventRecorderFor("topology/cluster-controller")
 	r.externalTracker = external.ObjectTracker{
 		Controller: c,
 	}
@@ -107,7 +114,21 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 		return ctrl.Result{}, nil
 	}
 
-	// TODO: Add patching as soon as we define how to report managed topology state into conditions
+	// Initialize the patch helper.
+	patchHelper, err := patch.NewHelper(cluster, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Always attempt to patch the object and status after each reconciliation.
+	defer func() {
+		if err := patchHelper.Patch(ctx, cluster, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
+			clusterv1.TopologyReconciledCondition,
+			clusterv1.TopologyUpgradedCondition,
+		}}); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, errors.Wrap(err, "failed to patch Cluster")})
+		}
+	}()
 
 	// In case the object is deleted, the managed topology stops to reconcile;
 	// (the other controllers will take care of deletion).
@@ -122,7 +143,16 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 }
 
 // reconcile handles cluster reconciliation.
-func (r *ClusterReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cluster) (ctrl.Result, error) {
+func (r *ClusterReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cluster) (_ ctrl.Result, reterr error) {
+	var desiredState *clusterTopologyState
+
+	// Always report the outcome of the reconciliation in the TopologyReconciled condition.
+	defer func() {
+		if err := setTopologyReconciledCondition(cluster, desiredState, reterr); err != nil {
+			reterr = kerrors.NewAggregate([]error{reterr, err})
+		}
+	}()
+
 	// Gets the ClusterClass and the referenced templates.
 	class, err := r.getClass(ctx, cluster)
 	if err != nil {
@@ -136,7 +166,7 @@ func (r *ClusterReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cl
 	}
 
 	// Computes the desired state of the Cluster
-	desiredState, err := r.computeDesiredState(ctx, class, currentState)
+	desiredState, err = r.computeDesiredState(ctx, class, currentState)
 	if err != nil {
 		return ctrl.Result{}, errors.Wrap(err, "error computing the desired state of the Cluster topology")
 	}
@@ -146,6 +176,14 @@ func (r *ClusterReconciler) reconcile(ctx context.Context, cluster *clusterv1.Cl
 		return ctrl.Result{}, errors.Wrap(err, "error reconciling the Cluster topology")
 	}
 
+	// Keeps track of the generation of the ClusterClass reconciled into the Cluster topology.
+	cluster.Status.ObservedClusterClassGeneration = class.clusterClass.GetGeneration()
+
+	// Reports the progress of the upgrade of the Cluster topology.
+	if err := setTopologyUpgradedCondition(cluster, currentState, desiredState); err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "error computing the upgrade status of the Cluster topology")
+	}
+
 	return ctrl.Result{}, nil
 }
 
@@ -21,9 +21,12 @@ import (
 	"fmt"
 
 	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 )
 
 // getCurrentState gets information about the current state of a Cluster by inspecting the state of the InfrastructureCluster,
@@ -86,6 +89,18 @@ func (r *ClusterReconciler) getCurrentControlPlaneState(ctx context.Context, clu
 		return nil, errors.Wrapf(err, "failed to read %s %s", cluster.Spec.ControlPlaneRef.Kind, cluster.Spec.ControlPlaneRef.Name)
 	}
 
+	// Ensure we add a watch to the control plane object, so the upgrade of the MachineDeployments can start
+	// as soon as the control plane reports it is upgraded.
+	if err := r.externalTracker.Watch(ctrl.LoggerFrom(ctx), res.object, &handler.EnqueueRequestForOwner{OwnerType: &clusterv1.Cluster{}}); err != nil {
+		return nil, err
+	}
+
+	// Get the MachineHealthCheck for the control plane machines, if any.
+	res.machineHealthCheck, err = r.getCurrentMachineHealthCheck(ctx, cluster.Namespace, res.object.GetName())
+	if err != nil {
+		return nil, err
+	}
+
 	// Some ControlPlane providers may not require MachineInfrastructure to run. This check returns early if the field
 	// indicating MachineInfrastructure is required is not found in the ClusterClass of the given Cluster.
 	if class.Spec.ControlPlane.MachineInfrastructure == nil {
@@ -158,11 +173,35 @@ func (r *ClusterReconciler) getCurrentMachineDeploymentState(ctx context.Context
 		if err != nil {
 			return nil, errors.Wrap(err, fmt.Sprintf("MachineDeployment %s Bootstrap reference could not be retrieved", m.Name))
 		}
+		mhc, err := r.getCurrentMachineHealthCheck(ctx, m.Namespace, m.Name)
+		if err != nil {
+			return nil, err
+		}
 		state[mdTopologyName] = &machineDeploymentTopologyState{
 			object:                        m,
 			bootstrapTemplate:             b,
 			infrastructureMachineTemplate: i,
+			machineHealthCheck:            mhc,
 		}
 	}
 	return state, nil
 }
+
+// getCurrentMachineHealthCheck gets the MachineHealthCheck with the given name. MachineHealthChecks of a managed
+// topology have the same name of the object they are checking the machines for (the ControlPlane object or the MachineDeployment);
+// if the MachineHealthCheck is not found nil is returned, while an error is thrown if the MachineHealthCheck exists
+// but it is not managed by the topology controller.
+func (r *ClusterReconciler) getCurrentMachineHealthCheck(ctx context.Context, namespace, name string) (*clusterv1.MachineHealthCheck, error) {
+	mhc := &clusterv1.MachineHealthCheck{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, mhc); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read MachineHealthCheck %s", name)
+	}
+
+	if _, ok := mhc.Labels[clusterv1.ClusterTopologyLabelName]; !ok {
+		return nil, errors.Errorf("MachineHealthCheck %s exists but it is not managed by the topology controller", name)
+	}
+	return mhc, nil
+}
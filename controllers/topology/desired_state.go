@@ -28,6 +28,7 @@ import (
 	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/controllers/topology/internal/patches"
 )
 
 // computeDesiredState computes the desired state of the cluster topology.
@@ -36,7 +37,15 @@ import (
 // subset of a topology will be implemented.
 func (r *ClusterReconciler) computeDesiredState(_ context.Context, class *clusterTopologyClass, current *clusterTopologyState) (*clusterTopologyState, error) {
 	var err error
-	desiredState := &clusterTopologyState{}
+	desiredState := &clusterTopologyState{
+		controlPlane: &controlPlaneTopologyState{},
+	}
+
+	// Customize the ClusterClass templates for this Cluster by applying the ClusterClass patches,
+	// using the values of the variables defined in the Cluster topology.
+	if class, err = applyPatches(class, current.cluster); err != nil {
+		return nil, newTopologyReconcileError(clusterv1.TopologyReconciledPatchFailedReason, err)
+	}
 
 	// Compute the desired state of the InfrastructureCluster object.
 	if desiredState.infrastructureCluster, err = computeInfrastructureCluster(class, current); err != nil {
@@ -56,6 +65,17 @@ func (r *ClusterReconciler) computeDesiredState(_ context.Context, class *cluste
 		return nil, err
 	}
 
+	// If the ControlPlane class defines a MachineHealthCheck, compute the MachineHealthCheck for the control plane machines.
+	if class.controlPlane.machineHealthCheck != nil {
+		desiredState.controlPlane.machineHealthCheck = computeMachineHealthCheck(
+			class.controlPlane.machineHealthCheck,
+			current.cluster,
+			desiredState.controlPlane.object.GetName(),
+			selectorForControlPlaneMHC(),
+			nil,
+		)
+	}
+
 	// Compute the desired state for the Cluster object adding a reference to the
 	// InfrastructureCluster and the ControlPlane objects generated by the previous step.
 	desiredState.cluster = computeCluster(current, desiredState.infrastructureCluster, desiredState.controlPlane.object)
@@ -65,9 +85,16 @@ func (r *ClusterReconciler) computeDesiredState(_ context.Context, class *cluste
 		return desiredState, nil
 	}
 
+	// Compute the Kubernetes version for each MachineDeployment, so MachineDeployments are upgraded only
+	// after the control plane, respecting the order and the concurrency limit defined in the topology.
+	versions, err := computeMachineDeploymentVersions(current)
+	if err != nil {
+		return nil, err
+	}
+
 	desiredState.machineDeployments = map[string]*machineDeploymentTopologyState{}
 	for _, mdTopology := range current.cluster.Spec.Topology.Workers.MachineDeployments {
-		desiredMachineDeployment, err := computeMachineDeployment(class, current, mdTopology)
+		desiredMachineDeployment, err := computeMachineDeployment(class, current, mdTopology, versions[mdTopology.Name])
 		if err != nil {
 			return nil, err
 		}
@@ -76,6 +103,67 @@ func (r *ClusterReconciler) computeDesiredState(_ context.Context, class *cluste
 	return desiredState, nil
 }
 
+// applyPatches returns a copy of the clusterTopologyClass where the templates are customized by applying
+// the patches defined in the ClusterClass, using the values of the variables defined in the Cluster topology.
+// NOTE: The templates read from the ClusterClass are never modified, so the customization applies only to the
+// templates and objects generated for this Cluster.
+func applyPatches(class *clusterTopologyClass, cluster *clusterv1.Cluster) (*clusterTopologyClass, error) {
+	values, err := patches.VariableValues(cluster, class.clusterClass.Spec.Variables)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to compute variables for ClusterClass %q", class.clusterClass.Name)
+	}
+
+	if len(class.clusterClass.Spec.Patches) == 0 {
+		return class, nil
+	}
+
+	patchedClass := &clusterTopologyClass{
+		clusterClass:                  class.clusterClass,
+		infrastructureClusterTemplate: class.infrastructureClusterTemplate.DeepCopy(),
+	}
+	templates := []*patches.Template{
+		{Type: patches.InfrastructureClusterTemplate, Object: patchedClass.infrastructureClusterTemplate},
+	}
+
+	if class.controlPlane != nil {
+		patchedClass.controlPlane = &controlPlaneTopologyClass{
+			template:                      class.controlPlane.template.DeepCopy(),
+			infrastructureMachineTemplate: class.controlPlane.infrastructureMachineTemplate.DeepCopy(),
+			machineHealthCheck:            class.controlPlane.machineHealthCheck,
+		}
+		templates = append(templates,
+			&patches.Template{Type: patches.ControlPlaneTemplate, Object: patchedClass.controlPlane.template},
+			&patches.Template{Type: patches.ControlPlaneInfrastructureMachineTemplate, Object: patchedClass.controlPlane.infrastructureMachineTemplate},
+		)
+	}
+
+	if class.machineDeployments != nil {
+		patchedClass.machineDeployments = make(map[string]*machineDeploymentTopologyClass, len(class.machineDeployments))
+		for name, machineDeploymentClass := range class.machineDeployments {
+			patchedMachineDeploymentClass := &machineDeploymentTopologyClass{
+				metadata:                      machineDeploymentClass.metadata,
+				bootstrapTemplate:             machineDeploymentClass.bootstrapTemplate.DeepCopy(),
+				infrastructureMachineTemplate: machineDeploymentClass.infrastructureMachineTemplate.DeepCopy(),
+				failureDomain:                 machineDeploymentClass.failureDomain,
+				nodeDrainTimeout:              machineDeploymentClass.nodeDrainTimeout,
+				minReadySeconds:               machineDeploymentClass.minReadySeconds,
+				strategy:                      machineDeploymentClass.strategy,
+				machineHealthCheck:            machineDeploymentClass.machineHealthCheck,
+			}
+			patchedClass.machineDeployments[name] = patchedMachineDeploymentClass
+			templates = append(templates,
+				&patches.Template{Type: patches.MachineDeploymentBootstrapTemplate, MachineDeploymentClass: name, Object: patchedMachineDeploymentClass.bootstrapTemplate},
+				&patches.Template{Type: patches.MachineDeploymentInfrastructureMachineTemplate, MachineDeploymentClass: name, Object: patchedMachineDeploymentClass.infrastructureMachineTemplate},
+			)
+		}
+	}
+
+	if err := patches.Apply(class.clusterClass.Spec.Patches, values, templates); err != nil {
+		return nil, errors.Wrapf(err, "failed to apply patches from ClusterClass %q", class.clusterClass.Name)
+	}
+	return patchedClass, nil
+}
+
 // computeInfrastructureCluster computes the desired state for the InfrastructureCluster object starting from the
 // corresponding template defined in ClusterClass.
 func computeInfrastructureCluster(class *clusterTopologyClass, current *clusterTopologyState) (*unstructured.Unstructured, error) {
@@ -150,8 +238,11 @@ func computeControlPlane(class *clusterTopologyClass, current *clusterTopologySt
 	}
 
 	// Sets the desired Kubernetes version for the control plane.
-	// TODO: improve this logic by adding support for version upgrade component by component
-	if err := unstructured.SetNestedField(controlPlane.UnstructuredContent(), current.cluster.Spec.Topology.Version, "spec", "version"); err != nil {
+	version, err := computeControlPlaneVersion(current)
+	if err != nil {
+		return nil, err
+	}
+	if err := unstructured.SetNestedField(controlPlane.UnstructuredContent(), version, "spec", "version"); err != nil {
 		return nil, errors.Wrap(err, "failed to set spec.version in the ControlPlane object")
 	}
 
@@ -185,14 +276,15 @@ func computeCluster(current *clusterTopologyState, infrastructureCluster, contro
 
 // computeMachineDeployment computes the desired state for a MachineDeploymentTopology.
 // The generated machineDeployment object is calculated using the values from the machineDeploymentTopology and
-// the machineDeployment class.
-func computeMachineDeployment(class *clusterTopologyClass, current *clusterTopologyState, machineDeploymentTopology clusterv1.MachineDeploymentTopology) (*machineDeploymentTopologyState, error) {
+// the machineDeployment class, while the Kubernetes version is provided by the caller.
+func computeMachineDeployment(class *clusterTopologyClass, current *clusterTopologyState, machineDeploymentTopology clusterv1.MachineDeploymentTopology, version string) (*machineDeploymentTopologyState, error) {
 	desiredMachineDeployment := &machineDeploymentTopologyState{}
 
 	className := machineDeploymentTopology.Class
 	machineDeploymentClass, ok := class.machineDeployments[className]
 	if !ok {
-		return nil, errors.Errorf("MachineDeployment class %s not found in ClusterClass %s", className, class.clusterClass.Name)
+		return nil, newTopologyReconcileError(clusterv1.TopologyReconciledClassNotFoundReason,
+			errors.Errorf("MachineDeployment class %s not found in ClusterClass %s", className, class.clusterClass.Name))
 	}
 
 	currentMachineDeployment := current.machineDeployments[machineDeploymentTopology.Name]
@@ -239,14 +331,34 @@ func computeMachineDeployment(class *clusterTopologyClass, current *clusterTopol
 			Template: clusterv1.MachineTemplateSpec{
 				Spec: clusterv1.MachineSpec{
 					ClusterName:       current.cluster.Name,
-					Version:           pointer.String(current.cluster.Spec.Topology.Version),
+					Version:           pointer.String(version),
 					Bootstrap:         clusterv1.Bootstrap{ConfigRef: objToRef(desiredMachineDeployment.bootstrapTemplate)},
 					InfrastructureRef: *objToRef(desiredMachineDeployment.infrastructureMachineTemplate),
+					FailureDomain:     machineDeploymentClass.failureDomain,
+					NodeDrainTimeout:  machineDeploymentClass.nodeDrainTimeout,
 				},
 			},
+			MinReadySeconds: machineDeploymentClass.minReadySeconds,
+			Strategy:        machineDeploymentClass.strategy,
 		},
 	}
 
+	// Values defined in the MachineDeploymentTopology override the defaults defined in the MachineDeploymentClass.
+	// NOTE: Those fields are set on the MachineDeployment only, so changing them does not require a rotation
+	// of the referenced templates.
+	if machineDeploymentTopology.FailureDomain != nil {
+		desiredMachineDeploymentObj.Spec.Template.Spec.FailureDomain = machineDeploymentTopology.FailureDomain
+	}
+	if machineDeploymentTopology.NodeDrainTimeout != nil {
+		desiredMachineDeploymentObj.Spec.Template.Spec.NodeDrainTimeout = machineDeploymentTopology.NodeDrainTimeout
+	}
+	if machineDeploymentTopology.MinReadySeconds != nil {
+		desiredMachineDeploymentObj.Spec.MinReadySeconds = machineDeploymentTopology.MinReadySeconds
+	}
+	if machineDeploymentTopology.Strategy != nil {
+		desiredMachineDeploymentObj.Spec.Strategy = machineDeploymentTopology.Strategy
+	}
+
 	// If an existing MachineDeployment is present, override the MachineDeployment
 	// object with the same name.
 	if currentMachineDeployment != nil && currentMachineDeployment.object != nil {
@@ -266,9 +378,77 @@ func computeMachineDeployment(class *clusterTopologyClass, current *clusterTopol
 	}
 
 	desiredMachineDeployment.object = desiredMachineDeploymentObj
+
+	// If the MachineDeployment class defines a MachineHealthCheck, compute the MachineHealthCheck for the MachineDeployment machines.
+	if machineDeploymentClass.machineHealthCheck != nil {
+		desiredMachineDeployment.machineHealthCheck = computeMachineHealthCheck(
+			machineDeploymentClass.machineHealthCheck,
+			current.cluster,
+			desiredMachineDeploymentObj.Name,
+			selectorForMachineDeploymentMHC(desiredMachineDeploymentObj.Name),
+			map[string]string{clusterv1.ClusterTopologyMachineDeploymentLabelName: machineDeploymentTopology.Name},
+		)
+	}
+
 	return desiredMachineDeployment, nil
 }
 
+// computeMachineHealthCheck computes the desired state for a MachineHealthCheck starting from the corresponding
+// definition in ClusterClass.
+// NOTE: The MachineHealthCheck gets the same name of the object it is checking the machines for (the ControlPlane object
+// or the MachineDeployment), so it is possible to find it at later stages of the reconcile process.
+func computeMachineHealthCheck(healthCheckClass *clusterv1.MachineHealthCheckClass, cluster *clusterv1.Cluster, name string, selector *metav1.LabelSelector, labels map[string]string) *clusterv1.MachineHealthCheck {
+	// Enforce the topology labels into the provided label set.
+	mhcLabels := mergeMap(labels, nil)
+	mhcLabels[clusterv1.ClusterLabelName] = cluster.Name
+	mhcLabels[clusterv1.ClusterTopologyLabelName] = ""
+
+	healthCheck := healthCheckClass.DeepCopy()
+	gv := clusterv1.GroupVersion
+	return &clusterv1.MachineHealthCheck{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       gv.WithKind("MachineHealthCheck").Kind,
+			APIVersion: gv.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cluster.Namespace,
+			Labels:    mhcLabels,
+		},
+		Spec: clusterv1.MachineHealthCheckSpec{
+			ClusterName:         cluster.Name,
+			Selector:            *selector,
+			UnhealthyConditions: healthCheck.UnhealthyConditions,
+			MaxUnhealthy:        healthCheck.MaxUnhealthy,
+			NodeStartupTimeout:  healthCheck.NodeStartupTimeout,
+			RemediationTemplate: healthCheck.RemediationTemplate,
+		},
+	}
+}
+
+// selectorForControlPlaneMHC returns the selector for the MachineHealthCheck of the control plane machines.
+// NOTE: The MachineHealthCheck controller restricts the selector to the machines of the Cluster.
+func selectorForControlPlaneMHC() *metav1.LabelSelector {
+	return &metav1.LabelSelector{
+		MatchLabels: map[string]string{
+			clusterv1.MachineControlPlaneLabelName: "",
+		},
+	}
+}
+
+// selectorForMachineDeploymentMHC returns the selector for the MachineHealthCheck of the machines
+// of a MachineDeployment in a managed topology.
+// NOTE: The selector uses the deployment-name label that the MachineDeployment webhook adds to the machine template,
+// so the machine template of existing MachineDeployments doesn't change and no rollout is triggered.
+// NOTE: The MachineHealthCheck controller restricts the selector to the machines of the Cluster.
+func selectorForMachineDeploymentMHC(machineDeploymentName string) *metav1.LabelSelector {
+	return &metav1.LabelSelector{
+		MatchLabels: map[string]string{
+			clusterv1.MachineDeploymentLabelName: machineDeploymentName,
+		},
+	}
+}
+
 type templateToInput struct {
 	template              *unstructured.Unstructured
 	templateClonedFromRef *corev1.ObjectReference
@@ -19,11 +19,15 @@ package topology
 import (
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 )
 
@@ -447,6 +451,7 @@ func TestComputeMachineDeployment(t *testing.T) {
 		cluster: newFakeCluster(metav1.NamespaceDefault, "cluster1").Obj(),
 	}
 
+	version := "v1.21.2"
 	replicas := 5
 	mdTopology := clusterv1.MachineDeploymentTopology{
 		Metadata: clusterv1.ObjectMeta{
@@ -459,12 +464,13 @@ func TestComputeMachineDeployment(t *testing.T) {
 
 	t.Run("Generates the machine deployment and the referenced templates", func(t *testing.T) {
 		g := NewWithT(t)
-		actual, err := computeMachineDeployment(class, current, mdTopology)
+		actual, err := computeMachineDeployment(class, current, mdTopology, version)
 		g.Expect(err).ToNot(HaveOccurred())
 
 		actualMd := actual.object
 		g.Expect(*actualMd.Spec.Replicas).To(Equal(int32(replicas)))
 		g.Expect(actualMd.Spec.ClusterName).To(Equal("cluster1"))
+		g.Expect(*actualMd.Spec.Template.Spec.Version).To(Equal(version))
 		g.Expect(actualMd.Name).To(ContainSubstring("cluster1"))
 		g.Expect(actualMd.Name).To(ContainSubstring("big-pool-of-machines"))
 
@@ -505,7 +511,7 @@ func TestComputeMachineDeployment(t *testing.T) {
 			},
 		}
 
-		actual, err := computeMachineDeployment(class, current, mdTopology)
+		actual, err := computeMachineDeployment(class, current, mdTopology, version)
 		g.Expect(err).ToNot(HaveOccurred())
 
 		actualMd := actual.object
@@ -522,6 +528,96 @@ func TestComputeMachineDeployment(t *testing.T) {
 		g.Expect(actualMd.Spec.Template.Spec.Bootstrap.ConfigRef.Name).To(Equal("linux-worker-bootstraptemplate"))
 	})
 
+	t.Run("Uses the machine deployment class defaults unless they are overridden in the topology", func(t *testing.T) {
+		g := NewWithT(t)
+
+		classWithDefaults := &clusterTopologyClass{
+			clusterClass: fakeClass,
+			machineDeployments: map[string]*machineDeploymentTopologyClass{
+				"linux-worker": {
+					bootstrapTemplate:             workerBootstrapTemplate,
+					infrastructureMachineTemplate: workerInfrastructureMachineTemplate,
+					failureDomain:                 pointer.StringPtr("class-fd"),
+					nodeDrainTimeout:              &metav1.Duration{Duration: 10 * time.Second},
+					minReadySeconds:               pointer.Int32Ptr(5),
+					strategy: &clusterv1.MachineDeploymentStrategy{
+						Type: clusterv1.RollingUpdateMachineDeploymentStrategyType,
+					},
+				},
+			},
+		}
+
+		actual, err := computeMachineDeployment(classWithDefaults, current, mdTopology, version)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		actualMd := actual.object
+		g.Expect(actualMd.Spec.Template.Spec.FailureDomain).To(Equal(pointer.StringPtr("class-fd")))
+		g.Expect(actualMd.Spec.Template.Spec.NodeDrainTimeout).To(Equal(&metav1.Duration{Duration: 10 * time.Second}))
+		g.Expect(actualMd.Spec.MinReadySeconds).To(Equal(pointer.Int32Ptr(5)))
+		g.Expect(actualMd.Spec.Strategy.Type).To(Equal(clusterv1.RollingUpdateMachineDeploymentStrategyType))
+
+		mdTopologyWithOverrides := mdTopology.DeepCopy()
+		mdTopologyWithOverrides.FailureDomain = pointer.StringPtr("topology-fd")
+		mdTopologyWithOverrides.NodeDrainTimeout = &metav1.Duration{Duration: 20 * time.Second}
+		mdTopologyWithOverrides.MinReadySeconds = pointer.Int32Ptr(10)
+		mdTopologyWithOverrides.Strategy = &clusterv1.MachineDeploymentStrategy{
+			Type: clusterv1.OnDeleteMachineDeploymentStrategyType,
+		}
+
+		actual, err = computeMachineDeployment(classWithDefaults, current, *mdTopologyWithOverrides, version)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		actualMd = actual.object
+		g.Expect(actualMd.Spec.Template.Spec.FailureDomain).To(Equal(pointer.StringPtr("topology-fd")))
+		g.Expect(actualMd.Spec.Template.Spec.NodeDrainTimeout).To(Equal(&metav1.Duration{Duration: 20 * time.Second}))
+		g.Expect(actualMd.Spec.MinReadySeconds).To(Equal(pointer.Int32Ptr(10)))
+		g.Expect(actualMd.Spec.Strategy.Type).To(Equal(clusterv1.OnDeleteMachineDeploymentStrategyType))
+
+		// Changing the defaults must not rotate the templates referenced by an existing machine deployment.
+		g.Expect(actualMd.Spec.Template.Spec.InfrastructureRef.Name).To(Equal("linux-worker-inframachinetemplate"))
+		g.Expect(actualMd.Spec.Template.Spec.Bootstrap.ConfigRef.Name).To(Equal("linux-worker-bootstraptemplate"))
+	})
+
+	t.Run("Generates the MachineHealthCheck if it is defined in the machine deployment class", func(t *testing.T) {
+		g := NewWithT(t)
+
+		healthCheckClass := &clusterv1.MachineHealthCheckClass{
+			UnhealthyConditions: []clusterv1.UnhealthyCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionFalse, Timeout: metav1.Duration{Duration: 5 * time.Minute}},
+			},
+			NodeStartupTimeout: &metav1.Duration{Duration: 10 * time.Minute},
+		}
+		classWithHealthCheck := &clusterTopologyClass{
+			clusterClass: fakeClass,
+			machineDeployments: map[string]*machineDeploymentTopologyClass{
+				"linux-worker": {
+					bootstrapTemplate:             workerBootstrapTemplate,
+					infrastructureMachineTemplate: workerInfrastructureMachineTemplate,
+					machineHealthCheck:            healthCheckClass,
+				},
+			},
+		}
+
+		actual, err := computeMachineDeployment(classWithHealthCheck, current, mdTopology, version)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		actualMd := actual.object
+		// The machine template labels are not changed, so existing MachineDeployments are not rolled out.
+		g.Expect(actualMd.Spec.Template.Labels).To(BeEmpty())
+
+		actualMHC := actual.machineHealthCheck
+		g.Expect(actualMHC).ToNot(BeNil())
+		g.Expect(actualMHC.Name).To(Equal(actualMd.Name))
+		g.Expect(actualMHC.Namespace).To(Equal(actualMd.Namespace))
+		g.Expect(actualMHC.Labels).To(HaveKeyWithValue(clusterv1.ClusterTopologyLabelName, ""))
+		g.Expect(actualMHC.Labels).To(HaveKeyWithValue(clusterv1.ClusterTopologyMachineDeploymentLabelName, "big-pool-of-machines"))
+		g.Expect(actualMHC.Spec.ClusterName).To(Equal("cluster1"))
+		// The selector of the MachineHealthCheck matches the deployment-name label the MachineDeployment webhook adds to the machines.
+		g.Expect(actualMHC.Spec.Selector.MatchLabels).To(Equal(map[string]string{clusterv1.MachineDeploymentLabelName: actualMd.Name}))
+		g.Expect(actualMHC.Spec.UnhealthyConditions).To(Equal(healthCheckClass.UnhealthyConditions))
+		g.Expect(actualMHC.Spec.NodeStartupTimeout).To(Equal(healthCheckClass.NodeStartupTimeout))
+	})
+
 	t.Run("If a machine deployment references a topology class that does not exist, machine deployment generation fails", func(t *testing.T) {
 		g := NewWithT(t)
 		mdTopology = clusterv1.MachineDeploymentTopology{
@@ -532,7 +628,158 @@ func TestComputeMachineDeployment(t *testing.T) {
 			Name:  "big-pool-of-machines",
 		}
 
-		_, err := computeMachineDeployment(class, current, mdTopology)
+		_, err := computeMachineDeployment(class, current, mdTopology, version)
+		g.Expect(err).To(HaveOccurred())
+	})
+}
+
+func TestComputeMachineHealthCheck(t *testing.T) {
+	g := NewWithT(t)
+
+	maxUnhealthy := intstr.FromString("40%")
+	healthCheckClass := &clusterv1.MachineHealthCheckClass{
+		UnhealthyConditions: []clusterv1.UnhealthyCondition{
+			{Type: corev1.NodeReady, Status: corev1.ConditionUnknown, Timeout: metav1.Duration{Duration: 5 * time.Minute}},
+		},
+		MaxUnhealthy:        &maxUnhealthy,
+		NodeStartupTimeout:  &metav1.Duration{Duration: 10 * time.Minute},
+		RemediationTemplate: fakeRef1,
+	}
+	cluster := newFakeCluster(metav1.NamespaceDefault, "cluster1").Obj()
+
+	actual := computeMachineHealthCheck(healthCheckClass, cluster, "control-plane1", selectorForControlPlaneMHC(), nil)
+
+	g.Expect(actual.GroupVersionKind()).To(Equal(clusterv1.GroupVersion.WithKind("MachineHealthCheck")))
+	g.Expect(actual.Name).To(Equal("control-plane1"))
+	g.Expect(actual.Namespace).To(Equal(metav1.NamespaceDefault))
+	g.Expect(actual.Labels).To(Equal(map[string]string{
+		clusterv1.ClusterLabelName:         "cluster1",
+		clusterv1.ClusterTopologyLabelName: "",
+	}))
+	g.Expect(actual.Spec).To(Equal(clusterv1.MachineHealthCheckSpec{
+		ClusterName: "cluster1",
+		Selector: metav1.LabelSelector{
+			MatchLabels: map[string]string{clusterv1.MachineControlPlaneLabelName: ""},
+		},
+		UnhealthyConditions: healthCheckClass.UnhealthyConditions,
+		MaxUnhealthy:        &maxUnhealthy,
+		NodeStartupTimeout:  &metav1.Duration{Duration: 10 * time.Minute},
+		RemediationTemplate: fakeRef1,
+	}))
+
+	// The MachineHealthCheck must not share memory with the ClusterClass definition.
+	actual.Spec.UnhealthyConditions[0].Status = corev1.ConditionFalse
+	g.Expect(healthCheckClass.UnhealthyConditions[0].Status).To(Equal(corev1.ConditionUnknown))
+}
+
+func TestApplyPatches(t *testing.T) {
+	infrastructureClusterTemplate := newFakeInfrastructureClusterTemplate(metav1.NamespaceDefault, "template1").Obj()
+	workerInfrastructureMachineTemplate := newFakeInfrastructureMachineTemplate(metav1.NamespaceDefault, "linux-worker-inframachinetemplate").Obj()
+	workerBootstrapTemplate := newFakeBootstrapTemplate(metav1.NamespaceDefault, "linux-worker-bootstraptemplate").Obj()
+	// JSON patches add fields to existing objects, so the template spec to be patched must exist.
+	if err := unstructured.SetNestedMap(workerBootstrapTemplate.Object, map[string]interface{}{}, "spec", "template", "spec"); err != nil {
+		t.Fatal(err)
+	}
+
+	clusterClass := newFakeClusterClass(metav1.NamespaceDefault, "class1").
+		WithInfrastructureClusterTemplate(infrastructureClusterTemplate).
+		WithWorkerMachineDeploymentClass("linux-worker", nil, nil, workerInfrastructureMachineTemplate, workerBootstrapTemplate).
+		Obj()
+	clusterClass.Spec.Variables = []clusterv1.ClusterClassVariable{
+		{
+			Name:     "region",
+			Required: true,
+			Schema: clusterv1.VariableSchema{
+				OpenAPIV3Schema: clusterv1.JSONSchemaProps{Type: "string"},
+			},
+		},
+	}
+	clusterClass.Spec.Patches = []clusterv1.ClusterClassPatch{
+		{
+			Name: "region",
+			Definitions: []clusterv1.PatchDefinition{
+				{
+					Selector: clusterv1.PatchSelector{
+						APIVersion:     infrastructureClusterTemplate.GetAPIVersion(),
+						Kind:           infrastructureClusterTemplate.GetKind(),
+						MatchResources: clusterv1.PatchSelectorMatch{InfrastructureCluster: true},
+					},
+					JSONPatches: []clusterv1.JSONPatch{
+						{
+							Op:        "add",
+							Path:      "/spec/template/spec/region",
+							ValueFrom: &clusterv1.PatchValueFrom{Variable: "region"},
+						},
+					},
+				},
+				{
+					Selector: clusterv1.PatchSelector{
+						APIVersion: workerBootstrapTemplate.GetAPIVersion(),
+						Kind:       workerBootstrapTemplate.GetKind(),
+						MatchResources: clusterv1.PatchSelectorMatch{
+							MachineDeploymentClass: &clusterv1.PatchSelectorMatchMachineDeploymentClass{Names: []string{"linux-worker"}},
+						},
+					},
+					JSONPatches: []clusterv1.JSONPatch{
+						{
+							Op:        "add",
+							Path:      "/spec/template/spec/clusterName",
+							ValueFrom: &clusterv1.PatchValueFrom{Variable: "builtin.cluster.name"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// aggregating templates and cluster class into topologyClass (simulating getClass)
+	topologyClass := &clusterTopologyClass{
+		clusterClass:                  clusterClass,
+		infrastructureClusterTemplate: infrastructureClusterTemplate,
+		machineDeployments: map[string]*machineDeploymentTopologyClass{
+			"linux-worker": {
+				bootstrapTemplate:             workerBootstrapTemplate,
+				infrastructureMachineTemplate: workerInfrastructureMachineTemplate,
+			},
+		},
+	}
+
+	t.Run("Applies the patches to a copy of the templates", func(t *testing.T) {
+		g := NewWithT(t)
+
+		cluster := newFakeCluster(metav1.NamespaceDefault, "cluster1").Obj()
+		cluster.Spec.Topology.Variables = []clusterv1.ClusterVariable{
+			{Name: "region", Value: apiextensionsv1.JSON{Raw: []byte(`"us-east-1"`)}},
+		}
+
+		patchedClass, err := applyPatches(topologyClass, cluster)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		assertNestedField(g, patchedClass.infrastructureClusterTemplate, "us-east-1", "spec", "template", "spec", "region")
+		assertNestedField(g, patchedClass.machineDeployments["linux-worker"].bootstrapTemplate, "cluster1", "spec", "template", "spec", "clusterName")
+		g.Expect(patchedClass.machineDeployments["linux-worker"].infrastructureMachineTemplate).To(Equal(workerInfrastructureMachineTemplate))
+
+		// The ClusterClass templates should not be modified.
+		assertNestedFieldUnset(g, topologyClass.infrastructureClusterTemplate, "spec", "template", "spec", "region")
+		assertNestedFieldUnset(g, topologyClass.machineDeployments["linux-worker"].bootstrapTemplate, "spec", "template", "spec", "clusterName")
+	})
+	t.Run("Fails if a required variable is not set", func(t *testing.T) {
+		g := NewWithT(t)
+
+		cluster := newFakeCluster(metav1.NamespaceDefault, "cluster1").Obj()
+
+		_, err := applyPatches(topologyClass, cluster)
+		g.Expect(err).To(HaveOccurred())
+	})
+	t.Run("Fails if a variable does not match the schema", func(t *testing.T) {
+		g := NewWithT(t)
+
+		cluster := newFakeCluster(metav1.NamespaceDefault, "cluster1").Obj()
+		cluster.Spec.Topology.Variables = []clusterv1.ClusterVariable{
+			{Name: "region", Value: apiextensionsv1.JSON{Raw: []byte(`1`)}},
+		}
+
+		_, err := applyPatches(topologyClass, cluster)
 		g.Expect(err).To(HaveOccurred())
 	})
 }
@@ -0,0 +1,198 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package patches implements support for ClusterClass variables and patches in managed topologies.
+package patches
+
+import (
+	"encoding/json"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/pkg/errors"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+// TemplateType identifies where a template is referenced in a ClusterClass.
+type TemplateType string
+
+const (
+	// InfrastructureClusterTemplate is the template referenced in ClusterClass.spec.infrastructure.
+	InfrastructureClusterTemplate TemplateType = "InfrastructureClusterTemplate"
+
+	// ControlPlaneTemplate is the template referenced in ClusterClass.spec.controlPlane.
+	ControlPlaneTemplate TemplateType = "ControlPlaneTemplate"
+
+	// ControlPlaneInfrastructureMachineTemplate is the template referenced in ClusterClass.spec.controlPlane.machineInfrastructure.
+	ControlPlaneInfrastructureMachineTemplate TemplateType = "ControlPlaneInfrastructureMachineTemplate"
+
+	// MachineDeploymentBootstrapTemplate is the bootstrap template referenced in a MachineDeploymentClass.
+	MachineDeploymentBootstrapTemplate TemplateType = "MachineDeploymentBootstrapTemplate"
+
+	// MachineDeploymentInfrastructureMachineTemplate is the infrastructure template referenced in a MachineDeploymentClass.
+	MachineDeploymentInfrastructureMachineTemplate TemplateType = "MachineDeploymentInfrastructureMachineTemplate"
+)
+
+// Template is a template to be patched.
+type Template struct {
+	// Type identifies where the template is referenced in the ClusterClass.
+	Type TemplateType
+
+	// MachineDeploymentClass is the name of the MachineDeploymentClass referencing the template;
+	// it is set only for MachineDeployment templates.
+	MachineDeploymentClass string
+
+	// Object is the template to be patched; patches are applied in place.
+	Object *unstructured.Unstructured
+}
+
+// jsonPatchOperation is a JSON patch (RFC 6902) operation.
+type jsonPatchOperation struct {
+	Op    string                `json:"op"`
+	Path  string                `json:"path"`
+	Value *apiextensionsv1.JSON `json:"value,omitempty"`
+}
+
+// Apply applies the patches defined in a ClusterClass to the templates, using the given variable values.
+// NOTE: Patches are applied in the order they are defined, and each patch definition is applied to all the
+// templates matching its selector.
+func Apply(patches []clusterv1.ClusterClassPatch, values map[string]apiextensionsv1.JSON, templates []*Template) error {
+	for _, patch := range patches {
+		for i, definition := range patch.Definitions {
+			for _, template := range templates {
+				if template.Object == nil || !matchSelector(definition.Selector, template) {
+					continue
+				}
+				if err := applyDefinition(definition, values, template.Object); err != nil {
+					return errors.Wrapf(err, "failed to apply patch %q (definition %d) to %s %s", patch.Name, i, template.Object.GetKind(), template.Object.GetName())
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// matchSelector returns true if the template matches the selector of a patch definition.
+func matchSelector(selector clusterv1.PatchSelector, template *Template) bool {
+	if template.Object.GetAPIVersion() != selector.APIVersion || template.Object.GetKind() != selector.Kind {
+		return false
+	}
+
+	switch template.Type {
+	case InfrastructureClusterTemplate:
+		return selector.MatchResources.InfrastructureCluster
+	case ControlPlaneTemplate, ControlPlaneInfrastructureMachineTemplate:
+		return selector.MatchResources.ControlPlane
+	case MachineDeploymentBootstrapTemplate, MachineDeploymentInfrastructureMachineTemplate:
+		if selector.MatchResources.MachineDeploymentClass == nil {
+			return false
+		}
+		for _, name := range selector.MatchResources.MachineDeploymentClass.Names {
+			if name == template.MachineDeploymentClass {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyDefinition applies the JSON patches and then the merge patch of a patch definition to a template.
+func applyDefinition(definition clusterv1.PatchDefinition, values map[string]apiextensionsv1.JSON, template *unstructured.Unstructured) error {
+	operations := []jsonPatchOperation{}
+	for _, p := range definition.JSONPatches {
+		if !strings.HasPrefix(p.Path, "/spec/") {
+			return errors.Errorf("invalid path %q: only the spec of a template can be patched", p.Path)
+		}
+
+		operation := jsonPatchOperation{Op: p.Op, Path: p.Path}
+		if p.Op != "remove" {
+			value, err := patchValue(p.Value, p.ValueFrom, values)
+			if err != nil {
+				return err
+			}
+			// If the value is read from a variable which is not set, skip the operation.
+			if value == nil {
+				continue
+			}
+			operation.Value = value
+		}
+		operations = append(operations, operation)
+	}
+
+	var mergePatch *apiextensionsv1.JSON
+	if definition.MergePatch != nil {
+		var err error
+		if mergePatch, err = patchValue(definition.MergePatch.Value, definition.MergePatch.ValueFrom, values); err != nil {
+			return err
+		}
+	}
+
+	if len(operations) == 0 && mergePatch == nil {
+		return nil
+	}
+
+	doc, err := template.MarshalJSON()
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal template to json")
+	}
+
+	if len(operations) > 0 {
+		rawPatch, err := json.Marshal(operations)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal json patch")
+		}
+		patch, err := jsonpatch.DecodePatch(rawPatch)
+		if err != nil {
+			return errors.Wrap(err, "failed to decode json patch")
+		}
+		if doc, err = patch.Apply(doc); err != nil {
+			return errors.Wrap(err, "failed to apply json patch")
+		}
+	}
+
+	if mergePatch != nil {
+		fields := map[string]interface{}{}
+		if err := json.Unmarshal(mergePatch.Raw, &fields); err != nil {
+			return errors.Wrap(err, "invalid merge patch: it must be an object")
+		}
+		for name := range fields {
+			if name != "spec" {
+				return errors.Errorf("invalid merge patch: only the spec of a template can be patched, found %q", name)
+			}
+		}
+		if doc, err = jsonpatch.MergePatch(doc, mergePatch.Raw); err != nil {
+			return errors.Wrap(err, "failed to apply merge patch")
+		}
+	}
+
+	if err := template.UnmarshalJSON(doc); err != nil {
+		return errors.Wrap(err, "failed to unmarshal patched template")
+	}
+	return nil
+}
+
+// patchValue returns the value of a patch, either the inline value or the value of the referenced variable.
+func patchValue(value *apiextensionsv1.JSON, valueFrom *clusterv1.PatchValueFrom, values map[string]apiextensionsv1.JSON) (*apiextensionsv1.JSON, error) {
+	if valueFrom != nil {
+		return variableValue(values, valueFrom.Variable)
+	}
+	if value == nil {
+		return nil, errors.New("either value or valueFrom must be set")
+	}
+	return value, nil
+}
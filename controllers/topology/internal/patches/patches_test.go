@@ -0,0 +1,237 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package patches
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+func TestApply(t *testing.T) {
+	newTemplate := func() *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "infrastructure.cluster.x-k8s.io/v1alpha4",
+				"kind":       "FakeInfrastructureMachineTemplate",
+				"metadata": map[string]interface{}{
+					"name": "template1",
+				},
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"instanceType": "small",
+							"replicas":     int64(1),
+						},
+					},
+				},
+			},
+		}
+	}
+	selector := clusterv1.PatchSelector{
+		APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha4",
+		Kind:       "FakeInfrastructureMachineTemplate",
+		MatchResources: clusterv1.PatchSelectorMatch{
+			MachineDeploymentClass: &clusterv1.PatchSelectorMatchMachineDeploymentClass{Names: []string{"linux-worker"}},
+		},
+	}
+	values := map[string]apiextensionsv1.JSON{
+		"instance": {Raw: []byte(`{"type":"large","zone":"a"}`)},
+		"disk":     {Raw: []byte(`{"spec":{"template":{"spec":{"diskSize":100}}}}`)},
+	}
+
+	tests := []struct {
+		name                   string
+		definition             clusterv1.PatchDefinition
+		machineDeploymentClass string
+		wantSpec               map[string]interface{}
+		wantErr                bool
+	}{
+		{
+			name: "Applies json patches with inline values and values from variables",
+			definition: clusterv1.PatchDefinition{
+				Selector: selector,
+				JSONPatches: []clusterv1.JSONPatch{
+					{Op: "replace", Path: "/spec/template/spec/instanceType", ValueFrom: &clusterv1.PatchValueFrom{Variable: "instance.type"}},
+					{Op: "add", Path: "/spec/template/spec/zone", ValueFrom: &clusterv1.PatchValueFrom{Variable: "instance.zone"}},
+					{Op: "add", Path: "/spec/template/spec/tags", Value: &apiextensionsv1.JSON{Raw: []byte(`["a","b"]`)}},
+					{Op: "remove", Path: "/spec/template/spec/replicas"},
+				},
+			},
+			machineDeploymentClass: "linux-worker",
+			wantSpec: map[string]interface{}{
+				"instanceType": "large",
+				"zone":         "a",
+				"tags":         []interface{}{"a", "b"},
+			},
+		},
+		{
+			name: "Skips json patches reading from variables which are not set",
+			definition: clusterv1.PatchDefinition{
+				Selector: selector,
+				JSONPatches: []clusterv1.JSONPatch{
+					{Op: "add", Path: "/spec/template/spec/zone", ValueFrom: &clusterv1.PatchValueFrom{Variable: "instance.region"}},
+					{Op: "add", Path: "/spec/template/spec/image", ValueFrom: &clusterv1.PatchValueFrom{Variable: "image"}},
+				},
+			},
+			machineDeploymentClass: "linux-worker",
+			wantSpec: map[string]interface{}{
+				"instanceType": "small",
+				"replicas":     int64(1),
+			},
+		},
+		{
+			name: "Applies merge patches from variables",
+			definition: clusterv1.PatchDefinition{
+				Selector:   selector,
+				MergePatch: &clusterv1.MergePatch{ValueFrom: &clusterv1.PatchValueFrom{Variable: "disk"}},
+			},
+			machineDeploymentClass: "linux-worker",
+			wantSpec: map[string]interface{}{
+				"instanceType": "small",
+				"replicas":     int64(1),
+				"diskSize":     int64(100),
+			},
+		},
+		{
+			name: "Does not patch templates not matching the selector",
+			definition: clusterv1.PatchDefinition{
+				Selector: selector,
+				JSONPatches: []clusterv1.JSONPatch{
+					{Op: "replace", Path: "/spec/template/spec/instanceType", ValueFrom: &clusterv1.PatchValueFrom{Variable: "instance.type"}},
+				},
+			},
+			machineDeploymentClass: "windows-worker",
+			wantSpec: map[string]interface{}{
+				"instanceType": "small",
+				"replicas":     int64(1),
+			},
+		},
+		{
+			name: "Fails for json patches outside of spec",
+			definition: clusterv1.PatchDefinition{
+				Selector: selector,
+				JSONPatches: []clusterv1.JSONPatch{
+					{Op: "add", Path: "/metadata/labels", Value: &apiextensionsv1.JSON{Raw: []byte(`{"a":"b"}`)}},
+				},
+			},
+			machineDeploymentClass: "linux-worker",
+			wantErr:                true,
+		},
+		{
+			name: "Fails for merge patches outside of spec",
+			definition: clusterv1.PatchDefinition{
+				Selector:   selector,
+				MergePatch: &clusterv1.MergePatch{Value: &apiextensionsv1.JSON{Raw: []byte(`{"metadata":{"name":"foo"}}`)}},
+			},
+			machineDeploymentClass: "linux-worker",
+			wantErr:                true,
+		},
+		{
+			name: "Fails for variables with a field of a non-object value",
+			definition: clusterv1.PatchDefinition{
+				Selector: selector,
+				JSONPatches: []clusterv1.JSONPatch{
+					{Op: "add", Path: "/spec/template/spec/zone", ValueFrom: &clusterv1.PatchValueFrom{Variable: "instance.type.name"}},
+				},
+			},
+			machineDeploymentClass: "linux-worker",
+			wantErr:                true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			template := &Template{
+				Type:                   MachineDeploymentInfrastructureMachineTemplate,
+				MachineDeploymentClass: tt.machineDeploymentClass,
+				Object:                 newTemplate(),
+			}
+			patches := []clusterv1.ClusterClassPatch{
+				{Name: "patch1", Definitions: []clusterv1.PatchDefinition{tt.definition}},
+			}
+
+			err := Apply(patches, values, []*Template{template})
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			spec, ok, err := unstructured.NestedMap(template.Object.UnstructuredContent(), "spec", "template", "spec")
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(ok).To(BeTrue())
+			g.Expect(spec).To(Equal(tt.wantSpec))
+			g.Expect(template.Object.GetName()).To(Equal("template1"))
+		})
+	}
+}
+
+func Test_matchSelector(t *testing.T) {
+	template := &unstructured.Unstructured{}
+	template.SetAPIVersion("controlplane.cluster.x-k8s.io/v1alpha4")
+	template.SetKind("FakeControlPlaneTemplate")
+
+	tests := []struct {
+		name     string
+		selector clusterv1.PatchSelector
+		template *Template
+		want     bool
+	}{
+		{
+			name: "Matches the control plane template",
+			selector: clusterv1.PatchSelector{
+				APIVersion:     "controlplane.cluster.x-k8s.io/v1alpha4",
+				Kind:           "FakeControlPlaneTemplate",
+				MatchResources: clusterv1.PatchSelectorMatch{ControlPlane: true},
+			},
+			template: &Template{Type: ControlPlaneTemplate, Object: template},
+			want:     true,
+		},
+		{
+			name: "Does not match a different apiVersion",
+			selector: clusterv1.PatchSelector{
+				APIVersion:     "controlplane.cluster.x-k8s.io/v1alpha3",
+				Kind:           "FakeControlPlaneTemplate",
+				MatchResources: clusterv1.PatchSelectorMatch{ControlPlane: true},
+			},
+			template: &Template{Type: ControlPlaneTemplate, Object: template},
+			want:     false,
+		},
+		{
+			name: "Does not match templates referenced elsewhere",
+			selector: clusterv1.PatchSelector{
+				APIVersion:     "controlplane.cluster.x-k8s.io/v1alpha4",
+				Kind:           "FakeControlPlaneTemplate",
+				MatchResources: clusterv1.PatchSelectorMatch{InfrastructureCluster: true},
+			},
+			template: &Template{Type: ControlPlaneTemplate, Object: template},
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(matchSelector(tt.selector, tt.template)).To(Equal(tt.want))
+		})
+	}
+}
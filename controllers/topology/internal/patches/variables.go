@@ -0,0 +1,216 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package patches
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+// builtinValue is the value of the builtin variable.
+type builtinValue struct {
+	Cluster builtinClusterValue `json:"cluster"`
+}
+
+// builtinClusterValue holds the Cluster information exposed through the builtin variable.
+type builtinClusterValue struct {
+	Name      string                      `json:"name"`
+	Namespace string                      `json:"namespace"`
+	Topology  builtinClusterTopologyValue `json:"topology"`
+}
+
+// builtinClusterTopologyValue holds the Cluster topology information exposed through the builtin variable.
+type builtinClusterTopologyValue struct {
+	Class   string `json:"class"`
+	Version string `json:"version"`
+}
+
+// VariableValues returns the values of the variables to be used when patching the templates of a Cluster.
+// Values are read from the Cluster topology and validated against the schema of the corresponding variable
+// defined in the ClusterClass; variables not set in the Cluster get the default value from the schema, if any.
+// The builtin variable is always added to the returned values.
+func VariableValues(cluster *clusterv1.Cluster, definitions []clusterv1.ClusterClassVariable) (map[string]apiextensionsv1.JSON, error) {
+	var clusterVariables []clusterv1.ClusterVariable
+	if cluster.Spec.Topology != nil {
+		clusterVariables = cluster.Spec.Topology.Variables
+	}
+
+	definitionsByName := make(map[string]clusterv1.ClusterClassVariable, len(definitions))
+	for _, definition := range definitions {
+		definitionsByName[definition.Name] = definition
+	}
+
+	var allErrs field.ErrorList
+	fldPath := field.NewPath("spec", "topology", "variables")
+	values := map[string]apiextensionsv1.JSON{}
+	for i, variable := range clusterVariables {
+		definition, ok := definitionsByName[variable.Name]
+		if !ok {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("name"), variable.Name, "variable is not defined in the ClusterClass"))
+			continue
+		}
+		if _, ok := values[variable.Name]; ok {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i).Child("name"), variable.Name))
+			continue
+		}
+		allErrs = append(allErrs, validateValue(variable.Value, definition.Schema, fldPath.Index(i).Child("value"))...)
+		values[variable.Name] = variable.Value
+	}
+
+	for _, definition := range definitions {
+		if _, ok := values[definition.Name]; ok {
+			continue
+		}
+		if definition.Schema.OpenAPIV3Schema.Default != nil {
+			values[definition.Name] = *definition.Schema.OpenAPIV3Schema.Default
+			continue
+		}
+		if definition.Required {
+			allErrs = append(allErrs, field.Required(fldPath, fmt.Sprintf("required variable %q is not set", definition.Name)))
+		}
+	}
+
+	if len(allErrs) > 0 {
+		return nil, allErrs.ToAggregate()
+	}
+
+	builtin := builtinValue{
+		Cluster: builtinClusterValue{
+			Name:      cluster.Name,
+			Namespace: cluster.Namespace,
+		},
+	}
+	if cluster.Spec.Topology != nil {
+		builtin.Cluster.Topology = builtinClusterTopologyValue{
+			Class:   cluster.Spec.Topology.Class,
+			Version: cluster.Spec.Topology.Version,
+		}
+	}
+	builtinRaw, err := json.Marshal(builtin)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to compute the %s variable", clusterv1.BuiltinVariableName)
+	}
+	values[clusterv1.BuiltinVariableName] = apiextensionsv1.JSON{Raw: builtinRaw}
+
+	return values, nil
+}
+
+// validateValue validates a variable value against the variable schema.
+func validateValue(value apiextensionsv1.JSON, schema clusterv1.VariableSchema, fldPath *field.Path) field.ErrorList {
+	var v interface{}
+	if err := utiljson.Unmarshal(value.Raw, &v); err != nil {
+		return field.ErrorList{field.Invalid(fldPath, string(value.Raw), fmt.Sprintf("failed to parse the variable value: %v", err))}
+	}
+
+	internalSchema := &apiextensions.JSONSchemaProps{}
+	if err := apiextensionsv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(convertToAPIExtensionsJSONSchemaProps(&schema.OpenAPIV3Schema), internalSchema, nil); err != nil {
+		return field.ErrorList{field.InternalError(fldPath, errors.Wrap(err, "failed to convert the variable schema"))}
+	}
+	validator, _, err := validation.NewSchemaValidator(&apiextensions.CustomResourceValidation{OpenAPIV3Schema: internalSchema})
+	if err != nil {
+		return field.ErrorList{field.InternalError(fldPath, errors.Wrap(err, "failed to create a validator for the variable schema"))}
+	}
+
+	return validation.ValidateCustomResource(fldPath, v, validator)
+}
+
+// convertToAPIExtensionsJSONSchemaProps converts a clusterv1.JSONSchemaProps to apiextensionsv1.JSONSchemaProps.
+func convertToAPIExtensionsJSONSchemaProps(schema *clusterv1.JSONSchemaProps) *apiextensionsv1.JSONSchemaProps {
+	props := &apiextensionsv1.JSONSchemaProps{
+		Type:             schema.Type,
+		Required:         schema.Required,
+		MaxItems:         schema.MaxItems,
+		MinItems:         schema.MinItems,
+		UniqueItems:      schema.UniqueItems,
+		Format:           schema.Format,
+		MaxLength:        schema.MaxLength,
+		MinLength:        schema.MinLength,
+		Pattern:          schema.Pattern,
+		ExclusiveMaximum: schema.ExclusiveMaximum,
+		ExclusiveMinimum: schema.ExclusiveMinimum,
+		Default:          schema.Default,
+		Enum:             schema.Enum,
+	}
+
+	if schema.Maximum != nil {
+		f := float64(*schema.Maximum)
+		props.Maximum = &f
+	}
+
+	if schema.Minimum != nil {
+		f := float64(*schema.Minimum)
+		props.Minimum = &f
+	}
+
+	if schema.Properties != nil {
+		props.Properties = map[string]apiextensionsv1.JSONSchemaProps{}
+		for propertyName, propertySchema := range schema.Properties {
+			p := propertySchema
+			props.Properties[propertyName] = *convertToAPIExtensionsJSONSchemaProps(&p)
+		}
+	}
+
+	if schema.Items != nil {
+		props.Items = &apiextensionsv1.JSONSchemaPropsOrArray{
+			Schema: convertToAPIExtensionsJSONSchemaProps(schema.Items),
+		}
+	}
+
+	return props
+}
+
+// variableValue returns the value of a variable, or of a field of an object variable when
+// using the dot notation (e.g. `builtin.cluster.name`); nil is returned if the value is not set.
+func variableValue(values map[string]apiextensionsv1.JSON, name string) (*apiextensionsv1.JSON, error) {
+	path := strings.Split(name, ".")
+	value, ok := values[path[0]]
+	if !ok {
+		return nil, nil
+	}
+	if len(path) == 1 {
+		return &value, nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(value.Raw, &v); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the value of variable %q", path[0])
+	}
+	for i, fieldName := range path[1:] {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf("failed to get the value of %q: %q is not an object", name, strings.Join(path[:i+1], "."))
+		}
+		if v, ok = m[fieldName]; !ok {
+			return nil, nil
+		}
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal the value of %q", name)
+	}
+	return &apiextensionsv1.JSON{Raw: raw}, nil
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package patches
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+func TestVariableValues(t *testing.T) {
+	definitions := []clusterv1.ClusterClassVariable{
+		{
+			Name:     "region",
+			Required: true,
+			Schema: clusterv1.VariableSchema{
+				OpenAPIV3Schema: clusterv1.JSONSchemaProps{
+					Type:      "string",
+					MinLength: pointer.Int64(1),
+				},
+			},
+		},
+		{
+			Name: "replicas",
+			Schema: clusterv1.VariableSchema{
+				OpenAPIV3Schema: clusterv1.JSONSchemaProps{
+					Type:    "integer",
+					Minimum: pointer.Int64(1),
+					Default: &apiextensionsv1.JSON{Raw: []byte(`3`)},
+				},
+			},
+		},
+		{
+			Name: "instance",
+			Schema: clusterv1.VariableSchema{
+				OpenAPIV3Schema: clusterv1.JSONSchemaProps{
+					Type: "object",
+					Properties: map[string]clusterv1.JSONSchemaProps{
+						"type": {
+							Type: "string",
+							Enum: []apiextensionsv1.JSON{{Raw: []byte(`"small"`)}, {Raw: []byte(`"large"`)}},
+						},
+					},
+					Required: []string{"type"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		variables []clusterv1.ClusterVariable
+		want      map[string]string
+		wantErr   bool
+	}{
+		{
+			name: "Returns values, defaults and the builtin variable",
+			variables: []clusterv1.ClusterVariable{
+				{Name: "region", Value: apiextensionsv1.JSON{Raw: []byte(`"us-east-1"`)}},
+				{Name: "instance", Value: apiextensionsv1.JSON{Raw: []byte(`{"type":"large"}`)}},
+			},
+			want: map[string]string{
+				"region":   `"us-east-1"`,
+				"replicas": `3`,
+				"instance": `{"type":"large"}`,
+				"builtin":  `{"cluster":{"name":"cluster1","namespace":"default","topology":{"class":"class1","version":"v1.21.2"}}}`,
+			},
+		},
+		{
+			name:      "Fails if a required variable is not set",
+			variables: []clusterv1.ClusterVariable{},
+			wantErr:   true,
+		},
+		{
+			name: "Fails if a variable is not defined in the ClusterClass",
+			variables: []clusterv1.ClusterVariable{
+				{Name: "region", Value: apiextensionsv1.JSON{Raw: []byte(`"us-east-1"`)}},
+				{Name: "zone", Value: apiextensionsv1.JSON{Raw: []byte(`"a"`)}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fails if a variable is set more than once",
+			variables: []clusterv1.ClusterVariable{
+				{Name: "region", Value: apiextensionsv1.JSON{Raw: []byte(`"us-east-1"`)}},
+				{Name: "region", Value: apiextensionsv1.JSON{Raw: []byte(`"us-east-2"`)}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fails if a value does not match the schema",
+			variables: []clusterv1.ClusterVariable{
+				{Name: "region", Value: apiextensionsv1.JSON{Raw: []byte(`"us-east-1"`)}},
+				{Name: "replicas", Value: apiextensionsv1.JSON{Raw: []byte(`0`)}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fails if a nested value does not match the schema",
+			variables: []clusterv1.ClusterVariable{
+				{Name: "region", Value: apiextensionsv1.JSON{Raw: []byte(`"us-east-1"`)}},
+				{Name: "instance", Value: apiextensionsv1.JSON{Raw: []byte(`{"type":"medium"}`)}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cluster1",
+					Namespace: metav1.NamespaceDefault,
+				},
+				Spec: clusterv1.ClusterSpec{
+					Topology: &clusterv1.Topology{
+						Class:     "class1",
+						Version:   "v1.21.2",
+						Variables: tt.variables,
+					},
+				},
+			}
+
+			got, err := VariableValues(cluster, definitions)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			g.Expect(got).To(HaveLen(len(tt.want)))
+			for name, value := range tt.want {
+				g.Expect(got).To(HaveKey(name))
+				g.Expect(string(got[name].Raw)).To(Equal(value))
+			}
+		})
+	}
+}
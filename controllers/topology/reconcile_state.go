@@ -25,11 +25,20 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apiserver/pkg/storage/names"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/controllers/topology/internal/mergepatch"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// Reasons of the events recorded on the Cluster for the operations executed on the objects of the managed topology.
+const (
+	createEventReason = "TopologyCreate"
+	updateEventReason = "TopologyUpdate"
+	rotateEventReason = "TopologyRotate"
+	deleteEventReason = "TopologyDelete"
+)
+
 // reconcileState reconciles the current and desired state of the managed Cluster topology.
 // NOTE: We are assuming all the required objects are provided as input; also, in case of any error,
 // the entire reconcile operation will fail. This might be improved in the future if support for reconciling
@@ -45,6 +54,11 @@ func (r *ClusterReconciler) reconcileState(ctx context.Context, cpClass *control
 		return err
 	}
 
+	// Reconcile desired state of the MachineHealthCheck for the control plane machines.
+	if err := r.reconcileControlPlaneMachineHealthCheck(ctx, current, desired); err != nil {
+		return err
+	}
+
 	// Reconcile desired state of the InfrastructureCluster object.
 	if err := r.reconcileCluster(ctx, current, desired); err != nil {
 		return err
@@ -56,7 +70,7 @@ func (r *ClusterReconciler) reconcileState(ctx context.Context, cpClass *control
 
 // reconcileInfrastructureCluster reconciles the desired state of the InfrastructureCluster object.
 func (r *ClusterReconciler) reconcileInfrastructureCluster(ctx context.Context, current, desired *clusterTopologyState) error {
-	return r.reconcileReferencedObject(ctx, current.infrastructureCluster, desired.infrastructureCluster)
+	return r.reconcileReferencedObject(ctx, current.cluster, current.infrastructureCluster, desired.infrastructureCluster)
 }
 
 // reconcileControlPlane works to bring the current state of a managed topology in line with the desired state. This involves
@@ -79,6 +93,7 @@ func (r *ClusterReconciler) reconcileControlPlane(ctx context.Context, class *co
 		// Create or update the MachineInfrastructureTemplate of the control plane.
 		log.Info("Updating", desired.controlPlane.infrastructureMachineTemplate.GroupVersionKind().String(), desired.controlPlane.infrastructureMachineTemplate.GetName())
 		cleanup, err = r.reconcileReferencedTemplate(ctx, reconcileReferencedTemplateInput{
+			cluster:              current.cluster,
 			ref:                  cpInfraRef,
 			current:              current.controlPlane.infrastructureMachineTemplate,
 			desired:              desired.controlPlane.infrastructureMachineTemplate,
@@ -101,7 +116,7 @@ func (r *ClusterReconciler) reconcileControlPlane(ctx context.Context, class *co
 
 	// Create or update the ControlPlaneObject for the controlPlaneTopologyState.
 	log.Info("updating", desired.controlPlane.object.GroupVersionKind().String(), desired.controlPlane.object.GetName())
-	if err := r.reconcileReferencedObject(ctx, current.controlPlane.object, desired.controlPlane.object); err != nil {
+	if err := r.reconcileReferencedObject(ctx, current.cluster, current.controlPlane.object, desired.controlPlane.object); err != nil {
 		return kerrors.NewAggregate([]error{errors.Wrapf(err, "failed to update the %s object", desired.controlPlane.object.GetKind()), cleanup()})
 	}
 
@@ -110,6 +125,15 @@ func (r *ClusterReconciler) reconcileControlPlane(ctx context.Context, class *co
 	return cleanup()
 }
 
+// reconcileControlPlaneMachineHealthCheck reconciles the desired state of the MachineHealthCheck for the control plane machines.
+func (r *ClusterReconciler) reconcileControlPlaneMachineHealthCheck(ctx context.Context, current, desired *clusterTopologyState) error {
+	var currentMHC *clusterv1.MachineHealthCheck
+	if current.controlPlane != nil {
+		currentMHC = current.controlPlane.machineHealthCheck
+	}
+	return r.reconcileMachineHealthCheck(ctx, current.cluster, currentMHC, desired.controlPlane.machineHealthCheck)
+}
+
 // reconcileCluster reconciles the desired state of the Cluster object.
 // NOTE: this assumes reconcileInfrastructureCluster and reconcileControlPlane being already completed;
 // most specifically, after a Cluster is created it is assumed that the reference to the InfrastructureCluster /
@@ -127,6 +151,7 @@ func (r *ClusterReconciler) reconcileCluster(ctx context.Context, current, desir
 		if err := patchHelper.Patch(ctx); err != nil {
 			return errors.Wrapf(err, "failed to patch %s/%s", current.cluster.GroupVersionKind(), current.cluster.Name)
 		}
+		r.recorder.Eventf(current.cluster, corev1.EventTypeNormal, updateEventReason, "Updated Cluster %q", current.cluster.Name)
 	}
 	return nil
 }
@@ -138,7 +163,7 @@ func (r *ClusterReconciler) reconcileMachineDeployments(ctx context.Context, cur
 	// Create MachineDeployments.
 	for _, mdTopologyName := range diff.toCreate {
 		md := desired.machineDeployments[mdTopologyName]
-		if err := r.createMachineDeployment(ctx, md); err != nil {
+		if err := r.createMachineDeployment(ctx, current.cluster, md); err != nil {
 			return err
 		}
 	}
@@ -147,7 +172,7 @@ func (r *ClusterReconciler) reconcileMachineDeployments(ctx context.Context, cur
 	for _, mdTopologyName := range diff.toUpdate {
 		currentMD := current.machineDeployments[mdTopologyName]
 		desiredMD := desired.machineDeployments[mdTopologyName]
-		if err := r.updateMachineDeployment(ctx, current.cluster.Name, currentMD, desiredMD); err != nil {
+		if err := r.updateMachineDeployment(ctx, current.cluster, currentMD, desiredMD); err != nil {
 			return err
 		}
 	}
@@ -155,7 +180,7 @@ func (r *ClusterReconciler) reconcileMachineDeployments(ctx context.Context, cur
 	// Delete MachineDeployments.
 	for _, mdTopologyName := range diff.toDelete {
 		md := current.machineDeployments[mdTopologyName]
-		if err := r.deleteMachineDeployment(ctx, md); err != nil {
+		if err := r.deleteMachineDeployment(ctx, current.cluster, md); err != nil {
 			return err
 		}
 	}
@@ -164,16 +189,18 @@ func (r *ClusterReconciler) reconcileMachineDeployments(ctx context.Context, cur
 }
 
 // createMachineDeployment creates a MachineDeployment and the corresponding Templates.
-func (r *ClusterReconciler) createMachineDeployment(ctx context.Context, md *machineDeploymentTopologyState) error {
+func (r *ClusterReconciler) createMachineDeployment(ctx context.Context, cluster *clusterv1.Cluster, md *machineDeploymentTopologyState) error {
 	log := ctrl.LoggerFrom(ctx)
 
 	if _, err := r.reconcileReferencedTemplate(ctx, reconcileReferencedTemplateInput{
+		cluster: cluster,
 		desired: md.infrastructureMachineTemplate,
 	}); err != nil {
 		return errors.Wrapf(err, "failed to create %s/%s", md.object.GroupVersionKind(), md.object.Name)
 	}
 
 	if _, err := r.reconcileReferencedTemplate(ctx, reconcileReferencedTemplateInput{
+		cluster: cluster,
 		desired: md.bootstrapTemplate,
 	}); err != nil {
 		return errors.Wrapf(err, "failed to create %s/%s", md.object.GroupVersionKind(), md.object.Name)
@@ -183,19 +210,23 @@ func (r *ClusterReconciler) createMachineDeployment(ctx context.Context, md *mac
 	if err := r.Client.Create(ctx, md.object.DeepCopy()); err != nil {
 		return errors.Wrapf(err, "failed to create %s/%s", md.object.GroupVersionKind(), md.object.Name)
 	}
-	return nil
+	r.recorder.Eventf(cluster, corev1.EventTypeNormal, createEventReason, "Created %s %q", md.object.Kind, md.object.Name)
+
+	// Create the MachineHealthCheck for the MachineDeployment machines, if required.
+	return r.reconcileMachineHealthCheck(ctx, cluster, nil, md.machineHealthCheck)
 }
 
 // updateMachineDeployment updates a MachineDeployment. Also rotates the corresponding Templates if necessary.
-func (r *ClusterReconciler) updateMachineDeployment(ctx context.Context, clusterName string, currentMD, desiredMD *machineDeploymentTopologyState) error {
+func (r *ClusterReconciler) updateMachineDeployment(ctx context.Context, cluster *clusterv1.Cluster, currentMD, desiredMD *machineDeploymentTopologyState) error {
 	log := ctrl.LoggerFrom(ctx)
 
 	cleanupOldInfrastructureTemplate, err := r.reconcileReferencedTemplate(ctx, reconcileReferencedTemplateInput{
+		cluster: cluster,
 		ref:     &desiredMD.object.Spec.Template.Spec.InfrastructureRef,
 		current: currentMD.infrastructureMachineTemplate,
 		desired: desiredMD.infrastructureMachineTemplate,
 		templateNamer: func() string {
-			return infrastructureMachineTemplateNamePrefix(clusterName, desiredMD.object.Name)
+			return infrastructureMachineTemplateNamePrefix(cluster.Name, desiredMD.object.Name)
 		},
 		compatibilityChecker: checkReferencedObjectsAreCompatible,
 	})
@@ -204,11 +235,12 @@ func (r *ClusterReconciler) updateMachineDeployment(ctx context.Context, cluster
 	}
 
 	cleanupOldBootstrapTemplate, err := r.reconcileReferencedTemplate(ctx, reconcileReferencedTemplateInput{
+		cluster: cluster,
 		ref:     desiredMD.object.Spec.Template.Spec.Bootstrap.ConfigRef,
 		current: currentMD.bootstrapTemplate,
 		desired: desiredMD.bootstrapTemplate,
 		templateNamer: func() string {
-			return bootstrapTemplateNamePrefix(clusterName, desiredMD.object.Name)
+			return bootstrapTemplateNamePrefix(cluster.Name, desiredMD.object.Name)
 		},
 		compatibilityChecker: checkReferencedObjectsAreInTheSameNamespace,
 	})
@@ -226,20 +258,79 @@ func (r *ClusterReconciler) updateMachineDeployment(ctx context.Context, cluster
 		if err := patchHelper.Patch(ctx); err != nil {
 			return errors.Wrapf(err, "failed to update %s/%s", currentMD.object.GroupVersionKind(), currentMD.object.Kind)
 		}
+		r.recorder.Eventf(cluster, corev1.EventTypeNormal, updateEventReason, "Updated %s %q", desiredMD.object.Kind, currentMD.object.Name)
+	}
+
+	// Create, update or delete the MachineHealthCheck for the MachineDeployment machines.
+	if err := r.reconcileMachineHealthCheck(ctx, cluster, currentMD.machineHealthCheck, desiredMD.machineHealthCheck); err != nil {
+		return kerrors.NewAggregate([]error{err, cleanupOldInfrastructureTemplate(), cleanupOldBootstrapTemplate()})
 	}
 
 	// We want to call both cleanup functions even if one of them fails to clean up as much as possible.
 	return kerrors.NewAggregate([]error{cleanupOldInfrastructureTemplate(), cleanupOldBootstrapTemplate()})
 }
 
-// deleteMachineDeployment deletes a MachineDeployment.
-func (r *ClusterReconciler) deleteMachineDeployment(ctx context.Context, md *machineDeploymentTopologyState) error {
+// deleteMachineDeployment deletes a MachineDeployment and the corresponding MachineHealthCheck.
+func (r *ClusterReconciler) deleteMachineDeployment(ctx context.Context, cluster *clusterv1.Cluster, md *machineDeploymentTopologyState) error {
 	log := ctrl.LoggerFrom(ctx)
 
+	// NOTE: The MachineHealthCheck is deleted first, so it is not left behind in case the MachineDeployment
+	// is deleted but the MachineHealthCheck deletion fails.
+	if err := r.reconcileMachineHealthCheck(ctx, cluster, md.machineHealthCheck, nil); err != nil {
+		return err
+	}
+
 	log.Info("deleting", md.object.GroupVersionKind().String(), md.object.GetName())
 	if err := r.Client.Delete(ctx, md.object); err != nil && !apierrors.IsNotFound(err) {
 		return errors.Wrapf(err, "failed to delete %s/%s", md.object.GroupVersionKind(), md.object.Name)
 	}
+	r.recorder.Eventf(cluster, corev1.EventTypeNormal, deleteEventReason, "Deleted MachineDeployment %q", md.object.Name)
+	return nil
+}
+
+// reconcileMachineHealthCheck creates, updates or deletes a MachineHealthCheck according to the desired state.
+// NOTE: If desired is nil and there is a current MachineHealthCheck, e.g. because the MachineHealthCheck definition
+// has been removed from the ClusterClass, the current MachineHealthCheck is deleted.
+func (r *ClusterReconciler) reconcileMachineHealthCheck(ctx context.Context, cluster *clusterv1.Cluster, current, desired *clusterv1.MachineHealthCheck) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	// If there is no current and no desired MachineHealthCheck, nothing to do.
+	if current == nil && desired == nil {
+		return nil
+	}
+
+	// If there is no current MachineHealthCheck, create it.
+	if current == nil {
+		log.Info("creating", desired.GroupVersionKind().String(), desired.GetName())
+		if err := r.Client.Create(ctx, desired.DeepCopy()); err != nil {
+			return errors.Wrapf(err, "failed to create %s/%s", desired.GroupVersionKind(), desired.Name)
+		}
+		r.recorder.Eventf(cluster, corev1.EventTypeNormal, createEventReason, "Created MachineHealthCheck %q", desired.Name)
+		return nil
+	}
+
+	// If there is no desired MachineHealthCheck, delete the current one.
+	if desired == nil {
+		log.Info("deleting", current.GroupVersionKind().String(), current.GetName())
+		if err := r.Client.Delete(ctx, current); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete %s/%s", current.GroupVersionKind(), current.Name)
+		}
+		r.recorder.Eventf(cluster, corev1.EventTypeNormal, deleteEventReason, "Deleted MachineHealthCheck %q", current.Name)
+		return nil
+	}
+
+	// Check differences between current and desired MachineHealthCheck, and eventually patch the current object.
+	patchHelper, err := mergepatch.NewHelper(current, desired, r.Client)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create patch helper for %s/%s", current.GroupVersionKind(), current.Name)
+	}
+	if patchHelper.HasChanges() {
+		log.Info("updating", current.GroupVersionKind().String(), current.GetName())
+		if err := patchHelper.Patch(ctx); err != nil {
+			return errors.Wrapf(err, "failed to patch %s/%s", current.GroupVersionKind(), current.Name)
+		}
+		r.recorder.Eventf(cluster, corev1.EventTypeNormal, updateEventReason, "Updated MachineHealthCheck %q", current.Name)
+	}
 	return nil
 }
 
@@ -272,7 +363,7 @@ func calculateMachineDeploymentDiff(current, desired map[string]*machineDeployme
 // reconcileReferencedObject reconciles the desired state of the referenced object.
 // NOTE: After a referenced object is created it is assumed that the reference should
 // never change (only the content of the object can eventually change). Thus, we are checking for strict compatibility.
-func (r *ClusterReconciler) reconcileReferencedObject(ctx context.Context, current, desired *unstructured.Unstructured) error {
+func (r *ClusterReconciler) reconcileReferencedObject(ctx context.Context, cluster *clusterv1.Cluster, current, desired *unstructured.Unstructured) error {
 	log := ctrl.LoggerFrom(ctx)
 
 	// If there is no current object, create it.
@@ -281,12 +372,13 @@ func (r *ClusterReconciler) reconcileReferencedObject(ctx context.Context, curre
 		if err := r.Client.Create(ctx, desired.DeepCopy()); err != nil {
 			return errors.Wrapf(err, "failed to create %s/%s", desired.GroupVersionKind(), desired.GetKind())
 		}
+		r.recorder.Eventf(cluster, corev1.EventTypeNormal, createEventReason, "Created %s %q", desired.GetKind(), desired.GetName())
 		return nil
 	}
 
 	// Check if the current and desired referenced object are compatible.
 	if err := checkReferencedObjectsAreStrictlyCompatible(current, desired); err != nil {
-		return err
+		return newTopologyReconcileError(clusterv1.TopologyReconciledIncompatibleChangeReason, err)
 	}
 
 	// Check differences between current and desired state, and eventually patch the current object.
@@ -299,11 +391,13 @@ func (r *ClusterReconciler) reconcileReferencedObject(ctx context.Context, curre
 		if err := patchHelper.Patch(ctx); err != nil {
 			return errors.Wrapf(err, "failed to patch %s/%s", current.GroupVersionKind(), current.GetKind())
 		}
+		r.recorder.Eventf(cluster, corev1.EventTypeNormal, updateEventReason, "Updated %s %q", current.GetKind(), current.GetName())
 	}
 	return nil
 }
 
 type reconcileReferencedTemplateInput struct {
+	cluster              *clusterv1.Cluster
 	ref                  *corev1.ObjectReference
 	current              *unstructured.Unstructured
 	desired              *unstructured.Unstructured
@@ -328,6 +422,7 @@ func (r *ClusterReconciler) reconcileReferencedTemplate(ctx context.Context, in
 		if err := r.Client.Create(ctx, in.desired.DeepCopy()); err != nil {
 			return nil, errors.Wrapf(err, "failed to create %s/%s", in.desired.GroupVersionKind(), in.desired.GetName())
 		}
+		r.recorder.Eventf(in.cluster, corev1.EventTypeNormal, createEventReason, "Created %s %q", in.desired.GetKind(), in.desired.GetName())
 		return cleanupFunc, nil
 	}
 
@@ -337,7 +432,7 @@ func (r *ClusterReconciler) reconcileReferencedTemplate(ctx context.Context, in
 
 	// Check if the current and desired referenced object are compatible.
 	if err := in.compatibilityChecker(in.current, in.desired); err != nil {
-		return nil, err
+		return nil, newTopologyReconcileError(clusterv1.TopologyReconciledIncompatibleChangeReason, err)
 	}
 
 	// Check differences between current and desired objects, and if there are changes eventually start the template rotation.
@@ -359,6 +454,7 @@ func (r *ClusterReconciler) reconcileReferencedTemplate(ctx context.Context, in
 		if err := r.Client.Create(ctx, in.desired.DeepCopy()); err != nil {
 			return nil, errors.Wrapf(err, "failed to create %s/%s", in.desired.GroupVersionKind(), in.desired.GetName())
 		}
+		r.recorder.Eventf(in.cluster, corev1.EventTypeNormal, rotateEventReason, "Rotated %s %q to %q", in.desired.GetKind(), in.current.GetName(), newName)
 
 		// Update the reference with the new name.
 		// NOTE: Updating the object hosting reference to the template is executed outside this func.
@@ -371,6 +467,7 @@ func (r *ClusterReconciler) reconcileReferencedTemplate(ctx context.Context, in
 			if err := r.Client.Delete(ctx, in.current); err != nil {
 				return errors.Wrapf(err, "failed to delete %s/%s", in.desired.GroupVersionKind(), in.desired.GetName())
 			}
+			r.recorder.Eventf(in.cluster, corev1.EventTypeNormal, deleteEventReason, "Deleted %s %q", in.current.GetKind(), in.current.GetName())
 			return nil
 		}
 	}
@@ -20,11 +20,16 @@ import (
 	"fmt"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -82,7 +87,8 @@ func TestReconcileCluster(t *testing.T) {
 			desiredState := &clusterTopologyState{cluster: tt.desired}
 
 			r := ClusterReconciler{
-				Client: fakeClient,
+				Client:   fakeClient,
+				recorder: record.NewFakeRecorder(32),
 			}
 			err := r.reconcileCluster(ctx, currentState, desiredState)
 			if tt.wantErr {
@@ -177,7 +183,8 @@ func TestReconcileInfrastructureCluster(t *testing.T) {
 			desiredState := &clusterTopologyState{infrastructureCluster: tt.desired}
 
 			r := ClusterReconciler{
-				Client: fakeClient,
+				Client:   fakeClient,
+				recorder: record.NewFakeRecorder(32),
 			}
 			err := r.reconcileInfrastructureCluster(ctx, currentState, desiredState)
 			if tt.wantErr {
@@ -328,7 +335,8 @@ func TestReconcileControlPlaneObject(t *testing.T) {
 				tt.desired.object.SetResourceVersion("")
 			}
 			r := ClusterReconciler{
-				Client: fakeClient,
+				Client:   fakeClient,
+				recorder: record.NewFakeRecorder(32),
 			}
 			desiredState := &clusterTopologyState{controlPlane: &controlPlaneTopologyState{object: tt.desired.object, infrastructureMachineTemplate: tt.desired.infrastructureMachineTemplate}}
 
@@ -455,7 +463,8 @@ func TestReconcileControlPlaneInfrastructureMachineTemplate(t *testing.T) {
 				tt.desired.object.SetResourceVersion("")
 			}
 			r := ClusterReconciler{
-				Client: fakeClient,
+				Client:   fakeClient,
+				recorder: record.NewFakeRecorder(32),
 			}
 			desiredState := &clusterTopologyState{controlPlane: &controlPlaneTopologyState{object: tt.desired.object, infrastructureMachineTemplate: tt.desired.infrastructureMachineTemplate}}
 
@@ -692,7 +701,8 @@ func TestReconcileMachineDeployments(t *testing.T) {
 			desiredState := &clusterTopologyState{machineDeployments: toMachineDeploymentTopologyStateMap(tt.desired)}
 
 			r := ClusterReconciler{
-				Client: fakeClient,
+				Client:   fakeClient,
+				recorder: record.NewFakeRecorder(32),
 			}
 			err := r.reconcileMachineDeployments(ctx, currentState, desiredState)
 			if tt.wantErr {
@@ -881,6 +891,125 @@ var referencedObjectsCompatibilityTestCases = []referencedObjectsCompatibilityTe
 	},
 }
 
+func TestReconcileMachineHealthCheck(t *testing.T) {
+	newMachineHealthCheck := func(name string, maxUnhealthy string) *clusterv1.MachineHealthCheck {
+		m := intstr.FromString(maxUnhealthy)
+		return &clusterv1.MachineHealthCheck{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "MachineHealthCheck",
+				APIVersion: clusterv1.GroupVersion.String(),
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: metav1.NamespaceDefault,
+				Labels: map[string]string{
+					clusterv1.ClusterLabelName:         "cluster1",
+					clusterv1.ClusterTopologyLabelName: "",
+				},
+			},
+			Spec: clusterv1.MachineHealthCheckSpec{
+				ClusterName: "cluster1",
+				Selector:    *selectorForControlPlaneMHC(),
+				UnhealthyConditions: []clusterv1.UnhealthyCondition{
+					{Type: corev1.NodeReady, Status: corev1.ConditionFalse, Timeout: metav1.Duration{Duration: 5 * time.Minute}},
+				},
+				MaxUnhealthy: &m,
+			},
+		}
+	}
+	mhc := newMachineHealthCheck("control-plane1", "40%")
+	mhcWithChanges := newMachineHealthCheck("control-plane1", "60%")
+	cluster := newFakeCluster(metav1.NamespaceDefault, "cluster1").Obj()
+
+	tests := []struct {
+		name       string
+		current    *clusterv1.MachineHealthCheck
+		desired    *clusterv1.MachineHealthCheck
+		want       *clusterv1.MachineHealthCheck
+		wantExists bool
+		wantEvents []string
+	}{
+		{
+			name:       "Should create the MachineHealthCheck if it does not exist",
+			current:    nil,
+			desired:    mhc,
+			want:       mhc,
+			wantExists: true,
+			wantEvents: []string{"Normal TopologyCreate Created MachineHealthCheck \"control-plane1\""},
+		},
+		{
+			name:       "Should update the MachineHealthCheck if it has changes",
+			current:    mhc,
+			desired:    mhcWithChanges,
+			want:       mhcWithChanges,
+			wantExists: true,
+			wantEvents: []string{"Normal TopologyUpdate Updated MachineHealthCheck \"control-plane1\""},
+		},
+		{
+			name:       "Should be a no op if the MachineHealthCheck has no changes",
+			current:    mhc,
+			desired:    mhc,
+			want:       mhc,
+			wantExists: true,
+		},
+		{
+			name:       "Should delete the MachineHealthCheck if it is no longer desired",
+			current:    mhc,
+			desired:    nil,
+			want:       mhc,
+			wantExists: false,
+			wantEvents: []string{"Normal TopologyDelete Deleted MachineHealthCheck \"control-plane1\""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			fakeObjs := make([]client.Object, 0)
+			var current *clusterv1.MachineHealthCheck
+			if tt.current != nil {
+				current = tt.current.DeepCopy()
+				fakeObjs = append(fakeObjs, current)
+			}
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(fakeScheme).
+				WithObjects(fakeObjs...).
+				Build()
+
+			var desired *clusterv1.MachineHealthCheck
+			if tt.desired != nil {
+				desired = tt.desired.DeepCopy()
+			}
+
+			recorder := record.NewFakeRecorder(32)
+			r := ClusterReconciler{
+				Client:   fakeClient,
+				recorder: recorder,
+			}
+			err := r.reconcileMachineHealthCheck(ctx, cluster, current, desired)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			close(recorder.Events)
+			events := []string{}
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			g.Expect(events).To(ConsistOf(tt.wantEvents))
+
+			got := &clusterv1.MachineHealthCheck{}
+			err = fakeClient.Get(ctx, client.ObjectKeyFromObject(tt.want), got)
+			if !tt.wantExists {
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			g.Expect(got.Labels).To(Equal(tt.want.Labels))
+			g.Expect(got.Spec).To(Equal(tt.want.Spec), cmp.Diff(got.Spec, tt.want.Spec))
+		})
+	}
+}
+
 func TestCheckReferencedObjectsAreCompatible(t *testing.T) {
 	for _, tt := range referencedObjectsCompatibilityTestCases {
 		t.Run(tt.name, func(t *testing.T) {
@@ -17,6 +17,7 @@ limitations under the License.
 package topology
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 )
@@ -29,17 +30,26 @@ type clusterTopologyClass struct {
 	machineDeployments            map[string]*machineDeploymentTopologyClass
 }
 
-// controlPlaneTopologyClass holds the templates required for computing the desired state of a managed control plane.
+// controlPlaneTopologyClass holds the templates required for computing the desired state of a managed control plane,
+// as well as the MachineHealthCheck definition for the control plane machines.
 type controlPlaneTopologyClass struct {
 	template                      *unstructured.Unstructured
 	infrastructureMachineTemplate *unstructured.Unstructured
+	machineHealthCheck            *clusterv1.MachineHealthCheckClass
 }
 
-// machineDeploymentTopologyClass holds the templates required for computing the desired state of a managed deployment.
+// machineDeploymentTopologyClass holds the templates required for computing the desired state of a managed deployment,
+// as well as the default values for the MachineDeployment fields which can be overridden in the Cluster topology
+// and the MachineHealthCheck definition for the MachineDeployment machines.
 type machineDeploymentTopologyClass struct {
 	metadata                      clusterv1.ObjectMeta
 	bootstrapTemplate             *unstructured.Unstructured
 	infrastructureMachineTemplate *unstructured.Unstructured
+	failureDomain                 *string
+	nodeDrainTimeout              *metav1.Duration
+	minReadySeconds               *int32
+	strategy                      *clusterv1.MachineDeploymentStrategy
+	machineHealthCheck            *clusterv1.MachineHealthCheckClass
 }
 
 // clusterTopologyState holds all the objects representing the state of a managed Cluster topology.
@@ -56,6 +66,7 @@ type clusterTopologyState struct {
 type controlPlaneTopologyState struct {
 	object                        *unstructured.Unstructured
 	infrastructureMachineTemplate *unstructured.Unstructured
+	machineHealthCheck            *clusterv1.MachineHealthCheck
 }
 
 // machineDeploymentTopologyState all the objects representing the state of a managed deployment.
@@ -63,4 +74,5 @@ type machineDeploymentTopologyState struct {
 	object                        *clusterv1.MachineDeployment
 	bootstrapTemplate             *unstructured.Unstructured
 	infrastructureMachineTemplate *unstructured.Unstructured
+	machineHealthCheck            *clusterv1.MachineHealthCheck
 }
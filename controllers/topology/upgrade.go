@@ -0,0 +1,313 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topology
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+// computeControlPlaneVersion computes the Kubernetes version for the ControlPlane object.
+// NOTE: When the version defined in the Cluster topology changes, the upgrade of the control plane starts only
+// when the control plane is stable and all the MachineDeployments are at the current version of the control plane;
+// this ensures that the version skew policy is respected, no matter of how many times the version is changed.
+func computeControlPlaneVersion(current *clusterTopologyState) (string, error) {
+	desiredVersion := current.cluster.Spec.Topology.Version
+
+	// If the control plane is not yet created, it is created with the version defined in the topology.
+	if current.controlPlane == nil || current.controlPlane.object == nil {
+		return desiredVersion, nil
+	}
+
+	currentVersion, ok, err := unstructured.NestedString(current.controlPlane.object.UnstructuredContent(), "spec", "version")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get spec.version from %s", current.controlPlane.object.GetKind())
+	}
+	if !ok || currentVersion == desiredVersion {
+		return desiredVersion, nil
+	}
+
+	// Wait for the control plane to be stable before starting the upgrade, e.g. in case a previous upgrade is still in progress.
+	stable, err := isControlPlaneStable(current.controlPlane.object)
+	if err != nil {
+		return "", err
+	}
+	if !stable {
+		return currentVersion, nil
+	}
+
+	// Wait for all the MachineDeployments to be at the current version of the control plane before starting the upgrade.
+	// NOTE: MachineDeployments are not required to have all the replicas available, so e.g. an unhealthy machine
+	// doesn't block the upgrade.
+	for _, md := range current.machineDeployments {
+		if machineDeploymentVersion(md.object) != currentVersion || md.object.Status.ObservedGeneration < md.object.Generation {
+			return currentVersion, nil
+		}
+	}
+
+	return desiredVersion, nil
+}
+
+// computeMachineDeploymentVersions computes the Kubernetes version for each MachineDeployment in the Cluster topology.
+// NOTE: MachineDeployments are upgraded only after the control plane has been upgraded to the version defined in the
+// topology and it is stable; MachineDeployments are then upgraded according to the order and the concurrency limit
+// defined in Cluster.spec.topology.upgrade, if any.
+func computeMachineDeploymentVersions(current *clusterTopologyState) (map[string]string, error) {
+	topology := current.cluster.Spec.Topology
+	versions := map[string]string{}
+	if topology.Workers == nil {
+		return versions, nil
+	}
+
+	controlPlaneUpgraded, err := isControlPlaneUpgraded(current)
+	if err != nil {
+		return nil, err
+	}
+
+	// If the control plane is not yet upgraded, MachineDeployments are kept at their current version, while new
+	// MachineDeployments are created with the version the control plane is running.
+	if !controlPlaneUpgraded {
+		controlPlaneVersion, err := controlPlaneRunningVersion(current.controlPlane.object)
+		if err != nil {
+			return nil, err
+		}
+		for _, mdTopology := range topology.Workers.MachineDeployments {
+			versions[mdTopology.Name] = controlPlaneVersion
+			if md, ok := current.machineDeployments[mdTopology.Name]; ok {
+				versions[mdTopology.Name] = machineDeploymentVersion(md.object)
+			}
+		}
+		return versions, nil
+	}
+
+	maxConcurrent := len(topology.Workers.MachineDeployments)
+	if topology.Upgrade != nil && topology.Upgrade.MaxConcurrentMachineDeployments != nil {
+		maxConcurrent = int(*topology.Upgrade.MaxConcurrentMachineDeployments)
+	}
+
+	// MachineDeployments already at the version defined in the topology but still rolling out count against
+	// the concurrency limit.
+	upgrading := 0
+	for _, mdTopology := range topology.Workers.MachineDeployments {
+		if md, ok := current.machineDeployments[mdTopology.Name]; ok {
+			if machineDeploymentVersion(md.object) == topology.Version && !isMachineDeploymentRolledOut(md.object) {
+				upgrading++
+			}
+		}
+	}
+
+	for _, name := range machineDeploymentUpgradeOrder(topology) {
+		md, ok := current.machineDeployments[name]
+		switch {
+		case !ok || machineDeploymentVersion(md.object) == topology.Version:
+			versions[name] = topology.Version
+		case upgrading < maxConcurrent:
+			versions[name] = topology.Version
+			upgrading++
+		default:
+			versions[name] = machineDeploymentVersion(md.object)
+		}
+	}
+	return versions, nil
+}
+
+// machineDeploymentUpgradeOrder returns the names of the MachineDeployments in the topology in the order they should be upgraded,
+// that is the MachineDeployments listed in Cluster.spec.topology.upgrade.machineDeploymentOrder first, and then all the other
+// MachineDeployments in the order they are defined in the topology.
+func machineDeploymentUpgradeOrder(topology *clusterv1.Topology) []string {
+	defined := map[string]bool{}
+	for _, mdTopology := range topology.Workers.MachineDeployments {
+		defined[mdTopology.Name] = true
+	}
+
+	order := []string{}
+	if topology.Upgrade != nil {
+		for _, name := range topology.Upgrade.MachineDeploymentOrder {
+			if defined[name] {
+				order = append(order, name)
+				delete(defined, name)
+			}
+		}
+	}
+	for _, mdTopology := range topology.Workers.MachineDeployments {
+		if defined[mdTopology.Name] {
+			order = append(order, mdTopology.Name)
+			delete(defined, mdTopology.Name)
+		}
+	}
+	return order
+}
+
+// isControlPlaneUpgraded returns true if the control plane is at the version defined in the Cluster topology and it is stable.
+// NOTE: If the control plane is not yet created, it is considered upgraded, given that it is going to be created with
+// the version defined in the topology.
+func isControlPlaneUpgraded(current *clusterTopologyState) (bool, error) {
+	if current.controlPlane == nil || current.controlPlane.object == nil {
+		return true, nil
+	}
+
+	version, ok, err := unstructured.NestedString(current.controlPlane.object.UnstructuredContent(), "spec", "version")
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get spec.version from %s", current.controlPlane.object.GetKind())
+	}
+	if ok && version != current.cluster.Spec.Topology.Version {
+		return false, nil
+	}
+	return isControlPlaneStable(current.controlPlane.object)
+}
+
+// isControlPlaneStable returns true if the control plane reports the version defined in its spec, and if
+// all the control plane replicas are updated and ready.
+// NOTE: status.version and the status replica fields are optional for control plane providers; if they are
+// not reported, the corresponding checks are skipped. However, if status.replicas is reported, missing
+// status.updatedReplicas and status.readyReplicas are considered 0, because providers like KCP omit zero values.
+func isControlPlaneStable(controlPlane *unstructured.Unstructured) (bool, error) {
+	content := controlPlane.UnstructuredContent()
+
+	observedGeneration, ok, err := unstructured.NestedInt64(content, "status", "observedGeneration")
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get status.observedGeneration from %s", controlPlane.GetKind())
+	}
+	if ok && observedGeneration < controlPlane.GetGeneration() {
+		return false, nil
+	}
+
+	specVersion, _, err := unstructured.NestedString(content, "spec", "version")
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get spec.version from %s", controlPlane.GetKind())
+	}
+	statusVersion, ok, err := unstructured.NestedString(content, "status", "version")
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get status.version from %s", controlPlane.GetKind())
+	}
+	if ok && statusVersion != specVersion {
+		return false, nil
+	}
+
+	replicas, ok, err := unstructured.NestedInt64(content, "spec", "replicas")
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get spec.replicas from %s", controlPlane.GetKind())
+	}
+	if !ok {
+		return true, nil
+	}
+	statusReplicas, ok, err := unstructured.NestedInt64(content, "status", "replicas")
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get status.replicas from %s", controlPlane.GetKind())
+	}
+	if !ok {
+		return true, nil
+	}
+	if statusReplicas != replicas {
+		return false, nil
+	}
+	for _, field := range []string{"updatedReplicas", "readyReplicas"} {
+		value, _, err := unstructured.NestedInt64(content, "status", field)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to get status.%s from %s", field, controlPlane.GetKind())
+		}
+		if value != replicas {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// controlPlaneRunningVersion returns the Kubernetes version the control plane is running, that is status.version
+// if reported by the control plane provider, spec.version otherwise.
+func controlPlaneRunningVersion(controlPlane *unstructured.Unstructured) (string, error) {
+	version, ok, err := unstructured.NestedString(controlPlane.UnstructuredContent(), "status", "version")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get status.version from %s", controlPlane.GetKind())
+	}
+	if ok && version != "" {
+		return version, nil
+	}
+	version, _, err = unstructured.NestedString(controlPlane.UnstructuredContent(), "spec", "version")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get spec.version from %s", controlPlane.GetKind())
+	}
+	return version, nil
+}
+
+// machineDeploymentVersion returns the Kubernetes version of a MachineDeployment.
+func machineDeploymentVersion(md *clusterv1.MachineDeployment) string {
+	if md.Spec.Template.Spec.Version == nil {
+		return ""
+	}
+	return *md.Spec.Template.Spec.Version
+}
+
+// isMachineDeploymentRolledOut returns true if all the replicas of a MachineDeployment are updated and available.
+func isMachineDeploymentRolledOut(md *clusterv1.MachineDeployment) bool {
+	if md.Status.ObservedGeneration < md.Generation {
+		return false
+	}
+	replicas := int32(1)
+	if md.Spec.Replicas != nil {
+		replicas = *md.Spec.Replicas
+	}
+	return md.Status.Replicas == replicas && md.Status.UpdatedReplicas == replicas && md.Status.AvailableReplicas == replicas
+}
+
+// setTopologyUpgradedCondition reports on the Cluster the progress of the upgrade of the control plane and of the
+// MachineDeployments to the Kubernetes version defined in the Cluster topology.
+func setTopologyUpgradedCondition(cluster *clusterv1.Cluster, current, desired *clusterTopologyState) error {
+	version := cluster.Spec.Topology.Version
+
+	controlPlaneUpgraded, err := isControlPlaneUpgraded(current)
+	if err != nil {
+		return err
+	}
+	if !controlPlaneUpgraded {
+		conditions.MarkFalse(cluster, clusterv1.TopologyUpgradedCondition, clusterv1.TopologyControlPlaneUpgradingReason, clusterv1.ConditionSeverityInfo,
+			"Control plane is upgrading to version %s", version)
+		return nil
+	}
+
+	// NOTE: MachineDeployments at the version defined in the topology are considered upgrading until they are rolled out,
+	// but only if an upgrade is already in progress, so a MachineDeployment scaling up or down is not reported as an upgrade.
+	upgradeInProgress := conditions.IsFalse(cluster, clusterv1.TopologyUpgradedCondition)
+	var upgrading, pending []string
+	for name, md := range desired.machineDeployments {
+		currentMD, ok := current.machineDeployments[name]
+		switch {
+		case machineDeploymentVersion(md.object) != version:
+			pending = append(pending, name)
+		case ok && machineDeploymentVersion(currentMD.object) != version:
+			upgrading = append(upgrading, name)
+		case ok && upgradeInProgress && !isMachineDeploymentRolledOut(currentMD.object):
+			upgrading = append(upgrading, name)
+		}
+	}
+
+	if len(upgrading) > 0 || len(pending) > 0 {
+		sort.Strings(upgrading)
+		sort.Strings(pending)
+		conditions.MarkFalse(cluster, clusterv1.TopologyUpgradedCondition, clusterv1.TopologyMachineDeploymentsUpgradingReason, clusterv1.ConditionSeverityInfo,
+			"MachineDeployments are upgrading to version %s (upgrading: [%s], pending: [%s])", version, strings.Join(upgrading, ", "), strings.Join(pending, ", "))
+		return nil
+	}
+
+	conditions.MarkTrue(cluster, clusterv1.TopologyUpgradedCondition)
+	return nil
+}
@@ -0,0 +1,410 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topology
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/pointer"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/conditions"
+)
+
+func TestComputeControlPlaneVersion(t *testing.T) {
+	tests := []struct {
+		name               string
+		controlPlane       *unstructured.Unstructured
+		machineDeployments map[string]*machineDeploymentTopologyState
+		want               string
+	}{
+		{
+			name:         "Uses the topology version if the control plane does not exist yet",
+			controlPlane: nil,
+			want:         "v1.21.2",
+		},
+		{
+			name:         "Uses the topology version if the control plane is already at the topology version",
+			controlPlane: newUpgradeTestControlPlane("v1.21.2", "v1.21.1"),
+			want:         "v1.21.2",
+		},
+		{
+			name:         "Keeps the current version if the control plane is not stable",
+			controlPlane: newUpgradeTestControlPlane("v1.21.1", "v1.21.0"),
+			want:         "v1.21.1",
+		},
+		{
+			name:         "Keeps the current version if a MachineDeployment is not at the control plane version",
+			controlPlane: newUpgradeTestControlPlane("v1.21.1", "v1.21.1"),
+			machineDeployments: map[string]*machineDeploymentTopologyState{
+				"md1": {object: newUpgradeTestMachineDeployment("md1", "v1.21.0", true)},
+			},
+			want: "v1.21.1",
+		},
+		{
+			name:         "Keeps the current version if a MachineDeployment has not observed its latest spec",
+			controlPlane: newUpgradeTestControlPlane("v1.21.1", "v1.21.1"),
+			machineDeployments: map[string]*machineDeploymentTopologyState{
+				"md1": {object: func() *clusterv1.MachineDeployment {
+					md := newUpgradeTestMachineDeployment("md1", "v1.21.1", true)
+					md.Generation = 2
+					md.Status.ObservedGeneration = 1
+					return md
+				}()},
+			},
+			want: "v1.21.1",
+		},
+		{
+			name:         "Upgrades the control plane if it is stable and all the MachineDeployments are at the control plane version",
+			controlPlane: newUpgradeTestControlPlane("v1.21.1", "v1.21.1"),
+			machineDeployments: map[string]*machineDeploymentTopologyState{
+				"md1": {object: newUpgradeTestMachineDeployment("md1", "v1.21.1", true)},
+			},
+			want: "v1.21.2",
+		},
+		{
+			name:         "Upgrades the control plane even if a MachineDeployment does not have all the replicas available",
+			controlPlane: newUpgradeTestControlPlane("v1.21.1", "v1.21.1"),
+			machineDeployments: map[string]*machineDeploymentTopologyState{
+				"md1": {object: newUpgradeTestMachineDeployment("md1", "v1.21.1", false)},
+			},
+			want: "v1.21.2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			current := &clusterTopologyState{
+				cluster:            newUpgradeTestCluster(nil, "md1"),
+				machineDeployments: tt.machineDeployments,
+			}
+			if tt.controlPlane != nil {
+				current.controlPlane = &controlPlaneTopologyState{object: tt.controlPlane}
+			}
+
+			got, err := computeControlPlaneVersion(current)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestIsControlPlaneStable(t *testing.T) {
+	withReplicas := func(controlPlane *unstructured.Unstructured, fields map[string]int64) *unstructured.Unstructured {
+		for field, value := range fields {
+			path := strings.Split(field, ".")
+			if err := unstructured.SetNestedField(controlPlane.UnstructuredContent(), value, path...); err != nil {
+				panic(err)
+			}
+		}
+		return controlPlane
+	}
+
+	tests := []struct {
+		name         string
+		controlPlane *unstructured.Unstructured
+		want         bool
+	}{
+		{
+			name:         "Stable if the control plane does not report replicas",
+			controlPlane: newUpgradeTestControlPlane("v1.21.1", "v1.21.1"),
+			want:         true,
+		},
+		{
+			name:         "Not stable if the control plane is not at the spec version",
+			controlPlane: newUpgradeTestControlPlane("v1.21.1", "v1.21.0"),
+			want:         false,
+		},
+		{
+			name: "Stable if all the replicas are updated and ready",
+			controlPlane: withReplicas(newUpgradeTestControlPlane("v1.21.1", "v1.21.1"), map[string]int64{
+				"spec.replicas": 3, "status.replicas": 3, "status.updatedReplicas": 3, "status.readyReplicas": 3,
+			}),
+			want: true,
+		},
+		{
+			name: "Not stable if a replica is not updated",
+			controlPlane: withReplicas(newUpgradeTestControlPlane("v1.21.1", "v1.21.1"), map[string]int64{
+				"spec.replicas": 3, "status.replicas": 3, "status.updatedReplicas": 2, "status.readyReplicas": 3,
+			}),
+			want: false,
+		},
+		{
+			name: "Stable if the control plane does not report the status replicas",
+			controlPlane: withReplicas(newUpgradeTestControlPlane("v1.21.1", "v1.21.1"), map[string]int64{
+				"spec.replicas": 3,
+			}),
+			want: true,
+		},
+		{
+			name: "Not stable if the control plane does not report updated replicas",
+			controlPlane: withReplicas(newUpgradeTestControlPlane("v1.21.1", "v1.21.1"), map[string]int64{
+				"spec.replicas": 3, "status.replicas": 3, "status.readyReplicas": 3,
+			}),
+			want: false,
+		},
+		{
+			name: "Not stable if the control plane does not report updated and ready replicas",
+			controlPlane: withReplicas(newUpgradeTestControlPlane("v1.21.1", "v1.21.1"), map[string]int64{
+				"spec.replicas": 3, "status.replicas": 3,
+			}),
+			want: false,
+		},
+		{
+			name: "Not stable if the reported ready replicas are not enough",
+			controlPlane: withReplicas(newUpgradeTestControlPlane("v1.21.1", "v1.21.1"), map[string]int64{
+				"spec.replicas": 3, "status.replicas": 3, "status.readyReplicas": 2,
+			}),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			got, err := isControlPlaneStable(tt.controlPlane)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestComputeMachineDeploymentVersions(t *testing.T) {
+	tests := []struct {
+		name               string
+		upgrade            *clusterv1.TopologyUpgrade
+		controlPlane       *unstructured.Unstructured
+		machineDeployments map[string]*machineDeploymentTopologyState
+		want               map[string]string
+	}{
+		{
+			name:         "Keeps the current version while the control plane is upgrading, new MachineDeployments use the control plane version",
+			controlPlane: newUpgradeTestControlPlane("v1.21.2", "v1.21.1"),
+			machineDeployments: map[string]*machineDeploymentTopologyState{
+				"md1": {object: newUpgradeTestMachineDeployment("md1", "v1.21.0", true)},
+			},
+			want: map[string]string{"md1": "v1.21.0", "md2": "v1.21.1", "md3": "v1.21.1"},
+		},
+		{
+			name:         "Upgrades all the MachineDeployments at once if the control plane is upgraded and no concurrency limit is set",
+			controlPlane: newUpgradeTestControlPlane("v1.21.2", "v1.21.2"),
+			machineDeployments: map[string]*machineDeploymentTopologyState{
+				"md1": {object: newUpgradeTestMachineDeployment("md1", "v1.21.1", true)},
+				"md2": {object: newUpgradeTestMachineDeployment("md2", "v1.21.1", true)},
+				"md3": {object: newUpgradeTestMachineDeployment("md3", "v1.21.1", true)},
+			},
+			want: map[string]string{"md1": "v1.21.2", "md2": "v1.21.2", "md3": "v1.21.2"},
+		},
+		{
+			name: "Upgrades MachineDeployments in the given order respecting the concurrency limit",
+			upgrade: &clusterv1.TopologyUpgrade{
+				MachineDeploymentOrder:          []string{"md3"},
+				MaxConcurrentMachineDeployments: pointer.Int32(1),
+			},
+			controlPlane: newUpgradeTestControlPlane("v1.21.2", "v1.21.2"),
+			machineDeployments: map[string]*machineDeploymentTopologyState{
+				"md1": {object: newUpgradeTestMachineDeployment("md1", "v1.21.1", true)},
+				"md2": {object: newUpgradeTestMachineDeployment("md2", "v1.21.1", true)},
+				"md3": {object: newUpgradeTestMachineDeployment("md3", "v1.21.1", true)},
+			},
+			want: map[string]string{"md1": "v1.21.1", "md2": "v1.21.1", "md3": "v1.21.2"},
+		},
+		{
+			name: "Waits for MachineDeployments being rolled out before upgrading the next ones",
+			upgrade: &clusterv1.TopologyUpgrade{
+				MaxConcurrentMachineDeployments: pointer.Int32(1),
+			},
+			controlPlane: newUpgradeTestControlPlane("v1.21.2", "v1.21.2"),
+			machineDeployments: map[string]*machineDeploymentTopologyState{
+				"md1": {object: newUpgradeTestMachineDeployment("md1", "v1.21.2", true)},
+				"md2": {object: newUpgradeTestMachineDeployment("md2", "v1.21.2", false)},
+				"md3": {object: newUpgradeTestMachineDeployment("md3", "v1.21.1", true)},
+			},
+			want: map[string]string{"md1": "v1.21.2", "md2": "v1.21.2", "md3": "v1.21.1"},
+		},
+		{
+			name: "New MachineDeployments are created with the topology version without counting against the concurrency limit",
+			upgrade: &clusterv1.TopologyUpgrade{
+				MaxConcurrentMachineDeployments: pointer.Int32(1),
+			},
+			controlPlane: newUpgradeTestControlPlane("v1.21.2", "v1.21.2"),
+			machineDeployments: map[string]*machineDeploymentTopologyState{
+				"md2": {object: newUpgradeTestMachineDeployment("md2", "v1.21.1", true)},
+				"md3": {object: newUpgradeTestMachineDeployment("md3", "v1.21.1", true)},
+			},
+			want: map[string]string{"md1": "v1.21.2", "md2": "v1.21.2", "md3": "v1.21.1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			current := &clusterTopologyState{
+				cluster:            newUpgradeTestCluster(tt.upgrade, "md1", "md2", "md3"),
+				controlPlane:       &controlPlaneTopologyState{object: tt.controlPlane},
+				machineDeployments: tt.machineDeployments,
+			}
+
+			got, err := computeMachineDeploymentVersions(current)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestSetTopologyUpgradedCondition(t *testing.T) {
+	tests := []struct {
+		name            string
+		condition       *clusterv1.Condition
+		controlPlane    *unstructured.Unstructured
+		current         map[string]*machineDeploymentTopologyState
+		desired         map[string]*machineDeploymentTopologyState
+		wantStatus      corev1.ConditionStatus
+		wantReason      string
+		wantMessageHint string
+	}{
+		{
+			name:         "Reports the control plane is upgrading",
+			controlPlane: newUpgradeTestControlPlane("v1.21.2", "v1.21.1"),
+			wantStatus:   corev1.ConditionFalse,
+			wantReason:   clusterv1.TopologyControlPlaneUpgradingReason,
+		},
+		{
+			name:         "Reports MachineDeployments upgrading and pending",
+			controlPlane: newUpgradeTestControlPlane("v1.21.2", "v1.21.2"),
+			current: map[string]*machineDeploymentTopologyState{
+				"md1": {object: newUpgradeTestMachineDeployment("md1", "v1.21.1", true)},
+				"md2": {object: newUpgradeTestMachineDeployment("md2", "v1.21.1", true)},
+			},
+			desired: map[string]*machineDeploymentTopologyState{
+				"md1": {object: newUpgradeTestMachineDeployment("md1", "v1.21.2", true)},
+				"md2": {object: newUpgradeTestMachineDeployment("md2", "v1.21.1", true)},
+			},
+			wantStatus:      corev1.ConditionFalse,
+			wantReason:      clusterv1.TopologyMachineDeploymentsUpgradingReason,
+			wantMessageHint: "upgrading: [md1], pending: [md2]",
+		},
+		{
+			name:         "Reports MachineDeployments still rolling out if an upgrade is in progress",
+			condition:    conditions.FalseCondition(clusterv1.TopologyUpgradedCondition, clusterv1.TopologyMachineDeploymentsUpgradingReason, clusterv1.ConditionSeverityInfo, ""),
+			controlPlane: newUpgradeTestControlPlane("v1.21.2", "v1.21.2"),
+			current: map[string]*machineDeploymentTopologyState{
+				"md1": {object: newUpgradeTestMachineDeployment("md1", "v1.21.2", false)},
+			},
+			desired: map[string]*machineDeploymentTopologyState{
+				"md1": {object: newUpgradeTestMachineDeployment("md1", "v1.21.2", false)},
+			},
+			wantStatus:      corev1.ConditionFalse,
+			wantReason:      clusterv1.TopologyMachineDeploymentsUpgradingReason,
+			wantMessageHint: "upgrading: [md1], pending: []",
+		},
+		{
+			name:         "Reports the upgrade is completed",
+			condition:    conditions.FalseCondition(clusterv1.TopologyUpgradedCondition, clusterv1.TopologyMachineDeploymentsUpgradingReason, clusterv1.ConditionSeverityInfo, ""),
+			controlPlane: newUpgradeTestControlPlane("v1.21.2", "v1.21.2"),
+			current: map[string]*machineDeploymentTopologyState{
+				"md1": {object: newUpgradeTestMachineDeployment("md1", "v1.21.2", true)},
+			},
+			desired: map[string]*machineDeploymentTopologyState{
+				"md1": {object: newUpgradeTestMachineDeployment("md1", "v1.21.2", true)},
+			},
+			wantStatus: corev1.ConditionTrue,
+		},
+		{
+			name:         "Does not report MachineDeployments scaling as an upgrade",
+			controlPlane: newUpgradeTestControlPlane("v1.21.2", "v1.21.2"),
+			current: map[string]*machineDeploymentTopologyState{
+				"md1": {object: newUpgradeTestMachineDeployment("md1", "v1.21.2", false)},
+			},
+			desired: map[string]*machineDeploymentTopologyState{
+				"md1": {object: newUpgradeTestMachineDeployment("md1", "v1.21.2", false)},
+			},
+			wantStatus: corev1.ConditionTrue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cluster := newUpgradeTestCluster(nil, "md1", "md2")
+			if tt.condition != nil {
+				conditions.Set(cluster, tt.condition)
+			}
+			current := &clusterTopologyState{
+				cluster:            cluster,
+				controlPlane:       &controlPlaneTopologyState{object: tt.controlPlane},
+				machineDeployments: tt.current,
+			}
+			desired := &clusterTopologyState{
+				cluster:            cluster,
+				controlPlane:       &controlPlaneTopologyState{object: tt.controlPlane},
+				machineDeployments: tt.desired,
+			}
+
+			g.Expect(setTopologyUpgradedCondition(cluster, current, desired)).To(Succeed())
+
+			condition := conditions.Get(cluster, clusterv1.TopologyUpgradedCondition)
+			g.Expect(condition).ToNot(BeNil())
+			g.Expect(condition.Status).To(Equal(tt.wantStatus))
+			g.Expect(condition.Reason).To(Equal(tt.wantReason))
+			g.Expect(condition.Message).To(ContainSubstring(tt.wantMessageHint))
+		})
+	}
+}
+
+func newUpgradeTestCluster(upgrade *clusterv1.TopologyUpgrade, machineDeployments ...string) *clusterv1.Cluster {
+	cluster := newFakeCluster(metav1.NamespaceDefault, "cluster1").Obj()
+	cluster.Spec.Topology.Version = "v1.21.2"
+	cluster.Spec.Topology.Upgrade = upgrade
+	cluster.Spec.Topology.Workers = &clusterv1.WorkersTopology{}
+	for _, name := range machineDeployments {
+		cluster.Spec.Topology.Workers.MachineDeployments = append(cluster.Spec.Topology.Workers.MachineDeployments, clusterv1.MachineDeploymentTopology{
+			Class: "linux-worker",
+			Name:  name,
+		})
+	}
+	return cluster
+}
+
+func newUpgradeTestControlPlane(specVersion, statusVersion string) *unstructured.Unstructured {
+	controlPlane := newFakeControlPlane(metav1.NamespaceDefault, "controlplane1").Obj()
+	if err := unstructured.SetNestedField(controlPlane.UnstructuredContent(), specVersion, "spec", "version"); err != nil {
+		panic(err)
+	}
+	if err := unstructured.SetNestedField(controlPlane.UnstructuredContent(), statusVersion, "status", "version"); err != nil {
+		panic(err)
+	}
+	return controlPlane
+}
+
+func newUpgradeTestMachineDeployment(name, version string, rolledOut bool) *clusterv1.MachineDeployment {
+	md := newFakeMachineDeployment(metav1.NamespaceDefault, name).Obj()
+	md.Spec.Replicas = pointer.Int32(2)
+	md.Spec.Template.Spec.Version = pointer.String(version)
+	md.Status.Replicas = 2
+	md.Status.UpdatedReplicas = 2
+	md.Status.AvailableReplicas = 2
+	if !rolledOut {
+		md.Status.UpdatedReplicas = 1
+	}
+	return md
+}